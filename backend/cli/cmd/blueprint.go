@@ -0,0 +1,105 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/client"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	blueprintCmd := &cobra.Command{
+		Use:   "blueprint",
+		Short: "Manage blueprints",
+	}
+
+	var label string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List blueprints",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			blueprints, err := c.ListBlueprints(&client.BlueprintQuery{Label: label})
+			if err != nil {
+				return err
+			}
+			return printJson(blueprints)
+		},
+	}
+	listCmd.Flags().StringVar(&label, "label", "", "list blueprints with the label only")
+
+	getCmd := &cobra.Command{
+		Use:   "get BLUEPRINT_ID",
+		Short: "Get a blueprint",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			blueprintId, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			blueprint, err := c.GetBlueprint(blueprintId)
+			if err != nil {
+				return err
+			}
+			return printJson(blueprint)
+		},
+	}
+
+	var wait bool
+	var interval time.Duration
+	triggerCmd := &cobra.Command{
+		Use:   "trigger BLUEPRINT_ID",
+		Short: "Trigger a blueprint and print the created pipeline",
+		Long: "Trigger a blueprint and print the created pipeline, with --wait it waits for the pipeline to finish " +
+			"and fails unless the pipeline is completed",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			blueprintId, err := parseId(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			pipeline, err := c.TriggerBlueprint(blueprintId)
+			if err != nil {
+				return err
+			}
+			if !wait {
+				return printJson(pipeline)
+			}
+			return waitPipeline(c, pipeline.ID, interval)
+		},
+	}
+	triggerCmd.Flags().BoolVar(&wait, "wait", false, "wait for the pipeline to finish")
+	triggerCmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "interval of polling the pipeline")
+
+	blueprintCmd.AddCommand(listCmd, getCmd, triggerCmd)
+	rootCmd.AddCommand(blueprintCmd)
+}
@@ -0,0 +1,102 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apache/incubator-devlake/client"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration as code",
+	}
+
+	var file string
+	var dryRun, expand, outputJson bool
+	applyCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply a YAML or JSON document of connections, transformation rules, scopes, projects and blueprints",
+		Long: "Apply a YAML or JSON document of connections, transformation rules, scopes, projects and blueprints. " +
+			"Resources matching the document are left untouched so the same document could be applied repeatedly, " +
+			"and --dry-run prints the plan without changing anything.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			var document []byte
+			var e error
+			if file == "-" {
+				document, e = io.ReadAll(os.Stdin)
+			} else {
+				document, e = os.ReadFile(file)
+			}
+			if e != nil {
+				return errors.Convert(e)
+			}
+			if expand {
+				document = []byte(os.ExpandEnv(string(document)))
+			}
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			result, err := c.ApplyConfig(document, dryRun)
+			if err != nil {
+				if result != nil && len(result.Changes) > 0 {
+					fmt.Fprintln(os.Stderr, "changes made before the failure:")
+					printConfigChanges(result.Changes)
+				}
+				return err
+			}
+			if outputJson {
+				return printJson(result)
+			}
+			printConfigChanges(result.Changes)
+			if dryRun {
+				fmt.Fprintln(os.Stderr, "dry run, nothing was changed")
+			}
+			return nil
+		},
+	}
+	applyCmd.Flags().StringVarP(&file, "file", "f", "", "the document, - for stdin")
+	applyCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the plan without changing anything")
+	applyCmd.Flags().BoolVar(&expand, "expand-env", false, "expand ${VAR} in the document with the environment variables before sending")
+	applyCmd.Flags().BoolVar(&outputJson, "json", false, "print the changes as json")
+	_ = applyCmd.MarkFlagRequired("file")
+
+	configCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func printConfigChanges(changes []*client.ConfigChange) {
+	for _, change := range changes {
+		name := change.Name
+		if change.Plugin != "" {
+			name = change.Plugin + "/" + name
+		}
+		fmt.Printf("%-6s %s %s\n", change.Action, change.Kind, name)
+		for field, value := range change.Fields {
+			fmt.Printf("         %s: %v\n", field, value)
+		}
+	}
+}
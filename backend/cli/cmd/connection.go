@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	connectionCmd := &cobra.Command{
+		Use:   "connection",
+		Short: "Manage connections of plugins",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list PLUGIN",
+		Short: "List connections of a plugin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			var connections []map[string]interface{}
+			if err = c.ListConnections(args[0], &connections); err != nil {
+				return err
+			}
+			return printJson(connections)
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get PLUGIN CONNECTION_ID",
+		Short: "Get a connection of a plugin",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			connectionId, err := parseId(args[1])
+			if err != nil {
+				return err
+			}
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			connection := make(map[string]interface{})
+			if err = c.GetConnection(args[0], connectionId, &connection); err != nil {
+				return err
+			}
+			return printJson(connection)
+		},
+	}
+
+	var file string
+	testCmd := &cobra.Command{
+		Use:   "test PLUGIN [CONNECTION_ID]",
+		Short: "Test a saved connection, or the one in the file given by --file",
+		Long: "Test a saved connection, or the one in the file given by --file. The saved connection is sent to the " +
+			"test api of the plugin as is, so secrets referring to a secret provider are not resolved.",
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			connection := make(map[string]interface{})
+			switch {
+			case file != "" && len(args) == 1:
+				blob, e := os.ReadFile(file)
+				if e != nil {
+					return errors.Convert(e)
+				}
+				if e = json.Unmarshal(blob, &connection); e != nil {
+					return errors.BadInput.Wrap(e, fmt.Sprintf("invalid connection in %s", file))
+				}
+			case file == "" && len(args) == 2:
+				connectionId, err := parseId(args[1])
+				if err != nil {
+					return err
+				}
+				if err = c.GetConnection(args[0], connectionId, &connection); err != nil {
+					return err
+				}
+			default:
+				return errors.BadInput.New("either CONNECTION_ID or --file is required")
+			}
+			result, err := c.TestConnection(args[0], connection)
+			if err != nil {
+				return err
+			}
+			if len(result) > 0 && string(result) != "null" {
+				fmt.Println(string(result))
+			}
+			fmt.Fprintln(os.Stderr, "connection is ok")
+			return nil
+		},
+	}
+	testCmd.Flags().StringVarP(&file, "file", "f", "", "json file of the connection")
+
+	connectionCmd.AddCommand(listCmd, getCmd, testCmd)
+	rootCmd.AddCommand(connectionCmd)
+}
@@ -0,0 +1,197 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apache/incubator-devlake/client"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	pipelineCmd := &cobra.Command{
+		Use:   "pipeline",
+		Short: "Manage pipelines",
+	}
+
+	query := &client.PipelineQuery{}
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List pipelines, the latest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			pipelines, err := c.ListPipelines(query)
+			if err != nil {
+				return err
+			}
+			return printJson(pipelines)
+		},
+	}
+	listCmd.Flags().StringVar(&query.Status, "status", "", "list pipelines with the status only, e.g. TASK_FAILED")
+	listCmd.Flags().BoolVar(&query.Pending, "pending", false, "list pending pipelines only")
+	listCmd.Flags().Uint64Var(&query.BlueprintId, "blueprint-id", 0, "list pipelines of the blueprint only")
+	listCmd.Flags().StringVar(&query.Label, "label", "", "list pipelines with the label only")
+	listCmd.Flags().IntVar(&query.Page, "page", 1, "page number")
+	listCmd.Flags().IntVar(&query.PageSize, "page-size", 50, "page size")
+
+	getCmd := &cobra.Command{
+		Use:   "get PIPELINE_ID",
+		Short: "Get a pipeline along with its tasks",
+		Args:  cobra.ExactArgs(1),
+		RunE: withPipeline(func(c *client.Client, pipelineId uint64) errors.Error {
+			pipeline, err := c.GetPipeline(pipelineId)
+			if err != nil {
+				return err
+			}
+			tasks, err := c.ListPipelineTasks(pipelineId)
+			if err != nil {
+				return err
+			}
+			return printJson(map[string]interface{}{"pipeline": pipeline, "tasks": tasks.Tasks})
+		}),
+	}
+
+	var interval time.Duration
+	waitCmd := &cobra.Command{
+		Use:   "wait PIPELINE_ID",
+		Short: "Wait for a pipeline to finish, it fails unless the pipeline is completed",
+		Args:  cobra.ExactArgs(1),
+		RunE: withPipeline(func(c *client.Client, pipelineId uint64) errors.Error {
+			return waitPipeline(c, pipelineId, interval)
+		}),
+	}
+	waitCmd.Flags().DurationVar(&interval, "interval", 10*time.Second, "interval of polling the pipeline")
+
+	cancelCmd := &cobra.Command{
+		Use:   "cancel PIPELINE_ID",
+		Short: "Cancel a pending or running pipeline",
+		Args:  cobra.ExactArgs(1),
+		RunE: withPipeline(func(c *client.Client, pipelineId uint64) errors.Error {
+			return c.CancelPipeline(pipelineId)
+		}),
+	}
+
+	rerunCmd := &cobra.Command{
+		Use:   "rerun PIPELINE_ID",
+		Short: "Rerun the failed tasks of a pipeline",
+		Args:  cobra.ExactArgs(1),
+		RunE: withPipeline(func(c *client.Client, pipelineId uint64) errors.Error {
+			tasks, err := c.RerunPipeline(pipelineId)
+			if err != nil {
+				return err
+			}
+			return printJson(tasks)
+		}),
+	}
+
+	var output string
+	logsCmd := &cobra.Command{
+		Use:   "logs PIPELINE_ID",
+		Short: "Print the logs of a pipeline",
+		Long:  "Print the logs of a pipeline, with --output the tar.gz archive of the logs is saved to the file instead",
+		Args:  cobra.ExactArgs(1),
+		RunE: withPipeline(func(c *client.Client, pipelineId uint64) errors.Error {
+			if output != "" {
+				file, err := os.Create(output)
+				if err != nil {
+					return errors.Convert(err)
+				}
+				defer file.Close()
+				return c.DownloadPipelineLogs(pipelineId, file)
+			}
+			archive := &bytes.Buffer{}
+			if err := c.DownloadPipelineLogs(pipelineId, archive); err != nil {
+				return err
+			}
+			return printArchive(archive, os.Stdout)
+		}),
+	}
+	logsCmd.Flags().StringVarP(&output, "output", "o", "", "save the tar.gz archive to the file")
+
+	pipelineCmd.AddCommand(listCmd, getCmd, waitCmd, cancelCmd, rerunCmd, logsCmd)
+	rootCmd.AddCommand(pipelineCmd)
+}
+
+func withPipeline(run func(c *client.Client, pipelineId uint64) errors.Error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		pipelineId, err := parseId(args[0])
+		if err != nil {
+			return err
+		}
+		c, err := newClient(cmd)
+		if err != nil {
+			return err
+		}
+		return run(c, pipelineId)
+	}
+}
+
+// waitPipeline prints the finished pipeline, and fails unless it is completed so that CI jobs fail along with it
+func waitPipeline(c *client.Client, pipelineId uint64, interval time.Duration) errors.Error {
+	fmt.Fprintf(os.Stderr, "waiting for pipeline #%d to finish\n", pipelineId)
+	pipeline, err := c.WaitPipeline(pipelineId, interval)
+	if err != nil {
+		return err
+	}
+	if err = printJson(pipeline); err != nil {
+		return err
+	}
+	if pipeline.Status != models.TASK_COMPLETED {
+		return errors.Default.New(fmt.Sprintf("pipeline #%d finished with %s: %s", pipelineId, pipeline.Status, pipeline.Message))
+	}
+	return nil
+}
+
+// printArchive writes the files in the tar.gz archive to w one after another
+func printArchive(archive io.Reader, w io.Writer) errors.Error {
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return errors.Default.Wrap(err, "invalid logs archive")
+	}
+	defer gz.Close()
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Default.Wrap(err, "invalid logs archive")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		fmt.Fprintf(w, "==> %s <==\n", header.Name)
+		if _, err = io.Copy(w, reader); err != nil {
+			return errors.Convert(err)
+		}
+	}
+}
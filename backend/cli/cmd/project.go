@@ -0,0 +1,158 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/apache/incubator-devlake/client"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	projectCmd := &cobra.Command{
+		Use:   "project",
+		Short: "Manage projects",
+	}
+
+	var page, pageSize int
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List projects",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			projects, err := c.ListProjects(page, pageSize)
+			if err != nil {
+				return err
+			}
+			return printJson(projects)
+		},
+	}
+	listCmd.Flags().IntVar(&page, "page", 1, "page number")
+	listCmd.Flags().IntVar(&pageSize, "page-size", 50, "page size")
+
+	getCmd := &cobra.Command{
+		Use:   "get PROJECT_NAME",
+		Short: "Get a project along with its metrics and blueprint",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			project, err := c.GetProject(args[0])
+			if err != nil {
+				return err
+			}
+			return printJson(project)
+		},
+	}
+
+	var output string
+	var withDomainData bool
+	exportCmd := &cobra.Command{
+		Use:   "export PROJECT_NAME",
+		Short: "Export a project along with its blueprint, connections, scopes and transformation rules",
+		Long: "Export a project along with its blueprint, connections, scopes and transformation rules into a bundle " +
+			"to be imported into another instance, secrets of the connections are stripped.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			if output == "" {
+				return c.ExportProject(args[0], withDomainData, os.Stdout)
+			}
+			file, e := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+			if e != nil {
+				return errors.Convert(e)
+			}
+			defer file.Close()
+			return c.ExportProject(args[0], withDomainData, file)
+		},
+	}
+	exportCmd.Flags().StringVarP(&output, "output", "o", "", "write to the file instead of stdout")
+	exportCmd.Flags().BoolVar(&withDomainData, "domain-data", false, "export the domain layer data of the project as well")
+
+	var file, secretsFile string
+	var dryRun, overwrite, outputJson bool
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a project exported by another instance",
+		Long: "Import a project exported by another instance. Connections are matched by names, secrets of those not " +
+			"existing yet are read from the YAML or JSON file of --secrets keyed by `plugin/name`, where ${VAR} is " +
+			"expanded with the environment variables. Existing resources differing from the bundle are reported as " +
+			"conflicts and nothing is imported unless --overwrite is given.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			bundle, e := os.ReadFile(file)
+			if e != nil {
+				return errors.Convert(e)
+			}
+			input := &client.ProjectImportInput{Bundle: bundle}
+			if secretsFile != "" {
+				blob, e := os.ReadFile(secretsFile)
+				if e != nil {
+					return errors.Convert(e)
+				}
+				if e = yaml.Unmarshal([]byte(os.ExpandEnv(string(blob))), &input.Secrets); e != nil {
+					return errors.BadInput.Wrap(e, "invalid secrets")
+				}
+			}
+			c, err := newClient(cmd)
+			if err != nil {
+				return err
+			}
+			result, err := c.ImportProject(input, dryRun, overwrite)
+			if err != nil {
+				return err
+			}
+			if outputJson {
+				return printJson(result)
+			}
+			printConfigChanges(result.Changes)
+			for table, rows := range result.DomainRows {
+				fmt.Printf("import %d rows of %s\n", rows, table)
+			}
+			for _, conflict := range result.Conflicts {
+				fmt.Fprintf(os.Stderr, "conflict with the existing %s %s\n", conflict.Kind, conflict.Name)
+			}
+			if dryRun {
+				fmt.Fprintln(os.Stderr, "dry run, nothing was changed")
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringVarP(&file, "file", "f", "", "the bundle written by export")
+	importCmd.Flags().StringVar(&secretsFile, "secrets", "", "the file of secrets of the connections keyed by plugin/name")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes and conflicts without importing")
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "overwrite the existing resources differing from the bundle")
+	importCmd.Flags().BoolVar(&outputJson, "json", false, "print the result as json")
+	_ = importCmd.MarkFlagRequired("file")
+
+	projectCmd.AddCommand(listCmd, getCmd, exportCmd, importCmd)
+	rootCmd.AddCommand(projectCmd)
+}
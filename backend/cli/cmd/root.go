@@ -0,0 +1,101 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/apache/incubator-devlake/client"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Used for flags, defaults are read from the environment so CI jobs could set them once
+	endpoint    string
+	apiKey      string
+	bearerToken string
+	timeout     time.Duration
+
+	rootCmd = &cobra.Command{
+		Use:           `devlake [command]`,
+		Short:         "Apache DevLake Cli Tool -- Client of the DevLake server",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+)
+
+// Execute executes the root command.
+func Execute() errors.Error {
+	err := rootCmd.Execute()
+	if lakeErr := errors.AsLakeErrorType(err); lakeErr != nil {
+		fmt.Fprintln(os.Stderr, "Error:", lakeErr.Messages().Format())
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+	return errors.Default.WrapRaw(err)
+}
+
+func init() {
+	defaultEndpoint := os.Getenv("DEVLAKE_ENDPOINT")
+	if defaultEndpoint == "" {
+		defaultEndpoint = "http://localhost:8080"
+	}
+	rootCmd.PersistentFlags().StringVar(&endpoint, "endpoint", defaultEndpoint, "endpoint of the DevLake server, env: DEVLAKE_ENDPOINT")
+	rootCmd.PersistentFlags().StringVar(&apiKey, "api-key", os.Getenv("DEVLAKE_API_KEY"), "api key, env: DEVLAKE_API_KEY")
+	rootCmd.PersistentFlags().StringVar(&bearerToken, "token", os.Getenv("DEVLAKE_TOKEN"), "bearer token, env: DEVLAKE_TOKEN")
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", client.DEFAULT_TIMEOUT, "timeout of each request except downloads, 0 for none")
+}
+
+func newClient(cmd *cobra.Command) (*client.Client, errors.Error) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	config := &client.Config{
+		Endpoint:    endpoint,
+		ApiKey:      apiKey,
+		BearerToken: bearerToken,
+		Timeout:     timeout,
+	}
+	if timeout == 0 {
+		// a zero timeout of the client falls back to the default
+		config.Timeout = -1
+	}
+	return client.NewClient(ctx, config)
+}
+
+// printJson writes v to stdout as indented json
+func printJson(v interface{}) errors.Error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return errors.Convert(encoder.Encode(v))
+}
+
+func parseId(arg string) (uint64, errors.Error) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return 0, errors.BadInput.New(fmt.Sprintf("invalid id: %s", arg))
+	}
+	return id, nil
+}
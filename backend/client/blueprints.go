@@ -0,0 +1,87 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// BlueprintQuery filters blueprints, nil and zero values are ignored
+type BlueprintQuery struct {
+	Enable   *bool
+	IsManual *bool
+	Label    string
+	Page     int
+	PageSize int
+}
+
+type PaginatedBlueprints struct {
+	Blueprints []*models.Blueprint `json:"blueprints"`
+	Count      int64               `json:"count"`
+}
+
+func (c *Client) ListBlueprints(query *BlueprintQuery) (*PaginatedBlueprints, errors.Error) {
+	values := url.Values{}
+	if query != nil {
+		values = paginate(query.Page, query.PageSize)
+		if query.Enable != nil {
+			values.Set("enable", strconv.FormatBool(*query.Enable))
+		}
+		if query.IsManual != nil {
+			values.Set("is_manual", strconv.FormatBool(*query.IsManual))
+		}
+		if query.Label != "" {
+			values.Set("label", query.Label)
+		}
+	}
+	result := &PaginatedBlueprints{}
+	return result, c.Get("/blueprints", values, result)
+}
+
+func (c *Client) CreateBlueprint(blueprint *models.Blueprint) (*models.Blueprint, errors.Error) {
+	result := &models.Blueprint{}
+	return result, c.Do(http.MethodPost, "/blueprints", nil, blueprint, result)
+}
+
+func (c *Client) GetBlueprint(blueprintId uint64) (*models.Blueprint, errors.Error) {
+	result := &models.Blueprint{}
+	return result, c.Get(fmt.Sprintf("/blueprints/%d", blueprintId), nil, result)
+}
+
+// PatchBlueprint updates the fields of the blueprint present in changes, e.g. `{"enable": false}`
+func (c *Client) PatchBlueprint(blueprintId uint64, changes map[string]interface{}) (*models.Blueprint, errors.Error) {
+	result := &models.Blueprint{}
+	return result, c.Do(http.MethodPatch, fmt.Sprintf("/blueprints/%d", blueprintId), nil, changes, result)
+}
+
+// TriggerBlueprint creates a pipeline out of the blueprint right away
+func (c *Client) TriggerBlueprint(blueprintId uint64) (*models.Pipeline, errors.Error) {
+	result := &models.Pipeline{}
+	return result, c.Do(http.MethodPost, fmt.Sprintf("/blueprints/%d/trigger", blueprintId), nil, nil, result)
+}
+
+func (c *Client) ListBlueprintPipelines(blueprintId uint64) (*PaginatedPipelines, errors.Error) {
+	result := &PaginatedPipelines{}
+	return result, c.Get(fmt.Sprintf("/blueprints/%d/pipelines", blueprintId), nil, result)
+}
@@ -0,0 +1,218 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package client is the Go client of the DevLake server apis, errors returned by the server carry the http status
+// of the response, e.g. `err.GetType() == errors.NotFound` for a missing pipeline
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+const DEFAULT_TIMEOUT = 60 * time.Second
+
+// Config of the Client, one of ApiKey and BearerToken is required when authentication is enabled on the server
+type Config struct {
+	// Endpoint of the server, e.g. `http://localhost:8080`
+	Endpoint    string
+	ApiKey      string
+	BearerToken string
+	// Timeout of each request except downloads, which may take long for large bodies, DEFAULT_TIMEOUT if 0 and
+	// none if negative
+	Timeout time.Duration
+}
+
+// Client calls the DevLake server apis
+type Client struct {
+	ctx        context.Context
+	endpoint   string
+	headers    map[string]string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+// apiBody is the body of failed responses
+type apiBody struct {
+	Message string   `json:"message"`
+	Causes  []string `json:"causes"`
+}
+
+// NewClient creates a Client, requests are cancelled along with the ctx
+func NewClient(ctx context.Context, config *Config) (*Client, errors.Error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, errors.BadInput.New(fmt.Sprintf("invalid endpoint: %s", config.Endpoint))
+	}
+	headers := make(map[string]string)
+	if config.ApiKey != "" {
+		headers["X-API-Key"] = config.ApiKey
+	}
+	if config.BearerToken != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", config.BearerToken)
+	}
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+	return &Client{
+		ctx:        ctx,
+		endpoint:   endpoint.String(),
+		headers:    headers,
+		timeout:    timeout,
+		httpClient: &http.Client{},
+	}, nil
+}
+
+// Get sends a GET request to the path and decodes the json response into result, it works for apis not covered
+// by the typed methods
+func (c *Client) Get(path string, query url.Values, result interface{}) errors.Error {
+	return c.Do(http.MethodGet, path, query, nil, result)
+}
+
+// Do sends the body as json to the path and decodes the json response into result unless it is nil
+func (c *Client) Do(method string, path string, query url.Values, body interface{}, result interface{}) errors.Error {
+	var reader io.Reader
+	if body != nil {
+		blob, err := json.Marshal(body)
+		if err != nil {
+			return errors.BadInput.Wrap(err, "failed to encode the request body")
+		}
+		reader = bytes.NewReader(blob)
+	}
+	return c.DoRaw(method, path, query, "application/json", reader, result)
+}
+
+// DoRaw sends the body of the contentType to the path and decodes the json response into result unless it is nil
+func (c *Client) DoRaw(method string, path string, query url.Values, contentType string, body io.Reader, result interface{}) errors.Error {
+	res, err := c.send(method, path, query, contentType, body, c.timeout)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if result == nil {
+		return nil
+	}
+	if e := json.NewDecoder(res.Body).Decode(result); e != nil && e != io.EOF {
+		return errors.Default.Wrap(e, fmt.Sprintf("failed to decode the response of %s %s", method, path))
+	}
+	return nil
+}
+
+// Download writes the body of the response of the GET request to w, it is only cancelled along with the ctx of
+// the Client since the body is streamed
+func (c *Client) Download(path string, query url.Values, w io.Writer) errors.Error {
+	res, err := c.send(http.MethodGet, path, query, "", nil, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if _, e := io.Copy(w, res.Body); e != nil {
+		return errors.Default.Wrap(e, fmt.Sprintf("failed to download %s", path))
+	}
+	return nil
+}
+
+func (c *Client) send(method string, path string, query url.Values, contentType string, body io.Reader, timeout time.Duration) (*http.Response, errors.Error) {
+	res, err := c.request(method, path, query, contentType, body, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		return nil, responseError(res)
+	}
+	return res, nil
+}
+
+// request sends the request, responses of any status are returned. The timeout covers reading the body of the
+// response as well, none if it is not positive
+func (c *Client) request(method string, path string, query url.Values, contentType string, body io.Reader, timeout time.Duration) (*http.Response, errors.Error) {
+	uri := c.endpoint + path
+	if len(query) > 0 {
+		uri += "?" + query.Encode()
+	}
+	ctx, cancel := c.ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(c.ctx, timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, uri, body)
+	if err != nil {
+		cancel()
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("failed to create request %s %s", method, path))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to request %s %s: %s", method, path, err))
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+// cancelOnClose releases the deadline of the request once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func responseError(res *http.Response) errors.Error {
+	blob, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	message := strings.TrimSpace(string(blob))
+	body := &apiBody{}
+	if json.Unmarshal(blob, body) == nil && body.Message != "" {
+		message = body.Message
+		if len(body.Causes) > 0 {
+			message = fmt.Sprintf("%s: %s", message, strings.Join(body.Causes, "; "))
+		}
+	}
+	if message == "" {
+		message = res.Status
+	}
+	return errors.HttpStatus(res.StatusCode).New(message)
+}
+
+func paginate(page int, pageSize int) url.Values {
+	query := url.Values{}
+	if page > 0 {
+		query.Set("page", fmt.Sprint(page))
+	}
+	if pageSize > 0 {
+		query.Set("pageSize", fmt.Sprint(pageSize))
+	}
+	return query
+}
@@ -0,0 +1,171 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	c, err := NewClient(context.Background(), &Config{Endpoint: server.URL + "/", ApiKey: "key"})
+	require.Nil(t, err)
+	return c
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestNewClient(t *testing.T) {
+	_, err := NewClient(context.Background(), &Config{Endpoint: "localhost"})
+	assert.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+}
+
+func TestClientRequests(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("X-API-Key"))
+		switch r.Method + " " + r.URL.Path {
+		case "GET /pipelines":
+			assert.Equal(t, "TASK_FAILED", r.URL.Query().Get("status"))
+			assert.Equal(t, "2", r.URL.Query().Get("page"))
+			assert.Equal(t, "10", r.URL.Query().Get("pageSize"))
+			writeJson(w, http.StatusOK, &PaginatedPipelines{Count: 1, Pipelines: []*models.Pipeline{{Name: "p"}}})
+		case "POST /blueprints/3/trigger":
+			writeJson(w, http.StatusOK, &models.Pipeline{Model: common.Model{ID: 7}})
+		case "GET /projects/a/b":
+			writeJson(w, http.StatusNotFound, map[string]interface{}{"success": false, "message": "project not found"})
+		case "GET /projects/p/export":
+			assert.Equal(t, "true", r.URL.Query().Get("domainData"))
+			_, _ = w.Write([]byte(`{"project":"p"}`))
+		case "POST /projects/import":
+			assert.Equal(t, "true", r.URL.Query().Get("dryRun"))
+			input := make(map[string]json.RawMessage)
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+			assert.JSONEq(t, `{"project":"p","domainData":{"repos":[{"id":12345678901234567}]}}`, string(input["bundle"]))
+			writeJson(w, http.StatusOK, &ProjectImportResult{DryRun: true, DomainRows: map[string]int{"repos": 1}})
+		case "POST /config/apply":
+			writeJson(w, http.StatusInternalServerError, map[string]interface{}{
+				"success": false,
+				"message": "error applying the configuration",
+				"result":  &ConfigApplyResult{Changes: []*ConfigChange{{Kind: "connection", Name: "c", Action: "create"}}},
+			})
+		case "PUT /plugins/github/connections/1/scopes":
+			body := make(map[string][]map[string]interface{})
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+			writeJson(w, http.StatusOK, body["data"])
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	})
+
+	pipelines, err := c.ListPipelines(&PipelineQuery{Status: models.TASK_FAILED, Page: 2, PageSize: 10})
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), pipelines.Count)
+	assert.Equal(t, "p", pipelines.Pipelines[0].Name)
+
+	pipeline, err := c.TriggerBlueprint(3)
+	require.Nil(t, err)
+	assert.Equal(t, uint64(7), pipeline.ID)
+
+	_, err = c.GetProject("a/b")
+	require.NotNil(t, err)
+	assert.Equal(t, errors.NotFound, err.GetType())
+	assert.Contains(t, err.Error(), "project not found")
+
+	bundle := &bytes.Buffer{}
+	require.Nil(t, c.ExportProject("p", true, bundle))
+	assert.Equal(t, `{"project":"p"}`, bundle.String())
+
+	// numbers of the bundle are sent as they are
+	imported, err := c.ImportProject(&ProjectImportInput{
+		Bundle: json.RawMessage(`{"project":"p","domainData":{"repos":[{"id":12345678901234567}]}}`),
+	}, true, false)
+	require.Nil(t, err)
+	assert.Equal(t, 1, imported.DomainRows["repos"])
+
+	// changes made before the failure are returned along with the error
+	applied, err := c.ApplyConfig([]byte("connections: []"), false)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "error applying the configuration")
+	assert.Equal(t, "create", applied.Changes[0].Action)
+
+	var scopes []map[string]interface{}
+	err = c.PutScopes("github", 1, []map[string]interface{}{{"githubId": 1}}, &scopes)
+	require.Nil(t, err)
+	assert.Equal(t, []map[string]interface{}{{"githubId": float64(1)}}, scopes)
+
+	err = c.DeleteConnection("github", 1)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusTeapot, err.GetType().GetHttpCode())
+}
+
+func TestWaitPipeline(t *testing.T) {
+	var polls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		status := models.TASK_RUNNING
+		if atomic.AddInt32(&polls, 1) == 3 {
+			status = models.TASK_FAILED
+		}
+		writeJson(w, http.StatusOK, &models.Pipeline{Status: status})
+	})
+	pipeline, err := c.WaitPipeline(1, time.Millisecond)
+	require.Nil(t, err)
+	assert.Equal(t, models.TASK_FAILED, pipeline.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+}
+
+func TestClientTimeout(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		// the body arrives in pieces over a longer time than the timeout
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte(" "))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+	c.timeout = 80 * time.Millisecond
+
+	// downloads are not cut off
+	blob := &bytes.Buffer{}
+	require.Nil(t, c.Download("/bundle", nil, blob))
+	assert.Equal(t, "   ", blob.String())
+	// requests of other methods are
+	assert.NotNil(t, c.Get("/slow", nil, &map[string]interface{}{}))
+
+	// and could be given no timeout
+	c.timeout = -1
+	assert.Nil(t, c.Get("/slow", nil, &map[string]interface{}{}))
+}
@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// ConfigChange is a change made, or to be made in dry run, by applying a configuration document
+type ConfigChange struct {
+	Kind   string                 `json:"kind"`
+	Plugin string                 `json:"plugin,omitempty"`
+	Name   string                 `json:"name"`
+	Id     interface{}            `json:"id,omitempty"`
+	Action string                 `json:"action"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+type ConfigApplyResult struct {
+	DryRun  bool            `json:"dryRun"`
+	Changes []*ConfigChange `json:"changes"`
+}
+
+// ApplyConfig applies the configuration document in YAML or JSON, nothing is changed in dry run. The changes made
+// before a failure are returned along with the error
+func (c *Client) ApplyConfig(document []byte, dryRun bool) (*ConfigApplyResult, errors.Error) {
+	query := url.Values{"dryRun": []string{strconv.FormatBool(dryRun)}}
+	res, err := c.request(http.MethodPost, "/config/apply", query, "application/yaml", bytes.NewReader(document), c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	blob, e := io.ReadAll(res.Body)
+	if e != nil {
+		return nil, errors.Default.Wrap(e, "failed to read the response of /config/apply")
+	}
+	if res.StatusCode >= http.StatusBadRequest {
+		failure := &struct {
+			Result *ConfigApplyResult `json:"result"`
+		}{}
+		_ = json.Unmarshal(blob, failure)
+		res.Body = io.NopCloser(bytes.NewReader(blob))
+		return failure.Result, responseError(res)
+	}
+	result := &ConfigApplyResult{}
+	if e = json.Unmarshal(blob, result); e != nil {
+		return nil, errors.Default.Wrap(e, "failed to decode the response of /config/apply")
+	}
+	return result, nil
+}
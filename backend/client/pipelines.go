@@ -0,0 +1,131 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/utils"
+)
+
+// PipelineQuery filters pipelines, zero values are ignored
+type PipelineQuery struct {
+	Status      string
+	Pending     bool
+	BlueprintId uint64
+	Label       string
+	Page        int
+	PageSize    int
+}
+
+type PaginatedPipelines struct {
+	Count     int64              `json:"count"`
+	Pipelines []*models.Pipeline `json:"pipelines"`
+}
+
+type PipelineTasks struct {
+	Tasks []*models.Task `json:"tasks"`
+	Count int            `json:"count"`
+}
+
+// ListPipelines lists pipelines matching the query, the latest first
+func (c *Client) ListPipelines(query *PipelineQuery) (*PaginatedPipelines, errors.Error) {
+	values := url.Values{}
+	if query != nil {
+		values = paginate(query.Page, query.PageSize)
+		if query.Status != "" {
+			values.Set("status", query.Status)
+		}
+		if query.Pending {
+			values.Set("pending", "1")
+		}
+		if query.BlueprintId > 0 {
+			values.Set("blueprint_id", fmt.Sprint(query.BlueprintId))
+		}
+		if query.Label != "" {
+			values.Set("label", query.Label)
+		}
+	}
+	result := &PaginatedPipelines{}
+	return result, c.Get("/pipelines", values, result)
+}
+
+// CreatePipeline creates a pipeline, it is run as soon as possible
+func (c *Client) CreatePipeline(pipeline *models.NewPipeline) (*models.Pipeline, errors.Error) {
+	result := &models.Pipeline{}
+	return result, c.Do(http.MethodPost, "/pipelines", nil, pipeline, result)
+}
+
+func (c *Client) GetPipeline(pipelineId uint64) (*models.Pipeline, errors.Error) {
+	result := &models.Pipeline{}
+	return result, c.Get(fmt.Sprintf("/pipelines/%d", pipelineId), nil, result)
+}
+
+// CancelPipeline cancels a pending or running pipeline
+func (c *Client) CancelPipeline(pipelineId uint64) errors.Error {
+	return c.Do(http.MethodDelete, fmt.Sprintf("/pipelines/%d", pipelineId), nil, nil, nil)
+}
+
+// RerunPipeline reruns the failed tasks of the pipeline
+func (c *Client) RerunPipeline(pipelineId uint64) ([]*models.Task, errors.Error) {
+	var result []*models.Task
+	return result, c.Do(http.MethodPost, fmt.Sprintf("/pipelines/%d/rerun", pipelineId), nil, nil, &result)
+}
+
+func (c *Client) ListPipelineTasks(pipelineId uint64) (*PipelineTasks, errors.Error) {
+	result := &PipelineTasks{}
+	return result, c.Get(fmt.Sprintf("/pipelines/%d/tasks", pipelineId), nil, result)
+}
+
+// RerunTask reruns a task of a finished pipeline
+func (c *Client) RerunTask(taskId uint64) (*models.Task, errors.Error) {
+	result := &models.Task{}
+	return result, c.Do(http.MethodPost, fmt.Sprintf("/tasks/%d/rerun", taskId), nil, nil, result)
+}
+
+// DownloadPipelineLogs writes the logs of the pipeline to w as a tar.gz archive
+func (c *Client) DownloadPipelineLogs(pipelineId uint64, w io.Writer) errors.Error {
+	return c.Download(fmt.Sprintf("/pipelines/%d/logging.tar.gz", pipelineId), nil, w)
+}
+
+// WaitPipeline polls the pipeline every interval until it is finished, the finished pipeline is returned whatever
+// its status is
+func (c *Client) WaitPipeline(pipelineId uint64, interval time.Duration) (*models.Pipeline, errors.Error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		pipeline, err := c.GetPipeline(pipelineId)
+		if err != nil {
+			return nil, err
+		}
+		if !utils.StringsContains(models.PendingTaskStatus, pipeline.Status) {
+			return pipeline, nil
+		}
+		select {
+		case <-c.ctx.Done():
+			return pipeline, errors.Default.Wrap(c.ctx.Err(), fmt.Sprintf("stopped waiting for pipeline #%d", pipelineId))
+		case <-ticker.C:
+		}
+	}
+}
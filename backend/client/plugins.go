@@ -0,0 +1,99 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// Apis of plugins differ in types, so the results are decoded into the values passed in, which would be the
+// models of the plugin, e.g. `&[]models.GithubConnection{}` for ListConnections of the github plugin
+
+func pluginPath(pluginName string, format string, args ...interface{}) string {
+	return fmt.Sprintf("/plugins/%s/", url.PathEscape(pluginName)) + fmt.Sprintf(format, args...)
+}
+
+func (c *Client) ListConnections(pluginName string, connections interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "connections"), nil, connections)
+}
+
+func (c *Client) GetConnection(pluginName string, connectionId uint64, connection interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "connections/%d", connectionId), nil, connection)
+}
+
+// CreateConnection creates the connection and decodes the created one back into it
+func (c *Client) CreateConnection(pluginName string, connection interface{}) errors.Error {
+	return c.Do(http.MethodPost, pluginPath(pluginName, "connections"), nil, connection, connection)
+}
+
+// PatchConnection updates the fields present in changes and decodes the updated connection into result
+func (c *Client) PatchConnection(pluginName string, connectionId uint64, changes interface{}, result interface{}) errors.Error {
+	return c.Do(http.MethodPatch, pluginPath(pluginName, "connections/%d", connectionId), nil, changes, result)
+}
+
+func (c *Client) DeleteConnection(pluginName string, connectionId uint64) errors.Error {
+	return c.Do(http.MethodDelete, pluginPath(pluginName, "connections/%d", connectionId), nil, nil, nil)
+}
+
+// TestConnection verifies the connection against the data source without saving it, the response differs among
+// plugins so it is returned as is
+func (c *Client) TestConnection(pluginName string, connection interface{}) (json.RawMessage, errors.Error) {
+	var result json.RawMessage
+	return result, c.Do(http.MethodPost, pluginPath(pluginName, "test"), nil, connection, &result)
+}
+
+func (c *Client) ListScopes(pluginName string, connectionId uint64, page int, pageSize int, scopes interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "connections/%d/scopes", connectionId), paginate(page, pageSize), scopes)
+}
+
+func (c *Client) GetScope(pluginName string, connectionId uint64, scopeId string, scope interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "connections/%d/scopes/%s", connectionId, url.PathEscape(scopeId)), nil, scope)
+}
+
+// PutScopes creates or updates the scopes of the connection, scopes would be a slice of the scope model
+func (c *Client) PutScopes(pluginName string, connectionId uint64, scopes interface{}, result interface{}) errors.Error {
+	body := map[string]interface{}{"data": scopes}
+	return c.Do(http.MethodPut, pluginPath(pluginName, "connections/%d/scopes", connectionId), nil, body, result)
+}
+
+func (c *Client) PatchScope(pluginName string, connectionId uint64, scopeId string, changes interface{}, result interface{}) errors.Error {
+	path := pluginPath(pluginName, "connections/%d/scopes/%s", connectionId, url.PathEscape(scopeId))
+	return c.Do(http.MethodPatch, path, nil, changes, result)
+}
+
+func (c *Client) ListTransformationRules(pluginName string, page int, pageSize int, rules interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "transformation_rules"), paginate(page, pageSize), rules)
+}
+
+func (c *Client) GetTransformationRule(pluginName string, ruleId uint64, rule interface{}) errors.Error {
+	return c.Get(pluginPath(pluginName, "transformation_rules/%d", ruleId), nil, rule)
+}
+
+// CreateTransformationRule creates the rule and decodes the created one back into it
+func (c *Client) CreateTransformationRule(pluginName string, rule interface{}) errors.Error {
+	return c.Do(http.MethodPost, pluginPath(pluginName, "transformation_rules"), nil, rule, rule)
+}
+
+func (c *Client) PatchTransformationRule(pluginName string, ruleId uint64, changes interface{}, result interface{}) errors.Error {
+	return c.Do(http.MethodPatch, pluginPath(pluginName, "transformation_rules/%d", ruleId), nil, changes, result)
+}
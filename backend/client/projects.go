@@ -0,0 +1,85 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+type PaginatedProjects struct {
+	Projects []*models.Project `json:"projects"`
+	Count    int64             `json:"count"`
+}
+
+func (c *Client) ListProjects(page int, pageSize int) (*PaginatedProjects, errors.Error) {
+	result := &PaginatedProjects{}
+	return result, c.Get("/projects", paginate(page, pageSize), result)
+}
+
+func (c *Client) CreateProject(project *models.ApiInputProject) (*models.ApiOutputProject, errors.Error) {
+	result := &models.ApiOutputProject{}
+	return result, c.Do(http.MethodPost, "/projects", nil, project, result)
+}
+
+// GetProject returns the project along with its metrics and blueprint
+func (c *Client) GetProject(name string) (*models.ApiOutputProject, errors.Error) {
+	result := &models.ApiOutputProject{}
+	return result, c.Get("/projects/"+url.PathEscape(name), nil, result)
+}
+
+func (c *Client) PatchProject(name string, project *models.ApiInputProject) (*models.ApiOutputProject, errors.Error) {
+	result := &models.ApiOutputProject{}
+	return result, c.Do(http.MethodPatch, "/projects/"+url.PathEscape(name), nil, project, result)
+}
+
+// ExportProject writes the bundle of the project into w, along with its domain layer data if asked to
+func (c *Client) ExportProject(name string, withDomainData bool, w io.Writer) errors.Error {
+	query := url.Values{"domainData": []string{strconv.FormatBool(withDomainData)}}
+	return c.Download("/projects/"+url.PathEscape(name)+"/export", query, w)
+}
+
+type ProjectImportInput struct {
+	// Bundle is the one written by ExportProject
+	Bundle json.RawMessage `json:"bundle"`
+	// Secrets are values of the secret fields of the connections keyed by `plugin/name`
+	Secrets map[string]map[string]interface{} `json:"secrets,omitempty"`
+}
+
+type ProjectImportResult struct {
+	DryRun     bool            `json:"dryRun"`
+	Changes    []*ConfigChange `json:"changes"`
+	Conflicts  []*ConfigChange `json:"conflicts"`
+	DomainRows map[string]int  `json:"domainRows"`
+}
+
+// ImportProject imports the bundle exported by another instance, it fails on conflicts unless overwrite is true
+func (c *Client) ImportProject(input *ProjectImportInput, dryRun bool, overwrite bool) (*ProjectImportResult, errors.Error) {
+	query := url.Values{
+		"dryRun":    []string{strconv.FormatBool(dryRun)},
+		"overwrite": []string{strconv.FormatBool(overwrite)},
+	}
+	result := &ProjectImportResult{}
+	return result, c.Do(http.MethodPost, "/projects/import", query, input, result)
+}
@@ -79,6 +79,9 @@ type ColumnMeta interface {
 type SessionConfig struct {
 	PrepareStmt            bool
 	SkipDefaultTransaction bool
+	// DryRunRecorder, when set, receives every write statement instead of having it executed,
+	// read statements are still executed against the database
+	DryRunRecorder func(statement string)
 }
 
 // Dal aims to facilitate an isolation between DBS and our System by defining a set of operations should a DBS provide
@@ -20,6 +20,7 @@ package migration
 import (
 	"fmt"
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"sort"
@@ -31,12 +32,32 @@ type scriptWithComment struct {
 	comment string
 }
 
+func (swc *scriptWithComment) key() string {
+	return scriptKey(swc.script.Name(), swc.script.Version())
+}
+
+func scriptKey(name string, version uint64) string {
+	return fmt.Sprintf("%s:%d", name, version)
+}
+
+// dryRunBasicRes overrides the Dal of the embedded BasicRes with a dry-run session
+type dryRunBasicRes struct {
+	context.BasicRes
+	dal dal.Dal
+}
+
+func (r *dryRunBasicRes) GetDal() dal.Dal {
+	return r.dal
+}
+
 type migratorImpl struct {
 	sync.Mutex
 	basicRes context.BasicRes
-	executed map[string]bool
+	executed map[string]*MigrationHistory
 	scripts  []*scriptWithComment
 	pending  []*scriptWithComment
+	running  []*scriptWithComment
+	online   sync.WaitGroup
 }
 
 func (m *migratorImpl) loadExecuted() errors.Error {
@@ -47,14 +68,14 @@ func (m *migratorImpl) loadExecuted() errors.Error {
 		return errors.Default.Wrap(err, "error performing migrations")
 	}
 	// load executed scripts into memory
-	m.executed = make(map[string]bool)
-	var records []MigrationHistory
+	m.executed = make(map[string]*MigrationHistory)
+	var records []*MigrationHistory
 	err = db.All(&records)
 	if err != nil {
 		return errors.Default.Wrap(err, "error finding migration history records")
 	}
 	for _, record := range records {
-		m.executed[fmt.Sprintf("%s:%d", record.ScriptName, record.ScriptVersion)] = true
+		m.executed[scriptKey(record.ScriptName, record.ScriptVersion)] = record
 	}
 	return nil
 }
@@ -64,53 +85,240 @@ func (m *migratorImpl) Register(scripts []plugin.MigrationScript, comment string
 	m.Lock()
 	defer m.Unlock()
 	for _, script := range scripts {
-		key := fmt.Sprintf("%s:%d", script.Name(), script.Version())
 		swc := &scriptWithComment{
 			script:  script,
 			comment: comment,
 		}
 		m.scripts = append(m.scripts, swc)
-		if !m.executed[key] {
+		if m.executed[swc.key()] == nil {
 			m.pending = append(m.pending, swc)
 		}
 	}
 }
 
-// Execute all registered migration script in order and mark them as executed in migration_history table
+// Execute all registered migration script in order and mark them as executed in migration_history table.
+// Online scripts at the end of the queue are applied in the background after the others
 func (m *migratorImpl) Execute() errors.Error {
 	// sort the scripts by version
 	sort.Slice(m.pending, func(i, j int) bool {
 		return m.pending[i].script.Version() < m.pending[j].script.Version()
 	})
+	offline := len(m.pending)
+	for offline > 0 && isOnline(m.pending[offline-1].script) {
+		offline--
+	}
 	// execute them one by one
-	db := m.basicRes.GetDal()
-	logger := m.basicRes.GetLogger().Nested("migrator")
-	for _, swc := range m.pending {
-		scriptId := fmt.Sprintf("%d-%s", swc.script.Version(), swc.script.Name())
-		logger.Info("applying migratin script %s", scriptId)
-		err := swc.script.Up(m.basicRes)
+	for _, swc := range m.pending[:offline] {
+		err := m.apply(swc)
 		if err != nil {
 			return err
 		}
-		err = db.Create(&MigrationHistory{
-			ScriptVersion: swc.script.Version(),
-			ScriptName:    swc.script.Name(),
-			Comment:       swc.comment,
-		})
+	}
+	m.Lock()
+	m.running = append(m.running, m.pending...)
+	m.pending = nil
+	online := m.running
+	m.Unlock()
+	if len(online) > 0 {
+		m.online.Add(1)
+		go m.applyOnline(online)
+	}
+	return nil
+}
+
+// Wait blocks until the online scripts started by Execute are finished
+func (m *migratorImpl) Wait() {
+	m.online.Wait()
+}
+
+func (m *migratorImpl) applyOnline(scripts []*scriptWithComment) {
+	defer m.online.Done()
+	logger := m.basicRes.GetLogger().Nested("migrator")
+	for _, swc := range scripts {
+		err := m.apply(swc)
 		if err != nil {
-			return errors.Default.Wrap(err, fmt.Sprintf("failed to execute migration script %s", scriptId))
+			// the remaining scripts would be pending again after restarting
+			logger.Error(err, "failed to apply online migration script %d-%s", swc.script.Version(), swc.script.Name())
+			return
 		}
-		m.executed[scriptId] = true
-		m.pending = m.pending[1:]
 	}
+}
+
+func (m *migratorImpl) apply(swc *scriptWithComment) errors.Error {
+	db := m.basicRes.GetDal()
+	logger := m.basicRes.GetLogger().Nested("migrator")
+	scriptId := fmt.Sprintf("%d-%s", swc.script.Version(), swc.script.Name())
+	logger.Info("applying migratin script %s", scriptId)
+	err := swc.script.Up(m.basicRes)
+	if err != nil {
+		return err
+	}
+	record := &MigrationHistory{
+		ScriptVersion: swc.script.Version(),
+		ScriptName:    swc.script.Name(),
+		Comment:       swc.comment,
+	}
+	err = db.Create(record)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to execute migration script %s", scriptId))
+	}
+	m.Lock()
+	defer m.Unlock()
+	m.executed[swc.key()] = record
+	m.pending = removeScript(m.pending, swc)
+	m.running = removeScript(m.running, swc)
 	return nil
 }
 
+func removeScript(scripts []*scriptWithComment, swc *scriptWithComment) []*scriptWithComment {
+	for i, s := range scripts {
+		if s == swc {
+			return append(scripts[:i:i], scripts[i+1:]...)
+		}
+	}
+	return scripts
+}
+
+func isOnline(script plugin.MigrationScript) bool {
+	online, ok := script.(plugin.MigrationScriptOnline)
+	return ok && online.Online()
+}
+
 // HasPendingScripts returns if there is any pending migration scripts
 func (m *migratorImpl) HasPendingScripts() bool {
 	return len(m.executed) > 0 && len(m.pending) > 0
 }
 
+// Scripts returns all registered scripts ordered by version along with their execution status
+func (m *migratorImpl) Scripts() []*plugin.MigrationScriptInfo {
+	m.Lock()
+	defer m.Unlock()
+	infos := make([]*plugin.MigrationScriptInfo, 0, len(m.scripts))
+	for _, swc := range sortedByVersion(m.scripts) {
+		_, reversible := swc.script.(plugin.MigrationScriptDown)
+		info := &plugin.MigrationScriptInfo{
+			Version:    swc.script.Version(),
+			Name:       swc.script.Name(),
+			Comment:    swc.comment,
+			Reversible: reversible,
+		}
+		if record := m.executed[swc.key()]; record != nil {
+			info.Executed = true
+			info.ExecutedAt = &record.CreatedAt
+		}
+		for _, running := range m.running {
+			info.Running = info.Running || running == swc
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// DryRun runs pending scripts against a dry-run session and collects the write statements they
+// would execute. Since nothing gets applied, a script depending on the result of a previous one
+// may fail, the failure is reported along with the script instead of aborting the whole process
+func (m *migratorImpl) DryRun() ([]*plugin.MigrationDryRunResult, errors.Error) {
+	m.Lock()
+	defer m.Unlock()
+	results := make([]*plugin.MigrationDryRunResult, 0, len(m.pending))
+	for _, swc := range sortedByVersion(m.pending) {
+		result := &plugin.MigrationDryRunResult{
+			Version:    swc.script.Version(),
+			Name:       swc.script.Name(),
+			Comment:    swc.comment,
+			Statements: make([]string, 0),
+		}
+		dryRunRes := &dryRunBasicRes{
+			BasicRes: m.basicRes,
+			dal: m.basicRes.GetDal().Session(dal.SessionConfig{
+				DryRunRecorder: func(statement string) {
+					result.Statements = append(result.Statements, statement)
+				},
+			}),
+		}
+		err := runSafely(func() errors.Error {
+			return swc.script.Up(dryRunRes)
+		})
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Rollback reverts executed scripts newer than the specified version in reverse order and
+// removes them from the migration_history table, so they would be applied again by next Execute
+func (m *migratorImpl) Rollback(version uint64) errors.Error {
+	m.Lock()
+	defer m.Unlock()
+	if len(m.running) > 0 {
+		return errors.BadInput.New("online migration scripts are still running")
+	}
+	// collect scripts to be reverted and make sure all of them are reversible before touching anything
+	var targets []*scriptWithComment
+	registered := make(map[string]bool)
+	for _, swc := range m.scripts {
+		registered[swc.key()] = true
+		if swc.script.Version() > version && m.executed[swc.key()] != nil {
+			if _, ok := swc.script.(plugin.MigrationScriptDown); !ok {
+				return errors.BadInput.New(fmt.Sprintf(
+					"migration script %d-%s of %s is not reversible", swc.script.Version(), swc.script.Name(), swc.comment,
+				))
+			}
+			targets = append(targets, swc)
+		}
+	}
+	for key, record := range m.executed {
+		if record.ScriptVersion > version && !registered[key] {
+			return errors.BadInput.New(fmt.Sprintf(
+				"executed migration script %d-%s of %s is not registered", record.ScriptVersion, record.ScriptName, record.Comment,
+			))
+		}
+	}
+	targets = sortedByVersion(targets)
+	// revert them one by one, newest first
+	db := m.basicRes.GetDal()
+	logger := m.basicRes.GetLogger().Nested("migrator")
+	for i := len(targets) - 1; i >= 0; i-- {
+		swc := targets[i]
+		scriptId := fmt.Sprintf("%d-%s", swc.script.Version(), swc.script.Name())
+		logger.Info("reverting migration script %s", scriptId)
+		err := swc.script.(plugin.MigrationScriptDown).Down(m.basicRes)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to revert migration script %s", scriptId))
+		}
+		err = db.Delete(
+			&MigrationHistory{},
+			dal.Where("script_version = ? AND script_name = ?", swc.script.Version(), swc.script.Name()),
+		)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to delete migration history of %s", scriptId))
+		}
+		delete(m.executed, swc.key())
+		m.pending = append(m.pending, swc)
+	}
+	return nil
+}
+
+func sortedByVersion(scripts []*scriptWithComment) []*scriptWithComment {
+	sorted := make([]*scriptWithComment, len(scripts))
+	copy(sorted, scripts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].script.Version() < sorted[j].script.Version()
+	})
+	return sorted
+}
+
+func runSafely(fn func() errors.Error) (err errors.Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Default.New(fmt.Sprintf("dry run aborted: %v", r))
+		}
+	}()
+	return fn()
+}
+
 // NewMigrator returns a new Migrator instance, which
 // implemented based on migration_history from the same database
 func NewMigrator(basicRes context.BasicRes) (plugin.Migrator, errors.Error) {
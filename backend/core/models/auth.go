@@ -0,0 +1,64 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// ApiKey authenticates api requests on behalf of its creator, only the sha256 of the key is stored
+type ApiKey struct {
+	common.Model
+	Name       string     `gorm:"type:varchar(255)" json:"name" validate:"required"`
+	Role       string     `gorm:"type:varchar(20)" json:"role" validate:"required,oneof=viewer operator admin"`
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex" json:"-"`
+	Prefix     string     `gorm:"type:varchar(20)" json:"prefix"`
+	Creator    string     `gorm:"type:varchar(255)" json:"creator"`
+	ExpiredAt  *time.Time `json:"expiredAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+}
+
+func (ApiKey) TableName() string {
+	return "_devlake_api_keys"
+}
+
+// AuditLog records api calls which modified something or got rejected, and changes made to the configurations
+// (Resource/ResourceId/Action/Changes) along with who made them
+type AuditLog struct {
+	ID         uint64 `gorm:"primaryKey" json:"id"`
+	Actor      string `gorm:"type:varchar(255);index" json:"actor"`
+	AuthMethod string `gorm:"type:varchar(20)" json:"authMethod"`
+	Role       string `gorm:"type:varchar(20)" json:"role"`
+	Method     string `gorm:"type:varchar(10)" json:"method"`
+	Path       string `gorm:"type:varchar(255)" json:"path"`
+	Status     int    `json:"status"`
+	ClientIp   string `gorm:"type:varchar(100)" json:"clientIp"`
+	Resource   string `gorm:"type:varchar(100);index" json:"resource"`
+	ResourceId string `gorm:"type:varchar(255)" json:"resourceId"`
+	Action     string `gorm:"type:varchar(20)" json:"action"`
+	// Changes maps field names to their before/after values, sensitive fields are redacted
+	Changes   json.RawMessage `gorm:"type:json" json:"changes"`
+	CreatedAt time.Time       `gorm:"index" json:"createdAt"`
+}
+
+func (AuditLog) TableName() string {
+	return "_devlake_audit_logs"
+}
@@ -19,12 +19,15 @@ package crossdomain
 
 import (
 	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"time"
 )
 
 type User struct {
 	domainlayer.DomainEntity
 	Email string `gorm:"type:varchar(255)"`
 	Name  string `gorm:"type:varchar(255)"`
+	// set when the user was removed from the directory it was synced from
+	DeletedAt *time.Time
 }
 
 func (User) TableName() string {
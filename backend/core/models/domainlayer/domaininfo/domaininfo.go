@@ -57,6 +57,7 @@ func GetDomainTablesInfo() []Tabler {
 		&crossdomain.PullRequestIssue{},
 		&crossdomain.RefsIssuesDiffs{},
 		&crossdomain.Team{},
+		&crossdomain.TeamMetric{},
 		&crossdomain.TeamUser{},
 		&crossdomain.User{},
 		&crossdomain.UserAccount{},
@@ -49,3 +49,30 @@ type LockingStub struct {
 func (LockingStub) TableName() string {
 	return "_devlake_locking_stub"
 }
+
+// Lease is a named lock held by a devlake instance until it expires, the holder has to renew it before that.
+// It is used instead of the LockingStub when multiple devlake instances share the same database (CLUSTER_MODE=true)
+type Lease struct {
+	Name   string `gorm:"primaryKey;type:varchar(100)" json:"name"`
+	Holder string `gorm:"type:varchar(255)" json:"holder"`
+	// Token increases whenever the lease changes hands, writes guarded by the lease carry the token they were
+	// issued with so that a holder which lost the lease without knowing it could not overwrite its successor
+	Token     uint64    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (Lease) TableName() string {
+	return "_devlake_leases"
+}
+
+// PipelineCancelRequest asks the instance running the pipeline to cancel it, the instance picks it up with the
+// next heartbeat since running tasks could only be cancelled by the process executing them
+type PipelineCancelRequest struct {
+	PipelineId uint64    `gorm:"primaryKey" json:"pipelineId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (PipelineCancelRequest) TableName() string {
+	return "_devlake_pipeline_cancel_requests"
+}
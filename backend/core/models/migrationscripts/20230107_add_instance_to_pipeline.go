@@ -0,0 +1,53 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+type pipeline20230107 struct {
+	Instance    string `gorm:"type:varchar(255)"`
+	HeartbeatAt *time.Time
+}
+
+func (pipeline20230107) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type addInstanceToPipeline struct{}
+
+func (*addInstanceToPipeline) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &pipeline20230107{})
+}
+
+func (*addInstanceToPipeline) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropColumns("_devlake_pipelines", "instance", "heartbeat_at")
+}
+
+func (*addInstanceToPipeline) Version() uint64 {
+	return 20230107000001
+}
+
+func (*addInstanceToPipeline) Name() string {
+	return "add instance and heartbeat_at to _devlake_pipelines"
+}
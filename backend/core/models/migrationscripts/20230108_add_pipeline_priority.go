@@ -0,0 +1,75 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+type pipeline20230108 struct {
+	Priority int
+}
+
+func (pipeline20230108) TableName() string {
+	return "_devlake_pipelines"
+}
+
+type blueprint20230108 struct {
+	Priority int
+}
+
+func (blueprint20230108) TableName() string {
+	return "_devlake_blueprints"
+}
+
+type task20230108 struct {
+	ConnectionId uint64 `gorm:"index"`
+}
+
+func (task20230108) TableName() string {
+	return "_devlake_tasks"
+}
+
+type addPipelinePriority struct{}
+
+func (*addPipelinePriority) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &pipeline20230108{}, &blueprint20230108{}, &task20230108{})
+}
+
+func (*addPipelinePriority) Down(basicRes context.BasicRes) errors.Error {
+	db := basicRes.GetDal()
+	err := db.DropColumns("_devlake_pipelines", "priority")
+	if err != nil {
+		return err
+	}
+	err = db.DropColumns("_devlake_blueprints", "priority")
+	if err != nil {
+		return err
+	}
+	return db.DropColumns("_devlake_tasks", "connection_id")
+}
+
+func (*addPipelinePriority) Version() uint64 {
+	return 20230108000001
+}
+
+func (*addPipelinePriority) Name() string {
+	return "add priority to _devlake_pipelines and _devlake_blueprints, connection_id to _devlake_tasks"
+}
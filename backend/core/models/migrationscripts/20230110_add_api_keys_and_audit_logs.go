@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+type apiKey20230110 struct {
+	archived.Model
+	Name       string `gorm:"type:varchar(255)"`
+	Role       string `gorm:"type:varchar(20)"`
+	KeyHash    string `gorm:"type:varchar(64);uniqueIndex"`
+	Prefix     string `gorm:"type:varchar(20)"`
+	Creator    string `gorm:"type:varchar(255)"`
+	ExpiredAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+func (apiKey20230110) TableName() string {
+	return "_devlake_api_keys"
+}
+
+type auditLog20230110 struct {
+	ID         uint64 `gorm:"primaryKey"`
+	Actor      string `gorm:"type:varchar(255);index"`
+	AuthMethod string `gorm:"type:varchar(20)"`
+	Role       string `gorm:"type:varchar(20)"`
+	Method     string `gorm:"type:varchar(10)"`
+	Path       string `gorm:"type:varchar(255)"`
+	Status     int
+	ClientIp   string    `gorm:"type:varchar(100)"`
+	CreatedAt  time.Time `gorm:"index"`
+}
+
+func (auditLog20230110) TableName() string {
+	return "_devlake_audit_logs"
+}
+
+type addApiKeysAndAuditLogs struct{}
+
+func (*addApiKeysAndAuditLogs) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &apiKey20230110{}, &auditLog20230110{})
+}
+
+func (*addApiKeysAndAuditLogs) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropTables(&apiKey20230110{}, &auditLog20230110{})
+}
+
+func (*addApiKeysAndAuditLogs) Version() uint64 {
+	return 20230110000001
+}
+
+func (*addApiKeysAndAuditLogs) Name() string {
+	return "add _devlake_api_keys and _devlake_audit_logs"
+}
@@ -0,0 +1,55 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"encoding/json"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+type auditLog20230111 struct {
+	Resource   string          `gorm:"type:varchar(100);index"`
+	ResourceId string          `gorm:"type:varchar(255)"`
+	Action     string          `gorm:"type:varchar(20)"`
+	Changes    json.RawMessage `gorm:"type:json"`
+}
+
+func (auditLog20230111) TableName() string {
+	return "_devlake_audit_logs"
+}
+
+type addChangesToAuditLogs struct{}
+
+func (*addChangesToAuditLogs) Up(basicRes context.BasicRes) errors.Error {
+	return migrationhelper.AutoMigrateTables(basicRes, &auditLog20230111{})
+}
+
+func (*addChangesToAuditLogs) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropColumns("_devlake_audit_logs", "resource", "resource_id", "action", "changes")
+}
+
+func (*addChangesToAuditLogs) Version() uint64 {
+	return 20230111000001
+}
+
+func (*addChangesToAuditLogs) Name() string {
+	return "add resource, action and changes to _devlake_audit_logs"
+}
@@ -69,5 +69,11 @@ func All() []plugin.MigrationScript {
 		new(encryptTask221221),
 		new(renameProjectMetrics),
 		new(addOriginalTypeToIssue221230),
+		new(addDeletedAtToUser230104),
+		new(addTeamMetric),
+		new(addInstanceToPipeline),
+		new(addPipelinePriority),
+		new(addApiKeysAndAuditLogs),
+		new(addChangesToAuditLogs),
 	}
 }
@@ -0,0 +1,61 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/migration"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllOnSqlite(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	cfg.Set(plugin.EncodeKeyEnvStr, "migration-test-key")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		return
+	}
+	basicRes := contextimpl.NewDefaultBasicRes(cfg, logruslog.Global, dalgorm.NewDalgorm(db))
+	migrator, err := migration.NewMigrator(basicRes)
+	if !assert.Nil(t, err) {
+		return
+	}
+	migrator.Register(All(), "Framework")
+	err = migrator.Execute()
+	if !assert.Nil(t, err) {
+		t.Log(err.Messages().Format())
+	}
+	assert.False(t, migrator.HasPendingScripts())
+
+	// the scripts after 20230106 are reversible and could be applied again
+	err = migrator.Rollback(20230106235959)
+	if !assert.Nil(t, err) {
+		t.Log(err.Messages().Format())
+	}
+	assert.True(t, migrator.HasPendingScripts())
+	assert.Nil(t, migrator.Execute())
+	assert.False(t, migrator.HasPendingScripts())
+}
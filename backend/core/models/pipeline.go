@@ -42,15 +42,22 @@ type Pipeline struct {
 	Stage         int            `json:"stage"`
 	Labels        []string       `json:"labels"`
 	SkipOnFail    bool           `json:"skipOnFail"`
+	Instance      string         `json:"instance"`
+	HeartbeatAt   *time.Time     `json:"heartbeatAt"`
+	Priority      int            `json:"priority"`
+	// QueuePosition is the 1-based position among pending pipelines, 0 for the others
+	QueuePosition int `json:"queuePosition" gorm:"-"`
 }
 
 // We use a 2D array because the request body must be an array of a set of tasks
 // to be executed concurrently, while each set is to be executed sequentially.
 type NewPipeline struct {
-	Name        string              `json:"name"`
-	Plan        plugin.PipelinePlan `json:"plan" swaggertype:"array,string" example:"please check api /pipelines/<PLUGIN_NAME>/pipeline-plan"`
-	Labels      []string            `json:"labels"`
-	SkipOnFail  bool                `json:"skipOnFail"`
+	Name       string              `json:"name"`
+	Plan       plugin.PipelinePlan `json:"plan" swaggertype:"array,string" example:"please check api /pipelines/<PLUGIN_NAME>/pipeline-plan"`
+	Labels     []string            `json:"labels"`
+	SkipOnFail bool                `json:"skipOnFail"`
+	// Priority decides the order of pending pipelines, the higher ones run first
+	Priority    int `json:"priority"`
 	BlueprintId uint64
 }
 
@@ -69,6 +76,10 @@ type DbPipeline struct {
 	SpentSeconds  int        `json:"spentSeconds"`
 	Stage         int        `json:"stage"`
 	SkipOnFail    bool       `json:"skipOnFail"`
+	// Instance is the devlake instance running the pipeline, which renews HeartbeatAt periodically
+	Instance    string     `json:"instance" gorm:"type:varchar(255)"`
+	HeartbeatAt *time.Time `json:"heartbeatAt"`
+	Priority    int        `json:"priority"`
 
 	Labels []DbPipelineLabel `json:"-" gorm:"-"`
 }
@@ -50,6 +50,7 @@ type TaskProgressDetail struct {
 type Task struct {
 	common.Model
 	Plugin         string              `json:"plugin" gorm:"index"`
+	ConnectionId   uint64              `json:"connectionId" gorm:"index"`
 	Subtasks       datatypes.JSON      `json:"subtasks"`
 	Options        string              `json:"options" gorm:"serializer:encdec"`
 	Status         string              `json:"status"`
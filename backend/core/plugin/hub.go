@@ -20,7 +20,9 @@ package plugin
 import (
 	"fmt"
 	"github.com/apache/incubator-devlake/core/errors"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // Allowing plugin to know each other
@@ -69,3 +71,50 @@ func FindPluginNameBySubPkgPath(subPkgPath string) (string, errors.Error) {
 	}
 	return "", errors.Default.New(fmt.Sprintf("Unable to find plugin for subPkgPath %s", subPkgPath))
 }
+
+const (
+	PLUGIN_STATUS_LOADED       = "loaded"
+	PLUGIN_STATUS_DISABLED     = "disabled"
+	PLUGIN_STATUS_FAILED       = "failed"
+	PLUGIN_STATUS_INCOMPATIBLE = "incompatible"
+)
+
+// PluginLoadStatus describes the outcome of loading a plugin
+type PluginLoadStatus struct {
+	Name         string   `json:"name"`
+	Status       string   `json:"status"`
+	Error        string   `json:"error,omitempty"`
+	ApiVersion   string   `json:"apiVersion,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+var loadStatuses = make(map[string]*PluginLoadStatus)
+var loadStatusesLock sync.Mutex
+
+// SetPluginLoadStatus records the load status of a plugin
+func SetPluginLoadStatus(status *PluginLoadStatus) {
+	loadStatusesLock.Lock()
+	defer loadStatusesLock.Unlock()
+	loadStatuses[status.Name] = status
+}
+
+// GetPluginLoadStatus returns the load status of a plugin, nil if the loader has never seen it
+func GetPluginLoadStatus(name string) *PluginLoadStatus {
+	loadStatusesLock.Lock()
+	defer loadStatusesLock.Unlock()
+	return loadStatuses[name]
+}
+
+// AllPluginLoadStatuses returns load statuses of all plugins sorted by name
+func AllPluginLoadStatuses() []*PluginLoadStatus {
+	loadStatusesLock.Lock()
+	defer loadStatusesLock.Unlock()
+	statuses := make([]*PluginLoadStatus, 0, len(loadStatuses))
+	for _, status := range loadStatuses {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
@@ -19,6 +19,7 @@ package plugin
 
 import (
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
 	"net/http"
 	"net/url"
 )
@@ -29,6 +30,7 @@ type ApiResourceInput struct {
 	Query   url.Values             // query string
 	Body    map[string]interface{} // json body
 	Request *http.Request
+	User    *common.User // the authenticated caller
 }
 
 // OutputFile is the file returned
@@ -62,3 +64,21 @@ type ApiResourceHandler func(input *ApiResourceInput) (*ApiResourceOutput, error
 type PluginApi interface {
 	ApiResources() map[string]map[string]ApiResourceHandler
 }
+
+// ApiResourceDoc describes an api resource in the OpenAPI document served by the framework, Request and
+// Response are values of the types of json bodies, e.g. `&models.JiraConnection{}` or `[]models.JiraBoard{}`,
+// they are only inspected by reflection
+type ApiResourceDoc struct {
+	Summary     string
+	Description string
+	Query       map[string]string // query parameters along with their descriptions
+	Request     interface{}
+	Response    interface{}
+}
+
+// PluginApiDoc is implemented by plugins to attach type information to their api resources, it is keyed the same
+// way as ApiResources. Resources of a PluginSource following the conventions, e.g. `connections/:connectionId`,
+// are documented with its Connection, Scope and TransformationRule without it
+type PluginApiDoc interface {
+	ApiResourceDocs() map[string]map[string]*ApiResourceDoc
+}
@@ -0,0 +1,250 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+const (
+	// EncodeKeysEnvStr holds additional keys in the form of `id1=key1,id2=key2`
+	EncodeKeysEnvStr = "ENCODE_KEYS"
+	// EncodeKeysFileEnvStr points to a file containing one `id=key` per line
+	EncodeKeysFileEnvStr = "ENCODE_KEYS_FILE"
+	// EncodeKeyIdEnvStr specifies which key should be used to encrypt new data
+	EncodeKeyIdEnvStr = "ENCODE_KEY_ID"
+	// DefaultEncodeKeyId is the id assigned to the key set by ENCODE_KEY
+	DefaultEncodeKeyId = "default"
+)
+
+// ciphertexts produced by the keyring look like `v1:<key id>:<base64>`, the
+// legacy ones produced by Encrypt are plain base64 which never contains `:`
+const ciphertextVersionPrefix = "v1:"
+
+var keyIdPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// EncryptionKeyring holds all keys which might have been used to encrypt data,
+// new data is always encrypted by the primary key while existing data could be
+// decrypted by any key in the ring, which makes it possible to rotate keys
+type EncryptionKeyring struct {
+	keys      map[string]string
+	primaryId string
+	legacyKey string
+}
+
+// NewEncryptionKeyring creates a keyring with the given keys, `primaryId` must be one of them.
+// `legacyKey` is used to decrypt ciphertexts without key id, it is optional
+func NewEncryptionKeyring(keys map[string]string, primaryId string, legacyKey string) (*EncryptionKeyring, errors.Error) {
+	if len(keys) == 0 {
+		return nil, errors.Default.New("at least one encryption key is required")
+	}
+	for id, key := range keys {
+		if !keyIdPattern.MatchString(id) {
+			return nil, errors.Default.New(fmt.Sprintf("invalid encryption key id %q, only letters, digits, `_`, `.` and `-` are allowed", id))
+		}
+		if key == "" {
+			return nil, errors.Default.New(fmt.Sprintf("encryption key %s is empty", id))
+		}
+	}
+	if _, ok := keys[primaryId]; !ok {
+		return nil, errors.Default.New(fmt.Sprintf("primary encryption key %q not found", primaryId))
+	}
+	return &EncryptionKeyring{keys: keys, primaryId: primaryId, legacyKey: legacyKey}, nil
+}
+
+// LoadEncryptionKeyring loads the keyring from configuration:
+//   - ENCODE_KEY: the original key, registered as `default` and used for data encrypted before key versioning
+//   - ENCODE_KEYS: additional keys in the form of `id1=key1,id2=key2`
+//   - ENCODE_KEYS_FILE: a file containing one `id=key` per line, lines starting with `#` are ignored
+//   - ENCODE_KEY_ID: the id of the key to encrypt new data with, defaults to `default`
+func LoadEncryptionKeyring(getConfig func(name string) string) (*EncryptionKeyring, errors.Error) {
+	keys := make(map[string]string)
+	legacyKey := getConfig(EncodeKeyEnvStr)
+	if legacyKey != "" {
+		keys[DefaultEncodeKeyId] = legacyKey
+	}
+	if err := parseEncryptionKeys(keys, strings.Split(getConfig(EncodeKeysEnvStr), ","), EncodeKeysEnvStr); err != nil {
+		return nil, err
+	}
+	if path := getConfig(EncodeKeysFileEnvStr); path != "" {
+		lines, err := readEncryptionKeysFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := parseEncryptionKeys(keys, lines, path); err != nil {
+			return nil, err
+		}
+	}
+	primaryId := getConfig(EncodeKeyIdEnvStr)
+	if primaryId == "" {
+		primaryId = DefaultEncodeKeyId
+	}
+	return NewEncryptionKeyring(keys, primaryId, legacyKey)
+}
+
+func parseEncryptionKeys(keys map[string]string, entries []string, source string) errors.Error {
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		id, key, found := strings.Cut(entry, "=")
+		id, key = strings.TrimSpace(id), strings.TrimSpace(key)
+		if !found || id == "" || key == "" {
+			return errors.Default.New(fmt.Sprintf("invalid encryption key entry in %s, `id=key` expected", source))
+		}
+		if existing, ok := keys[id]; ok && existing != key {
+			return errors.Default.New(fmt.Sprintf("encryption key %s is defined more than once with different values", id))
+		}
+		keys[id] = key
+	}
+	return nil
+}
+
+func readEncryptionKeysFile(path string) ([]string, errors.Error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to open encryption keys file")
+	}
+	defer file.Close()
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to read encryption keys file")
+	}
+	return lines, nil
+}
+
+// PrimaryKeyId returns the id of the key used to encrypt new data
+func (k *EncryptionKeyring) PrimaryKeyId() string {
+	return k.primaryId
+}
+
+// KeyIds returns ids of all keys in the ring
+func (k *EncryptionKeyring) KeyIds() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Encrypt encrypts the plaintext with the primary key and tags the ciphertext with its id
+func (k *EncryptionKeyring) Encrypt(plainText string) (string, errors.Error) {
+	encrypted, err := Encrypt(k.keys[k.primaryId], plainText)
+	if err != nil {
+		return plainText, err
+	}
+	return ciphertextVersionPrefix + k.primaryId + ":" + encrypted, nil
+}
+
+// Decrypt decrypts ciphertexts produced by either the keyring or the legacy Encrypt function
+func (k *EncryptionKeyring) Decrypt(encryptedText string) (string, errors.Error) {
+	keyId, body, versioned := parseCiphertext(encryptedText)
+	if versioned {
+		key, ok := k.keys[keyId]
+		if !ok {
+			return encryptedText, errors.Default.New(fmt.Sprintf("encryption key %s not found, please add it to %s or %s", keyId, EncodeKeysEnvStr, EncodeKeysFileEnvStr))
+		}
+		return Decrypt(key, body)
+	}
+	// legacy ciphertexts carry no key id, try the original key first then the rest
+	if k.legacyKey != "" {
+		if plainText, err := Decrypt(k.legacyKey, encryptedText); err == nil {
+			return plainText, nil
+		}
+	}
+	for _, id := range k.KeyIds() {
+		if k.keys[id] == k.legacyKey {
+			continue
+		}
+		if plainText, err := Decrypt(k.keys[id], encryptedText); err == nil {
+			return plainText, nil
+		}
+	}
+	return encryptedText, errors.Default.New("none of the encryption keys could decrypt the data")
+}
+
+// NeedsRotation returns true if the ciphertext was not encrypted by the primary key
+func (k *EncryptionKeyring) NeedsRotation(encryptedText string) bool {
+	keyId, _, versioned := parseCiphertext(encryptedText)
+	return !versioned || keyId != k.primaryId
+}
+
+// Reencrypt decrypts the ciphertext and encrypts it again with the primary key,
+// the second return value indicates whether the ciphertext was changed
+func (k *EncryptionKeyring) Reencrypt(encryptedText string) (string, bool, errors.Error) {
+	if !k.NeedsRotation(encryptedText) {
+		return encryptedText, false, nil
+	}
+	plainText, err := k.Decrypt(encryptedText)
+	if err != nil {
+		return encryptedText, false, err
+	}
+	reencrypted, err := k.Encrypt(plainText)
+	if err != nil {
+		return encryptedText, false, err
+	}
+	return reencrypted, true, nil
+}
+
+func parseCiphertext(encryptedText string) (keyId string, body string, versioned bool) {
+	if !strings.HasPrefix(encryptedText, ciphertextVersionPrefix) {
+		return "", encryptedText, false
+	}
+	keyId, body, versioned = strings.Cut(strings.TrimPrefix(encryptedText, ciphertextVersionPrefix), ":")
+	return
+}
+
+var keyring *EncryptionKeyring
+var keyringLock sync.Mutex
+
+// SetEncryptionKeyring sets the application wide keyring
+func SetEncryptionKeyring(k *EncryptionKeyring) {
+	keyringLock.Lock()
+	defer keyringLock.Unlock()
+	keyring = k
+}
+
+// GetEncryptionKeyring returns the application wide keyring, it would be loaded from the
+// global configuration if it wasn't set
+func GetEncryptionKeyring() *EncryptionKeyring {
+	keyringLock.Lock()
+	defer keyringLock.Unlock()
+	if keyring == nil {
+		k, err := LoadEncryptionKeyring(config.GetConfig().GetString)
+		if err != nil {
+			panic(err)
+		}
+		keyring = k
+	}
+	return keyring
+}
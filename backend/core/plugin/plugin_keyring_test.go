@@ -0,0 +1,93 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptionKeyringRotation(t *testing.T) {
+	legacyKey := RandomEncKey()
+	legacyCiphertext, err := Encrypt(legacyKey, "secret")
+	assert.Nil(t, err)
+
+	oldRing, err := NewEncryptionKeyring(map[string]string{DefaultEncodeKeyId: legacyKey}, DefaultEncodeKeyId, legacyKey)
+	assert.Nil(t, err)
+	oldCiphertext, err := oldRing.Encrypt("secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1:default:", oldCiphertext[:11])
+
+	newRing, err := NewEncryptionKeyring(map[string]string{DefaultEncodeKeyId: legacyKey, "2023": RandomEncKey()}, "2023", legacyKey)
+	assert.Nil(t, err)
+	for _, ciphertext := range []string{legacyCiphertext, oldCiphertext} {
+		plainText, err := newRing.Decrypt(ciphertext)
+		assert.Nil(t, err)
+		assert.Equal(t, "secret", plainText)
+		assert.True(t, newRing.NeedsRotation(ciphertext))
+
+		rotated, changed, err := newRing.Reencrypt(ciphertext)
+		assert.Nil(t, err)
+		assert.True(t, changed)
+		assert.False(t, newRing.NeedsRotation(rotated))
+		plainText, err = newRing.Decrypt(rotated)
+		assert.Nil(t, err)
+		assert.Equal(t, "secret", plainText)
+
+		_, err = oldRing.Decrypt(rotated)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestLegacyCiphertextWithoutLegacyKey(t *testing.T) {
+	key := RandomEncKey()
+	legacyCiphertext, err := Encrypt(key, "secret")
+	assert.Nil(t, err)
+	ring, err := NewEncryptionKeyring(map[string]string{"old": key, "new": RandomEncKey()}, "new", "")
+	assert.Nil(t, err)
+	plainText, err := ring.Decrypt(legacyCiphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", plainText)
+}
+
+func TestLoadEncryptionKeyring(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	assert.Nil(t, os.WriteFile(path, []byte("# rotated on 2023-01-12\nk3=KEY3\n"), 0600))
+	settings := map[string]string{
+		EncodeKeyEnvStr:      "KEY1",
+		EncodeKeysEnvStr:     "k2=KEY2, k3=KEY3",
+		EncodeKeysFileEnvStr: path,
+		EncodeKeyIdEnvStr:    "k3",
+	}
+	ring, err := LoadEncryptionKeyring(func(name string) string { return settings[name] })
+	assert.Nil(t, err)
+	assert.Equal(t, "k3", ring.PrimaryKeyId())
+	assert.Equal(t, []string{"default", "k2", "k3"}, ring.KeyIds())
+
+	settings[EncodeKeyIdEnvStr] = "k4"
+	_, err = LoadEncryptionKeyring(func(name string) string { return settings[name] })
+	assert.NotNil(t, err)
+
+	settings[EncodeKeyIdEnvStr] = ""
+	settings[EncodeKeysEnvStr] = "k3=OTHER"
+	_, err = LoadEncryptionKeyring(func(name string) string { return settings[name] })
+	assert.NotNil(t, err)
+}
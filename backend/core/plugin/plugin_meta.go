@@ -17,6 +17,18 @@ limitations under the License.
 
 package plugin
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// ApiVersion is the version of the plugin api provided by the framework, the major version changes
+// when plugins built against an older one can no longer work, the minor one changes when something was added
+const ApiVersion = "1.0"
+
 // PluginMeta is the Minimal features a plugin should comply, should be implemented by all plugins
 type PluginMeta interface {
 	Description() string
@@ -47,3 +59,44 @@ type PluginSource interface {
 	Scope() interface{}
 	TransformationRule() interface{}
 }
+
+// PluginCompatibility declares the plugin api version the plugin was built against and the plugins it
+// depends on, the loader refuses to load a plugin if either of them is not satisfied
+type PluginCompatibility interface {
+	// RequiredApiVersion should return ApiVersion, which would be compiled into the plugin
+	RequiredApiVersion() string
+	// Dependencies returns names of plugins which have to be loaded before this one
+	Dependencies() []string
+}
+
+// CheckApiVersion returns an error if a plugin built against the required version can't work with the framework
+func CheckApiVersion(required string) errors.Error {
+	requiredMajor, requiredMinor, err := parseApiVersion(required)
+	if err != nil {
+		return err
+	}
+	major, minor, err := parseApiVersion(ApiVersion)
+	if err != nil {
+		return err
+	}
+	if requiredMajor != major || requiredMinor > minor {
+		return errors.Default.New(fmt.Sprintf("plugin api version %s is required but the framework provides %s", required, ApiVersion))
+	}
+	return nil
+}
+
+func parseApiVersion(version string) (int, int, errors.Error) {
+	majorStr, minorStr, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	major, err := strconv.Atoi(majorStr)
+	if err != nil {
+		return 0, 0, errors.Default.New(fmt.Sprintf("invalid plugin api version %q", version))
+	}
+	minor := 0
+	if minorStr != "" {
+		minor, err = strconv.Atoi(minorStr)
+		if err != nil {
+			return 0, 0, errors.Default.New(fmt.Sprintf("invalid plugin api version %q", version))
+		}
+	}
+	return major, minor, nil
+}
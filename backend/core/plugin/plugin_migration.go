@@ -20,6 +20,7 @@ package plugin
 import (
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/errors"
+	"time"
 )
 
 // MigrationScript upgrades database to a newer version
@@ -29,11 +30,51 @@ type MigrationScript interface {
 	Name() string
 }
 
+// MigrationScriptDown is implemented by the MigrationScript which could be reverted
+type MigrationScriptDown interface {
+	Down(basicRes context.BasicRes) errors.Error
+}
+
+// MigrationScriptOnline is implemented by the MigrationScript which keeps tables available while running,
+// i.e. the ones built upon migrationhelper.OnlineTransformTable. The Migrator applies trailing online
+// scripts in the background so DevLake could start serving without waiting for them
+type MigrationScriptOnline interface {
+	Online() bool
+}
+
+// MigrationScriptInfo describes a registered MigrationScript and its execution status
+type MigrationScriptInfo struct {
+	Version    uint64     `json:"version"`
+	Name       string     `json:"name"`
+	Comment    string     `json:"comment"`
+	Executed   bool       `json:"executed"`
+	Running    bool       `json:"running"`
+	ExecutedAt *time.Time `json:"executedAt"`
+	Reversible bool       `json:"reversible"`
+}
+
+// MigrationDryRunResult holds the SQL statements a pending MigrationScript would execute
+type MigrationDryRunResult struct {
+	Version    uint64   `json:"version"`
+	Name       string   `json:"name"`
+	Comment    string   `json:"comment"`
+	Statements []string `json:"statements"`
+	Error      string   `json:"error,omitempty"`
+}
+
 // Migrator is responsible for making sure the registered scripts get applied to database and only once
 type Migrator interface {
 	Register(scripts []MigrationScript, comment string)
 	Execute() errors.Error
+	// Wait blocks until the online scripts started by Execute are finished
+	Wait()
 	HasPendingScripts() bool
+	// Scripts returns all registered scripts ordered by version along with their execution status
+	Scripts() []*MigrationScriptInfo
+	// DryRun collects the SQL statements of pending scripts without applying them
+	DryRun() ([]*MigrationDryRunResult, errors.Error)
+	// Rollback reverts executed scripts newer than the specified version in reverse order
+	Rollback(version uint64) errors.Error
 }
 
 // PluginMigration is implemented by the plugin to declare all migration script that have to be applied to the database
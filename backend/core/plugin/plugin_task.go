@@ -110,3 +110,9 @@ type CloseablePluginTask interface {
 	PluginTask
 	Close(taskCtx TaskContext) errors.Error
 }
+
+// GitCredentialProvider Implement this interface to let gitextractor clone repositories of your connections with
+// credentials issued when the task runs, i.e. tokens which would expire before the task runs if put into the plan
+type GitCredentialProvider interface {
+	GitCredential(taskCtx TaskContext, connectionId uint64) (user string, password string, err errors.Error)
+}
@@ -49,7 +49,12 @@ func CreateAppBasicRes() context.BasicRes {
 	if err != nil {
 		panic(err)
 	}
-	dalgorm.Init(cfg.GetString(plugin.EncodeKeyEnvStr))
+	keyring, err := plugin.LoadEncryptionKeyring(cfg.GetString)
+	if err != nil {
+		panic(err)
+	}
+	plugin.SetEncryptionKeyring(keyring)
+	dalgorm.Init(keyring)
 	return CreateBasicRes(cfg, logger, db)
 }
 
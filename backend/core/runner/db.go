@@ -25,6 +25,7 @@ import (
 	"github.com/apache/incubator-devlake/core/log"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormLogger "gorm.io/gorm/logger"
 	"net/url"
@@ -78,19 +79,27 @@ func NewGormDbEx(config config.ConfigReader, logger log.Logger, sessionConfig *d
 	if dbUrl == "" {
 		return nil, errors.BadInput.New("DB_URL is required")
 	}
-	u, err := url.Parse(dbUrl)
-	if err != nil {
-		return nil, errors.Convert(err)
-	}
 	var db *gorm.DB
-	switch strings.ToLower(u.Scheme) {
-	case "mysql":
-		dbUrl = fmt.Sprintf("%s@tcp(%s)%s?%s", u.User.String(), u.Host, u.Path, u.RawQuery)
-		db, err = gorm.Open(mysql.Open(dbUrl), dbConfig)
-	case "postgresql", "postgres", "pg":
-		db, err = gorm.Open(postgres.Open(dbUrl), dbConfig)
-	default:
-		return nil, errors.BadInput.New(fmt.Sprintf("invalid DB_URL:%s", dbUrl))
+	var err error
+	if dsn, ok, e := SqliteDsn(dbUrl); e != nil {
+		return nil, e
+	} else if ok {
+		db, err = gorm.Open(sqlite.Open(dsn), dbConfig)
+	} else {
+		var u *url.URL
+		u, err = url.Parse(dbUrl)
+		if err != nil {
+			return nil, errors.Convert(err)
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "mysql":
+			dbUrl = fmt.Sprintf("%s@tcp(%s)%s?%s", u.User.String(), u.Host, u.Path, u.RawQuery)
+			db, err = gorm.Open(mysql.Open(dbUrl), dbConfig)
+		case "postgresql", "postgres", "pg":
+			db, err = gorm.Open(postgres.Open(dbUrl), dbConfig)
+		default:
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid DB_URL:%s", dbUrl))
+		}
 	}
 	if err != nil {
 		return nil, errors.Convert(err)
@@ -105,3 +114,44 @@ func NewGormDbEx(config config.ConfigReader, logger log.Logger, sessionConfig *d
 
 	return db, errors.Convert(err)
 }
+
+// SqliteDsn converts a `sqlite://` DB_URL into a DSN for the sqlite driver, the second return value is false if
+// the url is not a sqlite one. Accepted forms are:
+//
+//	sqlite:///absolute/path/to/lake.db
+//	sqlite://relative/path/to/lake.db
+//
+// Query parameters are passed to the driver as is, WAL journal mode and a busy timeout are enabled by default so
+// that Dal.Cursor readers would not block writers. In-memory databases are not supported since every connection of
+// the pool would get its own database, or table level locks when sharing the cache, use a temporary file instead.
+func SqliteDsn(dbUrl string) (string, bool, errors.Error) {
+	var path string
+	for _, scheme := range []string{"sqlite://", "sqlite3://"} {
+		if len(dbUrl) >= len(scheme) && strings.EqualFold(dbUrl[:len(scheme)], scheme) {
+			path = dbUrl[len(scheme):]
+			break
+		}
+	}
+	if path == "" {
+		return "", false, nil
+	}
+	query := url.Values{}
+	if i := strings.Index(path, "?"); i >= 0 {
+		var err error
+		query, err = url.ParseQuery(path[i+1:])
+		if err != nil {
+			return "", true, errors.BadInput.Wrap(err, "invalid sqlite DB_URL query")
+		}
+		path = path[:i]
+	}
+	if path == "" || strings.Contains(path, ":memory:") || query.Get("mode") == "memory" {
+		return "", true, errors.BadInput.New("sqlite DB_URL must point to a database file")
+	}
+	if query.Get("_journal_mode") == "" {
+		query.Set("_journal_mode", "WAL")
+	}
+	if query.Get("_busy_timeout") == "" {
+		query.Set("_busy_timeout", "5000")
+	}
+	return "file:" + path + "?" + query.Encode(), true, nil
+}
@@ -25,12 +25,21 @@ import (
 	"io/fs"
 	"path/filepath"
 	goplugin "plugin"
+	"sort"
 	"strings"
 )
 
-// LoadPlugins load plugins from local directory
+// LoadPlugins load plugins from local directory, plugins listed in DISABLED_PLUGINS are skipped.
+// A plugin failing to load doesn't stop others from loading, its status is recorded instead
 func LoadPlugins(basicRes context.BasicRes) errors.Error {
 	pluginsDir := basicRes.GetConfig("PLUGIN_DIR")
+	disabled := make(map[string]bool)
+	for _, name := range strings.Split(basicRes.GetConfig("DISABLED_PLUGINS"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			disabled[name] = true
+		}
+	}
+	candidates := make(map[string]plugin.PluginMeta)
 	walkErr := filepath.WalkDir(pluginsDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -38,32 +47,144 @@ func LoadPlugins(basicRes context.BasicRes) errors.Error {
 		fileName := d.Name()
 		if strings.HasSuffix(fileName, ".so") && fileName != ".so" {
 			pluginName := fileName[0 : len(d.Name())-3]
-			plug, loadErr := goplugin.Open(path)
+			if disabled[pluginName] {
+				plugin.SetPluginLoadStatus(&plugin.PluginLoadStatus{Name: pluginName, Status: plugin.PLUGIN_STATUS_DISABLED})
+				basicRes.GetLogger().Info(`plugin disabled %s`, pluginName)
+				return nil
+			}
+			pluginMeta, loadErr := openPlugin(pluginName, path)
 			if loadErr != nil {
-				return loadErr
+				setPluginFailed(basicRes, pluginName, plugin.PLUGIN_STATUS_FAILED, loadErr)
+				return nil
 			}
-			symPluginEntry, pluginEntryError := plug.Lookup("PluginEntry")
-			if pluginEntryError != nil {
-				return pluginEntryError
+			candidates[pluginName] = pluginMeta
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return errors.Convert(walkErr)
+	}
+	InitPlugins(basicRes, candidates)
+	return nil
+}
+
+func openPlugin(pluginName string, path string) (plugin.PluginMeta, errors.Error) {
+	plug, err := goplugin.Open(path)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to open %s, it might be built by a different Go toolchain or against a different framework version, please rebuild it", path))
+	}
+	symPluginEntry, err := plug.Lookup("PluginEntry")
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("%s doesn't export PluginEntry", pluginName))
+	}
+	pluginMeta, ok := symPluginEntry.(plugin.PluginMeta)
+	if !ok {
+		return nil, errors.Default.New(fmt.Sprintf("%s PluginEntry must implement PluginMeta interface", pluginName))
+	}
+	return pluginMeta, nil
+}
+
+// InitPlugins checks compatibility of plugins, then initializes and registers them in dependency order,
+// it is shared by the loaders of in-process and remote plugins
+func InitPlugins(basicRes context.BasicRes, candidates map[string]plugin.PluginMeta) {
+	pending := make(map[string]*plugin.PluginLoadStatus)
+	for pluginName, pluginMeta := range candidates {
+		status := &plugin.PluginLoadStatus{Name: pluginName}
+		if compatibility, ok := pluginMeta.(plugin.PluginCompatibility); ok {
+			status.ApiVersion = compatibility.RequiredApiVersion()
+			status.Dependencies = compatibility.Dependencies()
+			if err := plugin.CheckApiVersion(status.ApiVersion); err != nil {
+				status.Status = plugin.PLUGIN_STATUS_INCOMPATIBLE
+				status.Error = err.Error()
+				plugin.SetPluginLoadStatus(status)
+				basicRes.GetLogger().Error(err, "plugin %s is incompatible", pluginName)
+				continue
 			}
-			pluginMeta, ok := symPluginEntry.(plugin.PluginMeta)
-			if !ok {
-				return errors.Default.New(fmt.Sprintf("%s PluginEntry must implement PluginMeta interface", pluginName))
+		}
+		pending[pluginName] = status
+	}
+	for len(pending) > 0 {
+		progressed := false
+		for _, pluginName := range sortedPluginNames(pending) {
+			status := pending[pluginName]
+			ready, depErr := dependenciesReady(status, pending)
+			if !ready {
+				continue
 			}
-			if pluginEntry, ok := symPluginEntry.(plugin.PluginInit); ok {
-				err = pluginEntry.Init(basicRes)
-				if err != nil {
-					return err
-				}
+			delete(pending, pluginName)
+			progressed = true
+			if depErr != nil {
+				setPluginFailed(basicRes, pluginName, plugin.PLUGIN_STATUS_FAILED, depErr)
+				continue
 			}
-			err = plugin.RegisterPlugin(pluginName, pluginMeta)
+			err := initPlugin(basicRes, pluginName, candidates[pluginName])
 			if err != nil {
-				return nil
+				setPluginFailed(basicRes, pluginName, plugin.PLUGIN_STATUS_FAILED, err)
+				continue
 			}
-
+			status.Status = plugin.PLUGIN_STATUS_LOADED
+			plugin.SetPluginLoadStatus(status)
 			basicRes.GetLogger().Info(`plugin loaded %s`, pluginName)
 		}
-		return nil
-	})
-	return errors.Convert(walkErr)
+		if !progressed {
+			// whatever left depends on each other
+			for _, pluginName := range sortedPluginNames(pending) {
+				setPluginFailed(basicRes, pluginName, plugin.PLUGIN_STATUS_FAILED, errors.Default.New("circular dependency between plugins"))
+			}
+			return
+		}
+	}
+}
+
+// dependenciesReady returns true once all dependencies were settled, along with an error if any of them is not loaded
+func dependenciesReady(status *plugin.PluginLoadStatus, pending map[string]*plugin.PluginLoadStatus) (bool, errors.Error) {
+	for _, dependency := range status.Dependencies {
+		if _, ok := pending[dependency]; ok {
+			return false, nil
+		}
+	}
+	for _, dependency := range status.Dependencies {
+		dependencyStatus := plugin.GetPluginLoadStatus(dependency)
+		if dependencyStatus == nil {
+			return true, errors.Default.New(fmt.Sprintf("depends on plugin %s which is not found", dependency))
+		}
+		if dependencyStatus.Status != plugin.PLUGIN_STATUS_LOADED {
+			return true, errors.Default.New(fmt.Sprintf("depends on plugin %s which is %s", dependency, dependencyStatus.Status))
+		}
+	}
+	return true, nil
+}
+
+func initPlugin(basicRes context.BasicRes, pluginName string, pluginMeta plugin.PluginMeta) errors.Error {
+	if pluginEntry, ok := pluginMeta.(plugin.PluginInit); ok {
+		err := pluginEntry.Init(basicRes)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to initialize plugin %s", pluginName))
+		}
+	}
+	err := plugin.RegisterPlugin(pluginName, pluginMeta)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to register plugin %s", pluginName))
+	}
+	return nil
+}
+
+func setPluginFailed(basicRes context.BasicRes, pluginName string, status string, err errors.Error) {
+	loadStatus := plugin.GetPluginLoadStatus(pluginName)
+	if loadStatus == nil {
+		loadStatus = &plugin.PluginLoadStatus{Name: pluginName}
+	}
+	loadStatus.Status = status
+	loadStatus.Error = err.Error()
+	plugin.SetPluginLoadStatus(loadStatus)
+	basicRes.GetLogger().Error(err, "failed to load plugin %s", pluginName)
+}
+
+func sortedPluginNames(statuses map[string]*plugin.PluginLoadStatus) []string {
+	names := make([]string, 0, len(statuses))
+	for name := range statuses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,97 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type loaderTestPlugin struct {
+	apiVersion   string
+	dependencies []string
+	initErr      errors.Error
+}
+
+func (p *loaderTestPlugin) Description() string {
+	return "loader test plugin"
+}
+
+func (p *loaderTestPlugin) RootPkgPath() string {
+	return "path/to/loader/test"
+}
+
+func (p *loaderTestPlugin) RequiredApiVersion() string {
+	return p.apiVersion
+}
+
+func (p *loaderTestPlugin) Dependencies() []string {
+	return p.dependencies
+}
+
+func (p *loaderTestPlugin) Init(_ context.BasicRes) errors.Error {
+	return p.initErr
+}
+
+func TestInitPlugins(t *testing.T) {
+	basicRes := contextimpl.NewDefaultBasicRes(viper.New(), logruslog.Global, nil)
+	plugin.SetPluginLoadStatus(&plugin.PluginLoadStatus{Name: "loader_disabled", Status: plugin.PLUGIN_STATUS_DISABLED})
+	InitPlugins(basicRes, map[string]plugin.PluginMeta{
+		"loader_base":        &loaderTestPlugin{apiVersion: plugin.ApiVersion},
+		"loader_dependent":   &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_base"}},
+		"loader_transitive":  &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_dependent"}},
+		"loader_future":      &loaderTestPlugin{apiVersion: "2.0"},
+		"loader_broken":      &loaderTestPlugin{apiVersion: plugin.ApiVersion, initErr: errors.Default.New("boom")},
+		"loader_on_broken":   &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_broken"}},
+		"loader_on_disabled": &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_disabled"}},
+		"loader_on_missing":  &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_missing"}},
+		"loader_cycle_a":     &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_cycle_b"}},
+		"loader_cycle_b":     &loaderTestPlugin{apiVersion: plugin.ApiVersion, dependencies: []string{"loader_cycle_a"}},
+		"loader_undeclared":  &loaderTestPlugin{},
+	})
+
+	expected := map[string]string{
+		"loader_base":        plugin.PLUGIN_STATUS_LOADED,
+		"loader_dependent":   plugin.PLUGIN_STATUS_LOADED,
+		"loader_transitive":  plugin.PLUGIN_STATUS_LOADED,
+		"loader_future":      plugin.PLUGIN_STATUS_INCOMPATIBLE,
+		"loader_broken":      plugin.PLUGIN_STATUS_FAILED,
+		"loader_on_broken":   plugin.PLUGIN_STATUS_FAILED,
+		"loader_on_disabled": plugin.PLUGIN_STATUS_FAILED,
+		"loader_on_missing":  plugin.PLUGIN_STATUS_FAILED,
+		"loader_cycle_a":     plugin.PLUGIN_STATUS_FAILED,
+		"loader_cycle_b":     plugin.PLUGIN_STATUS_FAILED,
+		"loader_undeclared":  plugin.PLUGIN_STATUS_INCOMPATIBLE,
+	}
+	for name, status := range expected {
+		loadStatus := plugin.GetPluginLoadStatus(name)
+		if assert.NotNil(t, loadStatus, name) {
+			assert.Equal(t, status, loadStatus.Status, name)
+		}
+		_, err := plugin.GetPlugin(name)
+		assert.Equal(t, status == plugin.PLUGIN_STATUS_LOADED, err == nil, name)
+	}
+	assert.Contains(t, plugin.GetPluginLoadStatus("loader_on_disabled").Error, "loader_disabled which is disabled")
+}
@@ -0,0 +1,105 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/migration"
+	"github.com/apache/incubator-devlake/core/plugin"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type migrationTestRecord struct {
+	Id string `gorm:"primaryKey;type:varchar(100)"`
+}
+
+func (migrationTestRecord) TableName() string {
+	return "_migration_test_records"
+}
+
+type addMigrationTestRecords struct{}
+
+func (*addMigrationTestRecords) Up(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&migrationTestRecord{})
+}
+
+func (*addMigrationTestRecords) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropTables(&migrationTestRecord{})
+}
+
+func (*addMigrationTestRecords) Version() uint64 {
+	return 20230107000001
+}
+
+func (*addMigrationTestRecords) Name() string {
+	return "add migration test records"
+}
+
+func TestMigratorDryRunAndRollback(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	db, err := NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		return
+	}
+	d := dalgorm.NewDalgorm(db)
+	basicRes := contextimpl.NewDefaultBasicRes(cfg, logruslog.Global, d)
+	m, err := migration.NewMigrator(basicRes)
+	if !assert.Nil(t, err) {
+		return
+	}
+	m.Register([]plugin.MigrationScript{&addMigrationTestRecords{}}, "test")
+
+	// dry run should report the statement without creating the table
+	results, err := m.DryRun()
+	assert.Nil(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Empty(t, results[0].Error)
+		if assert.Len(t, results[0].Statements, 1) {
+			assert.True(t, strings.HasPrefix(results[0].Statements[0], "CREATE TABLE `_migration_test_records`"))
+		}
+	}
+	assert.False(t, db.Migrator().HasTable(&migrationTestRecord{}))
+
+	// execute and check status
+	assert.Nil(t, m.Execute())
+	assert.True(t, db.Migrator().HasTable(&migrationTestRecord{}))
+	scripts := m.Scripts()
+	if assert.Len(t, scripts, 1) {
+		assert.True(t, scripts[0].Executed)
+		assert.True(t, scripts[0].Reversible)
+		assert.NotNil(t, scripts[0].ExecutedAt)
+	}
+
+	// rollback
+	assert.Nil(t, m.Rollback(20230107000000))
+	assert.False(t, db.Migrator().HasTable(&migrationTestRecord{}))
+	assert.False(t, m.Scripts()[0].Executed)
+	history, err := d.Count(dal.From(&migration.MigrationHistory{}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), history)
+}
@@ -68,6 +68,7 @@ func runPipelineTasks(
 		return err
 	}
 
+	_, pipelineFence := pipelineFences(dbPipeline)
 	// This double for loop executes each set of tasks sequentially while
 	// executing the set of tasks concurrently.
 	for i, row := range taskIds {
@@ -75,7 +76,7 @@ func runPipelineTasks(
 		err = db.UpdateColumns(dbPipeline, []dal.DalSet{
 			{ColumnName: "status", Value: models.TASK_RUNNING},
 			{ColumnName: "stage", Value: i + 1},
-		})
+		}, pipelineFence...)
 		if err != nil {
 			log.Error(err, "update pipeline state failed")
 			break
@@ -93,3 +94,16 @@ func runPipelineTasks(
 	log.Info("pipeline finished in %d ms: %v", time.Now().UnixMilli()-dbPipeline.BeganAt.UnixMilli(), err)
 	return err
 }
+
+// pipelineFences returns the clauses restricting writes to the tasks and the pipeline itself to the instance which
+// claimed the pipeline, so a run rescheduled to another instance would not overwrite the status of the new one
+func pipelineFences(dbPipeline *models.DbPipeline) (taskFence []dal.Clause, pipelineFence []dal.Clause) {
+	if dbPipeline.Instance == "" {
+		return nil, nil
+	}
+	taskFence = []dal.Clause{
+		dal.Where("pipeline_id IN (SELECT id FROM _devlake_pipelines WHERE instance = ?)", dbPipeline.Instance),
+	}
+	pipelineFence = []dal.Clause{dal.Where("instance = ?", dbPipeline.Instance)}
+	return taskFence, pipelineFence
+}
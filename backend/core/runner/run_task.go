@@ -56,6 +56,8 @@ func RunTask(
 	if err != nil {
 		return err
 	}
+	// the pipeline might be rescheduled to another instance meanwhile, which owns its tasks from then on
+	taskFence, pipelineFence := pipelineFences(dbPipeline)
 	beganAt := time.Now()
 	// make sure task status always correct even if it panicked
 	defer func() {
@@ -90,7 +92,7 @@ func RunTask(
 				{ColumnName: "finished_at", Value: finishedAt},
 				{ColumnName: "spent_seconds", Value: spentSeconds},
 				{ColumnName: "failed_sub_task", Value: subTaskName},
-			})
+			}, taskFence...)
 			if dbe != nil {
 				logger.Error(dbe, "failed to finalize task status into db (task failed)")
 			}
@@ -100,7 +102,7 @@ func RunTask(
 				{ColumnName: "message", Value: ""},
 				{ColumnName: "finished_at", Value: finishedAt},
 				{ColumnName: "spent_seconds", Value: spentSeconds},
-			})
+			}, taskFence...)
 			if dbe != nil {
 				logger.Error(dbe, "failed to finalize task status into db (task succeeded)")
 			}
@@ -109,7 +111,7 @@ func RunTask(
 		dbe := db.UpdateColumn(
 			&models.DbPipeline{},
 			"finished_tasks", dal.Expr("finished_tasks + 1"),
-			dal.Where("id=?", task.PipelineId),
+			append(pipelineFence, dal.Where("id=?", task.PipelineId))...,
 		)
 		if dbe != nil {
 			logger.Error(dbe, "update pipeline state failed")
@@ -122,7 +124,7 @@ func RunTask(
 		{ColumnName: "status", Value: models.TASK_RUNNING},
 		{ColumnName: "message", Value: ""},
 		{ColumnName: "began_at", Value: beganAt},
-	})
+	}, taskFence...)
 	if dbe != nil {
 		return dbe
 	}
@@ -0,0 +1,498 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationhelper
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ONLINE_PHASE_BACKFILLING = "BACKFILLING"
+	ONLINE_PHASE_REPLAYING   = "REPLAYING"
+	ONLINE_PHASE_SWAPPING    = "SWAPPING"
+	ONLINE_PHASE_DONE        = "DONE"
+	ONLINE_PHASE_FAILED      = "FAILED"
+)
+
+// OnlineOptions tunes the online transformation
+type OnlineOptions struct {
+	// BatchSize is the number of rows being copied per batch, 1000 by default
+	BatchSize int
+	// MaxReplays limits the number of replay passes before swapping, 10 by default
+	MaxReplays int
+	// UpdatedAtColumn is used to detect rows changed during the backfill, `updated_at` by default
+	UpdatedAtColumn string
+	// ClockSkew is subtracted from the watermarks to tolerate clock differences among writers, 1 minute by default
+	ClockSkew time.Duration
+}
+
+// OnlineMigrationProgress reports the progress of an online transformation
+type OnlineMigrationProgress struct {
+	Table        string     `json:"table"`
+	ShadowTable  string     `json:"shadowTable"`
+	Script       string     `json:"script"`
+	Phase        string     `json:"phase"`
+	TotalRows    int64      `json:"totalRows"`
+	CopiedRows   int64      `json:"copiedRows"`
+	ReplayedRows int64      `json:"replayedRows"`
+	Error        string     `json:"error,omitempty"`
+	StartedAt    time.Time  `json:"startedAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	FinishedAt   *time.Time `json:"finishedAt"`
+}
+
+// onlineCheckpoint keeps the watermark of the backfill into a shadow table, so an interrupted transformation,
+// e.g. by a restart, resumes after the last copied row instead of starting over
+type onlineCheckpoint struct {
+	ShadowTable string `gorm:"primaryKey;type:varchar(255)"`
+	Watermark   time.Time
+	CreatedAt   time.Time
+}
+
+func (onlineCheckpoint) TableName() string {
+	return "_devlake_online_migrations"
+}
+
+var onlineProgresses = make(map[string]*OnlineMigrationProgress)
+var onlineProgressesLock sync.Mutex
+
+// GetOnlineMigrationProgresses returns snapshots of all online transformations since the process started
+func GetOnlineMigrationProgresses() []*OnlineMigrationProgress {
+	onlineProgressesLock.Lock()
+	defer onlineProgressesLock.Unlock()
+	progresses := make([]*OnlineMigrationProgress, 0, len(onlineProgresses))
+	for _, progress := range onlineProgresses {
+		snapshot := *progress
+		progresses = append(progresses, &snapshot)
+	}
+	sort.Slice(progresses, func(i, j int) bool {
+		return progresses[i].StartedAt.Before(progresses[j].StartedAt)
+	})
+	return progresses
+}
+
+func updateOnlineProgress(progress *OnlineMigrationProgress, update func(progress *OnlineMigrationProgress)) {
+	onlineProgressesLock.Lock()
+	defer onlineProgressesLock.Unlock()
+	update(progress)
+	progress.UpdatedAt = time.Now()
+}
+
+// OnlineTransformTable works like TransformTable, except that the src table stays available for reading and
+// writing during the process, which makes it suitable for big tables:
+//  1. the transformed rows are backfilled into a shadow table in batches ordered by primary key
+//  2. rows updated during the backfill, detected by the `updated_at` column, are replayed until caught up
+//  3. rows deleted during the backfill are removed from the shadow table
+//  4. the shadow table is swapped with the src table, and rows updated in the meantime are replayed once more
+//
+// The shadow table is kept along with the watermark of the backfill in `_devlake_online_migrations` when the
+// process is interrupted or fails, the next run resumes the backfill after the last row copied into it.
+//
+// Rows must be updated along with their `updated_at` column, which is the case for all models based on
+// common.NoPKModel. The migration script should implement plugin.MigrationScriptOnline so the Migrator
+// could apply it in the background.
+func OnlineTransformTable[S any, D any](
+	basicRes context.BasicRes,
+	script plugin.MigrationScript,
+	tableName string,
+	transform func(*S) (*D, errors.Error),
+	options *OnlineOptions,
+) (err errors.Error) {
+	db := basicRes.GetDal()
+	logger := basicRes.GetLogger().Nested("online migration")
+	opts := OnlineOptions{}
+	if options != nil {
+		opts = *options
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.MaxReplays <= 0 {
+		opts.MaxReplays = 10
+	}
+	if opts.UpdatedAtColumn == "" {
+		opts.UpdatedAtColumn = "updated_at"
+	}
+	if opts.ClockSkew <= 0 {
+		opts.ClockSkew = time.Minute
+	}
+	hash := hashScript(script)
+	shadowTableName := fmt.Sprintf("%s_shadow_%s", tableName, hash[:8])
+	oldTableName := fmt.Sprintf("%s_old_%s", tableName, hash[:8])
+
+	progress := &OnlineMigrationProgress{
+		Table:       tableName,
+		ShadowTable: shadowTableName,
+		Script:      fmt.Sprintf("%d-%s", script.Version(), script.Name()),
+		Phase:       ONLINE_PHASE_BACKFILLING,
+		StartedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	onlineProgressesLock.Lock()
+	onlineProgresses[progress.Script+":"+tableName] = progress
+	onlineProgressesLock.Unlock()
+	defer func() {
+		updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+			now := time.Now()
+			progress.FinishedAt = &now
+			if err != nil {
+				progress.Phase = ONLINE_PHASE_FAILED
+				progress.Error = err.Error()
+			} else {
+				progress.Phase = ONLINE_PHASE_DONE
+			}
+		})
+	}()
+
+	// verify the src table
+	pkColumns, err := dal.GetPrimarykeyColumnNames(db, dal.DefaultTabler{Name: tableName})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to get primary key of table [%s]", tableName))
+	}
+	if len(pkColumns) == 0 {
+		return errors.Default.New(fmt.Sprintf("table [%s] has no primary key", tableName))
+	}
+	updatedAtColumns, err := dal.GetColumnNames(db, dal.DefaultTabler{Name: tableName}, func(cm dal.ColumnMeta) bool {
+		return cm.Name() == opts.UpdatedAtColumn
+	})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to get columns of table [%s]", tableName))
+	}
+	if len(updatedAtColumns) == 0 {
+		return errors.Default.New(fmt.Sprintf("table [%s] has no [%s] column to track changes", tableName, opts.UpdatedAtColumn))
+	}
+	total, err := db.Count(dal.From(tableName))
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to count table [%s]", tableName))
+	}
+	updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+		progress.TotalRows = total
+	})
+
+	// resume the backfill of a previous run, or start over with a fresh shadow table
+	checkpoint, lastKey, err := loadOnlineCheckpoint(db, shadowTableName, pkColumns)
+	if err != nil {
+		return err
+	}
+	if checkpoint != nil {
+		copied, err := db.Count(dal.From(shadowTableName))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to count shadow table [%s]", shadowTableName))
+		}
+		updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+			progress.CopiedRows = copied
+		})
+		logger.Info("resuming the backfill of [%s] after %v with %d rows copied", shadowTableName, lastKey, copied)
+	} else {
+		err = db.DropTables(shadowTableName)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to drop shadow table [%s]", shadowTableName))
+		}
+		err = db.AutoMigrate(new(D), dal.From(shadowTableName))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to create shadow table [%s]", shadowTableName))
+		}
+		checkpoint = &onlineCheckpoint{ShadowTable: shadowTableName, Watermark: time.Now().Add(-opts.ClockSkew)}
+		err = db.CreateOrUpdate(checkpoint)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to save the checkpoint of [%s]", shadowTableName))
+		}
+	}
+
+	// backfill, rows updated after the watermark, including those before a restart, are replayed afterward
+	watermark := checkpoint.Watermark
+	logger.Info("backfilling %d rows from [%s] to [%s]", total, tableName, shadowTableName)
+	for {
+		upperKey, err := nextKeysetBoundary(db, tableName, pkColumns, lastKey, opts.BatchSize)
+		if err != nil {
+			return err
+		}
+		clauses := []dal.Clause{dal.From(tableName)}
+		if lastKey != nil {
+			clauses = append(clauses, dal.Where(fmt.Sprintf("(%s) > (%s)", strings.Join(pkColumns, ","), placeholders(len(pkColumns))), lastKey...))
+		}
+		if upperKey != nil {
+			clauses = append(clauses, dal.Where(fmt.Sprintf("(%s) <= (%s)", strings.Join(pkColumns, ","), placeholders(len(pkColumns))), upperKey...))
+		}
+		copied, err := copyTransformedRows(basicRes, shadowTableName, transform, opts.BatchSize, clauses...)
+		if err != nil {
+			return err
+		}
+		updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+			progress.CopiedRows += copied
+		})
+		if upperKey == nil {
+			break
+		}
+		lastKey = upperKey
+	}
+
+	// replay rows updated during the backfill until there are only a few left
+	updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+		progress.Phase = ONLINE_PHASE_REPLAYING
+	})
+	replay := func(srcTableName, dstTableName string, clauses ...dal.Clause) (int64, errors.Error) {
+		since := watermark
+		watermark = time.Now().Add(-opts.ClockSkew)
+		clauses = append(clauses,
+			dal.From(srcTableName),
+			dal.Where(fmt.Sprintf("%s.%s >= ?", srcTableName, opts.UpdatedAtColumn), since),
+		)
+		replayed, err := copyTransformedRows(basicRes, dstTableName, transform, opts.BatchSize, clauses...)
+		if err != nil {
+			return 0, err
+		}
+		updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+			progress.ReplayedRows += replayed
+		})
+		return replayed, nil
+	}
+	for i := 0; i < opts.MaxReplays; i++ {
+		replayed, err := replay(tableName, shadowTableName)
+		if err != nil {
+			return err
+		}
+		if replayed < int64(opts.BatchSize) {
+			break
+		}
+	}
+
+	// remove rows deleted during the backfill
+	shadowPkColumns, err := dal.GetPrimarykeyColumnNames(db, dal.DefaultTabler{Name: shadowTableName})
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to get primary key of table [%s]", shadowTableName))
+	}
+	if strings.Join(shadowPkColumns, ",") == strings.Join(pkColumns, ",") {
+		err = db.Exec(fmt.Sprintf(
+			"DELETE FROM %s WHERE NOT EXISTS (SELECT 1 FROM %s WHERE %s)",
+			shadowTableName, tableName, joinColumns(tableName, shadowTableName, pkColumns),
+		))
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to remove deleted rows from [%s]", shadowTableName))
+		}
+	} else {
+		logger.Warn(nil, "primary key of [%s] changed, rows deleted during the backfill would be kept", tableName)
+	}
+
+	// swap the shadow table with the src table
+	updateOnlineProgress(progress, func(progress *OnlineMigrationProgress) {
+		progress.Phase = ONLINE_PHASE_SWAPPING
+	})
+	err = swapTables(db, tableName, shadowTableName, oldTableName)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to swap [%s] with [%s]", tableName, shadowTableName))
+	}
+	err = db.Delete(&onlineCheckpoint{}, dal.Where("shadow_table = ?", shadowTableName))
+	if err != nil {
+		logger.Warn(err, "failed to delete the checkpoint of [%s]", shadowTableName)
+	}
+
+	// replay rows updated between the last replay and the swapping, unless they were updated again afterward.
+	// the old table is not needed anymore, there is nothing we could do when failing to drop it
+	_, replayErr := replay(oldTableName, tableName, dal.Where(fmt.Sprintf(
+		"NOT EXISTS (SELECT 1 FROM %s WHERE %s AND %s.%s > %s.%s)",
+		tableName, joinColumns(tableName, oldTableName, pkColumns),
+		tableName, opts.UpdatedAtColumn, oldTableName, opts.UpdatedAtColumn,
+	)))
+	if replayErr != nil {
+		logger.Warn(replayErr, "failed to replay rows from [%s] to [%s], you may have to do it manually", oldTableName, tableName)
+	} else {
+		_ = db.DropTables(oldTableName)
+	}
+	logger.Info("table [%s] transformed", tableName)
+	return nil
+}
+
+// loadOnlineCheckpoint returns the checkpoint of the shadow table along with the primary key of the last row copied
+// into it, the checkpoint is nil if there is nothing to resume
+func loadOnlineCheckpoint(db dal.Dal, shadowTableName string, pkColumns []string) (*onlineCheckpoint, []interface{}, errors.Error) {
+	err := db.AutoMigrate(&onlineCheckpoint{})
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, "failed to create the checkpoint table of online migrations")
+	}
+	checkpoint := &onlineCheckpoint{}
+	err = db.First(checkpoint, dal.Where("shadow_table = ?", shadowTableName))
+	if db.IsErrorNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to load the checkpoint of [%s]", shadowTableName))
+	}
+	tables, err := db.AllTables()
+	if err != nil {
+		return nil, nil, err
+	}
+	found := false
+	for _, table := range tables {
+		found = found || table == shadowTableName
+	}
+	if !found {
+		return nil, nil, nil
+	}
+	// rows are copied in the order of primary key, which only works if the shadow table shares it
+	shadowPkColumns, err := dal.GetPrimarykeyColumnNames(db, dal.DefaultTabler{Name: shadowTableName})
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to get primary key of table [%s]", shadowTableName))
+	}
+	if strings.Join(shadowPkColumns, ",") != strings.Join(pkColumns, ",") {
+		return nil, nil, nil
+	}
+	cursor, err := db.Cursor(
+		dal.Select(strings.Join(pkColumns, ",")),
+		dal.From(shadowTableName),
+		dal.Orderby(strings.Join(pkColumns, " DESC,")+" DESC"),
+		dal.Limit(1),
+	)
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to locate the last row of [%s]", shadowTableName))
+	}
+	defer cursor.Close()
+	if !cursor.Next() {
+		return checkpoint, nil, nil
+	}
+	lastKey, err := scanKey(cursor, len(pkColumns))
+	if err != nil {
+		return nil, nil, errors.Default.Wrap(err, fmt.Sprintf("failed to locate the last row of [%s]", shadowTableName))
+	}
+	return checkpoint, lastKey, nil
+}
+
+// nextKeysetBoundary returns the primary key of the last row of the batch after `lastKey`,
+// or nil if there are no more than `batchSize` rows left
+func nextKeysetBoundary(db dal.Dal, tableName string, pkColumns []string, lastKey []interface{}, batchSize int) ([]interface{}, errors.Error) {
+	clauses := []dal.Clause{
+		dal.Select(strings.Join(pkColumns, ",")),
+		dal.From(tableName),
+		dal.Orderby(strings.Join(pkColumns, ",")),
+		dal.Offset(batchSize - 1),
+		dal.Limit(1),
+	}
+	if lastKey != nil {
+		clauses = append(clauses, dal.Where(fmt.Sprintf("(%s) > (%s)", strings.Join(pkColumns, ","), placeholders(len(pkColumns))), lastKey...))
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to locate next batch of table [%s]", tableName))
+	}
+	defer cursor.Close()
+	if !cursor.Next() {
+		return nil, nil
+	}
+	key, err := scanKey(cursor, len(pkColumns))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to locate next batch of table [%s]", tableName))
+	}
+	return key, nil
+}
+
+// scanKey reads the primary key of the current row of the cursor
+func scanKey(cursor dal.Rows, n int) ([]interface{}, errors.Error) {
+	key := make([]interface{}, n)
+	dest := make([]interface{}, n)
+	for i := range key {
+		dest[i] = &key[i]
+	}
+	err := errors.Convert(cursor.Scan(dest...))
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range key {
+		if b, ok := v.([]byte); ok {
+			key[i] = string(b)
+		}
+	}
+	return key, nil
+}
+
+// copyTransformedRows transforms rows matching the clauses and upserts them into the dst table
+func copyTransformedRows[S any, D any](
+	basicRes context.BasicRes,
+	dstTableName string,
+	transform func(*S) (*D, errors.Error),
+	batchSize int,
+	clauses ...dal.Clause,
+) (int64, errors.Error) {
+	db := basicRes.GetDal()
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return 0, errors.Default.Wrap(err, "failed to load rows to be copied")
+	}
+	defer cursor.Close()
+	batch, err := helper.NewBatchSave(basicRes, reflect.TypeOf(new(D)), batchSize, dstTableName)
+	if err != nil {
+		return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to instantiate BatchSave for table [%s]", dstTableName))
+	}
+	var copied int64
+	for cursor.Next() {
+		src := new(S)
+		err = db.Fetch(cursor, src)
+		if err != nil {
+			return 0, errors.Default.Wrap(err, "failed to load row to be copied")
+		}
+		dst, err := transform(src)
+		if err != nil {
+			return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to transform row %v", src))
+		}
+		err = batch.Add(dst)
+		if err != nil {
+			return 0, errors.Default.Wrap(err, fmt.Sprintf("push to BatchSave failed %v", dstTableName))
+		}
+		copied++
+	}
+	return copied, batch.Close()
+}
+
+// swapTables renames `tableName` to `oldTableName` and `shadowTableName` to `tableName` atomically
+func swapTables(db dal.Dal, tableName, shadowTableName, oldTableName string) errors.Error {
+	if db.Dialect() == "mysql" {
+		// DDL statements cause implicit commits in mysql, but multiple tables could be renamed in one statement
+		return db.Exec(fmt.Sprintf("RENAME TABLE `%s` TO `%s`, `%s` TO `%s`", tableName, oldTableName, shadowTableName, tableName))
+	}
+	tx := db.Begin()
+	err := tx.RenameTable(tableName, oldTableName)
+	if err == nil {
+		err = tx.RenameTable(shadowTableName, tableName)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func joinColumns(leftTable, rightTable string, columns []string) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("%s.%s = %s.%s", leftTable, column, rightTable, column)
+	}
+	return strings.Join(conditions, " AND ")
+}
@@ -0,0 +1,144 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationhelper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/runner"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type onlineTestSrc struct {
+	Id        string `gorm:"primaryKey;type:varchar(100)"`
+	Count     string `gorm:"type:varchar(100)"`
+	UpdatedAt time.Time
+}
+
+type onlineTestDst struct {
+	Id        string `gorm:"primaryKey;type:varchar(100)"`
+	Count     int
+	UpdatedAt time.Time
+}
+
+// setupOnlineTest creates a sqlite database with the table `online_test_records` of 5 rows
+func setupOnlineTest(t *testing.T) (context.BasicRes, dal.Dal) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	d := dalgorm.NewDalgorm(db)
+	assert.Nil(t, d.AutoMigrate(&onlineTestSrc{}, dal.From("online_test_records")))
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		assert.Nil(t, d.Create(&onlineTestSrc{Id: id, Count: id}, dal.From("online_test_records")))
+	}
+	return contextimpl.NewDefaultBasicRes(cfg, logruslog.Global, d), d
+}
+
+func parseOnlineTestCount(src *onlineTestSrc) *onlineTestDst {
+	var count int
+	for _, c := range src.Count {
+		count = count*10 + int(c-'0')
+	}
+	return &onlineTestDst{Id: src.Id, Count: count, UpdatedAt: src.UpdatedAt}
+}
+
+func TestOnlineTransformTable(t *testing.T) {
+	basicRes, d := setupOnlineTest(t)
+	table := "online_test_records"
+
+	err := OnlineTransformTable(basicRes, &TestScript{}, table, func(src *onlineTestSrc) (*onlineTestDst, errors.Error) {
+		if src.Id == "2" {
+			// simulate changes made during the backfill
+			assert.Nil(t, d.Exec("UPDATE online_test_records SET count = '20', updated_at = ? WHERE id = '5'", time.Now()))
+			assert.Nil(t, d.Exec("DELETE FROM online_test_records WHERE id = '4'"))
+		}
+		return parseOnlineTestCount(src), nil
+	}, &OnlineOptions{BatchSize: 2})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var rows []*onlineTestDst
+	assert.Nil(t, d.All(&rows, dal.From(table), dal.Orderby("id")))
+	if assert.Len(t, rows, 4) {
+		assert.Equal(t, "5", rows[3].Id)
+		assert.Equal(t, 20, rows[3].Count)
+	}
+	tables, err := d.AllTables()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{table}, tables)
+	count, err := d.Count(dal.From(&onlineCheckpoint{}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	progresses := GetOnlineMigrationProgresses()
+	if assert.Len(t, progresses, 1) {
+		assert.Equal(t, ONLINE_PHASE_DONE, progresses[0].Phase)
+		assert.Equal(t, int64(5), progresses[0].TotalRows)
+		// row 4 was deleted before being copied
+		assert.Equal(t, int64(4), progresses[0].CopiedRows)
+	}
+}
+
+func TestOnlineTransformTableResume(t *testing.T) {
+	basicRes, d := setupOnlineTest(t)
+	table := "online_test_records"
+
+	// the first run fails in the second batch, after rows 1 and 2 were copied
+	err := OnlineTransformTable(basicRes, &TestScript{}, table, func(src *onlineTestSrc) (*onlineTestDst, errors.Error) {
+		if src.Id == "3" {
+			return nil, errors.Default.New("interrupted")
+		}
+		return parseOnlineTestCount(src), nil
+	}, &OnlineOptions{BatchSize: 2})
+	assert.NotNil(t, err)
+	// row 1 is updated before the next run, it is replayed since the watermark of the first run is kept
+	assert.Nil(t, d.Exec("UPDATE online_test_records SET count = '10', updated_at = ? WHERE id = '1'", time.Now()))
+
+	var transformed []string
+	err = OnlineTransformTable(basicRes, &TestScript{}, table, func(src *onlineTestSrc) (*onlineTestDst, errors.Error) {
+		transformed = append(transformed, src.Id)
+		return parseOnlineTestCount(src), nil
+	}, &OnlineOptions{BatchSize: 2})
+	if !assert.Nil(t, err) {
+		return
+	}
+	// the backfill resumes after row 2, and row 1 is replayed along with the rows copied since the watermark
+	if assert.Greater(t, len(transformed), 3) {
+		assert.Equal(t, []string{"3", "4", "5"}, transformed[:3])
+		assert.Contains(t, transformed[3:], "1")
+	}
+
+	var rows []*onlineTestDst
+	assert.Nil(t, d.All(&rows, dal.From(table), dal.Orderby("id")))
+	if assert.Len(t, rows, 5) {
+		assert.Equal(t, 10, rows[0].Count)
+		assert.Equal(t, 5, rows[4].Count)
+	}
+}
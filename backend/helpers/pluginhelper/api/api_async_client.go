@@ -42,6 +42,7 @@ type ApiAsyncClient struct {
 	maxRetry     int
 	scheduler    *WorkerScheduler
 	numOfWorkers int
+	budget       *RateLimitBudget
 }
 
 const defaultTimeout = 120 * time.Second
@@ -89,6 +90,15 @@ func CreateAsyncApiClient(
 		return nil, errors.Default.Wrap(err, "failed to calculate rateLimit for api")
 	}
 
+	// draw from the budget shared with other clients of the same connection
+	var budget *RateLimitBudget
+	if rateLimiter.BudgetKey != "" {
+		budget, err = GetRateLimitBudget(rateLimiter.BudgetKey, requests, duration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// it is hard to tell how many workers would be sufficient, it depends on how slow the server responds.
 	// we need more workers when server is responding slowly, because requests are sent in a fixed pace.
 	// and because workers are relatively cheap, lets assume response takes 5 seconds
@@ -122,6 +132,7 @@ func CreateAsyncApiClient(
 		retry,
 		scheduler,
 		numOfWorkers,
+		budget,
 	}, nil
 }
 
@@ -154,6 +165,11 @@ func (apiClient *ApiAsyncClient) DoAsync(
 		var respBody []byte
 
 		apiClient.logger.Debug("endpoint: %s  method: %s  header: %s  body: %s query: %s", path, method, header, body, query)
+		if apiClient.budget != nil {
+			if e := apiClient.budget.Take(apiClient.ctx, 1); e != nil {
+				return e
+			}
+		}
 		res, err = apiClient.Do(method, path, query, body, header)
 		// make sure response body is read successfully, or we might have to retry
 		if err == nil {
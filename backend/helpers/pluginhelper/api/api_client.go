@@ -56,6 +56,7 @@ type ApiClient struct {
 	headers       map[string]string
 	beforeRequest common.ApiClientBeforeRequest
 	afterResponse common.ApiClientAfterResponse
+	requestDone   []func(req *http.Request)
 	ctx           gocontext.Context
 	logger        log.Logger
 }
@@ -276,6 +277,9 @@ func (apiClient *ApiClient) Do(
 			return nil, errors.Default.Wrap(err, fmt.Sprintf("error running beforeRequest for %s", req.URL.String()))
 		}
 	}
+	for _, done := range apiClient.requestDone {
+		defer done(req)
+	}
 	apiClient.logDebug("[api-client] %v %v", method, *uri)
 	res, err = errors.Convert01(apiClient.client.Do(req))
 	if err != nil {
@@ -0,0 +1,179 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RateLimitBudget is a token bucket shared by all api clients of the same connection within the process,
+// so tasks running in parallel would not exhaust the rate limit of the connection by each taking it all.
+// NOTE: budgets are not shared among processes, i.e. the server and temporal workers
+type RateLimitBudget struct {
+	mu        sync.Mutex
+	key       string
+	rate      float64 // tokens per second
+	burst     float64
+	tokens    float64
+	refilled  time.Time
+	consumed  int64
+	waiting   int
+	createdAt time.Time
+}
+
+// RateLimitBudgetState is a snapshot of RateLimitBudget
+type RateLimitBudgetState struct {
+	Key             string    `json:"key"`
+	RequestsPerHour int       `json:"requestsPerHour"`
+	Available       int       `json:"available"`
+	Burst           int       `json:"burst"`
+	Consumed        int64     `json:"consumed"`
+	Waiting         int       `json:"waiting"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+var rateLimitBudgets = make(map[string]*RateLimitBudget)
+var rateLimitBudgetsLock sync.Mutex
+
+// RateLimitBudgetKey returns the key of the budget shared by all api clients of the connection, connections
+// are identified by their table so plugins sharing the same connection model would share the same budget
+func RateLimitBudgetKey(connection interface{ TableName() string }, connectionId uint64) string {
+	return fmt.Sprintf("%s:%d", connection.TableName(), connectionId)
+}
+
+// GetRateLimitBudget returns the budget of the key, the rate would be updated to `requests` per `duration`
+func GetRateLimitBudget(key string, requests int, duration time.Duration) (*RateLimitBudget, errors.Error) {
+	if requests <= 0 || duration <= 0 {
+		return nil, errors.Default.New(fmt.Sprintf("invalid rate limit %d per %v for %s", requests, duration, key))
+	}
+	rateLimitBudgetsLock.Lock()
+	defer rateLimitBudgetsLock.Unlock()
+	budget, ok := rateLimitBudgets[key]
+	if !ok {
+		budget = &RateLimitBudget{key: key, refilled: time.Now(), createdAt: time.Now()}
+		rateLimitBudgets[key] = budget
+	}
+	budget.setRate(requests, duration, !ok)
+	return budget, nil
+}
+
+// GetRateLimitBudgetStates returns snapshots of all budgets ordered by key
+func GetRateLimitBudgetStates() []*RateLimitBudgetState {
+	rateLimitBudgetsLock.Lock()
+	budgets := make([]*RateLimitBudget, 0, len(rateLimitBudgets))
+	for _, budget := range rateLimitBudgets {
+		budgets = append(budgets, budget)
+	}
+	rateLimitBudgetsLock.Unlock()
+	states := make([]*RateLimitBudgetState, 0, len(budgets))
+	for _, budget := range budgets {
+		states = append(states, budget.State())
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+	return states
+}
+
+func (b *RateLimitBudget) setRate(requests int, duration time.Duration, fill bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.rate = float64(requests) / duration.Seconds()
+	// allow a burst of one minute worth of requests
+	b.burst = b.rate * 60
+	if b.burst < 1 {
+		b.burst = 1
+	}
+	if fill || b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+func (b *RateLimitBudget) refill() {
+	now := time.Now()
+	b.tokens += now.Sub(b.refilled).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.refilled = now
+}
+
+// Take blocks until `n` tokens are available or the ctx is done
+func (b *RateLimitBudget) Take(ctx context.Context, n int) errors.Error {
+	b.mu.Lock()
+	b.waiting++
+	b.mu.Unlock()
+	defer func() {
+		b.mu.Lock()
+		b.waiting--
+		b.mu.Unlock()
+	}()
+	for {
+		b.mu.Lock()
+		b.refill()
+		// a request costing more than the burst would never be satisfied, let it go once the bucket is full
+		need := float64(n)
+		if need > b.burst {
+			need = b.burst
+		}
+		if b.tokens >= need {
+			b.tokens -= float64(n)
+			b.consumed += int64(n)
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((need - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return errors.Convert(ctx.Err())
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Consume deducts `n` tokens without waiting, the bucket may go negative and block following requests
+func (b *RateLimitBudget) Consume(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	b.tokens -= float64(n)
+	b.consumed += int64(n)
+}
+
+// State returns a snapshot of the budget
+func (b *RateLimitBudget) State() *RateLimitBudgetState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return &RateLimitBudgetState{
+		Key:             b.key,
+		RequestsPerHour: int(b.rate * 3600),
+		Available:       int(b.tokens),
+		Burst:           int(b.burst),
+		Consumed:        b.consumed,
+		Waiting:         b.waiting,
+		CreatedAt:       b.createdAt,
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitBudget(t *testing.T) {
+	// 60 requests per second, burst of 3600
+	budget, err := GetRateLimitBudget("test_connections:1", 3600*60, time.Hour)
+	assert.Nil(t, err)
+	same, err := GetRateLimitBudget("test_connections:1", 3600*60, time.Hour)
+	assert.Nil(t, err)
+	assert.Same(t, budget, same)
+
+	assert.Nil(t, budget.Take(context.Background(), 3600))
+	budget.Consume(60)
+	state := budget.State()
+	assert.Equal(t, int64(3660), state.Consumed)
+	assert.LessOrEqual(t, state.Available, 0)
+
+	// the bucket is drained, taking would block until the ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, budget.Take(ctx, 600))
+
+	_, err = GetRateLimitBudget("test_connections:2", 0, time.Hour)
+	assert.NotNil(t, err)
+}
+
+func TestRateLimitBudgetKey(t *testing.T) {
+	assert.Equal(t, "_tool_github_connections:3", RateLimitBudgetKey(testConnection{}, 3))
+}
+
+type testConnection struct{}
+
+func (testConnection) TableName() string {
+	return "_tool_github_connections"
+}
@@ -31,6 +31,9 @@ type ApiRateLimitCalculator struct {
 	Method                 string
 	ApiPath                string
 	DynamicRateLimit       func(res *http.Response) (int, time.Duration, errors.Error)
+	// BudgetKey makes all api clients with the same key draw from a shared RateLimitBudget, normally
+	// generated by RateLimitBudgetKey. Each client has the whole rate limit to itself if left empty
+	BudgetKey string
 }
 
 // Calculate FIXME ...
@@ -0,0 +1,171 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+const (
+	AUDIT_ACTION_CREATE = "create"
+	AUDIT_ACTION_UPDATE = "update"
+	AUDIT_ACTION_DELETE = "delete"
+)
+
+// AUDIT_REDACTED replaces values of sensitive fields in audit logs
+const AUDIT_REDACTED = "******"
+
+// AuditSnapshot is the state of a resource at some point, taken before and after a change to figure out
+// what was changed
+type AuditSnapshot struct {
+	values    map[string]interface{}
+	sensitive map[string]bool
+}
+
+// AuditChange is the before/after values of a changed field
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// TakeAuditSnapshot captures the fields of `v` by their json names, fields tagged with `encrypt:"yes"` or
+// stored by the encdec serializer are considered sensitive. The snapshot carries the error instead if `v`
+// could not be serialized, so the change would be recorded anyway.
+func TakeAuditSnapshot(v interface{}) *AuditSnapshot {
+	snapshot := &AuditSnapshot{sensitive: make(map[string]bool)}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(data, &snapshot.values)
+	}
+	if err != nil {
+		snapshot.values = map[string]interface{}{"error": err.Error()}
+		return snapshot
+	}
+	collectSensitiveFields(reflect.TypeOf(v), snapshot.sensitive)
+	return snapshot
+}
+
+func collectSensitiveFields(t reflect.Type, sensitive map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			collectSensitiveFields(field.Type, sensitive)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		encrypt := field.Tag.Get("encrypt")
+		if encrypt == "yes" || encrypt == "true" || strings.Contains(field.Tag.Get("gorm"), "serializer:encdec") {
+			sensitive[name] = true
+		}
+	}
+}
+
+// DiffAuditSnapshots returns the changed fields, values of sensitive fields are redacted. Either of the
+// snapshots could be nil for creation or deletion.
+func DiffAuditSnapshots(before *AuditSnapshot, after *AuditSnapshot) map[string]*AuditChange {
+	changes := make(map[string]*AuditChange)
+	sensitive := make(map[string]bool)
+	keys := make(map[string]bool)
+	for _, s := range []*AuditSnapshot{before, after} {
+		if s == nil {
+			continue
+		}
+		for k := range s.values {
+			keys[k] = true
+		}
+		for k := range s.sensitive {
+			sensitive[k] = true
+		}
+	}
+	for k := range keys {
+		change := &AuditChange{Before: before.value(k), After: after.value(k)}
+		if reflect.DeepEqual(change.Before, change.After) {
+			continue
+		}
+		if sensitive[k] {
+			change.Before, change.After = redact(change.Before), redact(change.After)
+		}
+		changes[k] = change
+	}
+	return changes
+}
+
+func (s *AuditSnapshot) value(key string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.values[key]
+}
+
+func redact(v interface{}) interface{} {
+	if v == nil || v == "" {
+		return v
+	}
+	return AUDIT_REDACTED
+}
+
+// RecordAuditLog saves the change of the resource made by the user, failures are logged instead of returned
+// since the change has been made already
+func RecordAuditLog(
+	basicRes context.BasicRes,
+	user *common.User,
+	resource string,
+	resourceId interface{},
+	action string,
+	before *AuditSnapshot,
+	after *AuditSnapshot,
+) {
+	changes, err := json.Marshal(DiffAuditSnapshots(before, after))
+	if err != nil {
+		basicRes.GetLogger().Error(err, "failed to serialize changes of %s %v", resource, resourceId)
+		return
+	}
+	auditLog := &models.AuditLog{
+		Resource:  resource,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if resourceId != nil {
+		auditLog.ResourceId = fmt.Sprintf("%v", resourceId)
+	}
+	if user != nil {
+		auditLog.Actor = user.Name
+	}
+	err = basicRes.GetDal().Create(auditLog)
+	if err != nil {
+		basicRes.GetLogger().Error(err, "failed to record audit log of %s %v", resource, resourceId)
+	}
+}
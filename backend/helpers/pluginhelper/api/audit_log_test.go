@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type auditTestConnection struct {
+	RestConnection `mapstructure:",squash"`
+	AccessToken    `mapstructure:",squash"`
+	Plan           string `json:"plan" gorm:"serializer:encdec"`
+}
+
+func TestDiffAuditSnapshots(t *testing.T) {
+	connection := &auditTestConnection{}
+	connection.Name = "github"
+	connection.Endpoint = "https://api.github.com/"
+	connection.Token = "secret1"
+	created := TakeAuditSnapshot(connection)
+
+	changes := DiffAuditSnapshots(nil, created)
+	assert.Equal(t, "github", changes["name"].After)
+	assert.Equal(t, AUDIT_REDACTED, changes["token"].After)
+	// empty values are not redacted
+	assert.Equal(t, &AuditChange{Before: nil, After: ""}, changes["plan"])
+
+	connection.Token = "secret2"
+	connection.Endpoint = "https://github.example.com/api/v3/"
+	connection.Plan = "[]"
+	changes = DiffAuditSnapshots(created, TakeAuditSnapshot(connection))
+	assert.Len(t, changes, 3)
+	assert.Equal(t, &AuditChange{Before: AUDIT_REDACTED, After: AUDIT_REDACTED}, changes["token"])
+	assert.Equal(t, &AuditChange{Before: "", After: AUDIT_REDACTED}, changes["plan"])
+	assert.Equal(t, &AuditChange{Before: "https://api.github.com/", After: "https://github.example.com/api/v3/"}, changes["endpoint"])
+
+	changes = DiffAuditSnapshots(created, nil)
+	assert.Nil(t, changes["name"].After)
+	assert.Equal(t, AUDIT_REDACTED, changes["token"].Before)
+}
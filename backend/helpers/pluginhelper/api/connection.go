@@ -71,26 +71,37 @@ type RestConnection struct {
 
 // ConnectionApiHelper is used to write the CURD of connection
 type ConnectionApiHelper struct {
-	encKey    string
-	log       log.Logger
-	db        dal.Dal
-	validator *validator.Validate
+	basicRes    context.BasicRes
+	keyring     *plugin.EncryptionKeyring
+	resolver    *SecretResolver
+	resolverErr errors.Error
+	log         log.Logger
+	db          dal.Dal
+	validator   *validator.Validate
 }
 
-// NewConnectionHelper FIXME ...
+// NewConnectionHelper creates a ConnectionApiHelper, the encryption keyring is loaded from the configuration of basicRes
 func NewConnectionHelper(
 	basicRes context.BasicRes,
 	vld *validator.Validate,
-) *ConnectionApiHelper {
+) (*ConnectionApiHelper, errors.Error) {
 	if vld == nil {
 		vld = validator.New()
 	}
-	return &ConnectionApiHelper{
-		encKey:    basicRes.GetConfig(plugin.EncodeKeyEnvStr),
-		log:       basicRes.GetLogger(),
-		db:        basicRes.GetDal(),
-		validator: vld,
+	keyring, err := plugin.LoadEncryptionKeyring(basicRes.GetConfig)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load encryption keyring")
 	}
+	resolver, err := NewSecretResolver(basicRes)
+	return &ConnectionApiHelper{
+		basicRes:    basicRes,
+		resolver:    resolver,
+		resolverErr: err,
+		keyring:     keyring,
+		log:         basicRes.GetLogger(),
+		db:          basicRes.GetDal(),
+		validator:   vld,
+	}, nil
 }
 
 // Create a connection record based on request body
@@ -100,38 +111,63 @@ func (c *ConnectionApiHelper) Create(connection interface{}, input *plugin.ApiRe
 	if err != nil {
 		return err
 	}
-	return c.save(connection)
+	err = c.save(connection)
+	if err != nil {
+		return err
+	}
+	c.audit(input.User, AUDIT_ACTION_CREATE, connection, nil)
+	return nil
 }
 
 // Patch (Modify) a connection record based on request body
 func (c *ConnectionApiHelper) Patch(connection interface{}, input *plugin.ApiResourceInput) errors.Error {
-	err := c.First(connection, input.Params)
+	err := c.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return err
 	}
+	before := TakeAuditSnapshot(connection)
 
 	err = c.merge(connection, input.Body)
 	if err != nil {
 		return err
 	}
-	return c.save(connection)
+	err = c.save(connection)
+	if err != nil {
+		return err
+	}
+	c.audit(input.User, AUDIT_ACTION_UPDATE, connection, before)
+	return nil
 }
 
-// First finds connection from db  by parsing request input and decrypt it
+// First finds connection from db  by parsing request input, decrypt it and resolve secret references
 func (c *ConnectionApiHelper) First(connection interface{}, params map[string]string) errors.Error {
-	connectionId := params["connectionId"]
-	if connectionId == "" {
-		return errors.BadInput.New("missing connectionId")
-	}
-	id, err := strconv.ParseUint(connectionId, 10, 64)
-	if err != nil || id < 1 {
-		return errors.BadInput.New("invalid connectionId")
+	id, err := parseConnectionId(params)
+	if err != nil {
+		return err
 	}
 	return c.FirstById(connection, id)
 }
 
-// FirstById finds connection from db by id and decrypt it
+// FirstById finds connection from db by id, decrypt it and resolve secret references
 func (c *ConnectionApiHelper) FirstById(connection interface{}, id uint64) errors.Error {
+	err := c.firstById(connection, id)
+	if err != nil {
+		return err
+	}
+	return c.resolve(connection)
+}
+
+// FirstUnresolved finds connection from db by parsing request input and decrypt it, secret references
+// are kept as they are, which is what should be returned to or updated by users
+func (c *ConnectionApiHelper) FirstUnresolved(connection interface{}, params map[string]string) errors.Error {
+	id, err := parseConnectionId(params)
+	if err != nil {
+		return err
+	}
+	return c.firstById(connection, id)
+}
+
+func (c *ConnectionApiHelper) firstById(connection interface{}, id uint64) errors.Error {
 	err := c.db.First(connection, dal.Where("id = ?", id))
 	if err != nil {
 		return err
@@ -140,6 +176,18 @@ func (c *ConnectionApiHelper) FirstById(connection interface{}, id uint64) error
 	return nil
 }
 
+func parseConnectionId(params map[string]string) (uint64, errors.Error) {
+	connectionId := params["connectionId"]
+	if connectionId == "" {
+		return 0, errors.BadInput.New("missing connectionId")
+	}
+	id, err := strconv.ParseUint(connectionId, 10, 64)
+	if err != nil || id < 1 {
+		return 0, errors.BadInput.New("invalid connectionId")
+	}
+	return id, nil
+}
+
 // List returns all connections with password/token decrypted
 func (c *ConnectionApiHelper) List(connections interface{}) errors.Error {
 	err := c.db.All(connections)
@@ -154,8 +202,31 @@ func (c *ConnectionApiHelper) List(connections interface{}) errors.Error {
 }
 
 // Delete connection
-func (c *ConnectionApiHelper) Delete(connection interface{}) errors.Error {
-	return c.db.Delete(connection)
+func (c *ConnectionApiHelper) Delete(connection interface{}, input *plugin.ApiResourceInput) errors.Error {
+	before := TakeAuditSnapshot(connection)
+	err := c.db.Delete(connection)
+	if err != nil {
+		return err
+	}
+	c.audit(input.User, AUDIT_ACTION_DELETE, connection, before)
+	return nil
+}
+
+// audit records the change made to the connection, `before` is nil for creation
+func (c *ConnectionApiHelper) audit(user *common.User, action string, connection interface{}, before *AuditSnapshot) {
+	var after *AuditSnapshot
+	if action != AUDIT_ACTION_DELETE {
+		after = TakeAuditSnapshot(connection)
+	}
+	resource := reflect.TypeOf(connection).Elem().Name()
+	if tabler, ok := connection.(interface{ TableName() string }); ok {
+		resource = tabler.TableName()
+	}
+	var id interface{}
+	if field := reflect.ValueOf(connection).Elem().FieldByName("ID"); field.IsValid() {
+		id = field.Interface()
+	}
+	RecordAuditLog(c.basicRes, user, resource, id, action, before, after)
 }
 
 func (c *ConnectionApiHelper) merge(connection interface{}, body map[string]interface{}) errors.Error {
@@ -177,9 +248,31 @@ func (c *ConnectionApiHelper) save(connection interface{}) errors.Error {
 	return nil
 }
 
+// RejectSecretReferences rejects secret references in connection, i.e. the request body of TestConnection handlers.
+// The endpoint of such a body is given by the caller as well, resolving the references would send the secrets of the
+// server to wherever the caller likes, so references are only resolved for saved connections.
+func (c *ConnectionApiHelper) RejectSecretReferences(connection interface{}) errors.Error {
+	if c.resolver == nil {
+		return nil
+	}
+	return UpdateEncryptFields(connection, func(value string) (string, errors.Error) {
+		if c.resolver.IsReference(value) {
+			return "", errors.BadInput.New("secret references are only resolved for saved connections, test with the secrets themselves")
+		}
+		return value, nil
+	})
+}
+
+func (c *ConnectionApiHelper) resolve(connection interface{}) errors.Error {
+	if c.resolverErr != nil {
+		return c.resolverErr
+	}
+	return c.resolver.ResolveFields(connection)
+}
+
 func (c *ConnectionApiHelper) decrypt(connection interface{}) {
 	err := UpdateEncryptFields(connection, func(encrypted string) (string, errors.Error) {
-		return plugin.Decrypt(c.encKey, encrypted)
+		return c.keyring.Decrypt(encrypted)
 	})
 	if err != nil {
 		c.log.Error(err, "failed to decrypt")
@@ -188,7 +281,7 @@ func (c *ConnectionApiHelper) decrypt(connection interface{}) {
 
 func (c *ConnectionApiHelper) encrypt(connection interface{}) {
 	err := UpdateEncryptFields(connection, func(plaintext string) (string, errors.Error) {
-		return plugin.Encrypt(c.encKey, plaintext)
+		return c.keyring.Encrypt(plaintext)
 	})
 	if err != nil {
 		c.log.Error(err, "failed to encrypt")
@@ -45,6 +45,7 @@ type GraphqlAsyncClient struct {
 	rateRemaining    int
 	getRateRemaining func(context.Context, *graphql.Client, log.Logger) (rateRemaining int, resetAt *time.Time, err errors.Error)
 	getRateCost      func(q interface{}) int
+	budget           *RateLimitBudget
 }
 
 // CreateAsyncGraphqlClient creates a new GraphqlAsyncClient
@@ -133,6 +134,17 @@ func (apiClient *GraphqlAsyncClient) updateRateRemaining(rateRemaining int, rese
 	}()
 }
 
+// SetRateLimitBudget makes the client draw from the budget shared with other clients using the same key,
+// `requests` per `duration` is the rate limit of the connection
+func (apiClient *GraphqlAsyncClient) SetRateLimitBudget(key string, requests int, duration time.Duration) errors.Error {
+	budget, err := GetRateLimitBudget(key, requests, duration)
+	if err != nil {
+		return err
+	}
+	apiClient.budget = budget
+	return nil
+}
+
 // SetGetRateCost to calculate how many rate cost
 // if not set, all query just cost 1
 func (apiClient *GraphqlAsyncClient) SetGetRateCost(getRateCost func(q interface{}) int) {
@@ -163,6 +175,12 @@ func (apiClient *GraphqlAsyncClient) Query(q interface{}, variables map[string]i
 		case <-apiClient.ctx.Done():
 			return nil, nil
 		default:
+			// the cost is unknown until the query is done, take 1 ahead and the rest afterward
+			if apiClient.budget != nil {
+				if e := apiClient.budget.Take(apiClient.ctx, 1); e != nil {
+					return nil, e
+				}
+			}
 			var dataErrors []graphql.DataError
 			dataErrors, err := apiClient.client.Query(apiClient.ctx, q, variables)
 			if err == context.Canceled {
@@ -182,6 +200,9 @@ func (apiClient *GraphqlAsyncClient) Query(q interface{}, variables map[string]i
 				cost = apiClient.getRateCost(q)
 			}
 			apiClient.rateRemaining -= cost
+			if apiClient.budget != nil && cost > 1 {
+				apiClient.budget.Consume(cost - 1)
+			}
 			apiClient.logger.Debug(`query cost %d in %v`, cost, variables)
 			return nil, nil
 		}
@@ -0,0 +1,293 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+const (
+	// SECRET_PROVIDERS_ENV lists the enabled secret providers, e.g. `file,env,vault`, none by default
+	SECRET_PROVIDERS_ENV = "SECRET_PROVIDERS"
+	// SECRET_FILE_DIRS_ENV lists directories the `file` provider may read from, `/run/secrets` by default
+	SECRET_FILE_DIRS_ENV = "SECRET_FILE_DIRS"
+	// SECRET_ENV_NAMES_ENV lists variables the `env` provider may read, `*` suffix matches any prefix, none by default
+	SECRET_ENV_NAMES_ENV = "SECRET_ENV_NAMES"
+	// VAULT_ADDR_ENV, VAULT_TOKEN_ENV and VAULT_NAMESPACE_ENV configure the `vault` provider
+	VAULT_ADDR_ENV      = "VAULT_ADDR"
+	VAULT_TOKEN_ENV     = "VAULT_TOKEN"
+	VAULT_NAMESPACE_ENV = "VAULT_NAMESPACE"
+)
+
+// variables which must never be handed out as connection credentials
+var protectedEnvNames = []string{"ENCODE_KEY", "ENCODE_KEYS", "DB_URL", "E2E_DB_URL", VAULT_TOKEN_ENV, "AUTH_ADMIN_API_KEY"}
+
+// SecretProvider resolves secret references of a scheme, e.g. `env:GITHUB_TOKEN` is resolved by
+// the provider of `env` with `GITHUB_TOKEN` as the reference
+type SecretProvider interface {
+	Resolve(ref string) (string, errors.Error)
+}
+
+// SecretProviderFactory creates a SecretProvider with the configuration of basicRes
+type SecretProviderFactory func(basicRes context.BasicRes) (SecretProvider, errors.Error)
+
+var secretProviderFactories = map[string]SecretProviderFactory{
+	"file":  NewFileSecretProvider,
+	"env":   NewEnvSecretProvider,
+	"vault": NewVaultSecretProvider,
+}
+var secretProviderLock sync.Mutex
+
+// RegisterSecretProvider makes a provider available for the scheme, it has to be enabled
+// by SECRET_PROVIDERS as well
+func RegisterSecretProvider(scheme string, factory SecretProviderFactory) {
+	secretProviderLock.Lock()
+	defer secretProviderLock.Unlock()
+	secretProviderFactories[scheme] = factory
+}
+
+// SecretResolver replaces secret references with the secrets they point to
+type SecretResolver struct {
+	providers map[string]SecretProvider
+}
+
+// NewSecretResolver creates a SecretResolver with providers enabled by SECRET_PROVIDERS
+func NewSecretResolver(basicRes context.BasicRes) (*SecretResolver, errors.Error) {
+	secretProviderLock.Lock()
+	defer secretProviderLock.Unlock()
+	resolver := &SecretResolver{providers: make(map[string]SecretProvider)}
+	for _, scheme := range splitConfigList(basicRes.GetConfig(SECRET_PROVIDERS_ENV)) {
+		factory, ok := secretProviderFactories[scheme]
+		if !ok {
+			return nil, errors.Default.New(fmt.Sprintf("unknown secret provider %s", scheme))
+		}
+		provider, err := factory(basicRes)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to create secret provider %s", scheme))
+		}
+		resolver.providers[scheme] = provider
+	}
+	return resolver, nil
+}
+
+// Resolve returns the secret if value is a reference of an enabled provider, or value itself otherwise
+func (r *SecretResolver) Resolve(value string) (string, errors.Error) {
+	scheme, ref, found := strings.Cut(value, ":")
+	if !found {
+		return value, nil
+	}
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return value, nil
+	}
+	secret, err := provider.Resolve(strings.TrimPrefix(ref, "//"))
+	if err != nil {
+		return "", errors.Default.Wrap(err, fmt.Sprintf("failed to resolve secret reference of %s", scheme))
+	}
+	return secret, nil
+}
+
+// IsReference tells whether value is a reference of an enabled provider
+func (r *SecretResolver) IsReference(value string) bool {
+	scheme, _, found := strings.Cut(value, ":")
+	if !found {
+		return false
+	}
+	_, ok := r.providers[scheme]
+	return ok
+}
+
+// ResolveFields resolves references stored in fields with tag `encrypt:"yes|true"`
+func (r *SecretResolver) ResolveFields(v interface{}) errors.Error {
+	if len(r.providers) == 0 {
+		return nil
+	}
+	return UpdateEncryptFields(v, r.Resolve)
+}
+
+func splitConfigList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FileSecretProvider reads secrets from files, e.g. `file:///run/secrets/github_token`
+type FileSecretProvider struct {
+	dirs []string
+}
+
+// NewFileSecretProvider creates a FileSecretProvider restricted to SECRET_FILE_DIRS
+func NewFileSecretProvider(basicRes context.BasicRes) (SecretProvider, errors.Error) {
+	dirs := splitConfigList(basicRes.GetConfig(SECRET_FILE_DIRS_ENV))
+	if len(dirs) == 0 {
+		dirs = []string{"/run/secrets"}
+	}
+	for i, dir := range dirs {
+		dirs[i] = filepath.Clean(dir)
+	}
+	return &FileSecretProvider{dirs: dirs}, nil
+}
+
+// Resolve reads the file, trailing line breaks are removed
+func (p *FileSecretProvider) Resolve(ref string) (string, errors.Error) {
+	path := filepath.Clean(ref)
+	if !filepath.IsAbs(path) {
+		return "", errors.BadInput.New("absolute path is required")
+	}
+	allowed := false
+	for _, dir := range p.dirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return "", errors.Forbidden.New(fmt.Sprintf("%s is not within %s", path, SECRET_FILE_DIRS_ENV))
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Default.Wrap(err, fmt.Sprintf("failed to read %s", path))
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// EnvSecretProvider reads secrets from environment variables, e.g. `env:GITHUB_TOKEN`
+type EnvSecretProvider struct {
+	names []string
+}
+
+// NewEnvSecretProvider creates an EnvSecretProvider restricted to SECRET_ENV_NAMES
+func NewEnvSecretProvider(basicRes context.BasicRes) (SecretProvider, errors.Error) {
+	return &EnvSecretProvider{names: splitConfigList(basicRes.GetConfig(SECRET_ENV_NAMES_ENV))}, nil
+}
+
+// Resolve reads the environment variable
+func (p *EnvSecretProvider) Resolve(ref string) (string, errors.Error) {
+	if !p.allowed(ref) {
+		return "", errors.Forbidden.New(fmt.Sprintf("environment variable %s is not allowed", ref))
+	}
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.NotFound.New(fmt.Sprintf("environment variable %s is not set", ref))
+	}
+	return value, nil
+}
+
+func (p *EnvSecretProvider) allowed(name string) bool {
+	for _, protected := range protectedEnvNames {
+		if name == protected {
+			return false
+		}
+	}
+	for _, pattern := range p.names {
+		if pattern == name || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// VaultSecretProvider reads secrets from HashiCorp Vault KV engines, the reference is the api path
+// followed by the field name, e.g. `vault://secret/data/devlake/github#token` for KV v2 or
+// `vault://kv/devlake/github#token` for KV v1, the field defaults to `value`
+type VaultSecretProvider struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// NewVaultSecretProvider creates a VaultSecretProvider with VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE
+func NewVaultSecretProvider(basicRes context.BasicRes) (SecretProvider, errors.Error) {
+	addr := strings.TrimSuffix(basicRes.GetConfig(VAULT_ADDR_ENV), "/")
+	if addr == "" {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is required", VAULT_ADDR_ENV))
+	}
+	token := basicRes.GetConfig(VAULT_TOKEN_ENV)
+	if token == "" {
+		return nil, errors.BadInput.New(fmt.Sprintf("%s is required", VAULT_TOKEN_ENV))
+	}
+	return &VaultSecretProvider{
+		addr:      addr,
+		token:     token,
+		namespace: basicRes.GetConfig(VAULT_NAMESPACE_ENV),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Resolve reads the field of the secret
+func (p *VaultSecretProvider) Resolve(ref string) (string, errors.Error) {
+	path, field, _ := strings.Cut(ref, "#")
+	if field == "" {
+		field = "value"
+	}
+	path = strings.Trim(path, "/")
+	if path == "" || strings.Contains(path, "..") {
+		return "", errors.BadInput.New(fmt.Sprintf("invalid vault path %s", path))
+	}
+	req, e := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v1/%s", p.addr, path), nil)
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	req.Header.Set("X-Vault-Token", p.token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+	res, e := p.client.Do(req)
+	if e != nil {
+		return "", errors.Default.Wrap(e, fmt.Sprintf("failed to read %s from vault", path))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to read %s from vault", path))
+	}
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	e = json.NewDecoder(res.Body).Decode(&body)
+	if e != nil {
+		return "", errors.Default.Wrap(e, "failed to decode vault response")
+	}
+	data := body.Data
+	// KV v2 nests the secret in data.data along with its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+	value, ok := data[field].(string)
+	if !ok {
+		return "", errors.NotFound.New(fmt.Sprintf("field %s not found in %s", field, path))
+	}
+	return value, nil
+}
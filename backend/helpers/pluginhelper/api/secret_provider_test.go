@@ -0,0 +1,127 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type secretTestConnection struct {
+	RestConnection `mapstructure:",squash"`
+	AccessToken    `mapstructure:",squash"`
+}
+
+func TestSecretResolver(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "gh"), []byte("file-token\n"), 0600))
+	t.Setenv("DEVLAKE_TEST_TOKEN", "env-token")
+	resolver := &SecretResolver{providers: map[string]SecretProvider{
+		"file": &FileSecretProvider{dirs: []string{dir}},
+		"env":  &EnvSecretProvider{names: []string{"DEVLAKE_TEST_*"}},
+	}}
+
+	secret, err := resolver.Resolve("file://" + filepath.Join(dir, "gh"))
+	assert.Nil(t, err)
+	assert.Equal(t, "file-token", secret)
+	secret, err = resolver.Resolve("env:DEVLAKE_TEST_TOKEN")
+	assert.Nil(t, err)
+	assert.Equal(t, "env-token", secret)
+	// plain values and references of disabled providers are kept as they are
+	secret, err = resolver.Resolve("ghp_plain")
+	assert.Nil(t, err)
+	assert.Equal(t, "ghp_plain", secret)
+	secret, err = resolver.Resolve("vault://secret/data/gh#token")
+	assert.Nil(t, err)
+	assert.Equal(t, "vault://secret/data/gh#token", secret)
+
+	_, err = resolver.Resolve("file://" + filepath.Join(dir, "..", "gh"))
+	assert.NotNil(t, err)
+	_, err = resolver.Resolve("env:HOME")
+	assert.NotNil(t, err)
+	_, err = resolver.Resolve("env:DEVLAKE_TEST_MISSING")
+	assert.NotNil(t, err)
+
+	connection := &secretTestConnection{}
+	connection.Endpoint = "env:DEVLAKE_TEST_TOKEN"
+	connection.Token = "env:DEVLAKE_TEST_TOKEN"
+	assert.Nil(t, resolver.ResolveFields(connection))
+	assert.Equal(t, "env-token", connection.Token)
+	assert.Equal(t, "env:DEVLAKE_TEST_TOKEN", connection.Endpoint)
+
+	// references in bodies of testing connections are rejected
+	connectionHelper := &ConnectionApiHelper{resolver: resolver}
+	connection.Token = "env:DEVLAKE_TEST_TOKEN"
+	assert.NotNil(t, connectionHelper.RejectSecretReferences(connection))
+	connection.Token = "vault://secret/data/gh#token"
+	assert.Nil(t, connectionHelper.RejectSecretReferences(connection))
+	connection.Token = "ghp_plain"
+	assert.Nil(t, connectionHelper.RejectSecretReferences(connection))
+}
+
+func TestEnvSecretProviderProtectedNames(t *testing.T) {
+	t.Setenv("ENCODE_KEY", "secret")
+	_, err := (&EnvSecretProvider{names: []string{"*"}}).Resolve("ENCODE_KEY")
+	assert.NotNil(t, err)
+}
+
+func TestEnvSecretProviderDeniesByDefault(t *testing.T) {
+	t.Setenv("DEVLAKE_TEST_TOKEN", "env-token")
+	_, err := (&EnvSecretProvider{}).Resolve("DEVLAKE_TEST_TOKEN")
+	assert.NotNil(t, err)
+}
+
+func TestVaultSecretProvider(t *testing.T) {
+	// mimics the KV engines of a vault server running in dev mode
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/devlake/github":
+			_, _ = w.Write([]byte(`{"data":{"data":{"token":"kv2-token"},"metadata":{"version":1}}}`))
+		case "/v1/kv/devlake/github":
+			_, _ = w.Write([]byte(`{"data":{"value":"kv1-token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := &VaultSecretProvider{addr: server.URL, token: "root", client: server.Client()}
+	secret, err := provider.Resolve("secret/data/devlake/github#token")
+	assert.Nil(t, err)
+	assert.Equal(t, "kv2-token", secret)
+	secret, err = provider.Resolve("kv/devlake/github")
+	assert.Nil(t, err)
+	assert.Equal(t, "kv1-token", secret)
+	_, err = provider.Resolve("secret/data/devlake/github#password")
+	assert.NotNil(t, err)
+	_, err = provider.Resolve("secret/data/devlake/gitlab#token")
+	assert.NotNil(t, err)
+
+	provider.token = "wrong"
+	_, err = provider.Resolve("secret/data/devlake/github#token")
+	assert.NotNil(t, err)
+}
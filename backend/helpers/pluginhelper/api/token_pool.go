@@ -0,0 +1,325 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// TokenSource provides a credential for the TokenPool
+type TokenSource interface {
+	// Token returns a valid token, refreshing it if necessary
+	Token(ctx context.Context) (string, errors.Error)
+}
+
+// RefreshableTokenSource is a TokenSource which could issue a new token once the current one got rejected,
+// the TokenPool would invalidate it instead of dropping it on 401
+type RefreshableTokenSource interface {
+	TokenSource
+	Invalidate()
+}
+
+// StaticTokenSource is a token which never changes
+type StaticTokenSource string
+
+// Token returns the token itself
+func (s StaticTokenSource) Token(_ context.Context) (string, errors.Error) {
+	return string(s), nil
+}
+
+// StaticTokenSources splits comma separated tokens into TokenSources
+func StaticTokenSources(tokens string) []TokenSource {
+	sources := make([]TokenSource, 0)
+	for _, token := range strings.Split(tokens, ",") {
+		token = strings.TrimSpace(token)
+		if token != "" {
+			sources = append(sources, StaticTokenSource(token))
+		}
+	}
+	return sources
+}
+
+// RateLimitHeaders names the response headers carrying the quota of the token
+type RateLimitHeaders struct {
+	Limit     string
+	Remaining string
+	// Reset is the header of the unix timestamp when the quota would be reset
+	Reset string
+}
+
+// GithubRateLimitHeaders are the quota headers used by github
+var GithubRateLimitHeaders = RateLimitHeaders{
+	Limit:     "X-RateLimit-Limit",
+	Remaining: "X-RateLimit-Remaining",
+	Reset:     "X-RateLimit-Reset",
+}
+
+// GitlabRateLimitHeaders are the quota headers used by gitlab
+var GitlabRateLimitHeaders = RateLimitHeaders{
+	Limit:     "RateLimit-Limit",
+	Remaining: "RateLimit-Remaining",
+	Reset:     "RateLimit-Reset",
+}
+
+// TokenState is a snapshot of a token in the TokenPool
+type TokenState struct {
+	Index     int        `json:"index"`
+	Limit     int        `json:"limit"`
+	Remaining int        `json:"remaining"`
+	ResetAt   *time.Time `json:"resetAt"`
+	Revoked   bool       `json:"revoked"`
+}
+
+type pooledToken struct {
+	source TokenSource
+	// -1 until the first response carrying the quota headers
+	limit     int
+	remaining int
+	resetAt   time.Time
+	revoked   bool
+}
+
+// TokenPool distributes requests among multiple tokens of the same connection, by the remaining quota of
+// each token reported in the response headers, and drops tokens that got rejected by the server
+type TokenPool struct {
+	mu       sync.Mutex
+	headers  RateLimitHeaders
+	tokens   []*pooledToken
+	next     int
+	inflight sync.Map
+}
+
+// NewTokenPool creates a TokenPool with the sources
+func NewTokenPool(headers RateLimitHeaders, sources ...TokenSource) (*TokenPool, errors.Error) {
+	if len(sources) == 0 {
+		return nil, errors.BadInput.New("no token provided")
+	}
+	pool := &TokenPool{headers: headers}
+	for _, source := range sources {
+		pool.tokens = append(pool.tokens, &pooledToken{source: source, limit: -1, remaining: -1})
+	}
+	return pool, nil
+}
+
+// Size returns the number of tokens not revoked
+func (p *TokenPool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	size := 0
+	for _, t := range p.tokens {
+		if !t.revoked {
+			size++
+		}
+	}
+	return size
+}
+
+// TotalLimit returns the sum of quota limits of the tokens not revoked, `fallback` would be used for
+// tokens without any response yet
+func (p *TokenPool) TotalLimit(fallback int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	total := 0
+	for _, t := range p.tokens {
+		if t.revoked {
+			continue
+		}
+		if t.limit < 0 {
+			total += fallback
+		} else {
+			total += t.limit
+		}
+	}
+	return total
+}
+
+// States returns snapshots of the tokens in the pool
+func (p *TokenPool) States() []*TokenState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	states := make([]*TokenState, len(p.tokens))
+	for i, t := range p.tokens {
+		states[i] = &TokenState{Index: i, Limit: t.limit, Remaining: t.remaining, Revoked: t.revoked}
+		if !t.resetAt.IsZero() {
+			resetAt := t.resetAt
+			states[i].ResetAt = &resetAt
+		}
+	}
+	return states
+}
+
+// Acquire returns a token for the next request along with its handle which should be passed to Update
+// once the response arrived
+func (p *TokenPool) Acquire(ctx context.Context) (string, interface{}, errors.Error) {
+	for {
+		t := p.pick()
+		if t == nil {
+			return "", nil, errors.Unauthorized.New("all tokens in the pool were revoked")
+		}
+		token, err := t.source.Token(ctx)
+		if err == nil {
+			return token, t, nil
+		}
+		if !isTokenSourceRevoked(err) {
+			// timeouts, network errors and server errors are likely to be transient, the request would be retried
+			return "", nil, err
+		}
+		// the source is unable to issue token anymore, i.e. the app installation was removed
+		p.mu.Lock()
+		t.revoked = true
+		p.mu.Unlock()
+	}
+}
+
+// isTokenSourceRevoked tells if the token endpoint rejected the source itself, i.e. the credential was
+// revoked (401), the app lost access (403) or the installation was removed (404)
+func isTokenSourceRevoked(err errors.Error) bool {
+	switch err.GetType().GetHttpCode() {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+// pick selects the token with the most remaining quota, tokens with unknown quota are preferred so
+// their quota would be revealed soon, ties are broken in a round-robin manner. When all tokens are
+// exhausted, the one reset the earliest is returned.
+func (p *TokenPool) pick() *pooledToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	var best, earliest *pooledToken
+	bestRemaining := 0
+	for i := range p.tokens {
+		t := p.tokens[(p.next+i)%len(p.tokens)]
+		if t.revoked {
+			continue
+		}
+		remaining := t.remaining
+		if remaining < 0 || (remaining == 0 && !t.resetAt.IsZero() && !now.Before(t.resetAt)) {
+			// unknown or reset already
+			remaining = int(^uint(0) >> 1)
+		}
+		if remaining > bestRemaining {
+			best, bestRemaining = t, remaining
+		}
+		if earliest == nil || t.resetAt.Before(earliest.resetAt) {
+			earliest = t
+		}
+	}
+	p.next = (p.next + 1) % len(p.tokens)
+	if best == nil {
+		best = earliest
+	}
+	if best != nil && best.remaining > 0 {
+		best.remaining--
+	}
+	return best
+}
+
+// Update records the quota of the token from the response, and drops the token if it was rejected
+func (p *TokenPool) Update(handle interface{}, res *http.Response) {
+	t, ok := handle.(*pooledToken)
+	if !ok || t == nil || res == nil {
+		return
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		if refreshable, ok := t.source.(RefreshableTokenSource); ok {
+			refreshable.Invalidate()
+			return
+		}
+		p.mu.Lock()
+		t.revoked = true
+		p.mu.Unlock()
+		return
+	}
+	limit, err1 := strconv.Atoi(res.Header.Get(p.headers.Limit))
+	remaining, err2 := strconv.Atoi(res.Header.Get(p.headers.Remaining))
+	reset, err3 := strconv.ParseInt(res.Header.Get(p.headers.Reset), 10, 64)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err1 == nil {
+		t.limit = limit
+	}
+	if err2 == nil {
+		t.remaining = remaining
+	}
+	if err3 == nil {
+		t.resetAt = time.Unix(reset, 0)
+	}
+}
+
+// SetupApiClient makes the apiClient authorize requests with tokens from the pool, `authorize` puts the
+// token into the request, i.e. the Authorization header or the query string
+func (p *TokenPool) SetupApiClient(apiClient *ApiClient, authorize func(req *http.Request, token string)) {
+	before := apiClient.GetBeforeFunction()
+	apiClient.SetBeforeFunction(func(req *http.Request) errors.Error {
+		if before != nil {
+			err := before(req)
+			if err != nil {
+				return err
+			}
+		}
+		token, handle, err := p.Acquire(req.Context())
+		if err != nil {
+			return err
+		}
+		authorize(req, token)
+		p.inflight.Store(req, handle)
+		return nil
+	})
+	// drop the handle whatever the outcome of the round trip, the after function is skipped on transport errors
+	apiClient.requestDone = append(apiClient.requestDone, func(req *http.Request) {
+		p.inflight.Delete(req)
+	})
+	after := apiClient.GetAfterFunction()
+	apiClient.SetAfterFunction(func(res *http.Response) errors.Error {
+		if handle, ok := p.inflight.LoadAndDelete(originalRequest(res.Request)); ok {
+			p.Update(handle, res)
+			if res.StatusCode == http.StatusUnauthorized && p.Size() > 0 {
+				// let the async client retry with another token
+				return errors.Unauthorized.New(fmt.Sprintf("token rejected by %s", res.Request.URL.Host))
+			}
+		}
+		if after != nil {
+			return after(res)
+		}
+		return nil
+	})
+}
+
+// originalRequest follows the redirect chain back to the request that was passed to the http client
+func originalRequest(req *http.Request) *http.Request {
+	for req != nil && req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req
+}
+
+// BearerAuthorization puts the token into the Authorization header
+func BearerAuthorization(req *http.Request, token string) {
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+}
@@ -0,0 +1,169 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// GithubAppTokenSource issues installation access tokens of a GitHub App, tokens are refreshed a few minutes
+// before they expire (1 hour after issued)
+type GithubAppTokenSource struct {
+	mu             sync.Mutex
+	endpoint       string
+	appId          string
+	installationId int64
+	privateKey     *rsa.PrivateKey
+	client         *http.Client
+	token          string
+	expiresAt      time.Time
+}
+
+var _ RefreshableTokenSource = (*GithubAppTokenSource)(nil)
+
+// NewGithubAppTokenSource creates a GithubAppTokenSource with the PEM encoded private key of the app
+func NewGithubAppTokenSource(
+	endpoint string,
+	appId string,
+	installationId int64,
+	privateKey string,
+	proxy string,
+) (*GithubAppTokenSource, errors.Error) {
+	key, err := parseRsaPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{}
+	if proxy != "" {
+		proxyUrl, e := url.Parse(proxy)
+		if e != nil {
+			return nil, errors.BadInput.Wrap(e, fmt.Sprintf("invalid proxy url %s", proxy))
+		}
+		transport.Proxy = http.ProxyURL(proxyUrl)
+	}
+	if !strings.HasSuffix(endpoint, "/") {
+		endpoint += "/"
+	}
+	return &GithubAppTokenSource{
+		endpoint:       endpoint,
+		appId:          appId,
+		installationId: installationId,
+		privateKey:     key,
+		client:         &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Token returns the cached installation token or requests a new one
+func (s *GithubAppTokenSource) Token(ctx context.Context) (string, errors.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Add(5*time.Minute).Before(s.expiresAt) {
+		return s.token, nil
+	}
+	jwt, err := s.signJwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	uri := fmt.Sprintf("%sapp/installations/%d/access_tokens", s.endpoint, s.installationId)
+	req, e := http.NewRequestWithContext(ctx, http.MethodPost, uri, nil)
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt))
+	res, e := s.client.Do(req)
+	if e != nil {
+		return "", errors.Default.Wrap(e, fmt.Sprintf("failed to request installation token of app %s", s.appId))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return "", errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to request installation token of app %s", s.appId))
+	}
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	e = json.NewDecoder(res.Body).Decode(&body)
+	if e != nil {
+		return "", errors.Default.Wrap(e, "failed to decode installation token")
+	}
+	s.token, s.expiresAt = body.Token, body.ExpiresAt
+	return s.token, nil
+}
+
+// Invalidate discards the cached token
+func (s *GithubAppTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
+
+// signJwt creates the RS256 JWT to authenticate as the app, backdated a minute to tolerate clock drift
+func (s *GithubAppTokenSource) signJwt(now time.Time) (string, errors.Error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, e := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.appId,
+	})
+	if e != nil {
+		return "", errors.Convert(e)
+	}
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, e := rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, hashed[:])
+	if e != nil {
+		return "", errors.Default.Wrap(e, "failed to sign jwt")
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRsaPrivateKey(privateKey string) (*rsa.PrivateKey, errors.Error) {
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, errors.BadInput.New("private key is not PEM encoded")
+	}
+	if key, e := x509.ParsePKCS1PrivateKey(block.Bytes); e == nil {
+		return key, nil
+	}
+	key, e := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if e != nil {
+		return nil, errors.BadInput.Wrap(e, "failed to parse private key")
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.BadInput.New("private key is not a RSA key")
+	}
+	return rsaKey, nil
+}
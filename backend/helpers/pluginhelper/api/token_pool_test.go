@@ -0,0 +1,191 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func quotaResponse(status int, limit, remaining int, reset time.Time) *http.Response {
+	res := &http.Response{StatusCode: status, Header: http.Header{}}
+	res.Header.Set("X-RateLimit-Limit", fmt.Sprint(limit))
+	res.Header.Set("X-RateLimit-Remaining", fmt.Sprint(remaining))
+	res.Header.Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+	return res
+}
+
+func TestTokenPool(t *testing.T) {
+	pool, err := NewTokenPool(GithubRateLimitHeaders, StaticTokenSources("a, b,c")...)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, pool.Size())
+	assert.Equal(t, 15000, pool.TotalLimit(5000))
+
+	ctx := context.Background()
+	reset := time.Now().Add(time.Hour)
+	handles := map[string]interface{}{}
+	for i := 0; i < 3; i++ {
+		token, handle, err := pool.Acquire(ctx)
+		assert.Nil(t, err)
+		handles[token] = handle
+	}
+	assert.Len(t, handles, 3)
+	pool.Update(handles["a"], quotaResponse(http.StatusOK, 5000, 10, reset))
+	pool.Update(handles["b"], quotaResponse(http.StatusOK, 15000, 9000, reset))
+	pool.Update(handles["c"], quotaResponse(http.StatusUnauthorized, 0, 0, reset))
+	assert.Equal(t, 2, pool.Size())
+	assert.Equal(t, 20000, pool.TotalLimit(5000))
+
+	// b has the most remaining quota
+	token, _, err := pool.Acquire(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "b", token)
+
+	// b exhausted, a would be used until it is exhausted as well
+	pool.Update(handles["b"], quotaResponse(http.StatusForbidden, 15000, 0, reset.Add(time.Minute)))
+	token, _, err = pool.Acquire(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", token)
+	pool.Update(handles["a"], quotaResponse(http.StatusOK, 5000, 0, reset))
+	// all exhausted, the one reset earlier is returned
+	token, _, err = pool.Acquire(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", token)
+
+	pool.Update(handles["a"], quotaResponse(http.StatusUnauthorized, 0, 0, reset))
+	pool.Update(handles["b"], quotaResponse(http.StatusUnauthorized, 0, 0, reset))
+	_, _, err = pool.Acquire(ctx)
+	assert.NotNil(t, err)
+
+	_, err = NewTokenPool(GithubRateLimitHeaders, StaticTokenSources(" , ")...)
+	assert.NotNil(t, err)
+}
+
+func TestTokenPoolInflight(t *testing.T) {
+	pool, err := NewTokenPool(GithubRateLimitHeaders, StaticTokenSources("a")...)
+	assert.Nil(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/target", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	apiClient := &ApiClient{}
+	apiClient.Setup(server.URL, nil, 5*time.Second)
+	pool.SetupApiClient(apiClient, BearerAuthorization)
+	countInflight := func() int {
+		count := 0
+		pool.inflight.Range(func(key, value interface{}) bool {
+			count++
+			return true
+		})
+		return count
+	}
+
+	res, err := apiClient.Get("redirect", nil, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res.Body.Close()
+	assert.Equal(t, 0, countInflight())
+
+	apiClient.SetEndpoint("http://127.0.0.1:1")
+	_, err = apiClient.Get("unreachable", nil, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, countInflight())
+}
+
+func TestGithubAppTokenSource(t *testing.T) {
+	key, e := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, e)
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/app/installations/42/access_tokens", r.URL.Path)
+		assert.Equal(t, http.MethodPost, r.Method)
+		issued++
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("ghs_%d", issued),
+			"expires_at": time.Now().Add(time.Hour),
+		})
+	}))
+	defer server.Close()
+
+	source, err := NewGithubAppTokenSource(server.URL, "123", 42, keyPem, "")
+	assert.Nil(t, err)
+	token, err := source.Token(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ghs_1", token)
+	token, err = source.Token(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ghs_1", token)
+
+	// an invalidated app token would be refreshed instead of dropped
+	pool, err := NewTokenPool(GithubRateLimitHeaders, source)
+	assert.Nil(t, err)
+	_, handle, err := pool.Acquire(context.Background())
+	assert.Nil(t, err)
+	pool.Update(handle, &http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}})
+	assert.Equal(t, 1, pool.Size())
+	token, _, err = pool.Acquire(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "ghs_2", token)
+
+	_, err = NewGithubAppTokenSource(server.URL, "123", 42, "not a key", "")
+	assert.NotNil(t, err)
+}
+
+func TestTokenPoolSourceErrors(t *testing.T) {
+	key, e := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, e)
+	keyPem := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	source, err := NewGithubAppTokenSource(server.URL, "123", 42, keyPem, "")
+	assert.Nil(t, err)
+	pool, err := NewTokenPool(GithubRateLimitHeaders, source)
+	assert.Nil(t, err)
+
+	// transient failures of the token endpoint keep the source
+	_, _, err = pool.Acquire(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, pool.Size())
+
+	// the installation was removed
+	status = http.StatusNotFound
+	_, _, err = pool.Acquire(context.Background())
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, pool.Size())
+}
@@ -0,0 +1,247 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MAX_MESSAGE_SIZE is the limit of messages in both directions, rows of the dal are sent in batches well below it
+const MAX_MESSAGE_SIZE = 64 * 1024 * 1024
+
+type hostServer interface {
+	config(ctx gocontext.Context, req *ConfigRequest) *ConfigResponse
+	log(ctx gocontext.Context, req *LogRequest) *ErrorResponse
+	progress(ctx gocontext.Context, req *ProgressRequest) *ErrorResponse
+	dal(ctx gocontext.Context, req *DalRequest) *DalResponse
+}
+
+var hostServiceDesc = grpc.ServiceDesc{
+	ServiceName: HOST_SERVICE,
+	HandlerType: (*hostServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Config", hostServer.config),
+		unaryMethod("Log", hostServer.log),
+		unaryMethod("Progress", hostServer.progress),
+		unaryMethod("Dal", hostServer.dal),
+	},
+}
+
+// hostSession holds resources of an ongoing call to a remote plugin, calls from the plugin back to the host
+// carry the session id so logs, progress and database operations go to the right place
+type hostSession struct {
+	pluginName string
+	basicRes   context.BasicRes
+	execCtx    plugin.ExecContext
+	cursors    map[string]*hostCursor
+	lock       sync.Mutex
+}
+
+// Host serves the Host service for remote plugins and keeps track of their sessions
+type Host struct {
+	basicRes context.BasicRes
+	address  string
+	// secret authenticates the host to remote plugins
+	secret        string
+	configAllowed func(name string) bool
+	// token => plugin name
+	tokens   map[string]string
+	sessions map[string]*hostSession
+	// plugin name => the session used by calls made outside of any session, e.g. by api handlers
+	plugins map[string]*hostSession
+	lock    sync.Mutex
+}
+
+// NewHost creates a Host, address is where remote plugins could reach the Host service
+func NewHost(basicRes context.BasicRes, address string) *Host {
+	return &Host{
+		basicRes:      basicRes,
+		address:       address,
+		secret:        basicRes.GetConfig(REMOTE_PLUGIN_SECRET_ENV),
+		configAllowed: configNameMatcher(basicRes.GetConfig(REMOTE_PLUGIN_CONFIG_NAMES_ENV)),
+		tokens:        make(map[string]string),
+		sessions:      make(map[string]*hostSession),
+		plugins:       make(map[string]*hostSession),
+	}
+}
+
+// Register registers the Host service to the grpc server
+func (h *Host) Register(server *grpc.Server) {
+	server.RegisterService(&hostServiceDesc, h)
+}
+
+// ServerOptions returns options required by the grpc server of the Host service
+func (h *Host) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(h.authenticate),
+		grpc.MaxRecvMsgSize(MAX_MESSAGE_SIZE),
+		grpc.MaxSendMsgSize(MAX_MESSAGE_SIZE),
+	}
+}
+
+type pluginNameKey struct{}
+
+// authenticate rejects calls without a valid token and puts the name of the calling plugin into the context
+func (h *Host) authenticate(ctx gocontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	tokens := md.Get(TOKEN_METADATA)
+	if len(tokens) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing plugin token")
+	}
+	h.lock.Lock()
+	pluginName, ok := h.tokens[tokens[0]]
+	h.lock.Unlock()
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "invalid plugin token")
+	}
+	return handler(gocontext.WithValue(ctx, pluginNameKey{}, pluginName), req)
+}
+
+// issueToken creates the token for the plugin to call the Host service
+func (h *Host) issueToken(pluginName string) (string, errors.Error) {
+	token, err := randomId()
+	if err != nil {
+		return "", err
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.tokens[token] = pluginName
+	if h.plugins[pluginName] == nil {
+		h.plugins[pluginName] = &hostSession{
+			pluginName: pluginName,
+			basicRes:   h.basicRes.NestedLogger(pluginName),
+			cursors:    make(map[string]*hostCursor),
+		}
+	}
+	return token, nil
+}
+
+// openSession registers resources for a call to the plugin, the returned function closes the session
+func (h *Host) openSession(pluginName string, basicRes context.BasicRes, execCtx plugin.ExecContext) (string, func(), errors.Error) {
+	sessionId, err := randomId()
+	if err != nil {
+		return "", nil, err
+	}
+	session := &hostSession{
+		pluginName: pluginName,
+		basicRes:   basicRes,
+		execCtx:    execCtx,
+		cursors:    make(map[string]*hostCursor),
+	}
+	h.lock.Lock()
+	h.sessions[sessionId] = session
+	h.lock.Unlock()
+	return sessionId, func() {
+		h.lock.Lock()
+		delete(h.sessions, sessionId)
+		h.lock.Unlock()
+		session.closeCursors()
+	}, nil
+}
+
+// getSession returns the session of the calling plugin, the session id is optional, resources of the plugin
+// are returned without one
+func (h *Host) getSession(ctx gocontext.Context, sessionId string) (*hostSession, errors.Error) {
+	pluginName, _ := ctx.Value(pluginNameKey{}).(string)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if sessionId == "" {
+		session, ok := h.plugins[pluginName]
+		if !ok {
+			return nil, errors.Unauthorized.New("unknown plugin")
+		}
+		return session, nil
+	}
+	session, ok := h.sessions[sessionId]
+	if !ok || session.pluginName != pluginName {
+		return nil, errors.NotFound.New(fmt.Sprintf("session %s not found", sessionId))
+	}
+	return session, nil
+}
+
+func (h *Host) config(ctx gocontext.Context, req *ConfigRequest) *ConfigResponse {
+	if !h.configAllowed(req.Name) {
+		return &ConfigResponse{}
+	}
+	return &ConfigResponse{Value: h.basicRes.GetConfig(req.Name)}
+}
+
+func (h *Host) log(ctx gocontext.Context, req *LogRequest) *ErrorResponse {
+	session, err := h.getSession(ctx, req.SessionId)
+	if err != nil {
+		return &ErrorResponse{Error: toRemoteError(err)}
+	}
+	session.basicRes.GetLogger().Log(log.LogLevel(req.Level), "%s", req.Message)
+	return &ErrorResponse{}
+}
+
+func (h *Host) progress(ctx gocontext.Context, req *ProgressRequest) *ErrorResponse {
+	session, err := h.getSession(ctx, req.SessionId)
+	if err != nil {
+		return &ErrorResponse{Error: toRemoteError(err)}
+	}
+	execCtx := session.execCtx
+	if subtaskCtx, ok := execCtx.(plugin.SubTaskContext); ok && req.Task {
+		execCtx = subtaskCtx.TaskContext()
+	}
+	if execCtx == nil {
+		return &ErrorResponse{Error: toRemoteError(errors.BadInput.New("progress is only available to tasks"))}
+	}
+	switch req.Type {
+	case PROGRESS_SET:
+		execCtx.SetProgress(req.Current, req.Total)
+	case PROGRESS_INC:
+		execCtx.IncProgress(req.Current)
+	default:
+		return &ErrorResponse{Error: toRemoteError(errors.BadInput.New("unknown progress type " + req.Type))}
+	}
+	return &ErrorResponse{}
+}
+
+func (h *Host) dal(ctx gocontext.Context, req *DalRequest) *DalResponse {
+	session, err := h.getSession(ctx, req.SessionId)
+	if err == nil {
+		var res *DalResponse
+		res, err = session.execute(req)
+		if err == nil {
+			return res
+		}
+	}
+	return &DalResponse{Error: toRemoteError(err)}
+}
+
+func randomId() (string, errors.Error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Convert(err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,475 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"gorm.io/datatypes"
+)
+
+// CURSOR_BATCH_SIZE is the number of rows sent for every fetch of a cursor
+const CURSOR_BATCH_SIZE = 500
+
+type hostCursor struct {
+	rows    dal.Rows
+	columns []string
+}
+
+// fetch reads the next batch of rows, the cursor is closed once exhausted
+func (c *hostCursor) fetch() ([][]interface{}, bool, errors.Error) {
+	batch := make([][]interface{}, 0, CURSOR_BATCH_SIZE)
+	for len(batch) < CURSOR_BATCH_SIZE {
+		if !c.rows.Next() {
+			_ = c.rows.Close()
+			return batch, true, nil
+		}
+		values := make([]interface{}, len(c.columns))
+		pointers := make([]interface{}, len(c.columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := c.rows.Scan(pointers...); err != nil {
+			_ = c.rows.Close()
+			return nil, true, errors.Convert(err)
+		}
+		for i, value := range values {
+			// text columns are returned as []byte by some drivers
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		batch = append(batch, values)
+	}
+	return batch, false, nil
+}
+
+func (s *hostSession) closeCursors() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id, cursor := range s.cursors {
+		_ = cursor.rows.Close()
+		delete(s.cursors, id)
+	}
+}
+
+// execute runs the database operation requested by the plugin
+func (s *hostSession) execute(req *DalRequest) (*DalResponse, errors.Error) {
+	db := s.basicRes.GetDal()
+	clauses, err := decodeClauses(req.Clauses)
+	if err != nil {
+		return nil, err
+	}
+	params, err := decodeValues(req.Params)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := decodeRows(req.Rows)
+	if err != nil {
+		return nil, err
+	}
+	res := &DalResponse{}
+	switch req.Op {
+	case DAL_DIALECT:
+		res.Strings = []string{db.Dialect()}
+	case DAL_EXEC:
+		err = db.Exec(req.Query, params...)
+	case DAL_QUERY:
+		err = s.query(db, clauses, res)
+	case DAL_FETCH:
+		err = s.fetch(req.Query, res)
+	case DAL_CLOSE_CURSOR:
+		s.closeCursor(req.Query)
+	case DAL_COUNT:
+		res.Count, err = db.Count(clauses...)
+	case DAL_INSERT, DAL_INSERT_IGNORE, DAL_UPSERT:
+		err = insert(db, req.Op, req.Table, req.Columns, req.PrimaryKeys, rows)
+	case DAL_UPDATE:
+		err = updateByPrimaryKeys(db, req.Table, req.Columns, req.PrimaryKeys, rows)
+	case DAL_UPDATE_SET:
+		err = updateSet(db, req.Table, req.Sets, clauses)
+	case DAL_DELETE:
+		err = deleteRows(db, req.Table, req.Columns, rows, clauses)
+	case DAL_AUTO_MIGRATE:
+		err = autoMigrate(db, req.Table, req.Fields)
+	case DAL_ALL_TABLES:
+		res.Strings, err = db.AllTables()
+	case DAL_DROP_TABLES:
+		tables := make([]interface{}, len(req.Args))
+		for i, table := range req.Args {
+			tables[i] = table
+		}
+		err = db.DropTables(tables...)
+	case DAL_ADD_COLUMN:
+		if len(req.Args) != 2 {
+			return nil, errors.BadInput.New("column name and type are required")
+		}
+		err = db.AddColumn(req.Table, req.Args[0], req.Args[1])
+	case DAL_DROP_COLUMNS:
+		err = db.DropColumns(req.Table, req.Args...)
+	case DAL_RENAME_TABLE:
+		if len(req.Args) != 1 {
+			return nil, errors.BadInput.New("new table name is required")
+		}
+		err = db.RenameTable(req.Table, req.Args[0])
+	case DAL_RENAME_COLUMN:
+		if len(req.Args) != 2 {
+			return nil, errors.BadInput.New("old and new column names are required")
+		}
+		err = db.RenameColumn(req.Table, req.Args[0], req.Args[1])
+	case DAL_DROP_INDEXES:
+		err = db.DropIndexes(req.Table, req.Args...)
+	default:
+		return nil, errors.BadInput.New("unknown dal operation " + req.Op)
+	}
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.Wrap(err, "record not found")
+		}
+		if db.IsDuplicationError(err) {
+			return nil, errors.HttpStatus(http.StatusConflict).Wrap(err, "duplicated record")
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+// query opens a cursor and returns the first batch of rows, the cursor id is returned if there are more
+func (s *hostSession) query(db dal.Dal, clauses []dal.Clause, res *DalResponse) errors.Error {
+	rows, err := db.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	columns, e := rows.Columns()
+	if e != nil {
+		_ = rows.Close()
+		return errors.Convert(e)
+	}
+	cursor := &hostCursor{rows: rows, columns: columns}
+	res.Columns = columns
+	batch, done, err := cursor.fetch()
+	if err != nil {
+		return err
+	}
+	if !done {
+		cursorId, err := randomId()
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		s.lock.Lock()
+		s.cursors[cursorId] = cursor
+		s.lock.Unlock()
+		res.Strings = []string{cursorId}
+	}
+	res.Rows, err = encodeRows(batch)
+	return err
+}
+
+func (s *hostSession) fetch(cursorId string, res *DalResponse) errors.Error {
+	s.lock.Lock()
+	cursor, ok := s.cursors[cursorId]
+	s.lock.Unlock()
+	if !ok {
+		return errors.BadInput.New("cursor not found")
+	}
+	batch, done, err := cursor.fetch()
+	if done {
+		s.lock.Lock()
+		delete(s.cursors, cursorId)
+		s.lock.Unlock()
+	} else {
+		res.Strings = []string{cursorId}
+	}
+	if err != nil {
+		return err
+	}
+	res.Columns = cursor.columns
+	res.Rows, err = encodeRows(batch)
+	return err
+}
+
+func (s *hostSession) closeCursor(cursorId string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if cursor, ok := s.cursors[cursorId]; ok {
+		_ = cursor.rows.Close()
+		delete(s.cursors, cursorId)
+	}
+}
+
+// placeholders returns `?` joined by comma
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+func columnParams(columns []string) []interface{} {
+	params := make([]interface{}, len(columns))
+	for i, column := range columns {
+		params[i] = dal.ClauseColumn{Name: column}
+	}
+	return params
+}
+
+func insert(db dal.Dal, op string, table string, columns []string, primaryKeys []string, rows [][]interface{}) errors.Error {
+	if table == "" || len(columns) == 0 {
+		return errors.BadInput.New("table and columns are required")
+	}
+	dialect := db.Dialect()
+	var updates []string
+	if op == DAL_UPSERT {
+		for _, column := range columns {
+			if !contains(primaryKeys, column) {
+				updates = append(updates, column)
+			}
+		}
+		if len(updates) == 0 {
+			// nothing to update besides primary keys
+			op = DAL_INSERT_IGNORE
+		}
+	}
+	insertInto := "INSERT INTO"
+	var suffix string
+	var suffixParams []interface{}
+	switch {
+	case op == DAL_INSERT_IGNORE && dialect == "mysql":
+		insertInto = "INSERT IGNORE INTO"
+	case op == DAL_INSERT_IGNORE:
+		suffix = " ON CONFLICT DO NOTHING"
+	case op == DAL_UPSERT && dialect == "mysql":
+		sets := make([]string, len(updates))
+		for i, column := range updates {
+			sets[i] = "? = VALUES(?)"
+			suffixParams = append(suffixParams, dal.ClauseColumn{Name: column}, dal.ClauseColumn{Name: column})
+		}
+		suffix = " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ",")
+	case op == DAL_UPSERT:
+		if len(primaryKeys) == 0 {
+			return errors.BadInput.New("primary keys are required for upsert")
+		}
+		suffixParams = columnParams(primaryKeys)
+		sets := make([]string, len(updates))
+		for i, column := range updates {
+			sets[i] = "? = EXCLUDED.?"
+			suffixParams = append(suffixParams, dal.ClauseColumn{Name: column}, dal.ClauseColumn{Name: column})
+		}
+		suffix = fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", placeholders(len(primaryKeys)), strings.Join(sets, ","))
+	}
+	rowPlaceholder := "(" + placeholders(len(columns)) + ")"
+	// keep the number of parameters of a statement under limits of databases
+	batchSize := 900 / len(columns)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		end := start + batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		values := make([]string, 0, end-start)
+		params := append([]interface{}{dal.ClauseTable{Name: table}}, columnParams(columns)...)
+		for _, row := range rows[start:end] {
+			if len(row) != len(columns) {
+				return errors.BadInput.New("row doesn't match columns")
+			}
+			values = append(values, rowPlaceholder)
+			params = append(params, row...)
+		}
+		params = append(params, suffixParams...)
+		query := fmt.Sprintf("%s ? (%s) VALUES %s%s", insertInto, placeholders(len(columns)), strings.Join(values, ","), suffix)
+		err := db.Exec(query, params...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateByPrimaryKeys updates all columns of the rows located by their primary keys
+func updateByPrimaryKeys(db dal.Dal, table string, columns []string, primaryKeys []string, rows [][]interface{}) errors.Error {
+	if table == "" || len(primaryKeys) == 0 {
+		return errors.BadInput.New("table and primary keys are required")
+	}
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return errors.BadInput.New("row doesn't match columns")
+		}
+		sets := make([]string, 0, len(columns))
+		params := []interface{}{dal.ClauseTable{Name: table}}
+		var wheres []string
+		var whereParams []interface{}
+		for i, column := range columns {
+			if contains(primaryKeys, column) {
+				wheres = append(wheres, "? = ?")
+				whereParams = append(whereParams, dal.ClauseColumn{Name: column}, row[i])
+			} else {
+				sets = append(sets, "? = ?")
+				params = append(params, dal.ClauseColumn{Name: column}, row[i])
+			}
+		}
+		if len(wheres) != len(primaryKeys) {
+			return errors.BadInput.New("primary keys are missing in the row")
+		}
+		if len(sets) == 0 {
+			continue
+		}
+		query := fmt.Sprintf("UPDATE ? SET %s WHERE %s", strings.Join(sets, ","), strings.Join(wheres, " AND "))
+		err := db.Exec(query, append(params, whereParams...)...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// whereClause joins where clauses, which are required to avoid updating or deleting the whole table
+func whereClause(clauses []dal.Clause) (string, []interface{}, errors.Error) {
+	var wheres []string
+	var params []interface{}
+	for _, c := range clauses {
+		if c.Type != dal.WhereClause {
+			return "", nil, errors.BadInput.New("only where clauses are supported")
+		}
+		where := c.Data.(dal.DalClause)
+		wheres = append(wheres, "("+where.Expr+")")
+		params = append(params, where.Params...)
+	}
+	if len(wheres) == 0 {
+		return "", nil, errors.BadInput.New("where clause is required")
+	}
+	return strings.Join(wheres, " AND "), params, nil
+}
+
+func updateSet(db dal.Dal, table string, sets []*WireSet, clauses []dal.Clause) errors.Error {
+	if table == "" || len(sets) == 0 {
+		return errors.BadInput.New("table and sets are required")
+	}
+	where, whereParams, err := whereClause(clauses)
+	if err != nil {
+		return err
+	}
+	exprs := make([]string, len(sets))
+	params := []interface{}{dal.ClauseTable{Name: table}}
+	for i, set := range sets {
+		setParams, err := decodeValues(set.Params)
+		if err != nil {
+			return err
+		}
+		params = append(params, dal.ClauseColumn{Name: set.Column})
+		if set.Expr != "" {
+			exprs[i] = "? = " + set.Expr
+			params = append(params, setParams...)
+		} else {
+			if len(setParams) != 1 {
+				return errors.BadInput.New("value of " + set.Column + " is required")
+			}
+			exprs[i] = "? = ?"
+			params = append(params, setParams[0])
+		}
+	}
+	query := fmt.Sprintf("UPDATE ? SET %s WHERE %s", strings.Join(exprs, ","), where)
+	return db.Exec(query, append(params, whereParams...)...)
+}
+
+// deleteRows deletes the rows matching where clauses, or the rows located by primary keys in `columns`
+func deleteRows(db dal.Dal, table string, columns []string, rows [][]interface{}, clauses []dal.Clause) errors.Error {
+	if table == "" {
+		return errors.BadInput.New("table is required")
+	}
+	if len(clauses) > 0 {
+		where, params, err := whereClause(clauses)
+		if err != nil {
+			return err
+		}
+		return db.Exec("DELETE FROM ? WHERE "+where, append([]interface{}{dal.ClauseTable{Name: table}}, params...)...)
+	}
+	if len(columns) == 0 || len(rows) == 0 {
+		return errors.BadInput.New("where clause or primary keys are required")
+	}
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return errors.BadInput.New("row doesn't match columns")
+		}
+		wheres := make([]string, len(columns))
+		params := []interface{}{dal.ClauseTable{Name: table}}
+		for i, column := range columns {
+			wheres[i] = "? = ?"
+			params = append(params, dal.ClauseColumn{Name: column}, row[i])
+		}
+		err := db.Exec("DELETE FROM ? WHERE "+strings.Join(wheres, " AND "), params...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var fieldTypes = map[string]reflect.Type{
+	"bool":   reflect.TypeOf(false),
+	"int":    reflect.TypeOf(int64(0)),
+	"uint":   reflect.TypeOf(uint64(0)),
+	"float":  reflect.TypeOf(float64(0)),
+	"string": reflect.TypeOf(""),
+	"time":   reflect.TypeOf(time.Time{}),
+	"bytes":  reflect.TypeOf([]byte{}),
+}
+
+// autoMigrate builds a struct from the fields sent by the plugin and migrates the table with it
+func autoMigrate(db dal.Dal, table string, fields []*WireField) errors.Error {
+	if table == "" || len(fields) == 0 {
+		return errors.BadInput.New("table and fields are required")
+	}
+	structFields := make([]reflect.StructField, len(fields))
+	for i, field := range fields {
+		fieldType, ok := fieldTypes[field.Kind]
+		if !ok {
+			return errors.BadInput.New("unknown kind " + field.Kind)
+		}
+		tag := "column:" + field.Column
+		if field.DataType == "json" {
+			fieldType = reflect.TypeOf(datatypes.JSON{})
+		} else if field.DataType != "" && !strings.Contains(strings.ToLower(field.Tag), "type:") {
+			tag += ";type:" + field.DataType
+		}
+		if field.Tag != "" {
+			tag += ";" + field.Tag
+		}
+		structFields[i] = reflect.StructField{
+			Name: fmt.Sprintf("F%d", i),
+			Type: fieldType,
+			Tag:  reflect.StructTag(fmt.Sprintf(`gorm:%q`, tag)),
+		}
+	}
+	entity := reflect.New(reflect.StructOf(structFields)).Interface()
+	return db.AutoMigrate(entity, dal.From(table))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	"google.golang.org/grpc"
+)
+
+const (
+	REMOTE_PLUGINS_ENV             = "REMOTE_PLUGINS"
+	REMOTE_PLUGIN_HOST_LISTEN_ENV  = "REMOTE_PLUGIN_HOST_LISTEN"
+	REMOTE_PLUGIN_HOST_ADDRESS_ENV = "REMOTE_PLUGIN_HOST_ADDRESS"
+	DEFAULT_HOST_LISTEN            = "localhost:8090"
+	DEFAULT_HOST_ADDRESS           = "localhost:8090"
+)
+
+// LoadRemotePlugins connects to plugins listed in REMOTE_PLUGINS, e.g. `jenkinsx=jenkinsx-plugin:9000,foo=localhost:9001`,
+// and registers them along with in-process ones. The Host service is started only if there are remote plugins
+func LoadRemotePlugins(basicRes context.BasicRes) errors.Error {
+	addresses, err := parseRemotePlugins(basicRes.GetConfig(REMOTE_PLUGINS_ENV))
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return nil
+	}
+	if basicRes.GetConfig(REMOTE_PLUGIN_SECRET_ENV) == "" {
+		return errors.BadInput.New(fmt.Sprintf("%s is required to load remote plugins", REMOTE_PLUGIN_SECRET_ENV))
+	}
+	serverCreds, err := serverCredentials(basicRes.GetConfig(REMOTE_PLUGIN_TLS_CERT_ENV), basicRes.GetConfig(REMOTE_PLUGIN_TLS_KEY_ENV))
+	if err != nil {
+		return err
+	}
+	dialCreds, err := clientCredentials(basicRes.GetConfig(REMOTE_PLUGIN_TLS_CA_ENV))
+	if err != nil {
+		return err
+	}
+	disabled := make(map[string]bool)
+	for _, name := range strings.Split(basicRes.GetConfig("DISABLED_PLUGINS"), ",") {
+		disabled[strings.TrimSpace(name)] = true
+	}
+
+	listen := basicRes.GetConfig(REMOTE_PLUGIN_HOST_LISTEN_ENV)
+	if listen == "" {
+		listen = DEFAULT_HOST_LISTEN
+	}
+	address := basicRes.GetConfig(REMOTE_PLUGIN_HOST_ADDRESS_ENV)
+	if address == "" {
+		address = DEFAULT_HOST_ADDRESS
+	}
+	listener, e := net.Listen("tcp", listen)
+	if e != nil {
+		return errors.Default.Wrap(e, fmt.Sprintf("failed to listen on %s for remote plugins", listen))
+	}
+	host := NewHost(basicRes, address)
+	serverOptions := host.ServerOptions()
+	if serverCreds != nil {
+		serverOptions = append(serverOptions, serverCreds)
+	}
+	server := grpc.NewServer(serverOptions...)
+	host.Register(server)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			basicRes.GetLogger().Error(err, "host service for remote plugins stopped")
+		}
+	}()
+	basicRes.GetLogger().Info("host service for remote plugins is listening on %s", listen)
+
+	candidates := make(map[string]plugin.PluginMeta)
+	for name, pluginAddress := range addresses {
+		if disabled[name] {
+			plugin.SetPluginLoadStatus(&plugin.PluginLoadStatus{Name: name, Status: plugin.PLUGIN_STATUS_DISABLED})
+			basicRes.GetLogger().Info(`plugin disabled %s`, name)
+			continue
+		}
+		remotePlugin, err := connect(host, name, pluginAddress, dialCreds)
+		if err != nil {
+			plugin.SetPluginLoadStatus(&plugin.PluginLoadStatus{Name: name, Status: plugin.PLUGIN_STATUS_FAILED, Error: err.Error()})
+			basicRes.GetLogger().Error(err, "failed to load remote plugin %s", name)
+			continue
+		}
+		candidates[name] = remotePlugin
+	}
+	runner.InitPlugins(basicRes, candidates)
+	return nil
+}
+
+func connect(host *Host, name string, address string, creds grpc.DialOption) (*RemotePlugin, errors.Error) {
+	conn, err := grpc.Dial(
+		address,
+		creds,
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MAX_MESSAGE_SIZE), grpc.MaxCallSendMsgSize(MAX_MESSAGE_SIZE)),
+	)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to connect to %s", address))
+	}
+	remotePlugin, e := host.Connect(name, conn)
+	if e != nil {
+		_ = conn.Close()
+		return nil, errors.Default.Wrap(e, fmt.Sprintf("failed to describe plugin served on %s", address))
+	}
+	return remotePlugin, nil
+}
+
+func parseRemotePlugins(value string) (map[string]string, errors.Error) {
+	addresses := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, address, ok := strings.Cut(item, "=")
+		name, address = strings.TrimSpace(name), strings.TrimSpace(address)
+		if !ok || name == "" || address == "" {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid %s entry %q, expecting name=address", REMOTE_PLUGINS_ENV, item))
+		}
+		addresses[name] = address
+	}
+	return addresses, nil
+}
@@ -0,0 +1,553 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remoteplugin implements the protocol for running plugins out of process. A remote plugin is a
+// standalone binary (or container) built with the SDK in this package, it serves the Plugin service while
+// DevLake serves the Host service which gives the plugin access to configuration, logging, progress and
+// the database. Both services are plain gRPC with JSON encoded messages, so they can be implemented in
+// any language without generated code.
+package remoteplugin
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	goerrors "errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+const (
+	PLUGIN_SERVICE = "devlake.plugin.v1.Plugin"
+	HOST_SERVICE   = "devlake.plugin.v1.Host"
+	// TOKEN_METADATA carries the token issued by the host in every call to the Host service
+	TOKEN_METADATA = "x-devlake-plugin-token"
+)
+
+// jsonCodec encodes messages with encoding/json, it is selected by the `json` content subtype
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// invoke calls a unary method of the service with the json codec
+func invoke(ctx context.Context, conn *grpc.ClientConn, service string, method string, req interface{}, res interface{}) errors.Error {
+	err := conn.Invoke(ctx, "/"+service+"/"+method, req, res, grpc.CallContentSubtype(jsonCodec{}.Name()))
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to call "+method)
+	}
+	return nil
+}
+
+// unaryMethod builds the descriptor of a unary method whose handler is `handle`
+func unaryMethod[S any, Req any, Res any](name string, handle func(srv S, ctx context.Context, req *Req) *Res) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			call := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return handle(srv.(S), ctx, req.(*Req)), nil
+			}
+			if interceptor == nil {
+				return call(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: name}
+			return interceptor(ctx, req, info, call)
+		},
+	}
+}
+
+// RemoteError carries an errors.Error over the wire
+type RemoteError struct {
+	Message    string `json:"message"`
+	HttpStatus int    `json:"httpStatus"`
+	// Uninitialized is set by plugins which haven't been initialized, e.g. after being restarted, the host
+	// initializes them again and retries the call
+	Uninitialized bool `json:"uninitialized,omitempty"`
+}
+
+func toRemoteError(err errors.Error) *RemoteError {
+	if err == nil {
+		return nil
+	}
+	return &RemoteError{
+		Message:       err.Messages().Format(),
+		HttpStatus:    err.GetType().GetHttpCode(),
+		Uninitialized: goerrors.Is(err, errUninitialized),
+	}
+}
+
+func fromRemoteError(err *RemoteError) errors.Error {
+	if err == nil {
+		return nil
+	}
+	return errors.HttpStatus(err.HttpStatus).New(err.Message)
+}
+
+// Messages of the Plugin service
+
+type SubTaskMetaDescription struct {
+	Name             string   `json:"name"`
+	Required         bool     `json:"required"`
+	EnabledByDefault bool     `json:"enabledByDefault"`
+	Description      string   `json:"description"`
+	DomainTypes      []string `json:"domainTypes"`
+}
+
+type ApiResourceDescription struct {
+	Path   string `json:"path"`
+	Method string `json:"method"`
+}
+
+type MigrationScriptDescription struct {
+	Version    uint64 `json:"version"`
+	Name       string `json:"name"`
+	Reversible bool   `json:"reversible"`
+}
+
+type PluginDescription struct {
+	Description      string                        `json:"description"`
+	RootPkgPath      string                        `json:"rootPkgPath"`
+	ApiVersion       string                        `json:"apiVersion"`
+	Dependencies     []string                      `json:"dependencies"`
+	SubTaskMetas     []*SubTaskMetaDescription     `json:"subTaskMetas"`
+	ApiResources     []*ApiResourceDescription     `json:"apiResources"`
+	MigrationScripts []*MigrationScriptDescription `json:"migrationScripts"`
+}
+
+// pluginResponse is implemented by responses of the Plugin service
+type pluginResponse interface {
+	remoteError() *RemoteError
+}
+
+type DescribeRequest struct{}
+
+type DescribeResponse struct {
+	Error       *RemoteError       `json:"error,omitempty"`
+	Description *PluginDescription `json:"description,omitempty"`
+}
+
+func (res *DescribeResponse) remoteError() *RemoteError {
+	return res.Error
+}
+
+type InitRequest struct {
+	PluginName  string `json:"pluginName"`
+	HostAddress string `json:"hostAddress"`
+	Token       string `json:"token"`
+}
+
+type PrepareTaskDataRequest struct {
+	SessionId string                 `json:"sessionId"`
+	TaskName  string                 `json:"taskName"`
+	Options   map[string]interface{} `json:"options"`
+}
+
+type PrepareTaskDataResponse struct {
+	Error  *RemoteError `json:"error,omitempty"`
+	Handle string       `json:"handle"`
+}
+
+func (res *PrepareTaskDataResponse) remoteError() *RemoteError {
+	return res.Error
+}
+
+type ExecuteSubTaskRequest struct {
+	SessionId   string `json:"sessionId"`
+	Handle      string `json:"handle"`
+	SubTaskName string `json:"subTaskName"`
+}
+
+type CloseTaskRequest struct {
+	SessionId string `json:"sessionId"`
+	Handle    string `json:"handle"`
+}
+
+type ApiRequest struct {
+	Path   string                 `json:"path"`
+	Method string                 `json:"method"`
+	Params map[string]string      `json:"params"`
+	Query  url.Values             `json:"query"`
+	Body   map[string]interface{} `json:"body"`
+	User   *common.User           `json:"user,omitempty"`
+}
+
+type ApiResponse struct {
+	Error       *RemoteError       `json:"error,omitempty"`
+	Body        json.RawMessage    `json:"body,omitempty"`
+	Status      int                `json:"status"`
+	ContentType string             `json:"contentType"`
+	File        *plugin.OutputFile `json:"file,omitempty"`
+}
+
+func (res *ApiResponse) remoteError() *RemoteError {
+	return res.Error
+}
+
+type MigrateRequest struct {
+	SessionId string `json:"sessionId"`
+	Version   uint64 `json:"version"`
+	Down      bool   `json:"down"`
+}
+
+// ErrorResponse is the response of methods returning nothing but an error
+type ErrorResponse struct {
+	Error *RemoteError `json:"error,omitempty"`
+}
+
+func (res *ErrorResponse) remoteError() *RemoteError {
+	return res.Error
+}
+
+// Messages of the Host service
+
+type ConfigRequest struct {
+	Name string `json:"name"`
+}
+
+type ConfigResponse struct {
+	Value string `json:"value"`
+}
+
+type LogRequest struct {
+	SessionId string `json:"sessionId"`
+	Level     uint32 `json:"level"`
+	Message   string `json:"message"`
+}
+
+const (
+	PROGRESS_SET = "set"
+	PROGRESS_INC = "inc"
+)
+
+type ProgressRequest struct {
+	SessionId string `json:"sessionId"`
+	// Task is true if the progress is reported through TaskContext() of a subtask
+	Task    bool   `json:"task"`
+	Type    string `json:"type"`
+	Current int    `json:"current"`
+	Total   int    `json:"total"`
+}
+
+const (
+	DAL_EXEC          = "exec"
+	DAL_QUERY         = "query"
+	DAL_FETCH         = "fetch"
+	DAL_CLOSE_CURSOR  = "closeCursor"
+	DAL_COUNT         = "count"
+	DAL_INSERT        = "insert"
+	DAL_INSERT_IGNORE = "insertIgnore"
+	DAL_UPSERT        = "upsert"
+	DAL_UPDATE        = "update"
+	DAL_UPDATE_SET    = "updateSet"
+	DAL_DELETE        = "delete"
+	DAL_DIALECT       = "dialect"
+	DAL_AUTO_MIGRATE  = "autoMigrate"
+	DAL_ALL_TABLES    = "allTables"
+	DAL_DROP_TABLES   = "dropTables"
+	DAL_ADD_COLUMN    = "addColumn"
+	DAL_DROP_COLUMNS  = "dropColumns"
+	DAL_RENAME_TABLE  = "renameTable"
+	DAL_RENAME_COLUMN = "renameColumn"
+	DAL_DROP_INDEXES  = "dropIndexes"
+)
+
+// WireClause is the serializable form of dal.Clause
+type WireClause struct {
+	Type   string          `json:"type"`
+	Expr   string          `json:"expr,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Value  interface{}     `json:"value,omitempty"`
+}
+
+// WireSet is the serializable form of dal.DalSet, the value is either a parameter or an expression
+type WireSet struct {
+	Column string          `json:"column"`
+	Expr   string          `json:"expr,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// WireField describes a column for AutoMigrate, the kind is one of the basic gorm data types, DataType is
+// set only if the field has a custom one, e.g. `json`
+type WireField struct {
+	Column   string `json:"column"`
+	Kind     string `json:"kind"`
+	DataType string `json:"dataType,omitempty"`
+	Tag      string `json:"tag"`
+}
+
+type DalRequest struct {
+	SessionId   string          `json:"sessionId"`
+	Op          string          `json:"op"`
+	Table       string          `json:"table,omitempty"`
+	Query       string          `json:"query,omitempty"`
+	Params      json.RawMessage `json:"params,omitempty"`
+	Clauses     []*WireClause   `json:"clauses,omitempty"`
+	Columns     []string        `json:"columns,omitempty"`
+	Rows        json.RawMessage `json:"rows,omitempty"`
+	PrimaryKeys []string        `json:"primaryKeys,omitempty"`
+	Sets        []*WireSet      `json:"sets,omitempty"`
+	Fields      []*WireField    `json:"fields,omitempty"`
+	Args        []string        `json:"args,omitempty"`
+}
+
+type DalResponse struct {
+	Error   *RemoteError    `json:"error,omitempty"`
+	Columns []string        `json:"columns,omitempty"`
+	Rows    json.RawMessage `json:"rows,omitempty"`
+	Count   int64           `json:"count,omitempty"`
+	Strings []string        `json:"strings,omitempty"`
+}
+
+// values which can't be represented by json natively are wrapped in a single key object
+
+const (
+	wireTime   = "$time"
+	wireBytes  = "$bytes"
+	wireTable  = "$table"
+	wireColumn = "$column"
+)
+
+func encodeValue(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case time.Time:
+		return map[string]interface{}{wireTime: vv.Format(time.RFC3339Nano)}
+	case *time.Time:
+		if vv == nil {
+			return nil
+		}
+		return encodeValue(*vv)
+	case []byte:
+		return map[string]interface{}{wireBytes: base64.StdEncoding.EncodeToString(vv)}
+	case dal.ClauseTable:
+		return map[string]interface{}{wireTable: vv}
+	case dal.ClauseColumn:
+		return map[string]interface{}{wireColumn: vv}
+	case []interface{}:
+		values := make([]interface{}, len(vv))
+		for i, item := range vv {
+			values[i] = encodeValue(item)
+		}
+		return values
+	}
+	return v
+}
+
+func decodeValue(v interface{}) (interface{}, errors.Error) {
+	switch vv := v.(type) {
+	case json.Number:
+		if !strings.ContainsAny(vv.String(), ".eE") {
+			if i, err := vv.Int64(); err == nil {
+				return i, nil
+			}
+			if u, err := strconv.ParseUint(vv.String(), 10, 64); err == nil {
+				return u, nil
+			}
+		}
+		f, err := vv.Float64()
+		return f, errors.Convert(err)
+	case []interface{}:
+		values := make([]interface{}, len(vv))
+		for i, item := range vv {
+			value, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	case map[string]interface{}:
+		if len(vv) != 1 {
+			return vv, nil
+		}
+		if s, ok := vv[wireTime].(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			return t, errors.Convert(err)
+		}
+		if s, ok := vv[wireBytes].(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return b, errors.Convert(err)
+		}
+		if m, ok := vv[wireTable].(map[string]interface{}); ok {
+			table := dal.ClauseTable{}
+			return table, errors.Convert(remarshal(m, &table))
+		}
+		if m, ok := vv[wireColumn].(map[string]interface{}); ok {
+			column := dal.ClauseColumn{}
+			return column, errors.Convert(remarshal(m, &column))
+		}
+	}
+	return v, nil
+}
+
+func remarshal(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, to)
+}
+
+func encodeValues(values []interface{}) (json.RawMessage, errors.Error) {
+	data, err := json.Marshal(encodeValue(values))
+	return data, errors.Convert(err)
+}
+
+func decodeValues(data json.RawMessage) ([]interface{}, errors.Error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var values []interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, errors.Convert(err)
+	}
+	decoded, err := decodeValue(values)
+	if err != nil {
+		return nil, err
+	}
+	return decoded.([]interface{}), nil
+}
+
+func encodeRows(rows [][]interface{}) (json.RawMessage, errors.Error) {
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = row
+	}
+	return encodeValues(values)
+}
+
+func decodeRows(data json.RawMessage) ([][]interface{}, errors.Error) {
+	values, err := decodeValues(data)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([][]interface{}, len(values))
+	for i, value := range values {
+		row, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Default.New("malformed rows")
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func encodeClauses(clauses []dal.Clause) ([]*WireClause, errors.Error) {
+	wireClauses := make([]*WireClause, 0, len(clauses))
+	for _, c := range clauses {
+		wireClause := &WireClause{Type: c.Type}
+		var params []interface{}
+		switch d := c.Data.(type) {
+		case dal.DalClause:
+			wireClause.Expr = d.Expr
+			params = d.Params
+		case string:
+			wireClause.Expr = d
+		case int:
+			wireClause.Value = d
+		case []bool:
+			wireClause.Value = d
+		case dal.ClauseTable:
+			wireClause.Value = encodeValue(d)
+		case dal.Tabler:
+			wireClause.Expr = d.TableName()
+		default:
+			return nil, errors.Default.New("unsupported clause " + c.Type)
+		}
+		if params != nil {
+			data, err := encodeValues(params)
+			if err != nil {
+				return nil, err
+			}
+			wireClause.Params = data
+		}
+		wireClauses = append(wireClauses, wireClause)
+	}
+	return wireClauses, nil
+}
+
+func decodeClauses(wireClauses []*WireClause) ([]dal.Clause, errors.Error) {
+	clauses := make([]dal.Clause, 0, len(wireClauses))
+	for _, wc := range wireClauses {
+		params, err := decodeValues(wc.Params)
+		if err != nil {
+			return nil, err
+		}
+		var data interface{}
+		switch wc.Type {
+		case dal.JoinClause, dal.WhereClause, dal.SelectClause, dal.HavingClause:
+			data = dal.DalClause{Expr: wc.Expr, Params: params}
+		case dal.OrderbyClause, dal.GroupbyClause:
+			data = wc.Expr
+		case dal.LimitClause, dal.OffsetClause:
+			n, ok := wc.Value.(float64)
+			if !ok {
+				return nil, errors.BadInput.New("invalid " + wc.Type)
+			}
+			data = int(n)
+		case dal.LockClause:
+			var lock []bool
+			if e := remarshal(wc.Value, &lock); e != nil || len(lock) != 2 {
+				return nil, errors.BadInput.New("invalid " + wc.Type)
+			}
+			data = lock
+		case dal.FromClause:
+			if wc.Value != nil {
+				table, err := decodeValue(wc.Value)
+				if err != nil {
+					return nil, err
+				}
+				data = table
+			} else if params != nil {
+				data = dal.DalClause{Expr: wc.Expr, Params: params}
+			} else {
+				data = wc.Expr
+			}
+		default:
+			return nil, errors.BadInput.New("unsupported clause " + wc.Type)
+		}
+		clauses = append(clauses, dal.Clause{Type: wc.Type, Data: data})
+	}
+	return clauses, nil
+}
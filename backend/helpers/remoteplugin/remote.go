@@ -0,0 +1,314 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"google.golang.org/grpc"
+)
+
+// DESCRIBE_TIMEOUT limits calls made to remote plugins while loading them
+const DESCRIBE_TIMEOUT = 30 * time.Second
+
+var _ interface {
+	plugin.PluginMeta
+	plugin.PluginInit
+	plugin.CloseablePluginTask
+	plugin.PluginApi
+	plugin.PluginMigration
+	plugin.PluginCompatibility
+} = (*RemotePlugin)(nil)
+
+// RemotePlugin is the proxy of a plugin running out of process, it is registered to the framework like any
+// other plugin and forwards everything to the remote plugin
+type RemotePlugin struct {
+	name        string
+	conn        *grpc.ClientConn
+	host        *Host
+	description *PluginDescription
+}
+
+// RemoteTaskData is the task data of remote plugins, the actual data stays in the plugin process
+type RemoteTaskData struct {
+	Handle string
+}
+
+// Connect describes the plugin served on the connection, the returned RemotePlugin is ready to be initialized
+func (h *Host) Connect(name string, conn *grpc.ClientConn) (*RemotePlugin, errors.Error) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), DESCRIBE_TIMEOUT)
+	defer cancel()
+	res := &DescribeResponse{}
+	err := invoke(withSecret(ctx, h.secret), conn, PLUGIN_SERVICE, "Describe", &DescribeRequest{}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err = fromRemoteError(res.Error); err != nil {
+		return nil, err
+	}
+	if res.Description == nil {
+		return nil, errors.Default.New(fmt.Sprintf("plugin %s returned no description", name))
+	}
+	if res.Description.RootPkgPath == "" {
+		// an empty path would match every package while looking up plugins by their models
+		res.Description.RootPkgPath = "remoteplugin/" + name
+	}
+	return &RemotePlugin{name: name, conn: conn, host: h, description: res.Description}, nil
+}
+
+// call calls the method of the plugin, the plugin is initialized again and the call is retried if it has lost
+// its initialization, e.g. by being restarted
+func (p *RemotePlugin) call(ctx gocontext.Context, method string, req interface{}, res pluginResponse) errors.Error {
+	err := invoke(withSecret(ctx, p.host.secret), p.conn, PLUGIN_SERVICE, method, req, res)
+	if err != nil || res.remoteError() == nil || !res.remoteError().Uninitialized || method == "Init" {
+		return err
+	}
+	p.host.basicRes.GetLogger().Warn(nil, "remote plugin %s is not initialized, initializing it again", p.name)
+	err = p.Init(p.host.basicRes)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to initialize remote plugin %s again", p.name))
+	}
+	// fields missing from the new response would be kept otherwise
+	reflect.ValueOf(res).Elem().Set(reflect.Zero(reflect.TypeOf(res).Elem()))
+	return invoke(withSecret(ctx, p.host.secret), p.conn, PLUGIN_SERVICE, method, req, res)
+}
+
+// Description returns the description of the remote plugin
+func (p *RemotePlugin) Description() string {
+	return p.description.Description
+}
+
+// RootPkgPath returns the package path of models of the remote plugin
+func (p *RemotePlugin) RootPkgPath() string {
+	return p.description.RootPkgPath
+}
+
+// RequiredApiVersion returns the plugin api version the remote plugin was built against
+func (p *RemotePlugin) RequiredApiVersion() string {
+	return p.description.ApiVersion
+}
+
+// Dependencies returns plugins the remote plugin depends on
+func (p *RemotePlugin) Dependencies() []string {
+	return p.description.Dependencies
+}
+
+// Init issues a token for the remote plugin and tells it where to find the Host service
+func (p *RemotePlugin) Init(basicRes context.BasicRes) errors.Error {
+	token, err := p.host.issueToken(p.name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), DESCRIBE_TIMEOUT)
+	defer cancel()
+	res := &ErrorResponse{}
+	err = p.call(ctx, "Init", &InitRequest{PluginName: p.name, HostAddress: p.host.address, Token: token}, res)
+	if err != nil {
+		return err
+	}
+	return fromRemoteError(res.Error)
+}
+
+// SubTaskMetas returns subtasks of the remote plugin, which are executed remotely
+func (p *RemotePlugin) SubTaskMetas() []plugin.SubTaskMeta {
+	metas := make([]plugin.SubTaskMeta, len(p.description.SubTaskMetas))
+	for i, meta := range p.description.SubTaskMetas {
+		metas[i] = plugin.SubTaskMeta{
+			Name:             meta.Name,
+			EntryPoint:       p.subTaskEntryPoint(meta.Name),
+			Required:         meta.Required,
+			EnabledByDefault: meta.EnabledByDefault,
+			Description:      meta.Description,
+			DomainTypes:      meta.DomainTypes,
+		}
+	}
+	return metas
+}
+
+func (p *RemotePlugin) subTaskEntryPoint(subtaskName string) plugin.SubTaskEntryPoint {
+	return func(taskCtx plugin.SubTaskContext) errors.Error {
+		data, ok := taskCtx.GetData().(*RemoteTaskData)
+		if !ok {
+			return errors.Default.New("task data of remote plugin is missing")
+		}
+		sessionId, closeSession, err := p.host.openSession(p.name, taskCtx, taskCtx)
+		if err != nil {
+			return err
+		}
+		defer closeSession()
+		res := &ErrorResponse{}
+		err = p.call(taskCtx.GetContext(), "ExecuteSubTask", &ExecuteSubTaskRequest{
+			SessionId:   sessionId,
+			Handle:      data.Handle,
+			SubTaskName: subtaskName,
+		}, res)
+		if err != nil {
+			return err
+		}
+		return fromRemoteError(res.Error)
+	}
+}
+
+// PrepareTaskData prepares the task data in the plugin process and returns the handle of it
+func (p *RemotePlugin) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]interface{}) (interface{}, errors.Error) {
+	sessionId, closeSession, err := p.host.openSession(p.name, taskCtx, taskCtx)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSession()
+	res := &PrepareTaskDataResponse{}
+	err = p.call(taskCtx.GetContext(), "PrepareTaskData", &PrepareTaskDataRequest{
+		SessionId: sessionId,
+		TaskName:  taskCtx.GetName(),
+		Options:   options,
+	}, res)
+	if err != nil {
+		return nil, err
+	}
+	if err = fromRemoteError(res.Error); err != nil {
+		return nil, err
+	}
+	return &RemoteTaskData{Handle: res.Handle}, nil
+}
+
+// Close releases the task data in the plugin process
+func (p *RemotePlugin) Close(taskCtx plugin.TaskContext) errors.Error {
+	data, ok := taskCtx.GetData().(*RemoteTaskData)
+	if !ok {
+		return nil
+	}
+	sessionId, closeSession, err := p.host.openSession(p.name, taskCtx, taskCtx)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	res := &ErrorResponse{}
+	err = p.call(taskCtx.GetContext(), "CloseTask", &CloseTaskRequest{SessionId: sessionId, Handle: data.Handle}, res)
+	if err != nil {
+		return err
+	}
+	return fromRemoteError(res.Error)
+}
+
+// ApiResources returns the apis of the remote plugin, requests are forwarded to the plugin as json
+func (p *RemotePlugin) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
+	resources := make(map[string]map[string]plugin.ApiResourceHandler)
+	for _, resource := range p.description.ApiResources {
+		if resources[resource.Path] == nil {
+			resources[resource.Path] = make(map[string]plugin.ApiResourceHandler)
+		}
+		resources[resource.Path][resource.Method] = p.apiHandler(resource.Path, resource.Method)
+	}
+	return resources
+}
+
+func (p *RemotePlugin) apiHandler(path string, method string) plugin.ApiResourceHandler {
+	return func(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+		if input.Request != nil {
+			return nil, errors.BadInput.New("multipart requests are not supported by remote plugins")
+		}
+		res := &ApiResponse{}
+		err := p.call(gocontext.Background(), "CallApi", &ApiRequest{
+			Path:   path,
+			Method: method,
+			Params: input.Params,
+			Query:  input.Query,
+			Body:   input.Body,
+			User:   input.User,
+		}, res)
+		if err != nil {
+			return nil, err
+		}
+		if err = fromRemoteError(res.Error); err != nil {
+			return nil, err
+		}
+		output := &plugin.ApiResourceOutput{Status: res.Status, ContentType: res.ContentType, File: res.File}
+		if len(res.Body) > 0 {
+			output.Body = res.Body
+		}
+		if output.Status == 0 {
+			output.Status = http.StatusOK
+		}
+		return output, nil
+	}
+}
+
+// MigrationScripts returns migration scripts of the remote plugin, which are executed remotely
+func (p *RemotePlugin) MigrationScripts() []plugin.MigrationScript {
+	scripts := make([]plugin.MigrationScript, len(p.description.MigrationScripts))
+	for i, script := range p.description.MigrationScripts {
+		remoteScript := &remoteMigrationScript{plugin: p, description: script}
+		if script.Reversible {
+			scripts[i] = &remoteReversibleMigrationScript{remoteScript}
+		} else {
+			scripts[i] = remoteScript
+		}
+	}
+	return scripts
+}
+
+type remoteMigrationScript struct {
+	plugin      *RemotePlugin
+	description *MigrationScriptDescription
+}
+
+func (script *remoteMigrationScript) Up(basicRes context.BasicRes) errors.Error {
+	return script.migrate(basicRes, false)
+}
+
+func (script *remoteMigrationScript) Version() uint64 {
+	return script.description.Version
+}
+
+func (script *remoteMigrationScript) Name() string {
+	return script.description.Name
+}
+
+func (script *remoteMigrationScript) migrate(basicRes context.BasicRes, down bool) errors.Error {
+	sessionId, closeSession, err := script.plugin.host.openSession(script.plugin.name, basicRes, nil)
+	if err != nil {
+		return err
+	}
+	defer closeSession()
+	res := &ErrorResponse{}
+	err = script.plugin.call(gocontext.Background(), "Migrate", &MigrateRequest{
+		SessionId: sessionId,
+		Version:   script.description.Version,
+		Down:      down,
+	}, res)
+	if err != nil {
+		return err
+	}
+	return fromRemoteError(res.Error)
+}
+
+type remoteReversibleMigrationScript struct {
+	*remoteMigrationScript
+}
+
+func (script *remoteReversibleMigrationScript) Down(basicRes context.BasicRes) errors.Error {
+	return script.migrate(basicRes, true)
+}
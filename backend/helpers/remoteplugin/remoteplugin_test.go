@@ -0,0 +1,435 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type remoteTestRecord struct {
+	Id        string `gorm:"primaryKey;type:varchar(100)"`
+	Name      string `gorm:"type:varchar(255)"`
+	Secret    string `gorm:"serializer:encdec"`
+	Count     int
+	Data      []byte
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (remoteTestRecord) TableName() string {
+	return "_tool_remote_test_records"
+}
+
+type remoteTestMigration struct{}
+
+func (remoteTestMigration) Up(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&remoteTestRecord{})
+}
+
+func (remoteTestMigration) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropTables(&remoteTestRecord{})
+}
+
+func (remoteTestMigration) Version() uint64 {
+	return 20230112000001
+}
+
+func (remoteTestMigration) Name() string {
+	return "add remote test records"
+}
+
+type remoteTestPlugin struct {
+	basicRes context.BasicRes
+	closed   bool
+}
+
+func (p *remoteTestPlugin) Description() string {
+	return "remote test plugin"
+}
+
+func (p *remoteTestPlugin) RootPkgPath() string {
+	return "github.com/apache/incubator-devlake/helpers/remoteplugin"
+}
+
+func (p *remoteTestPlugin) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p *remoteTestPlugin) Dependencies() []string {
+	return nil
+}
+
+func (p *remoteTestPlugin) Init(basicRes context.BasicRes) errors.Error {
+	p.basicRes = basicRes
+	return nil
+}
+
+func (p *remoteTestPlugin) SubTaskMetas() []plugin.SubTaskMeta {
+	return []plugin.SubTaskMeta{
+		{Name: "collectRecords", EntryPoint: collectRemoteTestRecords, EnabledByDefault: true, Description: "collect records"},
+	}
+}
+
+func (p *remoteTestPlugin) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]interface{}) (interface{}, errors.Error) {
+	taskCtx.SetProgress(0, 1)
+	return options["prefix"], nil
+}
+
+func (p *remoteTestPlugin) Close(_ plugin.TaskContext) errors.Error {
+	p.closed = true
+	return nil
+}
+
+func (p *remoteTestPlugin) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
+	return map[string]map[string]plugin.ApiResourceHandler{
+		"records/:id": {
+			"GET": func(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+				record := &remoteTestRecord{}
+				err := p.basicRes.GetDal().First(record, dal.Where("id = ?", input.Params["id"]))
+				if err != nil {
+					return nil, err
+				}
+				return &plugin.ApiResourceOutput{Body: record}, nil
+			},
+		},
+	}
+}
+
+func (p *remoteTestPlugin) MigrationScripts() []plugin.MigrationScript {
+	return []plugin.MigrationScript{remoteTestMigration{}}
+}
+
+func collectRemoteTestRecords(taskCtx plugin.SubTaskContext) errors.Error {
+	prefix := taskCtx.GetData().(string)
+	records := make([]*remoteTestRecord, 3)
+	for i := range records {
+		records[i] = &remoteTestRecord{Id: prefix + string(rune('1'+i)), Name: "record", Secret: "s3cr3t", Count: i, Data: []byte{byte(i)}}
+	}
+	taskCtx.SetProgress(0, len(records))
+	err := taskCtx.GetDal().CreateOrUpdate(records)
+	if err != nil {
+		return err
+	}
+	taskCtx.IncProgress(len(records))
+	taskCtx.TaskContext().IncProgress(1)
+	taskCtx.GetLogger().Info("collected %d records", len(records))
+	return nil
+}
+
+func TestRemotePlugin(t *testing.T) {
+	t.Setenv("ENCODE_KEY", "remote-plugin-test-key")
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	cfg.Set("DB_URL_FOR_PLUGINS", "visible")
+	cfg.Set(REMOTE_PLUGIN_CONFIG_NAMES_ENV, "DB_URL_FOR_*")
+	cfg.Set(REMOTE_PLUGIN_SECRET_ENV, "plugin-secret")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		return
+	}
+	basicRes := contextimpl.NewDefaultBasicRes(cfg, logruslog.Global, dalgorm.NewDalgorm(db))
+
+	// the host and the plugin talk to each other in memory
+	hostListener := bufconn.Listen(1024 * 1024)
+	host := NewHost(basicRes, "host")
+	hostServer := grpc.NewServer(host.ServerOptions()...)
+	host.Register(hostServer)
+	go func() { _ = hostServer.Serve(hostListener) }()
+	defer hostServer.Stop()
+	pluginListener := bufconn.Listen(1024 * 1024)
+	impl := &remoteTestPlugin{}
+	pluginServer := NewPluginServer(impl, "plugin-secret", grpc.WithContextDialer(func(gocontext.Context, string) (net.Conn, error) {
+		return hostListener.Dial()
+	}))
+	go func() { _ = pluginServer.Serve(pluginListener) }()
+	conn, e := grpc.Dial("plugin",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(gocontext.Context, string) (net.Conn, error) {
+			return pluginListener.Dial()
+		}),
+	)
+	if !assert.Nil(t, e) {
+		return
+	}
+	defer conn.Close()
+
+	// calls without the secret are rejected
+	_, err = NewHost(contextimpl.NewDefaultBasicRes(viper.New(), logruslog.Global, nil), "host").Connect("remote_test", conn)
+	assert.NotNil(t, err)
+
+	// describe and initialize
+	remotePlugin, err := host.Connect("remote_test", conn)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "remote test plugin", remotePlugin.Description())
+	assert.Equal(t, plugin.ApiVersion, remotePlugin.RequiredApiVersion())
+	assert.Nil(t, remotePlugin.Init(basicRes))
+	assert.NotNil(t, impl.basicRes)
+	assert.Equal(t, "visible", impl.basicRes.GetConfig("DB_URL_FOR_PLUGINS"))
+	assert.Equal(t, "", impl.basicRes.GetConfig("DB_URL"))
+	assert.Equal(t, "sqlite", impl.basicRes.GetDal().Dialect())
+
+	// migrations
+	scripts := remotePlugin.MigrationScripts()
+	if !assert.Len(t, scripts, 1) {
+		return
+	}
+	assert.Equal(t, uint64(20230112000001), scripts[0].Version())
+	assert.Implements(t, (*plugin.MigrationScriptDown)(nil), scripts[0])
+	if !assert.Nil(t, scripts[0].Up(basicRes)) {
+		return
+	}
+	tables, err := basicRes.GetDal().AllTables()
+	assert.Nil(t, err)
+	assert.Contains(t, tables, "_tool_remote_test_records")
+
+	// tasks
+	progress := make(chan plugin.RunningProgress, 100)
+	taskCtx := contextimpl.NewDefaultTaskContext(gocontext.Background(), basicRes, "remote_test", map[string]bool{"collectRecords": true}, progress)
+	data, err := remotePlugin.PrepareTaskData(taskCtx, map[string]interface{}{"prefix": "r"})
+	if !assert.Nil(t, err) {
+		return
+	}
+	taskCtx.SetData(data)
+	subtaskCtx, err := taskCtx.SubTaskContext("collectRecords")
+	assert.Nil(t, err)
+	metas := remotePlugin.SubTaskMetas()
+	if !assert.Len(t, metas, 1) {
+		return
+	}
+	assert.Nil(t, metas[0].EntryPoint(subtaskCtx))
+	assert.Nil(t, remotePlugin.Close(taskCtx))
+	assert.True(t, impl.closed)
+	progressTypes := make(map[plugin.ProgressType]bool)
+	for len(progress) > 0 {
+		progressTypes[(<-progress).Type] = true
+	}
+	assert.True(t, progressTypes[plugin.TaskSetProgress])
+	assert.True(t, progressTypes[plugin.TaskIncProgress])
+	assert.True(t, progressTypes[plugin.SubTaskSetProgress])
+
+	// the secret is encrypted by the plugin
+	var secret string
+	assert.Nil(t, basicRes.GetDal().Pluck("secret", &secret, dal.From("_tool_remote_test_records"), dal.Where("id = ?", "r1")))
+	var secrets []string
+	assert.Nil(t, basicRes.GetDal().Pluck("secret", &secrets, dal.From("_tool_remote_test_records")))
+	if assert.Len(t, secrets, 3) {
+		assert.NotEqual(t, "s3cr3t", secrets[0])
+	}
+
+	// apis
+	resources := remotePlugin.ApiResources()
+	output, err := resources["records/:id"]["GET"](&plugin.ApiResourceInput{Params: map[string]string{"id": "r2"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, output.Status)
+		assert.Contains(t, string(output.Body.(json.RawMessage)), `"Secret":"s3cr3t"`)
+	}
+	_, err = resources["records/:id"]["GET"](&plugin.ApiResourceInput{Params: map[string]string{"id": "missing"}})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusNotFound, err.GetType().GetHttpCode())
+	}
+
+	// the plugin is initialized again after losing its initialization, e.g. by being restarted
+	pluginServer.lock.Lock()
+	pluginServer.host = nil
+	pluginServer.lock.Unlock()
+	_, err = remotePlugin.PrepareTaskData(taskCtx, map[string]interface{}{"prefix": "r"})
+	assert.Nil(t, err)
+
+	// dal operations of the sdk
+	remoteDb := impl.basicRes.GetDal()
+	var records []*remoteTestRecord
+	assert.Nil(t, remoteDb.All(&records, dal.Orderby("id")))
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "r1", records[0].Id)
+		assert.Equal(t, "s3cr3t", records[0].Secret)
+		assert.Equal(t, 2, records[2].Count)
+		assert.Equal(t, []byte{2}, records[2].Data)
+		assert.False(t, records[0].CreatedAt.IsZero())
+	}
+	count, err := remoteDb.Count(dal.From(&remoteTestRecord{}), dal.Where("count > ?", 0))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Nil(t, remoteDb.UpdateColumn(&remoteTestRecord{}, "count", dal.Expr("count + ?", 10), dal.Where("id = ?", "r1")))
+	record := &remoteTestRecord{Id: "r3"}
+	assert.Nil(t, remoteDb.UpdateColumn(record, "name", "renamed"))
+	assert.Nil(t, remoteDb.First(record, dal.Where("id = ?", "r3")))
+	assert.Equal(t, "renamed", record.Name)
+	var ids []string
+	assert.Nil(t, remoteDb.Pluck("id", &ids, dal.From(&remoteTestRecord{}), dal.Where("count >= ?", 10)))
+	assert.Equal(t, []string{"r1"}, ids)
+	err = remoteDb.Create(&remoteTestRecord{Id: "r1"})
+	assert.True(t, remoteDb.IsDuplicationError(err))
+	assert.Nil(t, remoteDb.CreateIfNotExist(&remoteTestRecord{Id: "r1", Name: "ignored"}))
+	assert.Nil(t, remoteDb.Delete(&remoteTestRecord{Id: "r2"}))
+	assert.Nil(t, remoteDb.Delete(&remoteTestRecord{}, dal.Where("id = ?", "r3")))
+	err = remoteDb.First(&remoteTestRecord{}, dal.Where("id = ?", "r2"))
+	assert.True(t, remoteDb.IsErrorNotFound(err))
+	assert.NotNil(t, remoteDb.Delete(&remoteTestRecord{}, dal.From(&remoteTestRecord{}), dal.Orderby("id")))
+
+	// rows are read in batches
+	many := make([]*remoteTestRecord, CURSOR_BATCH_SIZE+10)
+	for i := range many {
+		many[i] = &remoteTestRecord{Id: "m" + time.Duration(i).String()}
+	}
+	assert.Nil(t, remoteDb.CreateOrUpdate(many))
+	cursor, err := remoteDb.Cursor(dal.From(&remoteTestRecord{}), dal.Where("id LIKE ?", "m%"))
+	if assert.Nil(t, err) {
+		n := 0
+		for cursor.Next() {
+			r := &remoteTestRecord{}
+			assert.Nil(t, remoteDb.Fetch(cursor, r))
+			n++
+		}
+		assert.Nil(t, cursor.Close())
+		assert.Equal(t, len(many), n)
+	}
+
+	// rollback
+	assert.Nil(t, scripts[0].(plugin.MigrationScriptDown).Down(basicRes))
+	tables, err = basicRes.GetDal().AllTables()
+	assert.Nil(t, err)
+	assert.NotContains(t, tables, "_tool_remote_test_records")
+}
+
+// writeTestCertificate writes a self-signed certificate of the host name, it is its own CA
+func writeTestCertificate(t *testing.T, hostName string) (certFile string, keyFile string) {
+	key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.Nil(t, e) {
+		t.FailNow()
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: hostName},
+		DNSNames:              []string{hostName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, e := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if !assert.Nil(t, e) {
+		t.FailNow()
+	}
+	keyDer, e := x509.MarshalECPrivateKey(key)
+	if !assert.Nil(t, e) {
+		t.FailNow()
+	}
+	certFile, keyFile = filepath.Join(t.TempDir(), "cert.pem"), filepath.Join(t.TempDir(), "key.pem")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+func TestRemotePluginTls(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set(REMOTE_PLUGIN_SECRET_ENV, "plugin-secret")
+	host := NewHost(contextimpl.NewDefaultBasicRes(cfg, logruslog.Global, nil), "host")
+	certFile, keyFile := writeTestCertificate(t, "plugin")
+	_, err := serverCredentials(certFile, "")
+	assert.NotNil(t, err)
+	serverCreds, err := serverCredentials(certFile, keyFile)
+	if !assert.Nil(t, err) {
+		return
+	}
+	pluginListener := bufconn.Listen(1024 * 1024)
+	go func() { _ = NewPluginServer(&remoteTestPlugin{}, "plugin-secret").Serve(pluginListener, serverCreds) }()
+	dial := func(creds grpc.DialOption) *grpc.ClientConn {
+		conn, e := grpc.Dial("plugin", creds, grpc.WithContextDialer(func(gocontext.Context, string) (net.Conn, error) {
+			return pluginListener.Dial()
+		}))
+		assert.Nil(t, e)
+		return conn
+	}
+
+	dialCreds, err := clientCredentials(certFile)
+	if !assert.Nil(t, err) {
+		return
+	}
+	conn := dial(dialCreds)
+	defer conn.Close()
+	remotePlugin, err := host.Connect("remote_test", conn)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "remote test plugin", remotePlugin.Description())
+	}
+	// plaintext is refused by the plugin serving tls
+	plaintextCreds, err := clientCredentials("")
+	assert.Nil(t, err)
+	plaintextConn := dial(plaintextCreds)
+	defer plaintextConn.Close()
+	_, err = host.Connect("remote_test", plaintextConn)
+	assert.NotNil(t, err)
+}
+
+func TestConfigNameMatcher(t *testing.T) {
+	allowed := configNameMatcher(" JENKINSX_*, PROXY ,")
+	assert.True(t, allowed("JENKINSX_TOKEN"))
+	assert.True(t, allowed("PROXY"))
+	assert.False(t, allowed("DB_URL"))
+	assert.False(t, configNameMatcher("")("DB_URL"))
+}
+
+func TestParseRemotePlugins(t *testing.T) {
+	addresses, err := parseRemotePlugins(" foo=localhost:9000, bar = bar-plugin:9001 ,")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"foo": "localhost:9000", "bar": "bar-plugin:9001"}, addresses)
+	_, err = parseRemotePlugins("foo")
+	assert.NotNil(t, err)
+}
+
+func TestWireValues(t *testing.T) {
+	now := time.Now().UTC()
+	data, err := encodeValues([]interface{}{int64(1), 1.5, "a", nil, true, now, []byte("b"), dal.ClauseColumn{Name: "c"}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	values, err := decodeValues(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(1), 1.5, "a", nil, true, now, []byte("b"), dal.ClauseColumn{Name: "c"}}, values)
+}
@@ -0,0 +1,397 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/gorm/schema"
+)
+
+type pluginServer interface {
+	describe(ctx gocontext.Context, req *DescribeRequest) *DescribeResponse
+	init(ctx gocontext.Context, req *InitRequest) *ErrorResponse
+	prepareTaskData(ctx gocontext.Context, req *PrepareTaskDataRequest) *PrepareTaskDataResponse
+	executeSubTask(ctx gocontext.Context, req *ExecuteSubTaskRequest) *ErrorResponse
+	closeTask(ctx gocontext.Context, req *CloseTaskRequest) *ErrorResponse
+	callApi(ctx gocontext.Context, req *ApiRequest) *ApiResponse
+	migrate(ctx gocontext.Context, req *MigrateRequest) *ErrorResponse
+}
+
+var pluginServiceDesc = grpc.ServiceDesc{
+	ServiceName: PLUGIN_SERVICE,
+	HandlerType: (*pluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryMethod("Describe", pluginServer.describe),
+		unaryMethod("Init", pluginServer.init),
+		unaryMethod("PrepareTaskData", pluginServer.prepareTaskData),
+		unaryMethod("ExecuteSubTask", pluginServer.executeSubTask),
+		unaryMethod("CloseTask", pluginServer.closeTask),
+		unaryMethod("CallApi", pluginServer.callApi),
+		unaryMethod("Migrate", pluginServer.migrate),
+	},
+}
+
+// PluginServer serves a plugin out of process. The plugin is implemented exactly like an in-process one,
+// i.e. plugin.PluginMeta along with any of PluginInit, PluginTask, CloseablePluginTask, PluginApi,
+// PluginMigration and PluginCompatibility, while resources handed to it are backed by the Host service:
+//   - the Dal doesn't support GetColumns, auto increment primary keys are not filled back after creation,
+//     and transactions are not atomic: statements are applied right away and Rollback fails
+//   - encrypted fields are handled in the plugin process, it needs the same ENCODE_KEY as DevLake to read them
+//   - api handlers receive no http.Request, so multipart requests are not supported
+type PluginServer struct {
+	impl plugin.PluginMeta
+	// secret is shared with DevLake, calls without it are rejected
+	secret      string
+	dialOptions []grpc.DialOption
+	host        *hostClient
+	// handle => task data
+	tasks map[string]interface{}
+	lock  sync.Mutex
+}
+
+// NewPluginServer creates a PluginServer for the plugin, secret is REMOTE_PLUGIN_SECRET of DevLake and
+// dialOptions are used to connect to the Host service
+func NewPluginServer(impl plugin.PluginMeta, secret string, dialOptions ...grpc.DialOption) *PluginServer {
+	if _, ok := schema.GetSerializer("encdec"); !ok {
+		schema.RegisterSerializer("encdec", &remoteEncDecSerializer{})
+	}
+	return &PluginServer{
+		impl:        impl,
+		secret:      secret,
+		dialOptions: dialOptions,
+		tasks:       make(map[string]interface{}),
+	}
+}
+
+// Register registers the Plugin service to the grpc server
+func (s *PluginServer) Register(server *grpc.Server) {
+	server.RegisterService(&pluginServiceDesc, s)
+}
+
+// ServerOptions returns options required by the grpc server of the Plugin service
+func (s *PluginServer) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(checkSecret(s.secret)),
+		grpc.MaxRecvMsgSize(MAX_MESSAGE_SIZE),
+		grpc.MaxSendMsgSize(MAX_MESSAGE_SIZE),
+	}
+}
+
+// Serve serves the plugin on the listener until it fails, options are appended to ServerOptions
+func (s *PluginServer) Serve(listener net.Listener, options ...grpc.ServerOption) errors.Error {
+	if s.secret == "" {
+		return errors.BadInput.New("the plugin secret is required")
+	}
+	server := grpc.NewServer(append(s.ServerOptions(), options...)...)
+	s.Register(server)
+	return errors.Convert(server.Serve(listener))
+}
+
+// Serve is the entry of remote plugins, e.g. `remoteplugin.Serve(impl.MyPlugin{}, ":9000")` in the main function.
+// The plugin is configured by environment variables shared with DevLake: REMOTE_PLUGIN_SECRET is required,
+// REMOTE_PLUGIN_TLS_CERT and REMOTE_PLUGIN_TLS_KEY enable tls, and REMOTE_PLUGIN_TLS_CA verifies the Host service
+func Serve(impl plugin.PluginMeta, address string) errors.Error {
+	secret := os.Getenv(REMOTE_PLUGIN_SECRET_ENV)
+	if secret == "" {
+		return errors.BadInput.New(fmt.Sprintf("%s is required", REMOTE_PLUGIN_SECRET_ENV))
+	}
+	var options []grpc.ServerOption
+	creds, err := serverCredentials(os.Getenv(REMOTE_PLUGIN_TLS_CERT_ENV), os.Getenv(REMOTE_PLUGIN_TLS_KEY_ENV))
+	if err != nil {
+		return err
+	}
+	if creds != nil {
+		options = append(options, creds)
+	}
+	dialCreds, err := clientCredentials(os.Getenv(REMOTE_PLUGIN_TLS_CA_ENV))
+	if err != nil {
+		return err
+	}
+	listener, e := net.Listen("tcp", address)
+	if e != nil {
+		return errors.Default.Wrap(e, fmt.Sprintf("failed to listen on %s", address))
+	}
+	return NewPluginServer(impl, secret, dialCreds).Serve(listener, options...)
+}
+
+// guard turns panics of the plugin into errors so a buggy subtask doesn't bring the plugin down
+func guard(f func() errors.Error) (err errors.Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = errors.Default.New(fmt.Sprintf("plugin panicked: %v\n%s", r, debug.Stack()))
+		}
+	}()
+	return f()
+}
+
+// errUninitialized is returned until the host initializes the plugin
+var errUninitialized = errors.Default.New("plugin is not initialized")
+
+func (s *PluginServer) getHost() (*hostClient, errors.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.host == nil {
+		return nil, errUninitialized
+	}
+	return s.host, nil
+}
+
+func (s *PluginServer) describe(_ gocontext.Context, _ *DescribeRequest) *DescribeResponse {
+	description := &PluginDescription{
+		Description: s.impl.Description(),
+		RootPkgPath: s.impl.RootPkgPath(),
+	}
+	err := guard(func() errors.Error {
+		if compatibility, ok := s.impl.(plugin.PluginCompatibility); ok {
+			description.ApiVersion = compatibility.RequiredApiVersion()
+			description.Dependencies = compatibility.Dependencies()
+		}
+		if task, ok := s.impl.(plugin.PluginTask); ok {
+			for _, meta := range task.SubTaskMetas() {
+				description.SubTaskMetas = append(description.SubTaskMetas, &SubTaskMetaDescription{
+					Name:             meta.Name,
+					Required:         meta.Required,
+					EnabledByDefault: meta.EnabledByDefault,
+					Description:      meta.Description,
+					DomainTypes:      meta.DomainTypes,
+				})
+			}
+		}
+		if api, ok := s.impl.(plugin.PluginApi); ok {
+			for path, methods := range api.ApiResources() {
+				for method := range methods {
+					description.ApiResources = append(description.ApiResources, &ApiResourceDescription{Path: path, Method: method})
+				}
+			}
+			sort.Slice(description.ApiResources, func(i, j int) bool {
+				a, b := description.ApiResources[i], description.ApiResources[j]
+				return a.Path < b.Path || (a.Path == b.Path && a.Method < b.Method)
+			})
+		}
+		if migration, ok := s.impl.(plugin.PluginMigration); ok {
+			for _, script := range migration.MigrationScripts() {
+				_, reversible := script.(plugin.MigrationScriptDown)
+				description.MigrationScripts = append(description.MigrationScripts, &MigrationScriptDescription{
+					Version:    script.Version(),
+					Name:       script.Name(),
+					Reversible: reversible,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return &DescribeResponse{Error: toRemoteError(err)}
+	}
+	return &DescribeResponse{Description: description}
+}
+
+func (s *PluginServer) init(_ gocontext.Context, req *InitRequest) *ErrorResponse {
+	options := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MAX_MESSAGE_SIZE), grpc.MaxCallSendMsgSize(MAX_MESSAGE_SIZE)),
+	}, s.dialOptions...)
+	conn, e := grpc.Dial(req.HostAddress, options...)
+	if e != nil {
+		return &ErrorResponse{Error: toRemoteError(errors.Default.Wrap(e, "failed to connect to the host"))}
+	}
+	host := &hostClient{conn: conn, token: req.Token}
+	s.lock.Lock()
+	if s.host != nil {
+		_ = s.host.conn.Close()
+	}
+	s.host = host
+	s.lock.Unlock()
+	err := guard(func() errors.Error {
+		// models of the plugin are looked up by their package path, e.g. by the domain id generator
+		err := plugin.RegisterPlugin(req.PluginName, s.impl)
+		if err != nil {
+			return err
+		}
+		if pluginInit, ok := s.impl.(plugin.PluginInit); ok {
+			return pluginInit.Init(newRemoteBasicRes(host, "", gocontext.Background()))
+		}
+		return nil
+	})
+	return &ErrorResponse{Error: toRemoteError(err)}
+}
+
+func (s *PluginServer) prepareTaskData(ctx gocontext.Context, req *PrepareTaskDataRequest) *PrepareTaskDataResponse {
+	var handle string
+	err := guard(func() errors.Error {
+		host, err := s.getHost()
+		if err != nil {
+			return err
+		}
+		task, ok := s.impl.(plugin.PluginTask)
+		if !ok {
+			return errors.BadInput.New("plugin doesn't support tasks")
+		}
+		taskCtx := newRemoteTaskContext(newRemoteBasicRes(host, req.SessionId, ctx), req.TaskName, nil)
+		data, err := task.PrepareTaskData(taskCtx, req.Options)
+		if err != nil {
+			return err
+		}
+		handle, err = randomId()
+		if err != nil {
+			return err
+		}
+		s.lock.Lock()
+		s.tasks[handle] = data
+		s.lock.Unlock()
+		return nil
+	})
+	return &PrepareTaskDataResponse{Error: toRemoteError(err), Handle: handle}
+}
+
+func (s *PluginServer) getTaskData(handle string) (interface{}, errors.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	data, ok := s.tasks[handle]
+	if !ok {
+		return nil, errors.NotFound.New("task data not found, the plugin might have been restarted")
+	}
+	return data, nil
+}
+
+func (s *PluginServer) executeSubTask(ctx gocontext.Context, req *ExecuteSubTaskRequest) *ErrorResponse {
+	err := guard(func() errors.Error {
+		host, err := s.getHost()
+		if err != nil {
+			return err
+		}
+		data, err := s.getTaskData(req.Handle)
+		if err != nil {
+			return err
+		}
+		task, ok := s.impl.(plugin.PluginTask)
+		if !ok {
+			return errors.BadInput.New("plugin doesn't support tasks")
+		}
+		for _, meta := range task.SubTaskMetas() {
+			if meta.Name == req.SubTaskName {
+				basicRes := newRemoteBasicRes(host, req.SessionId, ctx)
+				return meta.EntryPoint(newRemoteSubTaskContext(basicRes, req.SubTaskName, data))
+			}
+		}
+		return errors.NotFound.New(fmt.Sprintf("subtask %s not found", req.SubTaskName))
+	})
+	return &ErrorResponse{Error: toRemoteError(err)}
+}
+
+func (s *PluginServer) closeTask(ctx gocontext.Context, req *CloseTaskRequest) *ErrorResponse {
+	err := guard(func() errors.Error {
+		host, err := s.getHost()
+		if err != nil {
+			return err
+		}
+		data, err := s.getTaskData(req.Handle)
+		if err != nil {
+			return err
+		}
+		s.lock.Lock()
+		delete(s.tasks, req.Handle)
+		s.lock.Unlock()
+		if task, ok := s.impl.(plugin.CloseablePluginTask); ok {
+			taskCtx := newRemoteTaskContext(newRemoteBasicRes(host, req.SessionId, ctx), "", data)
+			return task.Close(taskCtx)
+		}
+		return nil
+	})
+	return &ErrorResponse{Error: toRemoteError(err)}
+}
+
+func (s *PluginServer) callApi(_ gocontext.Context, req *ApiRequest) *ApiResponse {
+	res := &ApiResponse{}
+	err := guard(func() errors.Error {
+		api, ok := s.impl.(plugin.PluginApi)
+		if !ok {
+			return errors.NotFound.New("plugin doesn't provide apis")
+		}
+		handler, ok := api.ApiResources()[req.Path][req.Method]
+		if !ok {
+			return errors.NotFound.New(fmt.Sprintf("api %s %s not found", req.Method, req.Path))
+		}
+		output, err := handler(&plugin.ApiResourceInput{
+			Params: req.Params,
+			Query:  req.Query,
+			Body:   req.Body,
+			User:   req.User,
+		})
+		if err != nil || output == nil {
+			return err
+		}
+		res.Status = output.Status
+		res.ContentType = output.ContentType
+		res.File = output.File
+		if blob, ok := output.Body.([]byte); ok && output.ContentType != "" {
+			res.File = &plugin.OutputFile{ContentType: output.ContentType, Data: blob}
+		} else if output.Body != nil {
+			body, e := json.Marshal(output.Body)
+			if e != nil {
+				return errors.Default.Wrap(e, "failed to encode the response")
+			}
+			res.Body = body
+		}
+		return nil
+	})
+	if err != nil {
+		return &ApiResponse{Error: toRemoteError(err)}
+	}
+	return res
+}
+
+func (s *PluginServer) migrate(ctx gocontext.Context, req *MigrateRequest) *ErrorResponse {
+	err := guard(func() errors.Error {
+		host, err := s.getHost()
+		if err != nil {
+			return err
+		}
+		migration, ok := s.impl.(plugin.PluginMigration)
+		if !ok {
+			return errors.BadInput.New("plugin has no migration scripts")
+		}
+		basicRes := newRemoteBasicRes(host, req.SessionId, ctx)
+		for _, script := range migration.MigrationScripts() {
+			if script.Version() != req.Version {
+				continue
+			}
+			if !req.Down {
+				return script.Up(basicRes)
+			}
+			if down, ok := script.(plugin.MigrationScriptDown); ok {
+				return down.Down(basicRes)
+			}
+			return errors.BadInput.New(fmt.Sprintf("migration script %d is not reversible", req.Version))
+		}
+		return errors.NotFound.New(fmt.Sprintf("migration script %d not found", req.Version))
+	})
+	return &ErrorResponse{Error: toRemoteError(err)}
+}
@@ -0,0 +1,347 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/config"
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/gorm/schema"
+)
+
+// hostClient calls the Host service with the token issued to the plugin
+type hostClient struct {
+	conn  *grpc.ClientConn
+	token string
+}
+
+func (c *hostClient) call(ctx gocontext.Context, method string, req interface{}, res interface{}) errors.Error {
+	ctx = metadata.AppendToOutgoingContext(ctx, TOKEN_METADATA, c.token)
+	return invoke(ctx, c.conn, HOST_SERVICE, method, req, res)
+}
+
+var _ context.BasicRes = (*remoteBasicRes)(nil)
+
+// remoteBasicRes provides resources of the host within a session
+type remoteBasicRes struct {
+	host      *hostClient
+	sessionId string
+	ctx       gocontext.Context
+	logger    log.Logger
+}
+
+func newRemoteBasicRes(host *hostClient, sessionId string, ctx gocontext.Context) *remoteBasicRes {
+	return &remoteBasicRes{
+		host:      host,
+		sessionId: sessionId,
+		ctx:       ctx,
+		logger:    &remoteLogger{host: host, sessionId: sessionId, ctx: ctx},
+	}
+}
+
+func (r *remoteBasicRes) GetConfigReader() config.ConfigReader {
+	return &remoteConfigReader{host: r.host, ctx: r.ctx}
+}
+
+func (r *remoteBasicRes) GetConfig(name string) string {
+	return r.GetConfigReader().GetString(name)
+}
+
+func (r *remoteBasicRes) GetLogger() log.Logger {
+	return r.logger
+}
+
+func (r *remoteBasicRes) NestedLogger(name string) context.BasicRes {
+	return r.ReplaceLogger(r.logger.Nested(name))
+}
+
+func (r *remoteBasicRes) ReplaceLogger(logger log.Logger) context.BasicRes {
+	return &remoteBasicRes{host: r.host, sessionId: r.sessionId, ctx: r.ctx, logger: logger}
+}
+
+func (r *remoteBasicRes) GetDal() dal.Dal {
+	return &remoteDal{host: r.host, sessionId: r.sessionId, ctx: r.ctx}
+}
+
+var _ log.Logger = (*remoteLogger)(nil)
+
+// remoteLogger sends messages to the logger of the session, levels are filtered by the host
+type remoteLogger struct {
+	host      *hostClient
+	sessionId string
+	ctx       gocontext.Context
+	prefix    string
+}
+
+func (l *remoteLogger) IsLevelEnabled(_ log.LogLevel) bool {
+	return true
+}
+
+func (l *remoteLogger) Printf(format string, a ...interface{}) {
+	l.Log(log.LOG_INFO, format, a...)
+}
+
+func (l *remoteLogger) Log(level log.LogLevel, format string, a ...interface{}) {
+	message := fmt.Sprintf(format, a...)
+	if l.prefix != "" {
+		message = l.prefix + " " + message
+	}
+	_ = l.host.call(l.ctx, "Log", &LogRequest{SessionId: l.sessionId, Level: uint32(level), Message: message}, &ErrorResponse{})
+}
+
+func (l *remoteLogger) Debug(format string, a ...interface{}) {
+	l.Log(log.LOG_DEBUG, format, a...)
+}
+
+func (l *remoteLogger) Info(format string, a ...interface{}) {
+	l.Log(log.LOG_INFO, format, a...)
+}
+
+func (l *remoteLogger) Warn(err error, format string, a ...interface{}) {
+	l.Log(log.LOG_WARN, "%s", formatLogMessage(err, format, a...))
+}
+
+func (l *remoteLogger) Error(err error, format string, a ...interface{}) {
+	l.Log(log.LOG_ERROR, "%s", formatLogMessage(err, format, a...))
+}
+
+func (l *remoteLogger) Nested(name string) log.Logger {
+	prefix := strings.TrimSpace(name)
+	if prefix != "" && !strings.HasPrefix(prefix, "[") {
+		prefix = "[" + prefix + "]"
+	}
+	if l.prefix != "" {
+		prefix = strings.TrimSpace(l.prefix + " " + prefix)
+	}
+	return &remoteLogger{host: l.host, sessionId: l.sessionId, ctx: l.ctx, prefix: prefix}
+}
+
+func (l *remoteLogger) GetConfig() *log.LoggerConfig {
+	return &log.LoggerConfig{Prefix: l.prefix}
+}
+
+func (l *remoteLogger) SetStream(_ *log.LoggerStreamConfig) {
+}
+
+func formatLogMessage(err error, format string, a ...interface{}) string {
+	message := fmt.Sprintf(format, a...)
+	if err == nil {
+		return message
+	}
+	if message == "" {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s\n\tcaused by: %s", message, strings.ReplaceAll(err.Error(), "\n", "\n\t"))
+}
+
+var _ config.ConfigReader = (*remoteConfigReader)(nil)
+
+// remoteConfigReader reads configuration of the host, values are converted from strings
+type remoteConfigReader struct {
+	host *hostClient
+	ctx  gocontext.Context
+}
+
+func (c *remoteConfigReader) Get(key string) interface{} {
+	return c.GetString(key)
+}
+
+func (c *remoteConfigReader) GetBool(name string) bool {
+	return cast.ToBool(c.GetString(name))
+}
+
+func (c *remoteConfigReader) GetFloat64(key string) float64 {
+	return cast.ToFloat64(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetInt(key string) int {
+	return cast.ToInt(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetInt64(key string) int64 {
+	return cast.ToInt64(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetUint(key string) uint {
+	return cast.ToUint(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetUint64(key string) uint64 {
+	return cast.ToUint64(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetIntSlice(key string) []int {
+	return cast.ToIntSlice(c.GetStringSlice(key))
+}
+
+func (c *remoteConfigReader) GetString(key string) string {
+	res := &ConfigResponse{}
+	if err := c.host.call(c.ctx, "Config", &ConfigRequest{Name: key}, res); err != nil {
+		return ""
+	}
+	return res.Value
+}
+
+func (c *remoteConfigReader) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetTime(key string) time.Time {
+	return cast.ToTime(c.GetString(key))
+}
+
+func (c *remoteConfigReader) GetDuration(key string) time.Duration {
+	return cast.ToDuration(c.GetString(key))
+}
+
+func (c *remoteConfigReader) IsSet(key string) bool {
+	return c.GetString(key) != ""
+}
+
+// AllSettings returns nothing since the host doesn't expose its whole configuration
+func (c *remoteConfigReader) AllSettings() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// remoteExecContext is shared by remoteTaskContext and remoteSubTaskContext
+type remoteExecContext struct {
+	*remoteBasicRes
+	name string
+	data interface{}
+	// task is true if the progress goes to the task rather than the subtask
+	task bool
+}
+
+func (c *remoteExecContext) GetName() string {
+	return c.name
+}
+
+func (c *remoteExecContext) GetContext() gocontext.Context {
+	return c.ctx
+}
+
+func (c *remoteExecContext) GetData() interface{} {
+	return c.data
+}
+
+func (c *remoteExecContext) SetProgress(current int, total int) {
+	c.progress(PROGRESS_SET, current, total)
+}
+
+func (c *remoteExecContext) IncProgress(quantity int) {
+	c.progress(PROGRESS_INC, quantity, 0)
+}
+
+func (c *remoteExecContext) progress(progressType string, current int, total int) {
+	req := &ProgressRequest{SessionId: c.sessionId, Task: c.task, Type: progressType, Current: current, Total: total}
+	if err := c.host.call(c.ctx, "Progress", req, &ErrorResponse{}); err != nil {
+		c.logger.Warn(err, "failed to report progress")
+	}
+}
+
+var _ plugin.TaskContext = (*remoteTaskContext)(nil)
+
+type remoteTaskContext struct {
+	*remoteExecContext
+}
+
+func newRemoteTaskContext(basicRes *remoteBasicRes, name string, data interface{}) *remoteTaskContext {
+	return &remoteTaskContext{&remoteExecContext{remoteBasicRes: basicRes, name: name, data: data, task: true}}
+}
+
+func (c *remoteTaskContext) SetData(data interface{}) {
+	c.data = data
+}
+
+func (c *remoteTaskContext) SubTaskContext(subtask string) (plugin.SubTaskContext, errors.Error) {
+	return newRemoteSubTaskContext(c.remoteBasicRes, subtask, c.data), nil
+}
+
+var _ plugin.SubTaskContext = (*remoteSubTaskContext)(nil)
+
+type remoteSubTaskContext struct {
+	*remoteExecContext
+}
+
+func newRemoteSubTaskContext(basicRes *remoteBasicRes, name string, data interface{}) *remoteSubTaskContext {
+	return &remoteSubTaskContext{&remoteExecContext{remoteBasicRes: basicRes, name: name, data: data}}
+}
+
+func (c *remoteSubTaskContext) TaskContext() plugin.TaskContext {
+	return newRemoteTaskContext(c.remoteBasicRes, c.name, c.data)
+}
+
+// remoteEncDecSerializer encrypts and decrypts fields in the plugin process, the keyring is loaded from the
+// configuration of the plugin process on first use since the host never hands out encryption keys
+type remoteEncDecSerializer struct {
+	keyring *plugin.EncryptionKeyring
+	once    sync.Once
+	err     errors.Error
+}
+
+func (s *remoteEncDecSerializer) getKeyring() (*plugin.EncryptionKeyring, errors.Error) {
+	s.once.Do(func() {
+		s.keyring, s.err = plugin.LoadEncryptionKeyring(config.GetConfig().GetString)
+	})
+	return s.keyring, s.err
+}
+
+func (s *remoteEncDecSerializer) Scan(ctx gocontext.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	if dbValue == nil {
+		return nil
+	}
+	keyring, err := s.getKeyring()
+	if err != nil {
+		return err
+	}
+	decrypted, err := keyring.Decrypt(cast.ToString(dbValue))
+	if err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(decrypted)
+	return nil
+}
+
+func (s *remoteEncDecSerializer) Value(_ gocontext.Context, _ *schema.Field, _ reflect.Value, fieldValue interface{}) (interface{}, error) {
+	keyring, err := s.getKeyring()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Encrypt(fieldValue.(string))
+}
@@ -0,0 +1,868 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/utils"
+	"github.com/spf13/cast"
+	"gorm.io/gorm/schema"
+)
+
+var _ dal.Dal = (*remoteDal)(nil)
+
+// remoteDal implements dal.Dal on top of the Host service, models are mapped to columns with gorm tags
+// just like the in-process dal, then rows are sent over the wire
+type remoteDal struct {
+	host      *hostClient
+	sessionId string
+	ctx       gocontext.Context
+}
+
+var schemaCache = &sync.Map{}
+
+func (d *remoteDal) call(req *DalRequest) (*DalResponse, errors.Error) {
+	req.SessionId = d.sessionId
+	res := &DalResponse{}
+	err := d.host.call(d.ctx, "Dal", req, res)
+	if err != nil {
+		return nil, err
+	}
+	if err = fromRemoteError(res.Error); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (d *remoteDal) callClauses(req *DalRequest, clauses []dal.Clause) (*DalResponse, errors.Error) {
+	wireClauses, err := encodeClauses(clauses)
+	if err != nil {
+		return nil, err
+	}
+	req.Clauses = wireClauses
+	return d.call(req)
+}
+
+func parseSchema(entity interface{}) (*schema.Schema, errors.Error) {
+	s, err := schema.Parse(entity, schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to parse model %T", entity))
+	}
+	return s, nil
+}
+
+// tableName returns the table of the entity, unless it is overridden by a From clause
+func tableName(entity interface{}, clauses []dal.Clause) (string, errors.Error) {
+	for _, c := range clauses {
+		if c.Type != dal.FromClause {
+			continue
+		}
+		switch from := c.Data.(type) {
+		case string:
+			return from, nil
+		case dal.ClauseTable:
+			return from.Name, nil
+		case dal.Tabler:
+			return from.TableName(), nil
+		}
+	}
+	if tabler, ok := entity.(dal.Tabler); ok {
+		return tabler.TableName(), nil
+	}
+	s, err := parseSchema(entity)
+	if err != nil {
+		return "", err
+	}
+	return s.Table, nil
+}
+
+// withoutClause returns clauses other than the type
+func withoutClause(clauses []dal.Clause, clauseType string) []dal.Clause {
+	result := make([]dal.Clause, 0, len(clauses))
+	for _, c := range clauses {
+		if c.Type != clauseType {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func hasClause(clauses []dal.Clause, clauseType string) bool {
+	return len(withoutClause(clauses, clauseType)) != len(clauses)
+}
+
+// withFrom adds the From clause of the entity if there is none
+func withFrom(entity interface{}, clauses []dal.Clause) ([]dal.Clause, errors.Error) {
+	if hasClause(clauses, dal.FromClause) {
+		return clauses, nil
+	}
+	table, err := tableName(entity, clauses)
+	if err != nil {
+		return nil, err
+	}
+	return append([]dal.Clause{dal.From(table)}, clauses...), nil
+}
+
+// elements returns the addressable struct values of the entity which is either a struct or a slice of structs
+func elements(entity interface{}) []reflect.Value {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return []reflect.Value{value}
+	}
+	values := make([]reflect.Value, value.Len())
+	for i := range values {
+		values[i] = reflect.Indirect(value.Index(i))
+	}
+	return values
+}
+
+// columnFields returns fields mapped to columns
+func columnFields(s *schema.Schema) []*schema.Field {
+	fields := make([]*schema.Field, 0, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.DBName != "" && s.FieldsByDBName[field.DBName] == field {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// fieldValue returns the value of the field to be sent to the database
+func (d *remoteDal) fieldValue(field *schema.Field, value reflect.Value) (interface{}, errors.Error) {
+	if field.Serializer != nil {
+		// ValueOf wraps values of serializer fields
+		v := field.ReflectValueOf(d.ctx, value).Interface()
+		serialized, err := field.Serializer.Value(d.ctx, field, value, v)
+		return serialized, errors.Convert(err)
+	}
+	v, _ := field.ValueOf(d.ctx, value)
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		return value, errors.Convert(err)
+	}
+	if rv := reflect.Indirect(reflect.ValueOf(v)); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return rv.Bytes(), nil
+	}
+	return v, nil
+}
+
+// autoTime returns the current time in the form of the auto create/update time field
+func autoTime(field *schema.Field, mode schema.TimeType, now time.Time) interface{} {
+	if field.DataType == schema.Time {
+		return now
+	}
+	switch mode {
+	case schema.UnixNanosecond:
+		return now.UnixNano()
+	case schema.UnixMillisecond:
+		return now.UnixMilli()
+	}
+	return now.Unix()
+}
+
+// rows converts the entity to rows, auto create/update time fields are filled like gorm does
+func (d *remoteDal) rows(entity interface{}, create bool) (*schema.Schema, []string, [][]interface{}, errors.Error) {
+	s, err := parseSchema(entity)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	fields := columnFields(s)
+	values := elements(entity)
+	now := time.Now()
+	for _, value := range values {
+		for _, field := range fields {
+			var autoValue interface{}
+			if create && field.AutoCreateTime > 0 {
+				if _, isZero := field.ValueOf(d.ctx, value); isZero {
+					autoValue = autoTime(field, field.AutoCreateTime, now)
+				}
+			}
+			if field.AutoUpdateTime > 0 {
+				autoValue = autoTime(field, field.AutoUpdateTime, now)
+			}
+			if autoValue != nil {
+				if e := field.Set(d.ctx, value, autoValue); e != nil {
+					return nil, nil, nil, errors.Convert(e)
+				}
+			}
+		}
+	}
+	columns := make([]string, 0, len(fields))
+	included := make([]*schema.Field, 0, len(fields))
+	for _, field := range fields {
+		if create && !field.Creatable || !create && !field.Updatable {
+			continue
+		}
+		if create && field.AutoIncrement {
+			// leave the id to the database unless it was specified
+			allZero := true
+			for _, value := range values {
+				if _, isZero := field.ValueOf(d.ctx, value); !isZero {
+					allZero = false
+				}
+			}
+			if allZero {
+				continue
+			}
+		}
+		columns = append(columns, field.DBName)
+		included = append(included, field)
+	}
+	rows := make([][]interface{}, len(values))
+	for i, value := range values {
+		row := make([]interface{}, len(included))
+		for j, field := range included {
+			row[j], err = d.fieldValue(field, value)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		rows[i] = row
+	}
+	return s, columns, rows, nil
+}
+
+func (d *remoteDal) write(op string, entity interface{}, clauses []dal.Clause) errors.Error {
+	s, columns, rows, err := d.rows(entity, op != DAL_UPDATE)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	table, err := tableName(entity, clauses)
+	if err != nil {
+		return err
+	}
+	data, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+	_, err = d.call(&DalRequest{Op: op, Table: table, Columns: columns, PrimaryKeys: s.PrimaryFieldDBNames, Rows: data})
+	return err
+}
+
+// AutoMigrate sends the columns of the entity to the host, which migrates the table accordingly
+func (d *remoteDal) AutoMigrate(entity interface{}, clauses ...dal.Clause) errors.Error {
+	s, err := parseSchema(entity)
+	if err != nil {
+		return err
+	}
+	table, err := tableName(entity, clauses)
+	if err != nil {
+		return err
+	}
+	var fields []*WireField
+	for _, field := range columnFields(s) {
+		if field.IgnoreMigration {
+			continue
+		}
+		fields = append(fields, wireField(field))
+	}
+	_, err = d.call(&DalRequest{Op: DAL_AUTO_MIGRATE, Table: table, Fields: fields})
+	return err
+}
+
+var basicDataTypes = map[schema.DataType]bool{
+	schema.Bool: true, schema.Int: true, schema.Uint: true, schema.Float: true,
+	schema.String: true, schema.Time: true, schema.Bytes: true,
+}
+
+func wireField(field *schema.Field) *WireField {
+	wf := &WireField{Column: field.DBName}
+	switch {
+	case field.Serializer != nil:
+		wf.Kind = string(schema.String)
+	case basicDataTypes[field.DataType]:
+		wf.Kind = string(field.DataType)
+	default:
+		wf.DataType = string(field.DataType)
+		switch field.IndirectFieldType.Kind() {
+		case reflect.Bool:
+			wf.Kind = string(schema.Bool)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			wf.Kind = string(schema.Int)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			wf.Kind = string(schema.Uint)
+		case reflect.Float32, reflect.Float64:
+			wf.Kind = string(schema.Float)
+		case reflect.Slice:
+			wf.Kind = string(schema.Bytes)
+		default:
+			wf.Kind = string(schema.String)
+		}
+	}
+	// the column and serializer are decided by the host
+	var tags []string
+	for _, tag := range strings.Split(field.Tag.Get("gorm"), ";") {
+		name := strings.ToLower(strings.TrimSpace(strings.SplitN(tag, ":", 2)[0]))
+		if tag != "" && name != "column" && name != "serializer" {
+			tags = append(tags, tag)
+		}
+	}
+	wf.Tag = strings.Join(tags, ";")
+	return wf
+}
+
+// AddColumn add column for the table
+func (d *remoteDal) AddColumn(table, columnName, columnType string) errors.Error {
+	_, err := d.call(&DalRequest{Op: DAL_ADD_COLUMN, Table: table, Args: []string{columnName, columnType}})
+	return err
+}
+
+// DropColumns drop column from the table
+func (d *remoteDal) DropColumns(table string, columnName ...string) errors.Error {
+	_, err := d.call(&DalRequest{Op: DAL_DROP_COLUMNS, Table: table, Args: columnName})
+	return err
+}
+
+// Exec executes raw sql query
+func (d *remoteDal) Exec(query string, params ...interface{}) errors.Error {
+	data, err := encodeValues(params)
+	if err != nil {
+		return err
+	}
+	_, err = d.call(&DalRequest{Op: DAL_EXEC, Query: query, Params: data})
+	return err
+}
+
+// Cursor returns a cursor reading rows from the host in batches
+func (d *remoteDal) Cursor(clauses ...dal.Clause) (dal.Rows, errors.Error) {
+	res, err := d.callClauses(&DalRequest{Op: DAL_QUERY}, clauses)
+	if err != nil {
+		return nil, err
+	}
+	rows := &remoteRows{dal: d, columns: res.Columns, index: -1}
+	return rows, rows.load(res)
+}
+
+// Fetch loads row data from `cursor` into `dst`
+func (d *remoteDal) Fetch(cursor dal.Rows, dst interface{}) errors.Error {
+	rows, ok := cursor.(*remoteRows)
+	if !ok {
+		return errors.Default.New(fmt.Sprintf("can not support type %T to be a dal.Rows interface", cursor))
+	}
+	s, err := parseSchema(dst)
+	if err != nil {
+		return err
+	}
+	return d.scanStruct(s, reflect.Indirect(reflect.ValueOf(dst)), rows.columns, rows.current())
+}
+
+func (d *remoteDal) scanStruct(s *schema.Schema, value reflect.Value, columns []string, row []interface{}) errors.Error {
+	for i, column := range columns {
+		field := s.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			continue
+		}
+		var err error
+		if field.Serializer != nil {
+			if row[i] == nil {
+				field.ReflectValueOf(d.ctx, value).Set(reflect.Zero(field.FieldType))
+				continue
+			}
+			err = field.Serializer.Scan(d.ctx, field, value, row[i])
+		} else {
+			err = field.Set(d.ctx, value, row[i])
+		}
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to scan column %s", column))
+		}
+	}
+	return nil
+}
+
+// All loads matched rows from database to `dst`, USE IT WITH CAUTIOUS!!
+func (d *remoteDal) All(dst interface{}, clauses ...dal.Clause) errors.Error {
+	clauses, err := withFrom(dst, clauses)
+	if err != nil {
+		return err
+	}
+	s, err := parseSchema(dst)
+	if err != nil {
+		return err
+	}
+	cursor, err := d.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	rows := cursor.(*remoteRows)
+	defer rows.Close()
+	slice := reflect.Indirect(reflect.ValueOf(dst))
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		elem := reflect.New(elemType)
+		err = d.scanStruct(s, elem.Elem(), rows.columns, rows.current())
+		if err != nil {
+			return err
+		}
+		if isPtr {
+			result = reflect.Append(result, elem)
+		} else {
+			result = reflect.Append(result, elem.Elem())
+		}
+	}
+	if rows.err != nil {
+		return rows.err
+	}
+	slice.Set(result)
+	return nil
+}
+
+// First loads first matched row from database to `dst`, error will be returned if no records were found
+func (d *remoteDal) First(dst interface{}, clauses ...dal.Clause) errors.Error {
+	clauses, err := withFrom(dst, clauses)
+	if err != nil {
+		return err
+	}
+	s, err := parseSchema(dst)
+	if err != nil {
+		return err
+	}
+	if !hasClause(clauses, dal.OrderbyClause) && len(s.PrimaryFieldDBNames) > 0 {
+		// ordered by primary keys like gorm does
+		clauses = append(clauses, dal.Orderby(strings.Join(s.PrimaryFieldDBNames, ",")))
+	}
+	cursor, err := d.Cursor(append(withoutClause(clauses, dal.LimitClause), dal.Limit(1))...)
+	if err != nil {
+		return err
+	}
+	rows := cursor.(*remoteRows)
+	defer rows.Close()
+	if !rows.Next() {
+		if rows.err != nil {
+			return rows.err
+		}
+		return errors.NotFound.New("record not found")
+	}
+	return d.scanStruct(s, reflect.Indirect(reflect.ValueOf(dst)), rows.columns, rows.current())
+}
+
+// Count matched rows from database
+func (d *remoteDal) Count(clauses ...dal.Clause) (int64, errors.Error) {
+	res, err := d.callClauses(&DalRequest{Op: DAL_COUNT}, clauses)
+	if err != nil {
+		return 0, err
+	}
+	return res.Count, nil
+}
+
+// Pluck used to query single column
+func (d *remoteDal) Pluck(column string, dest interface{}, clauses ...dal.Clause) errors.Error {
+	cursor, err := d.Cursor(append([]dal.Clause{dal.Select(column)}, withoutClause(clauses, dal.SelectClause)...)...)
+	if err != nil {
+		return err
+	}
+	rows := cursor.(*remoteRows)
+	defer rows.Close()
+	slice := reflect.Indirect(reflect.ValueOf(dest))
+	result := reflect.MakeSlice(slice.Type(), 0, 0)
+	for rows.Next() {
+		elem := reflect.New(slice.Type().Elem())
+		if e := rows.Scan(elem.Interface()); e != nil {
+			return errors.Convert(e)
+		}
+		result = reflect.Append(result, elem.Elem())
+	}
+	if rows.err != nil {
+		return rows.err
+	}
+	slice.Set(result)
+	return nil
+}
+
+// Create insert record to database, note that auto increment primary keys are not filled back
+func (d *remoteDal) Create(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return d.write(DAL_INSERT, entity, clauses)
+}
+
+// Update updates the record, or creates it if not exist
+func (d *remoteDal) Update(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return d.write(DAL_UPSERT, entity, clauses)
+}
+
+// UpdateColumn allows you to update multiple records
+func (d *remoteDal) UpdateColumn(entity interface{}, columnName string, value interface{}, clauses ...dal.Clause) errors.Error {
+	return d.UpdateColumns(entity, []dal.DalSet{{ColumnName: columnName, Value: value}}, clauses...)
+}
+
+// primaryKeyClauses locates the entity by its primary keys if they are set
+func (d *remoteDal) primaryKeyClauses(entity interface{}) ([]dal.Clause, errors.Error) {
+	value := reflect.Indirect(reflect.ValueOf(entity))
+	if value.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	s, err := parseSchema(entity)
+	if err != nil {
+		return nil, err
+	}
+	var clauses []dal.Clause
+	for _, field := range s.PrimaryFields {
+		v, isZero := field.ValueOf(d.ctx, value)
+		if isZero {
+			return nil, nil
+		}
+		clauses = append(clauses, dal.Where("? = ?", dal.ClauseColumn{Name: field.DBName}, v))
+	}
+	return clauses, nil
+}
+
+// UpdateColumns allows you to update multiple columns of multiple records
+func (d *remoteDal) UpdateColumns(entity interface{}, set []dal.DalSet, clauses ...dal.Clause) errors.Error {
+	table, err := tableName(entity, clauses)
+	if err != nil {
+		return err
+	}
+	clauses = withoutClause(clauses, dal.FromClause)
+	pkClauses, err := d.primaryKeyClauses(entity)
+	if err != nil {
+		return err
+	}
+	clauses = append(clauses, pkClauses...)
+	sets := make([]*WireSet, len(set))
+	for i, s := range set {
+		wireSet := &WireSet{Column: s.ColumnName}
+		params := []interface{}{s.Value}
+		if expr, ok := s.Value.(dal.DalClause); ok {
+			wireSet.Expr = expr.Expr
+			params = expr.Params
+		}
+		if wireSet.Params, err = encodeValues(params); err != nil {
+			return err
+		}
+		sets[i] = wireSet
+	}
+	_, err = d.callClauses(&DalRequest{Op: DAL_UPDATE_SET, Table: table, Sets: sets}, clauses)
+	return err
+}
+
+// UpdateAllColumn updated all Columns of entity
+func (d *remoteDal) UpdateAllColumn(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return d.write(DAL_UPDATE, entity, clauses)
+}
+
+// CreateOrUpdate tries to create the record, or fallback to update all if failed
+func (d *remoteDal) CreateOrUpdate(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return d.write(DAL_UPSERT, entity, clauses)
+}
+
+// CreateIfNotExist tries to create the record if not exist
+func (d *remoteDal) CreateIfNotExist(entity interface{}, clauses ...dal.Clause) errors.Error {
+	return d.write(DAL_INSERT_IGNORE, entity, clauses)
+}
+
+// Delete records from database, records are located by where clauses or primary keys of the entity
+func (d *remoteDal) Delete(entity interface{}, clauses ...dal.Clause) errors.Error {
+	table, err := tableName(entity, clauses)
+	if err != nil {
+		return err
+	}
+	clauses = withoutClause(clauses, dal.FromClause)
+	if len(clauses) > 0 {
+		_, err = d.callClauses(&DalRequest{Op: DAL_DELETE, Table: table}, clauses)
+		return err
+	}
+	s, err := parseSchema(entity)
+	if err != nil {
+		return err
+	}
+	var rows [][]interface{}
+	for _, value := range elements(entity) {
+		row := make([]interface{}, len(s.PrimaryFields))
+		for i, field := range s.PrimaryFields {
+			if row[i], err = d.fieldValue(field, value); err != nil {
+				return err
+			}
+		}
+		rows = append(rows, row)
+	}
+	data, err := encodeRows(rows)
+	if err != nil {
+		return err
+	}
+	_, err = d.call(&DalRequest{Op: DAL_DELETE, Table: table, Columns: s.PrimaryFieldDBNames, Rows: data})
+	return err
+}
+
+// AllTables returns all tables in database
+func (d *remoteDal) AllTables() ([]string, errors.Error) {
+	res, err := d.call(&DalRequest{Op: DAL_ALL_TABLES})
+	if err != nil {
+		return nil, err
+	}
+	return res.Strings, nil
+}
+
+// DropTables drops all specified tables
+func (d *remoteDal) DropTables(dst ...interface{}) errors.Error {
+	tables := make([]string, len(dst))
+	for i, entity := range dst {
+		if table, ok := entity.(string); ok {
+			tables[i] = table
+			continue
+		}
+		table, err := tableName(entity, nil)
+		if err != nil {
+			return err
+		}
+		tables[i] = table
+	}
+	_, err := d.call(&DalRequest{Op: DAL_DROP_TABLES, Args: tables})
+	return err
+}
+
+// RenameTable renames table name
+func (d *remoteDal) RenameTable(oldName, newName string) errors.Error {
+	_, err := d.call(&DalRequest{Op: DAL_RENAME_TABLE, Table: oldName, Args: []string{newName}})
+	return err
+}
+
+// GetColumns is not supported by remote plugins
+func (d *remoteDal) GetColumns(_ dal.Tabler, _ func(columnMeta dal.ColumnMeta) bool) ([]dal.ColumnMeta, errors.Error) {
+	return nil, errors.Default.New("GetColumns is not supported over the remote plugin protocol")
+}
+
+// GetPrimaryKeyFields get the PrimaryKey from `gorm` tag
+func (d *remoteDal) GetPrimaryKeyFields(t reflect.Type) []reflect.StructField {
+	return utils.WalkFields(t, func(field *reflect.StructField) bool {
+		return strings.Contains(strings.ToLower(field.Tag.Get("gorm")), "primarykey")
+	})
+}
+
+// RenameColumn renames column name for specified table
+func (d *remoteDal) RenameColumn(table, oldColumnName, newColumnName string) errors.Error {
+	_, err := d.call(&DalRequest{Op: DAL_RENAME_COLUMN, Table: table, Args: []string{oldColumnName, newColumnName}})
+	return err
+}
+
+// DropIndexes drops all specified tables
+func (d *remoteDal) DropIndexes(table string, indexes ...string) errors.Error {
+	_, err := d.call(&DalRequest{Op: DAL_DROP_INDEXES, Table: table, Args: indexes})
+	return err
+}
+
+// Dialect returns the dialect of the database of the host
+func (d *remoteDal) Dialect() string {
+	res, err := d.call(&DalRequest{Op: DAL_DIALECT})
+	if err != nil || len(res.Strings) == 0 {
+		return ""
+	}
+	return res.Strings[0]
+}
+
+// Session returns the dal itself since sessions are managed by the host
+func (d *remoteDal) Session(_ dal.SessionConfig) dal.Dal {
+	return d
+}
+
+// Begin returns a transaction which is not atomic, statements are applied right away and Rollback fails
+func (d *remoteDal) Begin() dal.Transaction {
+	return &remoteTransaction{d}
+}
+
+// IsErrorNotFound returns true if error is record-not-found
+func (d *remoteDal) IsErrorNotFound(err errors.Error) bool {
+	return err != nil && err.As(errors.NotFound) != nil
+}
+
+// IsDuplicationError returns true if error is duplicate-error
+func (d *remoteDal) IsDuplicationError(err errors.Error) bool {
+	return err != nil && err.As(errors.HttpStatus(http.StatusConflict)) != nil
+}
+
+type remoteTransaction struct {
+	*remoteDal
+}
+
+func (t *remoteTransaction) Rollback() errors.Error {
+	return errors.Default.New("transactions are not supported over the remote plugin protocol")
+}
+
+func (t *remoteTransaction) Commit() errors.Error {
+	return nil
+}
+
+var _ dal.Rows = (*remoteRows)(nil)
+
+// remoteRows iterates rows of a cursor held by the host, batches are fetched on demand
+type remoteRows struct {
+	dal      *remoteDal
+	columns  []string
+	batch    [][]interface{}
+	index    int
+	cursorId string
+	err      errors.Error
+}
+
+func (r *remoteRows) load(res *DalResponse) errors.Error {
+	batch, err := decodeRows(res.Rows)
+	if err != nil {
+		return err
+	}
+	r.batch = batch
+	r.index = -1
+	r.cursorId = ""
+	if len(res.Strings) > 0 {
+		r.cursorId = res.Strings[0]
+	}
+	return nil
+}
+
+func (r *remoteRows) current() []interface{} {
+	if r.index < 0 || r.index >= len(r.batch) {
+		return nil
+	}
+	return r.batch[r.index]
+}
+
+func (r *remoteRows) Next() bool {
+	r.index++
+	if r.index < len(r.batch) {
+		return true
+	}
+	if r.cursorId == "" || r.err != nil {
+		return false
+	}
+	res, err := r.dal.call(&DalRequest{Op: DAL_FETCH, Query: r.cursorId})
+	if err == nil {
+		err = r.load(res)
+	}
+	if err != nil {
+		r.err = err
+		r.cursorId = ""
+		return false
+	}
+	return r.Next()
+}
+
+func (r *remoteRows) Close() error {
+	if r.cursorId == "" {
+		return nil
+	}
+	cursorId := r.cursorId
+	r.cursorId = ""
+	_, err := r.dal.call(&DalRequest{Op: DAL_CLOSE_CURSOR, Query: cursorId})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *remoteRows) Scan(dest ...any) error {
+	row := r.current()
+	if row == nil {
+		return errors.Default.New("Scan called without calling Next")
+	}
+	if len(dest) != len(row) {
+		return errors.Default.New(fmt.Sprintf("expected %d destination arguments in Scan, not %d", len(row), len(dest)))
+	}
+	for i, value := range row {
+		if err := assign(dest[i], value); err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to scan column %s", r.columns[i]))
+		}
+	}
+	return nil
+}
+
+func (r *remoteRows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+func (r *remoteRows) ColumnTypes() ([]*sql.ColumnType, error) {
+	return nil, errors.Default.New("ColumnTypes is not supported over the remote plugin protocol")
+}
+
+// assign stores the value received from the host into dest, which is a pointer
+func assign(dest interface{}, value interface{}) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+	target := reflect.ValueOf(dest)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return errors.Default.New(fmt.Sprintf("destination %T is not a pointer", dest))
+	}
+	target = target.Elem()
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+	if target.Kind() == reflect.Ptr {
+		elem := reflect.New(target.Type().Elem())
+		if err := assign(elem.Interface(), value); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	}
+	source := reflect.ValueOf(value)
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+	var converted interface{}
+	var err error
+	switch target.Kind() {
+	case reflect.String:
+		converted, err = cast.ToStringE(value)
+	case reflect.Bool:
+		converted, err = cast.ToBoolE(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		converted, err = cast.ToInt64E(value)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		converted, err = cast.ToUint64E(value)
+	case reflect.Float32, reflect.Float64:
+		converted, err = cast.ToFloat64E(value)
+	case reflect.Struct:
+		if target.Type() == reflect.TypeOf(time.Time{}) {
+			converted, err = cast.ToTimeE(value)
+		}
+	case reflect.Slice:
+		if s, ok := value.(string); ok && target.Type().Elem().Kind() == reflect.Uint8 {
+			converted = []byte(s)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if converted == nil {
+		return errors.Default.New(fmt.Sprintf("unable to assign %T to %s", value, target.Type()))
+	}
+	target.Set(reflect.ValueOf(converted).Convert(target.Type()))
+	return nil
+}
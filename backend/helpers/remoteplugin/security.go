@@ -0,0 +1,110 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remoteplugin
+
+import (
+	gocontext "context"
+	"crypto/subtle"
+	"fmt"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// REMOTE_PLUGIN_SECRET_ENV is shared by DevLake and remote plugins, plugins reject calls without it
+	REMOTE_PLUGIN_SECRET_ENV = "REMOTE_PLUGIN_SECRET"
+	// REMOTE_PLUGIN_TLS_CERT_ENV and REMOTE_PLUGIN_TLS_KEY_ENV are the certificate served by the Host service or
+	// the remote plugin, REMOTE_PLUGIN_TLS_CA_ENV verifies the certificate of the other side. Plaintext is used
+	// when they are not set
+	REMOTE_PLUGIN_TLS_CERT_ENV = "REMOTE_PLUGIN_TLS_CERT"
+	REMOTE_PLUGIN_TLS_KEY_ENV  = "REMOTE_PLUGIN_TLS_KEY"
+	REMOTE_PLUGIN_TLS_CA_ENV   = "REMOTE_PLUGIN_TLS_CA"
+	// REMOTE_PLUGIN_CONFIG_NAMES_ENV lists config remote plugins may read, `*` suffix matches any prefix, none by default
+	REMOTE_PLUGIN_CONFIG_NAMES_ENV = "REMOTE_PLUGIN_CONFIG_NAMES"
+	// SECRET_METADATA carries the shared secret in every call to the Plugin service
+	SECRET_METADATA = "x-devlake-plugin-secret"
+)
+
+// serverCredentials returns the option to serve with the certificate, nil if no certificate is configured
+func serverCredentials(certFile string, keyFile string) (grpc.ServerOption, errors.Error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.BadInput.New(fmt.Sprintf("both %s and %s are required", REMOTE_PLUGIN_TLS_CERT_ENV, REMOTE_PLUGIN_TLS_KEY_ENV))
+	}
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load the tls certificate")
+	}
+	return grpc.Creds(creds), nil
+}
+
+// clientCredentials returns the option to dial with tls verified by the CA, or with plaintext if there is none
+func clientCredentials(caFile string) (grpc.DialOption, errors.Error) {
+	if caFile == "" {
+		return grpc.WithTransportCredentials(insecure.NewCredentials()), nil
+	}
+	creds, err := credentials.NewClientTLSFromFile(caFile, "")
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to load the tls CA")
+	}
+	return grpc.WithTransportCredentials(creds), nil
+}
+
+// withSecret attaches the shared secret to calls to the Plugin service
+func withSecret(ctx gocontext.Context, secret string) gocontext.Context {
+	return metadata.AppendToOutgoingContext(ctx, SECRET_METADATA, secret)
+}
+
+// checkSecret returns the interceptor rejecting calls which don't carry the shared secret
+func checkSecret(secret string) grpc.UnaryServerInterceptor {
+	return func(ctx gocontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		secrets := md.Get(SECRET_METADATA)
+		if secret == "" || len(secrets) == 0 || subtle.ConstantTimeCompare([]byte(secrets[0]), []byte(secret)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid plugin secret")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// configNameMatcher tells whether config is allowed by the comma separated patterns
+func configNameMatcher(patterns string) func(name string) bool {
+	var names []string
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			names = append(names, pattern)
+		}
+	}
+	return func(name string) bool {
+		for _, pattern := range names {
+			if pattern == name || (strings.HasSuffix(pattern, "*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))) {
+				return true
+			}
+		}
+		return false
+	}
+}
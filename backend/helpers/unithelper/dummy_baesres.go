@@ -18,6 +18,7 @@ limitations under the License.
 package unithelper
 
 import (
+	"github.com/apache/incubator-devlake/core/plugin"
 	mockcontext "github.com/apache/incubator-devlake/mocks/core/context"
 	mockdal "github.com/apache/incubator-devlake/mocks/core/dal"
 	"github.com/stretchr/testify/mock"
@@ -33,6 +34,7 @@ func DummyBasicRes(callback func(mockDal *mockdal.Dal)) *mockcontext.BasicRes {
 
 	mockRes.On("GetDal").Return(mockDal)
 	mockRes.On("GetLogger").Return(mockLog)
+	mockRes.On("GetConfig", plugin.EncodeKeyEnvStr).Return("dummy-encode-key")
 	mockRes.On("GetConfig", mock.Anything).Return("")
 	return mockRes
 }
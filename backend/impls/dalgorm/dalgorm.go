@@ -261,6 +261,13 @@ func (d *Dalgorm) DropColumns(table string, columnNames ...string) errors.Error
 		_ = d.Exec("SELECT * FROM ? LIMIT 1", clause.Table{Name: table})
 	}()
 	for _, columnName := range columnNames {
+		// sqlite refuses to drop a column that is still covered by an index
+		if d.Dialect() == "sqlite" {
+			err := d.dropSqliteColumnIndexes(table, columnName)
+			if err != nil {
+				return err
+			}
+		}
 		err := d.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: columnName})
 		// err := d.db.Migrator().DropColumn(table, columnName)
 		if err != nil {
@@ -270,6 +277,20 @@ func (d *Dalgorm) DropColumns(table string, columnNames ...string) errors.Error
 	return nil
 }
 
+// dropSqliteColumnIndexes drops the indexes created on the column, indexes behind primary keys or unique constraints
+// can not be dropped and are left to sqlite to report
+func (d *Dalgorm) dropSqliteColumnIndexes(table, columnName string) errors.Error {
+	var indexes []string
+	err := d.db.Raw(
+		"SELECT DISTINCT il.name FROM pragma_index_list(?) il, pragma_index_info(il.name) ii WHERE il.origin = 'c' AND ii.name = ?",
+		table, columnName,
+	).Scan(&indexes).Error
+	if err != nil {
+		return errors.Convert(err)
+	}
+	return d.DropIndexes(table, indexes...)
+}
+
 // GetPrimaryKeyFields get the PrimaryKey from `gorm` tag
 func (d *Dalgorm) GetPrimaryKeyFields(t reflect.Type) []reflect.StructField {
 	return utils.WalkFields(t, func(field *reflect.StructField) bool {
@@ -295,9 +316,12 @@ func (d *Dalgorm) RenameColumn(table, oldColumnName, newColumnName string) error
 // AllTables returns all tables in the database
 func (d *Dalgorm) AllTables() ([]string, errors.Error) {
 	var tableSql string
-	if d.db.Dialector.Name() == "mysql" {
+	switch d.db.Dialector.Name() {
+	case "mysql":
 		tableSql = "show tables"
-	} else {
+	case "sqlite":
+		tableSql = "select name from sqlite_master where type = 'table' and name not like 'sqlite_%'"
+	default:
 		tableSql = "select table_name from information_schema.tables where table_schema = 'public' and table_name not like '_devlake%'"
 	}
 	var tables []string
@@ -321,7 +345,41 @@ func (d *Dalgorm) DropTables(dst ...interface{}) errors.Error {
 
 // RenameTable renames table name
 func (d *Dalgorm) RenameTable(oldName, newName string) errors.Error {
-	return errors.Convert(d.db.Migrator().RenameTable(oldName, newName))
+	err := errors.Convert(d.db.Migrator().RenameTable(oldName, newName))
+	if err != nil || d.Dialect() != "sqlite" {
+		return err
+	}
+	return d.renameSqliteIndexes(oldName, newName)
+}
+
+// renameSqliteIndexes renames the indexes of a renamed table after it, index names are unique per database on sqlite
+// and would otherwise collide with the ones of a new table created under the old name
+func (d *Dalgorm) renameSqliteIndexes(oldName, newName string) errors.Error {
+	var indexes []struct {
+		Name string
+		Sql  string
+	}
+	err := d.db.Raw("SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL", newName).
+		Scan(&indexes).Error
+	if err != nil {
+		return errors.Convert(err)
+	}
+	for _, index := range indexes {
+		indexName := strings.Replace(index.Name, oldName, newName, 1)
+		if indexName == index.Name {
+			continue
+		}
+		err = d.db.Exec("DROP INDEX ?", clause.Table{Name: index.Name}).Error
+		if err != nil {
+			return errors.Convert(err)
+		}
+		// the index name comes first in the CREATE INDEX statement
+		err = d.db.Exec(strings.Replace(index.Sql, index.Name, indexName, 1)).Error
+		if err != nil {
+			return errors.Convert(err)
+		}
+	}
+	return nil
 }
 
 // DropIndexes drops indexes for specified table
@@ -346,6 +404,9 @@ func (d *Dalgorm) Session(config dal.SessionConfig) dal.Dal {
 		PrepareStmt:            config.PrepareStmt,
 		SkipDefaultTransaction: config.SkipDefaultTransaction,
 	})
+	if config.DryRunRecorder != nil {
+		session = newDryRunSession(session, config.DryRunRecorder)
+	}
 	return NewDalgorm(session)
 }
 
@@ -361,7 +422,9 @@ func (d *Dalgorm) IsErrorNotFound(err errors.Error) bool {
 
 // IsDuplicationError checking if the sql error is not found.
 func (d *Dalgorm) IsDuplicationError(err errors.Error) bool {
-	return strings.Contains(strings.ToLower(err.Error()), "duplicate")
+	msg := strings.ToLower(err.Error())
+	// sqlite reports `UNIQUE constraint failed` instead
+	return strings.Contains(msg, "duplicate") || strings.Contains(msg, "unique constraint failed")
 }
 
 // NewDalgorm creates a *Dalgorm
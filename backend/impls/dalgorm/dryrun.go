@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dalgorm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+)
+
+// dryRunConnPool hands write statements over to the recorder instead of executing them,
+// queries are passed through so scripts could still inspect the current schema
+type dryRunConnPool struct {
+	gorm.ConnPool
+	dialector gorm.Dialector
+	recorder  func(statement string)
+}
+
+// ExecContext records the statement and pretends it succeeded
+func (p *dryRunConnPool) ExecContext(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.recorder(p.dialector.Explain(query, args...))
+	return driver.RowsAffected(0), nil
+}
+
+func newDryRunSession(db *gorm.DB, recorder func(statement string)) *gorm.DB {
+	// setting the Context forces gorm to clone the Statement, so the ConnPool replacement
+	// would not leak into the original db
+	session := db.Session(&gorm.Session{Context: context.Background()})
+	session.Statement.ConnPool = &dryRunConnPool{
+		ConnPool:  session.Statement.ConnPool,
+		dialector: session.Dialector,
+		recorder:  recorder,
+	}
+	return session
+}
+
+// BeginTx pretends to start a transaction so scripts using transactions could be dry-run as well, nothing is
+// opened on the database since the write statements inside it are recorded instead of being executed
+func (p *dryRunConnPool) BeginTx(_ context.Context, _ *sql.TxOptions) (gorm.ConnPool, error) {
+	return &dryRunTx{dryRunConnPool: p}, nil
+}
+
+// dryRunTx is the pretended transaction of a dry-run session
+type dryRunTx struct {
+	*dryRunConnPool
+}
+
+var _ gorm.ConnPoolBeginner = (*dryRunConnPool)(nil)
+var _ gorm.TxCommitter = (*dryRunTx)(nil)
+
+func (t *dryRunTx) Commit() error {
+	return nil
+}
+
+func (t *dryRunTx) Rollback() error {
+	return nil
+}
@@ -31,7 +31,7 @@ var _ schema.SerializerInterface = (*EncDecSerializer)(nil)
 // EncDecSerializer is responsible for field encryption/decryption in Application Level
 // Ref: https://gorm.io/docs/serializer.html
 type EncDecSerializer struct {
-	encKey string
+	keyring *plugin.EncryptionKeyring
 }
 
 // Scan implements serializer interface
@@ -47,7 +47,7 @@ func (es *EncDecSerializer) Scan(ctx context.Context, field *schema.Field, dst r
 			return fmt.Errorf("failed to decrypt value: %#v", dbValue)
 		}
 
-		decrypted, err := plugin.Decrypt(es.encKey, base64str)
+		decrypted, err := es.keyring.Decrypt(base64str)
 		if err != nil {
 			return err
 		}
@@ -58,10 +58,10 @@ func (es *EncDecSerializer) Scan(ctx context.Context, field *schema.Field, dst r
 
 // Value implements serializer interface
 func (es *EncDecSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
-	return plugin.Encrypt(es.encKey, fieldValue.(string))
+	return es.keyring.Encrypt(fieldValue.(string))
 }
 
 // Init the encdec serializer
-func Init(encKey string) {
-	schema.RegisterSerializer("encdec", &EncDecSerializer{encKey: encKey})
+func Init(keyring *plugin.EncryptionKeyring) {
+	schema.RegisterSerializer("encdec", &EncDecSerializer{keyring: keyring})
 }
@@ -48,6 +48,9 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err := api.Decode(input.Body, &connection, vld); err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not decode request parameters")
 	}
+	if err := connectionHelper.RejectSecretReferences(&connection); err != nil {
+		return nil, err
+	}
 	// load and process cconfiguration
 	endpoint := connection.Endpoint
 	appId := connection.AppId
@@ -124,7 +127,7 @@ func ListConnections(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/ae/connections/{connectionId} [GET]
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.AeConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -154,10 +157,10 @@ func PatchConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/ae/connections/{connectionId} [DELETE]
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.AeConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
@@ -31,6 +31,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*AE)(nil)
+var _ plugin.PluginCompatibility = (*AE)(nil)
 var _ plugin.PluginInit = (*AE)(nil)
 var _ plugin.PluginTask = (*AE)(nil)
 var _ plugin.PluginApi = (*AE)(nil)
@@ -41,8 +42,7 @@ var _ plugin.CloseablePluginTask = (*AE)(nil)
 type AE struct{}
 
 func (p AE) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (plugin AE) GetTablesInfo() []dal.Tabler {
@@ -58,6 +58,14 @@ func (p AE) Description() string {
 	return "To collect and enrich data from AE"
 }
 
+func (p AE) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p AE) Dependencies() []string {
+	return nil
+}
+
 func (p AE) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectProjectMeta,
@@ -78,10 +86,13 @@ func (p AE) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]inter
 		return nil, errors.Default.New("projectId is required")
 	}
 	connection := &models.AeConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "error getting connection for AE plugin")
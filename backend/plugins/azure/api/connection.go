@@ -35,6 +35,9 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err := api.Decode(input.Body, &connection, vld); err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not decode request parameters")
 	}
+	if err := connectionHelper.RejectSecretReferences(&connection); err != nil {
+		return nil, err
+	}
 	// test connection
 	encodedToken := utils.GetEncodedToken(connection.Username, connection.Password)
 	apiClient, err := api.NewApiClient(
@@ -108,11 +111,11 @@ DELETE /plugins/zaure/connections/connectionId
 */
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.AzureConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -134,6 +137,6 @@ GET /plugins/zaure/connections/connectionId
 */
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.AzureConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
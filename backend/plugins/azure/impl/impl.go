@@ -32,6 +32,7 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*Azure)(nil)
+var _ plugin.PluginCompatibility = (*Azure)(nil)
 var _ plugin.PluginInit = (*Azure)(nil)
 var _ plugin.PluginTask = (*Azure)(nil)
 var _ plugin.PluginApi = (*Azure)(nil)
@@ -48,11 +49,18 @@ func (p Azure) Description() string {
 	return "collect some Azure data"
 }
 
-func (p Azure) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
+func (p Azure) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Azure) Dependencies() []string {
 	return nil
 }
 
+func (p Azure) Init(basicRes context.BasicRes) errors.Error {
+	return api.Init(basicRes)
+}
+
 func (p Azure) GetTablesInfo() []dal.Tabler {
 	return []dal.Tabler{
 		&models.AzureBuild{},
@@ -81,13 +89,16 @@ func (p Azure) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]in
 	}
 
 	connection := &models.AzureConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err != nil {
+		return nil, err
+	}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, err
@@ -36,7 +36,7 @@ type AzureResponse struct {
 type TestConnectionRequest struct {
 	Endpoint string `json:"endpoint" validate:"required"`
 	Username string `json:"username" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	Password string `json:"password" validate:"required" encrypt:"yes"`
 	Proxy    string `json:"proxy"`
 }
 
@@ -44,6 +44,9 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err := api.Decode(input.Body, &connection, vld); err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not decode request parameters")
 	}
+	if err := connectionHelper.RejectSecretReferences(&connection); err != nil {
+		return nil, err
+	}
 	// test connection
 	apiClient, err := api.NewApiClient(
 		context.TODO(),
@@ -113,11 +116,11 @@ func PatchConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/bitbucket/connections/{connectionId} [DELETE]
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.BitbucketConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -146,6 +149,6 @@ func ListConnections(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/bitbucket/connections/{connectionId} [GET]
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.BitbucketConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
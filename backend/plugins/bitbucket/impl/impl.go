@@ -31,6 +31,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Bitbucket)(nil)
+var _ plugin.PluginCompatibility = (*Bitbucket)(nil)
 var _ plugin.PluginInit = (*Bitbucket)(nil)
 var _ plugin.PluginTask = (*Bitbucket)(nil)
 var _ plugin.PluginApi = (*Bitbucket)(nil)
@@ -42,8 +43,7 @@ var _ plugin.CloseablePluginTask = (*Bitbucket)(nil)
 type Bitbucket string
 
 func (p Bitbucket) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Bitbucket) GetTablesInfo() []dal.Tabler {
@@ -65,6 +65,14 @@ func (p Bitbucket) Description() string {
 	return "To collect and enrich data from Bitbucket"
 }
 
+func (p Bitbucket) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Bitbucket) Dependencies() []string {
+	return nil
+}
+
 func (p Bitbucket) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectApiRepoMeta,
@@ -108,10 +116,13 @@ func (p Bitbucket) PrepareTaskData(taskCtx plugin.TaskContext, options map[strin
 	if err != nil {
 		return nil, err
 	}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	connection := &models.BitbucketConnection{}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
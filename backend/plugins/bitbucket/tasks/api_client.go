@@ -43,6 +43,7 @@ func CreateApiClient(taskCtx plugin.TaskContext, connection *models.BitbucketCon
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
 		taskCtx,
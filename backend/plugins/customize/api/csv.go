@@ -0,0 +1,433 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/customize/models"
+	"github.com/iancoleman/strcase"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxMemory = 32 << 20 // 32 MB
+
+// RAW_ISSUES_CSV_TABLE keeps every imported line, so imported issues can be traced back to the csv file
+const RAW_ISSUES_CSV_TABLE = "_raw_customize_csv_issues"
+
+type csvImportResult struct {
+	Rows int `json:"rows"`
+}
+
+// UploadFieldsCsv fills customized fields of existing rows from a CSV file
+// @Summary      Upload a CSV file to fill customized fields
+// @Description  the CSV file must have the primary key columns of the table, i.e. `id` for issues and pull_requests, the other columns must be existing customized fields. Lines matching no row are skipped and not counted
+// @Tags 		 plugins/customize
+// @Accept       multipart/form-data
+// @Param        table path string true "the table, i.e. issues"
+// @Param        file formData file true "select file to upload"
+// @Produce      json
+// @Success      200  {object} csvImportResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/customize/{table}/fields.csv [put]
+func (h *Handlers) UploadFieldsCsv(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	table := input.Params["table"]
+	header, records, err := readCsv(input.Request)
+	if err != nil {
+		return nil, err
+	}
+	pkFields, err := dal.GetPrimarykeyColumns(h.dal, &models.Table{Name: table})
+	if err != nil {
+		return nil, err
+	}
+	if len(pkFields) == 0 {
+		return nil, errors.BadInput.New(fmt.Sprintf("table %s has no primary key", table))
+	}
+	columns := make(map[string]int)
+	for i, column := range header {
+		columns[column] = i
+	}
+	var pkColumns []string
+	for _, pk := range pkFields {
+		if _, ok := columns[pk.Name()]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("primary key column %s is missing", pk.Name()))
+		}
+		pkColumns = append(pkColumns, pk.Name())
+	}
+	var fieldColumns []string
+	for _, column := range header {
+		if containsString(pkColumns, column) {
+			continue
+		}
+		exists, err := checkField(h.dal, table, column)
+		if err != nil {
+			return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid column %s", column))
+		}
+		if !exists {
+			return nil, errors.BadInput.New(fmt.Sprintf("customized field %s does not exist in %s", column, table))
+		}
+		fieldColumns = append(fieldColumns, column)
+	}
+	if len(fieldColumns) == 0 {
+		return nil, errors.BadInput.New("no customized field in the csv file")
+	}
+	rows, err := uploadFields(h.dal, table, header, records, pkColumns, fieldColumns)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: &csvImportResult{Rows: rows}, Status: http.StatusOK}, nil
+}
+
+// uploadFields updates the customized fields of all records in a transaction and returns the number of rows found,
+// records whose primary key matches no row are skipped
+func uploadFields(d dal.Dal, table string, header []string, records [][]string, pkColumns, fieldColumns []string) (rows int, err errors.Error) {
+	tx := d.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	for _, record := range records {
+		var wheres []string
+		var params []interface{}
+		for _, pk := range pkColumns {
+			for i, column := range header {
+				if column == pk {
+					wheres = append(wheres, fmt.Sprintf("%s = ?", column))
+					params = append(params, record[i])
+				}
+			}
+		}
+		count, err := tx.Count(dal.From(table), dal.Where(strings.Join(wheres, " AND "), params...))
+		if err != nil {
+			return 0, err
+		}
+		if count == 0 {
+			continue
+		}
+		err = updateCustomizedFields(tx, table, header, record, pkColumns, fieldColumns)
+		if err != nil {
+			return 0, err
+		}
+		rows++
+	}
+	return rows, tx.Commit()
+}
+
+// ImportIssuesCsv creates or updates issues from a CSV file and links them to a board
+// @Summary      Import issues from a CSV file
+// @Description  columns are the ones of the issues table, i.e. id,title,type,status,created_date, plus existing customized fields. Issues are upserted by id, columns not in the file and the raw data origin of issues collected by other plugins are kept on existing issues
+// @Tags 		 plugins/customize
+// @Accept       multipart/form-data
+// @Param        table path string true "only issues is supported"
+// @Param        boardId formData string true "the board the issues belong to, created if not exists"
+// @Param        boardName formData string false "name of the board when it is created"
+// @Param        file formData file true "select file to upload"
+// @Produce      json
+// @Success      200  {object} csvImportResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/customize/{table}/import.csv [put]
+func (h *Handlers) ImportIssuesCsv(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	if input.Params["table"] != "issues" {
+		return nil, errors.BadInput.New("only issues can be imported")
+	}
+	header, records, err := readCsv(input.Request)
+	if err != nil {
+		return nil, err
+	}
+	boardId := strings.TrimSpace(input.Request.FormValue("boardId"))
+	if boardId == "" {
+		return nil, errors.BadInput.New("boardId is required")
+	}
+	if !containsString(header, "id") {
+		return nil, errors.BadInput.New("id column is required")
+	}
+	issueColumns := entityColumns(reflect.TypeOf(ticket.Issue{}))
+	var fieldColumns []string
+	for _, column := range header {
+		if _, ok := issueColumns[column]; ok {
+			continue
+		}
+		exists, err := checkField(h.dal, "issues", column)
+		if err != nil || !exists {
+			return nil, errors.BadInput.New(fmt.Sprintf("%s is neither a column nor a customized field of issues", column))
+		}
+		fieldColumns = append(fieldColumns, column)
+	}
+
+	err = h.dal.AutoMigrate(&helper.RawData{}, dal.From(RAW_ISSUES_CSV_TABLE))
+	if err != nil {
+		return nil, err
+	}
+	boardName := input.Request.FormValue("boardName")
+	if boardName == "" {
+		boardName = boardId
+	}
+	fileName := ""
+	if fh := input.Request.MultipartForm.File["file"]; len(fh) > 0 {
+		fileName = fh[0].Filename
+	}
+	err = importIssues(h.dal, &ticket.Board{
+		DomainEntity: domainlayer.DomainEntity{Id: boardId},
+		Name:         boardName,
+	}, fileName, header, records, fieldColumns)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: &csvImportResult{Rows: len(records)}, Status: http.StatusOK}, nil
+}
+
+// importIssues upserts the issues in a transaction, only the columns in the csv file are updated on existing issues
+func importIssues(d dal.Dal, board *ticket.Board, fileName string, header []string, records [][]string, fieldColumns []string) (err errors.Error) {
+	tx := d.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	err = tx.CreateIfNotExist(board)
+	if err != nil {
+		return err
+	}
+	params, _ := json.Marshal(map[string]string{"BoardId": board.Id})
+	for _, record := range records {
+		values := make(map[string]string)
+		for i, column := range header {
+			values[column] = record[i]
+		}
+		if strings.TrimSpace(values["id"]) == "" {
+			return errors.BadInput.New("id must not be empty")
+		}
+		data, _ := json.Marshal(values)
+		raw := &helper.RawData{Params: string(params), Data: data, Url: fileName, CreatedAt: time.Now()}
+		err = tx.Create(raw, dal.From(RAW_ISSUES_CSV_TABLE))
+		if err != nil {
+			return err
+		}
+		issue := &ticket.Issue{}
+		err = tx.First(issue, dal.Where("id = ?", values["id"]))
+		if err != nil && !tx.IsErrorNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		err = setEntityColumns(issue, values)
+		if err != nil {
+			return err
+		}
+		csvOrigin := common.RawDataOrigin{RawDataTable: RAW_ISSUES_CSV_TABLE, RawDataParams: string(params), RawDataId: raw.ID}
+		// issues collected by other plugins keep their origin, otherwise they would be deleted or left
+		// behind by the next collection of those plugins which is scoped by the raw data params
+		if !exists || issue.RawDataTable == RAW_ISSUES_CSV_TABLE {
+			issue.RawDataOrigin = csvOrigin
+		}
+		if exists {
+			err = tx.Update(issue)
+		} else {
+			err = tx.Create(issue)
+		}
+		if err != nil {
+			return err
+		}
+		boardIssue := &ticket.BoardIssue{BoardId: board.Id, IssueId: issue.Id}
+		boardIssue.RawDataOrigin = csvOrigin
+		err = tx.CreateOrUpdate(boardIssue)
+		if err != nil {
+			return err
+		}
+		if len(fieldColumns) > 0 {
+			err = updateCustomizedFields(tx, "issues", header, record, []string{"id"}, fieldColumns)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// readCsv reads the uploaded file, the first line is the header
+func readCsv(r *http.Request) ([]string, [][]string, errors.Error) {
+	if r == nil {
+		return nil, nil, errors.BadInput.New("a multipart/form-data request is required")
+	}
+	if r.MultipartForm == nil {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return nil, nil, errors.BadInput.Wrap(err, "failed to parse the form")
+		}
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, nil, errors.BadInput.Wrap(err, "file is required")
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.BadInput.New("the csv file is empty")
+	}
+	if err != nil {
+		return nil, nil, errors.BadInput.Wrap(err, "invalid csv file")
+	}
+	for i, column := range header {
+		header[i] = strings.TrimSpace(column)
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, errors.BadInput.Wrap(err, "invalid csv file")
+	}
+	return header, records, nil
+}
+
+func updateCustomizedFields(d dal.Dal, table string, header, record, pkColumns, fieldColumns []string) errors.Error {
+	var sets, wheres []string
+	var params []interface{}
+	for i, column := range header {
+		if containsString(fieldColumns, column) {
+			sets = append(sets, fmt.Sprintf("%s = ?", column))
+			params = append(params, record[i])
+		}
+	}
+	for _, pk := range pkColumns {
+		for i, column := range header {
+			if column == pk {
+				wheres = append(wheres, fmt.Sprintf("%s = ?", column))
+				params = append(params, record[i])
+			}
+		}
+	}
+	return d.Exec(fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), strings.Join(wheres, " AND ")), params...)
+}
+
+// entityColumns maps the column names of an entity to the index path of their struct fields,
+// raw data origin and timestamps of the record itself are left out
+func entityColumns(t reflect.Type) map[string][]int {
+	columns := make(map[string][]int)
+	var walk func(t reflect.Type, index []int)
+	walk = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			path := append(append([]int{}, index...), i)
+			if field.Anonymous {
+				if field.Name != "RawDataOrigin" && field.Name != "NoPKModel" {
+					walk(field.Type, path)
+				}
+				continue
+			}
+			column := strcase.ToSnake(field.Name)
+			for _, tag := range strings.Split(field.Tag.Get("gorm"), ";") {
+				if strings.HasPrefix(tag, "column:") {
+					column = strings.TrimPrefix(tag, "column:")
+				}
+			}
+			columns[column] = path
+		}
+	}
+	walk(t, nil)
+	return columns
+}
+
+// setEntityColumns sets the fields of entity from the csv values, keyed by column names
+func setEntityColumns(entity interface{}, values map[string]string) errors.Error {
+	v := reflect.ValueOf(entity).Elem()
+	columns := entityColumns(v.Type())
+	for column, value := range values {
+		path, ok := columns[column]
+		if !ok || value == "" {
+			continue
+		}
+		field := v.FieldByIndex(path)
+		err := setValue(field, value)
+		if err != nil {
+			return errors.BadInput.Wrap(err, fmt.Sprintf("invalid value %s for %s", value, column))
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case int, int64, int32:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case uint64:
+		i, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(i)
+	case float64, float32:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case time.Time:
+		t, err := helper.ConvertStringToTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(t))
+	case *time.Time:
+		t, err := helper.ConvertStringToTime(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(&t))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityColumns(t *testing.T) {
+	columns := entityColumns(reflect.TypeOf(ticket.Issue{}))
+	for _, column := range []string{"id", "title", "story_point", "icon_url", "resolution_date", "original_project"} {
+		assert.Contains(t, columns, column)
+	}
+	for _, column := range []string{"_raw_data_params", "created_at", "raw_data_origin", "domain_entity"} {
+		assert.NotContains(t, columns, column)
+	}
+}
+
+func TestSetEntityColumns(t *testing.T) {
+	issue := &ticket.Issue{}
+	err := setEntityColumns(issue, map[string]string{
+		"id":           "csv:1",
+		"title":        "Estimate the migration",
+		"story_point":  "5",
+		"created_date": "2023-01-02T10:00:00Z",
+		"x_estimate":   "ignored here",
+		"priority":     "",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "csv:1", issue.Id)
+	assert.Equal(t, "Estimate the migration", issue.Title)
+	assert.Equal(t, int64(5), issue.StoryPoint)
+	assert.Equal(t, time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC), issue.CreatedDate.UTC())
+
+	err = setEntityColumns(issue, map[string]string{"story_point": "five"})
+	assert.NotNil(t, err)
+}
+
+func csvRequest(t *testing.T, content string, form map[string]string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for k, v := range form {
+		assert.Nil(t, writer.WriteField(k, v))
+	}
+	part, err := writer.CreateFormFile("file", "issues.csv")
+	assert.Nil(t, err)
+	_, err = part.Write([]byte(content))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	req, err := http.NewRequest(http.MethodPut, "/", body)
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportAndUploadCsv(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		return
+	}
+	d := dalgorm.NewDalgorm(db)
+	assert.Nil(t, d.AutoMigrate(&ticket.Issue{}))
+	assert.Nil(t, d.AutoMigrate(&ticket.Board{}))
+	assert.Nil(t, d.AutoMigrate(&ticket.BoardIssue{}))
+	assert.Nil(t, CreateField(d, "issues", "x_team"))
+	h := NewHandlers(d)
+
+	_, err = h.ImportIssuesCsv(&plugin.ApiResourceInput{
+		Params:  map[string]string{"table": "issues"},
+		Request: csvRequest(t, "id,title,story_point\ncsv:1,Estimate the migration,5\n", map[string]string{"boardId": "csv"}),
+	})
+	assert.Nil(t, err)
+	// columns missing from the second file are kept
+	_, err = h.ImportIssuesCsv(&plugin.ApiResourceInput{
+		Params:  map[string]string{"table": "issues"},
+		Request: csvRequest(t, "id,status,x_team\ncsv:1,DONE,platform\n", map[string]string{"boardId": "csv"}),
+	})
+	assert.Nil(t, err)
+	issue := &ticket.Issue{}
+	assert.Nil(t, d.First(issue, dal.Where("id = ?", "csv:1")))
+	assert.Equal(t, "Estimate the migration", issue.Title)
+	assert.Equal(t, int64(5), issue.StoryPoint)
+	assert.Equal(t, "DONE", issue.Status)
+
+	// unknown ids are not counted
+	output, err := h.UploadFieldsCsv(&plugin.ApiResourceInput{
+		Params:  map[string]string{"table": "issues"},
+		Request: csvRequest(t, "id,x_team\ncsv:1,infra\ncsv:2,infra\n", nil),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, output.Body.(*csvImportResult).Rows)
+
+	// a failing line rolls back the whole file
+	_, err = h.ImportIssuesCsv(&plugin.ApiResourceInput{
+		Params:  map[string]string{"table": "issues"},
+		Request: csvRequest(t, "id,title,story_point\ncsv:1,Renamed,8\ncsv:3,Broken,five\n", map[string]string{"boardId": "csv"}),
+	})
+	assert.NotNil(t, err)
+	issue = &ticket.Issue{}
+	assert.Nil(t, d.First(issue, dal.Where("id = ?", "csv:1")))
+	assert.Equal(t, "Estimate the migration", issue.Title)
+	var team string
+	rows, err := d.Cursor(dal.Select("x_team"), dal.From("issues"), dal.Where("id = ?", "csv:1"))
+	assert.Nil(t, err)
+	defer rows.Close()
+	assert.True(t, rows.Next())
+	assert.Nil(t, rows.Scan(&team))
+	assert.Equal(t, "infra", team)
+
+	// issues collected by other plugins keep their raw data origin
+	collected := &ticket.Issue{DomainEntity: domainlayer.DomainEntity{Id: "jira:JiraIssue:1:1"}, Title: "Collected"}
+	collected.RawDataTable = "_raw_jira_api_issues"
+	collected.RawDataParams = `{"ConnectionId":1,"BoardId":1}`
+	collected.RawDataId = 7
+	assert.Nil(t, d.Create(collected))
+	_, err = h.ImportIssuesCsv(&plugin.ApiResourceInput{
+		Params:  map[string]string{"table": "issues"},
+		Request: csvRequest(t, "id,status\njira:JiraIssue:1:1,DONE\n", map[string]string{"boardId": "csv"}),
+	})
+	assert.Nil(t, err)
+	issue = &ticket.Issue{}
+	assert.Nil(t, d.First(issue, dal.Where("id = ?", "jira:JiraIssue:1:1")))
+	assert.Equal(t, "DONE", issue.Status)
+	assert.Equal(t, collected.RawDataOrigin, issue.RawDataOrigin)
+}
@@ -28,6 +28,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Customize)(nil)
+var _ plugin.PluginCompatibility = (*Customize)(nil)
 var _ plugin.PluginInit = (*Customize)(nil)
 var _ plugin.PluginApi = (*Customize)(nil)
 var _ plugin.PluginModel = (*Customize)(nil)
@@ -48,6 +49,7 @@ func (p Customize) GetTablesInfo() []dal.Tabler {
 func (p Customize) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.ExtractCustomizedFieldsMeta,
+		tasks.DeriveCustomizedFieldsMeta,
 	}
 }
 
@@ -63,6 +65,11 @@ func (p Customize) PrepareTaskData(taskCtx plugin.TaskContext, options map[strin
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "could not decode Jira options")
 	}
+	for _, rule := range op.TransformationRules {
+		if _, err := tasks.CompileDerivations(rule.Derivations); err != nil {
+			return nil, err
+		}
+	}
 	taskData := &tasks.TaskData{
 		Options: &op,
 	}
@@ -73,6 +80,14 @@ func (p Customize) Description() string {
 	return "To customize table fields"
 }
 
+func (p Customize) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Customize) Dependencies() []string {
+	return nil
+}
+
 func (p Customize) RootPkgPath() string {
 	return "github.com/apache/incubator-devlake/plugins/customize"
 }
@@ -86,5 +101,11 @@ func (p *Customize) ApiResources() map[string]map[string]plugin.ApiResourceHandl
 		":table/fields/:field": {
 			"DELETE": p.handlers.DeleteField,
 		},
+		":table/fields.csv": {
+			"PUT": p.handlers.UploadFieldsCsv,
+		},
+		":table/import.csv": {
+			"PUT": p.handlers.ImportIssuesCsv,
+		},
 	}
 }
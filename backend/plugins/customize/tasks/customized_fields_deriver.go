@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/customize/models"
+)
+
+var _ plugin.SubTaskEntryPoint = DeriveCustomizedFields
+
+var DeriveCustomizedFieldsMeta = plugin.SubTaskMeta{Name: "deriveCustomizedFields",
+	EntryPoint:       DeriveCustomizedFields,
+	EnabledByDefault: true,
+	Description:      "derive customized fields by expressions, regexes and lookups into other tables",
+}
+
+// DeriveCustomizedFields computes customized fields from the columns of domain layer tables
+func DeriveCustomizedFields(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TaskData)
+	if data == nil || data.Options == nil {
+		return nil
+	}
+	d := taskCtx.GetDal()
+	lookup := newCachedLookup(d)
+	for _, rule := range data.Options.TransformationRules {
+		if len(rule.Derivations) == 0 {
+			continue
+		}
+		err := deriveCustomizedFields(taskCtx, d, &rule, lookup)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("error deriving customized fields of %s", rule.Table))
+		}
+	}
+	return nil
+}
+
+func deriveCustomizedFields(taskCtx plugin.SubTaskContext, d dal.Dal, rule *MappingRules, lookup LookupFunc) errors.Error {
+	if !identifierPattern.MatchString(rule.Table) {
+		return errors.BadInput.New(fmt.Sprintf("invalid table %s", rule.Table))
+	}
+	derivers, err := CompileDerivations(rule.Derivations)
+	if err != nil {
+		return err
+	}
+	pkFields, err := dal.GetPrimarykeyColumns(d, &models.Table{Name: rule.Table})
+	if err != nil {
+		return err
+	}
+	clauses := []dal.Clause{
+		dal.Select("*"),
+		dal.From(rule.Table),
+	}
+	if rule.RawDataTable != "" {
+		clauses = append(clauses, dal.Where("_raw_data_table = ? AND _raw_data_params = ?", rule.RawDataTable, rule.RawDataParams))
+	}
+	rows, err := d.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	ctx := taskCtx.GetContext()
+	for rows.Next() {
+		select {
+		case <-ctx.Done():
+			return errors.Convert(ctx.Err())
+		default:
+		}
+		row := make(map[string]interface{})
+		err = d.Fetch(rows, &row)
+		if err != nil {
+			return err
+		}
+		normalizeRow(row)
+		updates := make(map[string]interface{})
+		for _, deriver := range derivers {
+			value, err := deriver.derive(row, lookup)
+			if err != nil {
+				return err
+			}
+			if value != nil {
+				updates[deriver.Field] = value
+				row[deriver.Field] = value
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		pk := make(map[string]interface{})
+		for _, field := range pkFields {
+			pk[field.Name()] = row[field.Name()]
+		}
+		query, params := mkUpdate(rule.Table, updates, pk)
+		err = d.Exec(query, params...)
+		if err != nil {
+			return errors.Default.Wrap(err, "Exec SQL error")
+		}
+	}
+	return nil
+}
+
+// maxLookupCacheSize bounds the number of cached lookup results, the cache is emptied once it is full
+const maxLookupCacheSize = 10000
+
+// newCachedLookup returns a LookupFunc querying the database, results are cached for the whole subtask
+func newCachedLookup(d dal.Dal) LookupFunc {
+	cache := make(map[string]interface{})
+	return func(step *LookupStep, v interface{}) (interface{}, errors.Error) {
+		key := fmt.Sprintf("%s.%s.%s=%v", step.Table, step.Key, step.Value, v)
+		if result, ok := cache[key]; ok {
+			return result, nil
+		}
+		if len(cache) >= maxLookupCacheSize {
+			cache = make(map[string]interface{})
+		}
+		var values []string
+		err := d.Pluck(
+			step.Value,
+			&values,
+			dal.From(step.Table),
+			dal.Where(fmt.Sprintf("%s = ?", step.Key), v),
+			dal.Limit(1),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var result interface{}
+		if len(values) > 0 {
+			result = values[0]
+		}
+		cache[key] = result
+		return result, nil
+	}
+}
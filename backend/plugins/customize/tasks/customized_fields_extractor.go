@@ -46,6 +46,10 @@ func ExtractCustomizedFields(taskCtx plugin.SubTaskContext) errors.Error {
 	d := taskCtx.GetDal()
 	var err error
 	for _, rule := range data.Options.TransformationRules {
+		// rules without raw data only have derivations
+		if rule.RawDataTable == "" || len(rule.Mapping) == 0 {
+			continue
+		}
 		err = extractCustomizedFields(taskCtx.GetContext(), d, rule.Table, rule.RawDataTable, rule.RawDataParams, rule.Mapping)
 		if err != nil {
 			return errors.Default.Wrap(err, "error extracting customized fields")
@@ -85,7 +89,7 @@ func extractCustomizedFields(ctx context.Context, d dal.Dal, table, rawTable, ra
 		default:
 		}
 		row := make(map[string]interface{})
-		updates := make(map[string]string)
+		updates := make(map[string]interface{})
 		err = d.Fetch(rows, &row)
 		if err != nil {
 			return err
@@ -117,7 +121,7 @@ func extractCustomizedFields(ctx context.Context, d dal.Dal, table, rawTable, ra
 	return nil
 }
 
-func mkUpdate(table string, updates map[string]string, pk map[string]interface{}) (string, []interface{}) {
+func mkUpdate(table string, updates map[string]interface{}, pk map[string]interface{}) (string, []interface{}) {
 	var params []interface{}
 	stat := fmt.Sprintf("UPDATE %s SET ", table)
 	var uu []string
@@ -0,0 +1,186 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var expressionFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trim":       strings.TrimSpace,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+	"contains":   strings.Contains,
+	"hasPrefix":  strings.HasPrefix,
+}
+
+var domainTables = func() map[string]bool {
+	tables := make(map[string]bool)
+	for _, table := range domaininfo.GetDomainTablesInfo() {
+		tables[table.TableName()] = true
+	}
+	return tables
+}()
+
+// isLookupTable tells whether values could be looked up in the table, which must be a domain table or a tool table
+// other than connections, so secrets and the tables of the framework, i.e. `_devlake_api_keys`, are never exposed
+func isLookupTable(table string) bool {
+	if domainTables[table] {
+		return true
+	}
+	return strings.HasPrefix(table, "_tool_") && !strings.HasSuffix(table, "_connections")
+}
+
+// LookupFunc returns the `value` column of the first row in `table` whose `key` column equals v, or nil
+type LookupFunc func(step *LookupStep, v interface{}) (interface{}, errors.Error)
+
+// fieldDeriver is a compiled Derivation
+type fieldDeriver struct {
+	*Derivation
+	template *template.Template
+	regex    *regexp.Regexp
+	group    int
+}
+
+// CompileDerivations validates the derivations and prepares templates and regexes
+func CompileDerivations(derivations []Derivation) ([]*fieldDeriver, errors.Error) {
+	result := make([]*fieldDeriver, 0, len(derivations))
+	for i := range derivations {
+		d := &fieldDeriver{Derivation: &derivations[i]}
+		if !strings.HasPrefix(d.Field, "x_") || !identifierPattern.MatchString(d.Field) {
+			return nil, errors.BadInput.New(fmt.Sprintf("invalid field %s, customized fields should start with `x_`", d.Field))
+		}
+		switch d.Type {
+		case DERIVATION_EXPRESSION:
+			t, err := template.New(d.Field).Funcs(expressionFuncs).Option("missingkey=error").Parse(d.Expression)
+			if err != nil {
+				return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid expression for %s", d.Field))
+			}
+			d.template = t
+		case DERIVATION_REGEX:
+			if !identifierPattern.MatchString(d.Source) {
+				return nil, errors.BadInput.New(fmt.Sprintf("invalid source column %s for %s", d.Source, d.Field))
+			}
+			re, err := regexp.Compile(d.Pattern)
+			if err != nil {
+				return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid pattern for %s", d.Field))
+			}
+			d.regex = re
+			if named := re.SubexpIndex("value"); named > 0 {
+				d.group = named
+			} else if re.NumSubexp() > 0 {
+				d.group = 1
+			}
+		case DERIVATION_LOOKUP:
+			if !identifierPattern.MatchString(d.Source) {
+				return nil, errors.BadInput.New(fmt.Sprintf("invalid source column %s for %s", d.Source, d.Field))
+			}
+			if len(d.Lookup) == 0 {
+				return nil, errors.BadInput.New(fmt.Sprintf("lookup steps are required for %s", d.Field))
+			}
+			for _, step := range d.Lookup {
+				for _, identifier := range []string{step.Table, step.Key, step.Value} {
+					if !identifierPattern.MatchString(identifier) {
+						return nil, errors.BadInput.New(fmt.Sprintf("invalid lookup step %v for %s", step, d.Field))
+					}
+				}
+				if !isLookupTable(step.Table) {
+					return nil, errors.BadInput.New(fmt.Sprintf("table %s can't be looked up for %s, only domain and tool tables are allowed", step.Table, d.Field))
+				}
+			}
+		default:
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported derivation type %s for %s", d.Type, d.Field))
+		}
+		result = append(result, d)
+	}
+	return result, nil
+}
+
+// derive computes the value of the field from the row, nil means nothing could be derived
+func (d *fieldDeriver) derive(row map[string]interface{}, lookup LookupFunc) (interface{}, errors.Error) {
+	switch d.Type {
+	case DERIVATION_EXPRESSION:
+		// NULL columns render as empty strings instead of "<no value>"
+		data := make(map[string]interface{}, len(row))
+		for k, v := range row {
+			if v == nil {
+				v = ""
+			}
+			data[k] = v
+		}
+		var sb strings.Builder
+		err := d.template.Execute(&sb, data)
+		if err != nil {
+			return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to evaluate expression for %s", d.Field))
+		}
+		return sb.String(), nil
+	case DERIVATION_REGEX:
+		source := toString(row[d.Source])
+		m := d.regex.FindStringSubmatch(source)
+		if m == nil {
+			return nil, nil
+		}
+		return m[d.group], nil
+	case DERIVATION_LOOKUP:
+		v := row[d.Source]
+		for i := range d.Lookup {
+			if v == nil || v == "" {
+				return nil, nil
+			}
+			var err errors.Error
+			v, err = lookup(&d.Lookup[i], v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// normalizeRow turns the []byte values returned by some drivers into strings so templates print them nicely
+func normalizeRow(row map[string]interface{}) {
+	for k, v := range row {
+		if b, ok := v.([]byte); ok {
+			row[k] = string(b)
+		}
+	}
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	default:
+		return fmt.Sprint(s)
+	}
+}
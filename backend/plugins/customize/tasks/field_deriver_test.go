@@ -0,0 +1,100 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFieldDeriver(t *testing.T) {
+	derivers, err := CompileDerivations([]Derivation{
+		{Field: "x_key", Type: DERIVATION_EXPRESSION, Expression: `{{.priority}}-{{lower .type}}`},
+		{Field: "x_ticket", Type: DERIVATION_REGEX, Source: "title", Pattern: `\[(?P<value>[A-Z]+-\d+)\]`},
+		{Field: "x_team", Type: DERIVATION_LOOKUP, Source: "assignee_id", Lookup: []LookupStep{
+			{Table: "user_accounts", Key: "account_id", Value: "user_id"},
+			{Table: "team_users", Key: "user_id", Value: "team_id"},
+		}},
+		{Field: "x_summary", Type: DERIVATION_EXPRESSION, Expression: `{{.x_ticket}}@{{.x_team}}`},
+	})
+	assert.Nil(t, err)
+
+	tables := map[string]map[interface{}]interface{}{
+		"user_accounts": {"jira:1": "user:1"},
+		"team_users":    {"user:1": "platform"},
+	}
+	lookup := func(step *LookupStep, v interface{}) (interface{}, errors.Error) {
+		return tables[step.Table][v], nil
+	}
+	row := map[string]interface{}{
+		"priority":    []byte("P1"),
+		"type":        "BUG",
+		"title":       "[DL-42] fix the login page",
+		"assignee_id": "jira:1",
+	}
+	normalizeRow(row)
+	for _, d := range derivers {
+		v, err := d.derive(row, lookup)
+		assert.Nil(t, err)
+		row[d.Field] = v
+	}
+	assert.Equal(t, "P1-bug", row["x_key"])
+	assert.Equal(t, "DL-42", row["x_ticket"])
+	assert.Equal(t, "platform", row["x_team"])
+	assert.Equal(t, "DL-42@platform", row["x_summary"])
+
+	// nothing to derive
+	v, err := derivers[2].derive(map[string]interface{}{"assignee_id": "jira:2"}, lookup)
+	assert.Nil(t, err)
+	assert.Nil(t, v)
+}
+
+func TestFieldDeriverNullColumns(t *testing.T) {
+	derivers, err := CompileDerivations([]Derivation{
+		{Field: "x_key", Type: DERIVATION_EXPRESSION, Expression: `{{.priority}}-{{.component}}`},
+		{Field: "x_typo", Type: DERIVATION_EXPRESSION, Expression: `{{.priorty}}`},
+	})
+	assert.Nil(t, err)
+	row := map[string]interface{}{
+		"priority":  "P1",
+		"component": nil,
+	}
+	v, err := derivers[0].derive(row, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "P1-", v)
+	// a column missing from the row is a mistake in the expression
+	_, err = derivers[1].derive(row, nil)
+	assert.NotNil(t, err)
+}
+
+func TestCompileDerivationsRejectsInvalidInput(t *testing.T) {
+	invalid := []Derivation{
+		{Field: "team", Type: DERIVATION_EXPRESSION, Expression: "x"},
+		{Field: "x_a", Type: "sql", Expression: "x"},
+		{Field: "x_a", Type: DERIVATION_REGEX, Source: "title", Pattern: "("},
+		{Field: "x_a", Type: DERIVATION_LOOKUP, Source: "id"},
+		{Field: "x_a", Type: DERIVATION_LOOKUP, Source: "id", Lookup: []LookupStep{{Table: "users; DROP TABLE users", Key: "id", Value: "name"}}},
+		{Field: "x_a", Type: DERIVATION_LOOKUP, Source: "id", Lookup: []LookupStep{{Table: "_devlake_api_keys", Key: "id", Value: "api_key"}}},
+		{Field: "x_a", Type: DERIVATION_LOOKUP, Source: "id", Lookup: []LookupStep{{Table: "_tool_github_connections", Key: "id", Value: "token"}}},
+	}
+	for _, d := range invalid {
+		_, err := CompileDerivations([]Derivation{d})
+		assert.NotNil(t, err, "%v should be rejected", d)
+	}
+}
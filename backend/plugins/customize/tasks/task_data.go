@@ -17,11 +17,44 @@ limitations under the License.
 
 package tasks
 
+// MappingRules fills the `x_` columns of a domain layer table. `Mapping` extracts values from the raw table by JSON
+// paths, `Derivations` compute values from the columns of the table itself and other tables. When `RawDataTable`
+// is omitted, only `Derivations` are applied, to all rows of the table.
 type MappingRules struct {
 	Table         string            `json:"table" example:"issues"`
 	RawDataTable  string            `json:"_raw_data_table" example:"_raw_jira_api_issues"`
 	RawDataParams string            `json:"_raw_data_params" example:"{\"ConnectionId\":1,\"BoardId\":8}"`
 	Mapping       map[string]string `json:"mapping" example:"x_text:fields.created"`
+	Derivations   []Derivation      `json:"derivations"`
+}
+
+const (
+	DERIVATION_EXPRESSION = "expression"
+	DERIVATION_REGEX      = "regex"
+	DERIVATION_LOOKUP     = "lookup"
+)
+
+// Derivation computes the value of an `x_` column, derivations of a rule are applied in order so a derivation
+// can use the result of the previous ones
+type Derivation struct {
+	Field string `json:"field" example:"x_team"`
+	// expression, regex or lookup
+	Type string `json:"type" example:"lookup"`
+	// expression: a Go template over the columns of the row, i.e. `{{.priority}}-{{lower .type}}`
+	Expression string `json:"expression"`
+	// regex: the column to match, and the pattern. The named group `value`, or else the first group, is captured
+	Source  string `json:"source" example:"assignee_id"`
+	Pattern string `json:"pattern"`
+	// lookup: starting from the value of `Source`, follow the steps to find the value in other tables
+	Lookup []LookupStep `json:"lookup"`
+}
+
+// LookupStep finds the row of `Table` whose `Key` equals the current value, and takes its `Value` column.
+// `Table` must be a domain table or a tool table other than connections
+type LookupStep struct {
+	Table string `json:"table" example:"user_accounts"`
+	Key   string `json:"key" example:"account_id"`
+	Value string `json:"value" example:"user_id"`
 }
 
 type Options struct {
@@ -43,6 +43,7 @@ type Options struct {
 	Defer          bool     `json:"defer"`
 	NoDefer        bool     `json:"noDefer"`
 	FullRefresh    bool     `json:"fullRefresh"`
+	Tasks          []string `json:"tasks"`
 	ProjectVars    struct {
 		Demokey1 string `json:"demokey1"`
 		Demokey2 string `json:"demokey2"`
@@ -46,6 +46,7 @@ func main() {
 	noDefer := dbtCmd.Flags().BoolP("noDefer", "", false, "dbt no defer")
 	fullRefresh := dbtCmd.Flags().BoolP("fullRefresh", "", false, "dbt full refresh")
 	dbtArgs := dbtCmd.Flags().StringSliceP("args", "a", []string{}, "dbt run args")
+	dbtTasks := dbtCmd.Flags().StringSliceP("tasks", "t", []string{"run"}, "dbt tasks to execute in order: seed, snapshot, run or test")
 	projectVars := make(map[string]string)
 	projectVars["event_min_id"] = "7581"
 	projectVars["event_max_id"] = "7582"
@@ -74,6 +75,7 @@ func main() {
 			"defer":          *deferFlag,
 			"noDefer":        *noDefer,
 			"fullRefresh":    *fullRefresh,
+			"tasks":          *dbtTasks,
 		})
 	}
 	runner.RunCmd(dbtCmd)
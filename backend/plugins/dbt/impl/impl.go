@@ -18,17 +18,23 @@ limitations under the License.
 package impl
 
 import (
+	"fmt"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/dbt/models"
+	"github.com/apache/incubator-devlake/plugins/dbt/models/migrationscripts"
 	"github.com/apache/incubator-devlake/plugins/dbt/tasks"
+	"golang.org/x/exp/slices"
 )
 
 var (
-	_ plugin.PluginMeta  = (*Dbt)(nil)
-	_ plugin.PluginTask  = (*Dbt)(nil)
-	_ plugin.PluginModel = (*Dbt)(nil)
+	_ plugin.PluginMeta          = (*Dbt)(nil)
+	_ plugin.PluginCompatibility = (*Dbt)(nil)
+	_ plugin.PluginTask          = (*Dbt)(nil)
+	_ plugin.PluginModel         = (*Dbt)(nil)
+	_ plugin.PluginMigration     = (*Dbt)(nil)
 )
 
 type Dbt struct{}
@@ -37,6 +43,14 @@ func (p Dbt) Description() string {
 	return "Convert data by dbt"
 }
 
+func (p Dbt) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Dbt) Dependencies() []string {
+	return nil
+}
+
 func (p Dbt) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.GitMeta,
@@ -45,7 +59,15 @@ func (p Dbt) SubTaskMetas() []plugin.SubTaskMeta {
 }
 
 func (plugin Dbt) GetTablesInfo() []dal.Tabler {
-	return []dal.Tabler{}
+	return []dal.Tabler{
+		&models.DbtRunResult{},
+		&models.DbtNode{},
+		&models.DbtNodeDependency{},
+	}
+}
+
+func (p Dbt) MigrationScripts() []plugin.MigrationScript {
+	return migrationscripts.All()
 }
 
 func (p Dbt) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]interface{}) (interface{}, errors.Error) {
@@ -54,16 +76,22 @@ func (p Dbt) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]inte
 	if err != nil {
 		return nil, err
 	}
-	if op.ProjectPath == "" {
-		return nil, errors.Default.New("projectPath is required for dbt plugin")
+	if op.ProjectPath == "" && op.ProjectGitURL == "" {
+		return nil, errors.BadInput.New("projectPath or projectGitURL is required for dbt plugin")
 	}
 
 	if op.ProjectTarget == "" {
 		op.ProjectTarget = "dev"
 	}
+	for _, task := range op.Tasks {
+		if !slices.Contains(tasks.DbtTasks, task) {
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported dbt task %s, should be one of %v", task, tasks.DbtTasks))
+		}
+	}
 
 	return &tasks.DbtTaskData{
-		Options: &op,
+		Options:     &op,
+		ProjectPath: op.ProjectPath,
 	}, nil
 }
 
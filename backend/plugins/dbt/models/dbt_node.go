@@ -0,0 +1,54 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// DbtNode is a model, test, seed, snapshot or source of a dbt project, parsed from `target/manifest.json`
+type DbtNode struct {
+	ProjectName      string `gorm:"primaryKey;type:varchar(255)" json:"projectName"`
+	UniqueId         string `gorm:"primaryKey;type:varchar(255)" json:"uniqueId"`
+	ResourceType     string `gorm:"type:varchar(20)" json:"resourceType"`
+	Name             string `gorm:"type:varchar(255)" json:"name"`
+	PackageName      string `gorm:"type:varchar(255)" json:"packageName"`
+	Database         string `gorm:"type:varchar(255)" json:"database"`
+	Schema           string `gorm:"type:varchar(255)" json:"schema"`
+	Alias            string `gorm:"type:varchar(255)" json:"alias"`
+	Materialized     string `gorm:"type:varchar(50)" json:"materialized"`
+	OriginalFilePath string `gorm:"type:varchar(255)" json:"originalFilePath"`
+	Description      string `json:"description"`
+	common.NoPKModel
+}
+
+func (DbtNode) TableName() string {
+	return "_tool_dbt_nodes"
+}
+
+// DbtNodeDependency is an edge of the dbt lineage graph, `UniqueId` depends on `DependsOnId`
+type DbtNodeDependency struct {
+	ProjectName string `gorm:"primaryKey;type:varchar(255)" json:"projectName"`
+	UniqueId    string `gorm:"primaryKey;type:varchar(255)" json:"uniqueId"`
+	DependsOnId string `gorm:"primaryKey;type:varchar(255)" json:"dependsOnId"`
+	common.NoPKModel
+}
+
+func (DbtNodeDependency) TableName() string {
+	return "_tool_dbt_node_dependencies"
+}
@@ -0,0 +1,46 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package models
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/common"
+)
+
+// DbtRunResult is the result of a node executed by one dbt invocation, parsed from `target/run_results.json`
+type DbtRunResult struct {
+	InvocationId  string     `gorm:"primaryKey;type:varchar(100)" json:"invocationId"`
+	UniqueId      string     `gorm:"primaryKey;type:varchar(255)" json:"uniqueId"`
+	ProjectName   string     `gorm:"type:varchar(255);index" json:"projectName"`
+	Command       string     `gorm:"type:varchar(20)" json:"command"`
+	ResourceType  string     `gorm:"type:varchar(20)" json:"resourceType"`
+	Name          string     `gorm:"type:varchar(255)" json:"name"`
+	Status        string     `gorm:"type:varchar(20);index" json:"status"`
+	ExecutionTime float64    `json:"executionTime"`
+	RowsAffected  *int64     `json:"rowsAffected"`
+	Failures      *int64     `json:"failures"`
+	Message       string     `json:"message"`
+	StartedAt     *time.Time `json:"startedAt"`
+	CompletedAt   *time.Time `json:"completedAt"`
+	common.NoPKModel
+}
+
+func (DbtRunResult) TableName() string {
+	return "_tool_dbt_run_results"
+}
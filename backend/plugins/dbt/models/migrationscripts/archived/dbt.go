@@ -0,0 +1,75 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archived
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/models/migrationscripts/archived"
+)
+
+type DbtRunResult struct {
+	InvocationId  string `gorm:"primaryKey;type:varchar(100)"`
+	UniqueId      string `gorm:"primaryKey;type:varchar(255)"`
+	ProjectName   string `gorm:"type:varchar(255);index"`
+	Command       string `gorm:"type:varchar(20)"`
+	ResourceType  string `gorm:"type:varchar(20)"`
+	Name          string `gorm:"type:varchar(255)"`
+	Status        string `gorm:"type:varchar(20);index"`
+	ExecutionTime float64
+	RowsAffected  *int64
+	Failures      *int64
+	Message       string
+	StartedAt     *time.Time
+	CompletedAt   *time.Time
+	archived.NoPKModel
+}
+
+func (DbtRunResult) TableName() string {
+	return "_tool_dbt_run_results"
+}
+
+type DbtNode struct {
+	ProjectName      string `gorm:"primaryKey;type:varchar(255)"`
+	UniqueId         string `gorm:"primaryKey;type:varchar(255)"`
+	ResourceType     string `gorm:"type:varchar(20)"`
+	Name             string `gorm:"type:varchar(255)"`
+	PackageName      string `gorm:"type:varchar(255)"`
+	Database         string `gorm:"type:varchar(255)"`
+	Schema           string `gorm:"type:varchar(255)"`
+	Alias            string `gorm:"type:varchar(255)"`
+	Materialized     string `gorm:"type:varchar(50)"`
+	OriginalFilePath string `gorm:"type:varchar(255)"`
+	Description      string
+	archived.NoPKModel
+}
+
+func (DbtNode) TableName() string {
+	return "_tool_dbt_nodes"
+}
+
+type DbtNodeDependency struct {
+	ProjectName string `gorm:"primaryKey;type:varchar(255)"`
+	UniqueId    string `gorm:"primaryKey;type:varchar(255)"`
+	DependsOnId string `gorm:"primaryKey;type:varchar(255)"`
+	archived.NoPKModel
+}
+
+func (DbtNodeDependency) TableName() string {
+	return "_tool_dbt_node_dependencies"
+}
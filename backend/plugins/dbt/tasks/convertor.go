@@ -22,217 +22,200 @@ import (
 	"encoding/json"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
-	"net"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/spf13/viper"
 )
 
 func DbtConverter(taskCtx plugin.SubTaskContext) errors.Error {
 	logger := taskCtx.GetLogger()
 	taskCtx.SetProgress(0, -1)
 	data := taskCtx.GetData().(*DbtTaskData)
-	models := data.Options.SelectedModels
-	projectPath := data.Options.ProjectPath
-	projectName := data.Options.ProjectName
-	projectTarget := data.Options.ProjectTarget
-	projectVars := data.Options.ProjectVars
-	args := data.Options.Args
-	failFast := data.Options.FailFast
-	noVersionCheck := data.Options.NoVersionCheck
-	excludeModels := data.Options.ExcludeModels
-	selector := data.Options.Selector
-	state := data.Options.State
-	deferFlag := data.Options.Defer
-	noDefer := data.Options.NoDefer
-	fullRefresh := data.Options.FullRefresh
-	profilesPath := data.Options.ProfilesPath
-	profile := data.Options.Profile
+	// remove the cloned project after all done
+	if data.WorkDir != "" {
+		defer func() {
+			if err := os.RemoveAll(data.WorkDir); err != nil {
+				logger.Warn(err, "failed to remove dbt working directory %s", data.WorkDir)
+			}
+		}()
+	}
+	projectPath := data.ProjectPath
+	if projectPath == "" {
+		projectPath = data.Options.ProjectPath
+	}
 
-	defaultProfilesPath := filepath.Join(projectPath, "profiles.yml")
-	_, err := errors.Convert01(os.Stat(defaultProfilesPath))
-	// if profiles.yml not exist, create it manually
-	if err != nil {
-		dbUrl := taskCtx.GetConfig("DB_URL")
-		u, err := errors.Convert01(url.Parse(dbUrl))
+	profilesPath := data.Options.ProfilesPath
+	var env []string
+	if profilesPath == "" {
+		profilesPath = projectPath
+		_, err := errors.Convert01(os.Stat(filepath.Join(projectPath, "profiles.yml")))
+		// if profiles.yml not exist, generate one without credentials in it
 		if err != nil {
-			return err
-		}
-		dbType := u.Scheme
-		dbUsername := u.User.Username()
-		dbPassword, _ := u.User.Password()
-		dbServer, dbPort, _ := net.SplitHostPort(u.Host)
-		dbDataBase := u.Path[1:]
-		var dbSchema string
-		flag := strings.Compare(dbType, "mysql")
-		if flag == 0 {
-			// mysql database
-			dbSchema = dbDataBase
-		} else {
-			// other database
-			mapQuery, err := errors.Convert01(url.ParseQuery(u.RawQuery))
+			profilesPath, env, err = generateDbtProfile(taskCtx.GetConfig("DB_URL"), data.Options.ProjectName, data.Options.ProjectTarget)
 			if err != nil {
 				return err
 			}
-			if value, ok := mapQuery["search_path"]; ok {
-				if len(value) < 1 {
-					return errors.Default.New("DB_URL search_path parses error")
-				}
-				dbSchema = value[0]
-			} else {
-				dbSchema = "public"
-			}
-		}
-		config := viper.New()
-		config.Set(projectName+".target", projectTarget)
-		config.Set(projectName+".outputs."+projectTarget+".type", dbType)
-		dbPortInt, _ := strconv.Atoi(dbPort)
-		config.Set(projectName+".outputs."+projectTarget+".port", dbPortInt)
-		config.Set(projectName+".outputs."+projectTarget+".password", dbPassword)
-		config.Set(projectName+".outputs."+projectTarget+".schema", dbSchema)
-		if flag == 0 {
-			config.Set(projectName+".outputs."+projectTarget+".server", dbServer)
-			config.Set(projectName+".outputs."+projectTarget+".username", dbUsername)
-			config.Set(projectName+".outputs."+projectTarget+".database", dbDataBase)
-		} else {
-			config.Set(projectName+".outputs."+projectTarget+".host", dbServer)
-			config.Set(projectName+".outputs."+projectTarget+".user", dbUsername)
-			config.Set(projectName+".outputs."+projectTarget+".dbname", dbDataBase)
-		}
-		err = errors.Convert(config.WriteConfigAs(defaultProfilesPath))
-		if err != nil {
-			return err
+			defer os.RemoveAll(profilesPath)
 		}
 	}
+	env = append(os.Environ(), env...)
+
 	// if package.yml exist, install dbt dependencies
 	defaultPackagesPath := filepath.Join(projectPath, "packages.yml")
-	_, err = errors.Convert01(os.Stat(defaultPackagesPath))
+	_, err := errors.Convert01(os.Stat(defaultPackagesPath))
 	if err == nil {
-		cmdDeps := exec.Command("dbt", "deps")
+		cmdDeps := exec.Command("dbt", "deps", "--project-dir", projectPath, "--profiles-dir", profilesPath)
+		cmdDeps.Env = env
 		logger.Info("dbt deps run script: ", cmdDeps)
-		// prevent zombie process
-		defer func() {
-			if err := errors.Convert(cmdDeps.Wait()); err != nil {
-				logger.Error(nil, "dbt deps run cmd.cmdDeps() error")
-			}
-		}()
-		if err = errors.Convert(cmdDeps.Start()); err != nil {
-			return err
+		out, err := cmdDeps.CombinedOutput()
+		if err != nil {
+			logger.Error(err, "dbt deps run cmd.cmdDeps() error: %s", string(out))
+			return errors.Convert(err)
 		}
+	}
 
+	dbtTasks := data.Options.Tasks
+	if len(dbtTasks) == 0 {
+		dbtTasks = []string{DBT_TASK_RUN}
 	}
-	//set default threads = 1, prevent dbt threads can not release, so occur zombie process
-	dbtExecParams := []string{"dbt", "run", "--project-dir", projectPath, "--threads", "1"}
-	if projectVars != nil {
-		jsonProjectVars, err := json.Marshal(projectVars)
+	for _, dbtTask := range dbtTasks {
+		err = runDbt(taskCtx, dbtTask, projectPath, profilesPath, env)
 		if err != nil {
-			return errors.Default.New("parameters vars json marshal error")
+			return err
 		}
-		dbtExecParams = append(dbtExecParams, "--vars")
-		dbtExecParams = append(dbtExecParams, string(jsonProjectVars))
 	}
-	if len(models) > 0 {
-		dbtExecParams = append(dbtExecParams, "--select")
-		dbtExecParams = append(dbtExecParams, models...)
+	return nil
+}
+
+// dbtExecParams builds the command line of the dbt task
+func dbtExecParams(dbtTask string, projectPath string, profilesPath string, options *DbtOptions) ([]string, errors.Error) {
+	threads := options.Threads
+	if threads <= 0 {
+		//set default threads = 1, prevent dbt threads can not release, so occur zombie process
+		threads = 1
+	}
+	params := []string{"dbt", dbtTask, "--project-dir", projectPath, "--profiles-dir", profilesPath, "--threads", strconv.Itoa(threads)}
+	if options.ProjectVars != nil {
+		jsonProjectVars, err := json.Marshal(options.ProjectVars)
+		if err != nil {
+			return nil, errors.Default.New("parameters vars json marshal error")
+		}
+		params = append(params, "--vars", string(jsonProjectVars))
 	}
-	if args != nil {
-		dbtExecParams = append(dbtExecParams, args...)
+	if len(options.SelectedModels) > 0 {
+		params = append(params, "--select")
+		params = append(params, options.SelectedModels...)
 	}
-	if failFast {
-		dbtExecParams = append(dbtExecParams, "--fail-fast")
+	// the deprecated args are meant for dbt run only
+	if options.Args != nil && dbtTask == DBT_TASK_RUN {
+		params = append(params, options.Args...)
 	}
-	if noVersionCheck {
-		dbtExecParams = append(dbtExecParams, "--no-version-check")
+	if options.FailFast {
+		params = append(params, "--fail-fast")
 	}
-	if excludeModels != nil {
-		dbtExecParams = append(dbtExecParams, "--exclude")
-		dbtExecParams = append(dbtExecParams, excludeModels...)
+	if options.NoVersionCheck {
+		params = append(params, "--no-version-check")
 	}
-	if selector != "" {
-		dbtExecParams = append(dbtExecParams, "--selector")
-		dbtExecParams = append(dbtExecParams, selector)
+	if len(options.ExcludeModels) > 0 {
+		params = append(params, "--exclude")
+		params = append(params, options.ExcludeModels...)
 	}
-	if state != "" {
-		dbtExecParams = append(dbtExecParams, "--state")
-		dbtExecParams = append(dbtExecParams, state)
+	if options.Selector != "" {
+		params = append(params, "--selector", options.Selector)
 	}
-	if deferFlag {
-		dbtExecParams = append(dbtExecParams, "--defer")
+	if options.State != "" {
+		params = append(params, "--state", options.State)
 	}
-	if noDefer {
-		dbtExecParams = append(dbtExecParams, "--no-defer")
+	if options.Defer && dbtTask != DBT_TASK_SEED {
+		params = append(params, "--defer")
 	}
-	if fullRefresh {
-		dbtExecParams = append(dbtExecParams, "--full-refresh")
+	if options.NoDefer && dbtTask != DBT_TASK_SEED {
+		params = append(params, "--no-defer")
 	}
-	if profilesPath != "" {
-		dbtExecParams = append(dbtExecParams, "--profiles-dir")
-		dbtExecParams = append(dbtExecParams, profilesPath)
-	} else {
-		// default projectPath
-		dbtExecParams = append(dbtExecParams, "--profiles-dir")
-		dbtExecParams = append(dbtExecParams, projectPath)
+	if options.FullRefresh && (dbtTask == DBT_TASK_RUN || dbtTask == DBT_TASK_SEED) {
+		params = append(params, "--full-refresh")
+	}
+	if options.Profile != "" {
+		params = append(params, "--profile", options.Profile)
 	}
-	if profile != "" {
-		dbtExecParams = append(dbtExecParams, "--profile")
-		dbtExecParams = append(dbtExecParams, profile)
+	return params, nil
+}
+
+// runDbt executes one dbt task and collects its results
+func runDbt(taskCtx plugin.SubTaskContext, dbtTask string, projectPath string, profilesPath string, env []string) errors.Error {
+	logger := taskCtx.GetLogger()
+	data := taskCtx.GetData().(*DbtTaskData)
+	params, err := dbtExecParams(dbtTask, projectPath, profilesPath, data.Options)
+	if err != nil {
+		return err
 	}
-	cmd := exec.Command(dbtExecParams[0], dbtExecParams[1:]...)
-	logger.Info("dbt run script: ", cmd)
+	cmd := exec.Command(params[0], params[1:]...)
+	cmd.Env = env
+	logger.Info("dbt %s script: %v", dbtTask, cmd)
 
 	stdout, stdoutErr := cmd.StdoutPipe()
 	if stdoutErr != nil {
 		return errors.Convert(stdoutErr)
 	}
 
+	removeDbtArtifacts(projectPath)
 	if err = errors.Convert(cmd.Start()); err != nil {
 		return err
 	}
 
-	// prevent zombie process
-	defer func() {
-		err := errors.Convert(cmd.Wait())
-		if err != nil {
-			logger.Error(err, "The DBT project run failed!")
-		} else {
-			logger.Info("The DBT project run ended.")
-		}
-	}()
-
+	taskCtx.SetProgress(0, -1)
 	scanner := bufio.NewScanner(stdout)
 	var errStr string
+	finished := 0
 	for scanner.Scan() {
 		line := scanner.Text()
 		logger.Info(line)
 		if strings.Contains(line, "Encountered an error") || errStr != "" {
 			errStr += line + "\n"
 		}
-		if strings.Contains(line, "of") && strings.Contains(line, "OK") {
-			taskCtx.IncProgress(1)
+		// i.e. `1 of 5 OK created sql table model ...` or `2 of 5 FAIL 1 not_null_issues_id ...`
+		if matches := dbtProgressPattern.FindStringSubmatch(line); matches != nil {
+			total, _ := strconv.Atoi(matches[1])
+			finished++
+			taskCtx.SetProgress(finished, total)
 		}
 	}
 	if err := errors.Convert(scanner.Err()); err != nil {
 		logger.Error(err, "dbt read stdout failed.")
+		_ = cmd.Wait()
 		return err
 	}
 
-	// close stdout
-	if closeErr := stdout.Close(); closeErr != nil && err == nil {
-		logger.Error(closeErr, "dbt close stdout failed.")
-		return errors.Convert(closeErr)
+	// wait for the process to exit to prevent zombie process, dbt exits with non-zero code if any node failed
+	runErr := errors.Convert(cmd.Wait())
+	if runErr != nil {
+		logger.Error(runErr, "The DBT project %s failed!", dbtTask)
+	} else {
+		logger.Info("The DBT project %s ended.", dbtTask)
 	}
 
-	return nil
+	results, err := collectDbtResults(taskCtx, projectPath, data.Options.ProjectName)
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		if runErr != nil {
+			return errors.Default.Wrap(runErr, errStr)
+		}
+		return nil
+	}
+	taskCtx.SetProgress(len(results), len(results))
+	return checkDbtResults(logger, results, data.Options.FailFast)
 }
 
+var dbtProgressPattern = regexp.MustCompile(`\d+ of (\d+) (OK|PASS|WARN|ERROR|FAIL|SKIP)\b`)
+
 var DbtConverterMeta = plugin.SubTaskMeta{
 	Name:             "DbtConverter",
 	EntryPoint:       DbtConverter,
 	EnabledByDefault: true,
-	Description:      "Convert data by dbt",
+	Description:      "Convert data by dbt and collect the run results",
 }
@@ -0,0 +1,262 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/log"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/dbt/models"
+)
+
+const (
+	DBT_STATUS_ERROR         = "error"
+	DBT_STATUS_FAIL          = "fail"
+	DBT_STATUS_WARN          = "warn"
+	DBT_STATUS_RUNTIME_ERROR = "runtime error"
+)
+
+// dbtRunResults is the subset of `run_results.json` we care about
+type dbtRunResults struct {
+	Metadata struct {
+		InvocationId string `json:"invocation_id"`
+	} `json:"metadata"`
+	Args struct {
+		Which string `json:"which"`
+	} `json:"args"`
+	Results []struct {
+		UniqueId        string  `json:"unique_id"`
+		Status          string  `json:"status"`
+		ExecutionTime   float64 `json:"execution_time"`
+		Message         string  `json:"message"`
+		Failures        *int64  `json:"failures"`
+		AdapterResponse struct {
+			RowsAffected *int64 `json:"rows_affected"`
+		} `json:"adapter_response"`
+		Timing []struct {
+			Name        string     `json:"name"`
+			StartedAt   *time.Time `json:"started_at"`
+			CompletedAt *time.Time `json:"completed_at"`
+		} `json:"timing"`
+	} `json:"results"`
+}
+
+// dbtManifestNode is the subset of a node or source in `manifest.json` we care about
+type dbtManifestNode struct {
+	UniqueId         string `json:"unique_id"`
+	ResourceType     string `json:"resource_type"`
+	Name             string `json:"name"`
+	PackageName      string `json:"package_name"`
+	Database         string `json:"database"`
+	Schema           string `json:"schema"`
+	Alias            string `json:"alias"`
+	OriginalFilePath string `json:"original_file_path"`
+	Description      string `json:"description"`
+	Config           struct {
+		Materialized string `json:"materialized"`
+	} `json:"config"`
+	DependsOn struct {
+		Nodes []string `json:"nodes"`
+	} `json:"depends_on"`
+}
+
+type dbtManifest struct {
+	Nodes   map[string]*dbtManifestNode `json:"nodes"`
+	Sources map[string]*dbtManifestNode `json:"sources"`
+}
+
+// removeDbtArtifacts deletes the artifacts of the previous invocation, so stale results would never be collected
+func removeDbtArtifacts(projectPath string) {
+	for _, name := range []string{"run_results.json", "manifest.json"} {
+		_ = os.Remove(filepath.Join(projectPath, "target", name))
+	}
+}
+
+// parseDbtRunResults converts `run_results.json` into DbtRunResults, the names and resource types are looked up
+// from the manifest if available
+func parseDbtRunResults(content []byte, projectName string, manifest *dbtManifest) ([]*models.DbtRunResult, errors.Error) {
+	runResults := &dbtRunResults{}
+	err := errors.Convert(json.Unmarshal(content, runResults))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to parse run_results.json")
+	}
+	results := make([]*models.DbtRunResult, 0, len(runResults.Results))
+	for _, r := range runResults.Results {
+		result := &models.DbtRunResult{
+			InvocationId:  runResults.Metadata.InvocationId,
+			UniqueId:      r.UniqueId,
+			ProjectName:   projectName,
+			Command:       runResults.Args.Which,
+			Status:        r.Status,
+			ExecutionTime: r.ExecutionTime,
+			RowsAffected:  r.AdapterResponse.RowsAffected,
+			Failures:      r.Failures,
+			Message:       r.Message,
+		}
+		// unique_id is formatted as `<resource_type>.<package>.<name>`
+		parts := strings.Split(r.UniqueId, ".")
+		result.ResourceType = parts[0]
+		result.Name = parts[len(parts)-1]
+		if manifest != nil {
+			if node, ok := manifest.Nodes[r.UniqueId]; ok {
+				result.ResourceType = node.ResourceType
+				result.Name = node.Name
+			}
+		}
+		for _, timing := range r.Timing {
+			if result.StartedAt == nil || (timing.StartedAt != nil && timing.StartedAt.Before(*result.StartedAt)) {
+				result.StartedAt = timing.StartedAt
+			}
+			if result.CompletedAt == nil || (timing.CompletedAt != nil && timing.CompletedAt.After(*result.CompletedAt)) {
+				result.CompletedAt = timing.CompletedAt
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// parseDbtManifest converts `manifest.json` into DbtNodes and the lineage between them
+func parseDbtManifest(content []byte, projectName string) (*dbtManifest, []*models.DbtNode, []*models.DbtNodeDependency, errors.Error) {
+	manifest := &dbtManifest{}
+	err := errors.Convert(json.Unmarshal(content, manifest))
+	if err != nil {
+		return nil, nil, nil, errors.Default.Wrap(err, "failed to parse manifest.json")
+	}
+	var nodes []*models.DbtNode
+	var dependencies []*models.DbtNodeDependency
+	for _, manifestNodes := range []map[string]*dbtManifestNode{manifest.Nodes, manifest.Sources} {
+		for uniqueId, n := range manifestNodes {
+			nodes = append(nodes, &models.DbtNode{
+				ProjectName:      projectName,
+				UniqueId:         uniqueId,
+				ResourceType:     n.ResourceType,
+				Name:             n.Name,
+				PackageName:      n.PackageName,
+				Database:         n.Database,
+				Schema:           n.Schema,
+				Alias:            n.Alias,
+				Materialized:     n.Config.Materialized,
+				OriginalFilePath: n.OriginalFilePath,
+				Description:      n.Description,
+			})
+			for _, dependsOnId := range n.DependsOn.Nodes {
+				dependencies = append(dependencies, &models.DbtNodeDependency{
+					ProjectName: projectName,
+					UniqueId:    uniqueId,
+					DependsOnId: dependsOnId,
+				})
+			}
+		}
+	}
+	return manifest, nodes, dependencies, nil
+}
+
+// collectDbtResults saves the artifacts of the last dbt invocation into tool tables, nil would be returned if dbt
+// didn't produce `run_results.json`, i.e. failed before executing any node
+func collectDbtResults(taskCtx plugin.SubTaskContext, projectPath string, projectName string) ([]*models.DbtRunResult, errors.Error) {
+	db := taskCtx.GetDal()
+	var manifest *dbtManifest
+	content, err := os.ReadFile(filepath.Join(projectPath, "target", "manifest.json"))
+	if err == nil {
+		var nodes []*models.DbtNode
+		var dependencies []*models.DbtNodeDependency
+		var e errors.Error
+		manifest, nodes, dependencies, e = parseDbtManifest(content, projectName)
+		if e != nil {
+			return nil, e
+		}
+		e = saveDbtLineage(db, projectName, nodes, dependencies)
+		if e != nil {
+			return nil, e
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Convert(err)
+	}
+
+	content, err = os.ReadFile(filepath.Join(projectPath, "target", "run_results.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	results, e := parseDbtRunResults(content, projectName, manifest)
+	if e != nil {
+		return nil, e
+	}
+	if len(results) > 0 {
+		e = db.CreateOrUpdate(results)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return results, nil
+}
+
+func saveDbtLineage(db dal.Dal, projectName string, nodes []*models.DbtNode, dependencies []*models.DbtNodeDependency) errors.Error {
+	err := db.Delete(&models.DbtNodeDependency{}, dal.Where("project_name = ?", projectName))
+	if err != nil {
+		return err
+	}
+	err = db.Delete(&models.DbtNode{}, dal.Where("project_name = ?", projectName))
+	if err != nil {
+		return err
+	}
+	if len(nodes) > 0 {
+		err = db.CreateOrUpdate(nodes)
+		if err != nil {
+			return err
+		}
+	}
+	if len(dependencies) > 0 {
+		err = db.CreateOrUpdate(dependencies)
+	}
+	return err
+}
+
+// checkDbtResults fails the subtask if any node failed when `failFast` is set, otherwise the failures are only logged
+// as warnings
+func checkDbtResults(logger log.Logger, results []*models.DbtRunResult, failFast bool) errors.Error {
+	var failed []string
+	for _, result := range results {
+		switch result.Status {
+		case DBT_STATUS_ERROR, DBT_STATUS_FAIL, DBT_STATUS_RUNTIME_ERROR:
+			failed = append(failed, result.UniqueId)
+			if failFast {
+				logger.Error(nil, "dbt %s %s: %s", result.ResourceType, result.UniqueId, result.Message)
+			} else {
+				logger.Warn(nil, "dbt %s %s %s: %s", result.ResourceType, result.UniqueId, result.Status, result.Message)
+			}
+		case DBT_STATUS_WARN:
+			logger.Warn(nil, "dbt %s %s warn: %s", result.ResourceType, result.UniqueId, result.Message)
+		}
+	}
+	if failFast && len(failed) > 0 {
+		return errors.Default.New(fmt.Sprintf("dbt nodes failed: %s", strings.Join(failed, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/apache/incubator-devlake/plugins/dbt/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `{
+  "nodes": {
+    "model.lake.issues_summary": {
+      "unique_id": "model.lake.issues_summary",
+      "resource_type": "model",
+      "name": "issues_summary",
+      "package_name": "lake",
+      "schema": "lake",
+      "alias": "issues_summary",
+      "original_file_path": "models/issues_summary.sql",
+      "config": {"materialized": "table"},
+      "depends_on": {"nodes": ["source.lake.lake.issues"]}
+    },
+    "test.lake.not_null_issues_summary_id.1a2b3c": {
+      "unique_id": "test.lake.not_null_issues_summary_id.1a2b3c",
+      "resource_type": "test",
+      "name": "not_null_issues_summary_id",
+      "package_name": "lake",
+      "config": {"materialized": "test"},
+      "depends_on": {"nodes": ["model.lake.issues_summary"]}
+    }
+  },
+  "sources": {
+    "source.lake.lake.issues": {
+      "unique_id": "source.lake.lake.issues",
+      "resource_type": "source",
+      "name": "issues",
+      "package_name": "lake"
+    }
+  }
+}`
+
+const testRunResults = `{
+  "metadata": {"invocation_id": "9c2f7e8a"},
+  "args": {"which": "build"},
+  "results": [
+    {
+      "unique_id": "model.lake.issues_summary",
+      "status": "success",
+      "execution_time": 1.5,
+      "message": "SELECT 42",
+      "failures": null,
+      "adapter_response": {"rows_affected": 42},
+      "timing": [
+        {"name": "compile", "started_at": "2023-01-06T08:00:00.000000Z", "completed_at": "2023-01-06T08:00:00.500000Z"},
+        {"name": "execute", "started_at": "2023-01-06T08:00:00.500000Z", "completed_at": "2023-01-06T08:00:01.500000Z"}
+      ]
+    },
+    {
+      "unique_id": "test.lake.not_null_issues_summary_id.1a2b3c",
+      "status": "fail",
+      "execution_time": 0.2,
+      "message": "Got 3 results, configured to fail if != 0",
+      "failures": 3,
+      "adapter_response": {},
+      "timing": []
+    }
+  ]
+}`
+
+func TestParseDbtArtifacts(t *testing.T) {
+	manifest, nodes, dependencies, err := parseDbtManifest([]byte(testManifest), "lake")
+	assert.Nil(t, err)
+	assert.Len(t, nodes, 3)
+	assert.Len(t, dependencies, 2)
+
+	results, err := parseDbtRunResults([]byte(testRunResults), "lake", manifest)
+	assert.Nil(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	model := results[0]
+	assert.Equal(t, "9c2f7e8a", model.InvocationId)
+	assert.Equal(t, "build", model.Command)
+	assert.Equal(t, "model", model.ResourceType)
+	assert.Equal(t, "issues_summary", model.Name)
+	assert.Equal(t, int64(42), *model.RowsAffected)
+	assert.Nil(t, model.Failures)
+	assert.Equal(t, "2023-01-06T08:00:00Z", model.StartedAt.Format("2006-01-02T15:04:05Z07:00"))
+	assert.Equal(t, "2023-01-06T08:00:01Z", model.CompletedAt.Format("2006-01-02T15:04:05Z07:00"))
+
+	test := results[1]
+	assert.Equal(t, "test", test.ResourceType)
+	assert.Equal(t, "not_null_issues_summary_id", test.Name)
+	assert.Equal(t, DBT_STATUS_FAIL, test.Status)
+	assert.Equal(t, int64(3), *test.Failures)
+	assert.Nil(t, test.RowsAffected)
+}
+
+func TestDbtProgressPattern(t *testing.T) {
+	matches := dbtProgressPattern.FindStringSubmatch("08:00:01  1 of 5 OK created sql table model lake.issues_summary .. [SELECT 42 in 1.50s]")
+	assert.Equal(t, "5", matches[1])
+	matches = dbtProgressPattern.FindStringSubmatch("08:00:02  2 of 5 FAIL 3 not_null_issues_summary_id .. [FAIL 3 in 0.20s]")
+	assert.Equal(t, "5", matches[1])
+	assert.Nil(t, dbtProgressPattern.FindStringSubmatch("08:00:00  1 of 5 START sql table model lake.issues_summary"))
+}
+
+func TestCheckDbtResults(t *testing.T) {
+	results := []*models.DbtRunResult{
+		{UniqueId: "model.lake.issues_summary", ResourceType: "model", Status: "success"},
+		{UniqueId: "test.lake.not_null_issues_id", ResourceType: "test", Status: DBT_STATUS_FAIL, Message: "Got 1 result"},
+	}
+	assert.NotNil(t, checkDbtResults(logruslog.Global, results, true))
+	assert.Nil(t, checkDbtResults(logruslog.Global, results, false))
+	assert.Nil(t, checkDbtResults(logruslog.Global, results[:1], true))
+}
@@ -20,7 +20,9 @@ package tasks
 import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"os"
 	"os/exec"
+	"path/filepath"
 )
 
 func Git(taskCtx plugin.SubTaskContext) errors.Error {
@@ -29,14 +31,25 @@ func Git(taskCtx plugin.SubTaskContext) errors.Error {
 	if data.Options.ProjectGitURL == "" {
 		return nil
 	}
-	cmd := exec.Command("git", "clone", data.Options.ProjectGitURL)
+	// clone into an isolated working directory, so concurrent pipelines would not step on each other
+	workDir, err := os.MkdirTemp("", "devlake-dbt-")
+	if err != nil {
+		return errors.Convert(err)
+	}
+	cmd := exec.Command("git", "clone", "--depth", "1", data.Options.ProjectGitURL, workDir)
 	logger.Info("start clone dbt project: %v", cmd)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		logger.Error(err, "clone dbt project failed")
+		logger.Error(err, "clone dbt project failed: %s", string(out))
+		_ = os.RemoveAll(workDir)
 		return errors.Convert(err)
 	}
 	logger.Info("clone dbt project success: %v", string(out))
+	data.WorkDir = workDir
+	data.ProjectPath = workDir
+	if data.Options.ProjectPath != "" && !filepath.IsAbs(data.Options.ProjectPath) {
+		data.ProjectPath = filepath.Join(workDir, data.Options.ProjectPath)
+	}
 	return nil
 }
 
@@ -0,0 +1,121 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/viper"
+)
+
+// environment variables referenced by the generated profiles.yml, dbt scrubs the values of `DBT_ENV_SECRET_` prefixed
+// variables from its logs
+const (
+	DBT_ENV_HOST     = "DBT_DEVLAKE_HOST"
+	DBT_ENV_PORT     = "DBT_DEVLAKE_PORT"
+	DBT_ENV_USER     = "DBT_DEVLAKE_USER"
+	DBT_ENV_PASSWORD = "DBT_ENV_SECRET_DEVLAKE_PASSWORD"
+	DBT_ENV_DATABASE = "DBT_DEVLAKE_DATABASE"
+	DBT_ENV_SCHEMA   = "DBT_DEVLAKE_SCHEMA"
+)
+
+func envVar(name string) string {
+	return "{{ env_var('" + name + "') }}"
+}
+
+// generateDbtProfile writes a profiles.yml connecting to the database of DevLake into a temporary directory. The
+// credentials are not written into the file, they are passed to dbt by the returned environment variables instead.
+// The caller is responsible for removing the returned directory.
+func generateDbtProfile(dbUrl string, projectName string, projectTarget string) (string, []string, errors.Error) {
+	u, err := errors.Convert01(url.Parse(dbUrl))
+	if err != nil {
+		return "", nil, err
+	}
+	var dbType string
+	switch strings.ToLower(u.Scheme) {
+	case "mysql":
+		dbType = "mysql"
+	case "postgresql", "postgres", "pg":
+		dbType = "postgres"
+	default:
+		return "", nil, errors.BadInput.New("profiles.yml can only be generated for mysql and postgres, please provide profilesPath")
+	}
+	dbUsername := u.User.Username()
+	dbPassword, _ := u.User.Password()
+	dbServer, dbPort, _ := net.SplitHostPort(u.Host)
+	dbDataBase := strings.TrimPrefix(u.Path, "/")
+	var dbSchema string
+	if dbType == "mysql" {
+		// mysql database
+		dbSchema = dbDataBase
+	} else {
+		// other database
+		mapQuery, err := errors.Convert01(url.ParseQuery(u.RawQuery))
+		if err != nil {
+			return "", nil, err
+		}
+		if value, ok := mapQuery["search_path"]; ok {
+			if len(value) < 1 {
+				return "", nil, errors.Default.New("DB_URL search_path parses error")
+			}
+			dbSchema = value[0]
+		} else {
+			dbSchema = "public"
+		}
+	}
+	env := []string{
+		DBT_ENV_HOST + "=" + dbServer,
+		DBT_ENV_PORT + "=" + dbPort,
+		DBT_ENV_USER + "=" + dbUsername,
+		DBT_ENV_PASSWORD + "=" + dbPassword,
+		DBT_ENV_DATABASE + "=" + dbDataBase,
+		DBT_ENV_SCHEMA + "=" + dbSchema,
+	}
+
+	outputs := projectName + ".outputs." + projectTarget
+	config := viper.New()
+	config.Set(projectName+".target", projectTarget)
+	config.Set(outputs+".type", dbType)
+	config.Set(outputs+".port", "{{ env_var('"+DBT_ENV_PORT+"') | as_number }}")
+	config.Set(outputs+".password", envVar(DBT_ENV_PASSWORD))
+	config.Set(outputs+".schema", envVar(DBT_ENV_SCHEMA))
+	if dbType == "mysql" {
+		config.Set(outputs+".server", envVar(DBT_ENV_HOST))
+		config.Set(outputs+".username", envVar(DBT_ENV_USER))
+		config.Set(outputs+".database", envVar(DBT_ENV_DATABASE))
+	} else {
+		config.Set(outputs+".host", envVar(DBT_ENV_HOST))
+		config.Set(outputs+".user", envVar(DBT_ENV_USER))
+		config.Set(outputs+".dbname", envVar(DBT_ENV_DATABASE))
+	}
+	profilesDir, e := os.MkdirTemp("", "devlake-dbt-profiles-")
+	if e != nil {
+		return "", nil, errors.Convert(e)
+	}
+	err = errors.Convert(config.WriteConfigAs(filepath.Join(profilesDir, "profiles.yml")))
+	if err != nil {
+		_ = os.RemoveAll(profilesDir)
+		return "", nil, err
+	}
+	return profilesDir, env, nil
+}
@@ -17,11 +17,22 @@ limitations under the License.
 
 package tasks
 
+// dbt commands supported by `DbtOptions.Tasks`
+const (
+	DBT_TASK_SEED     = "seed"
+	DBT_TASK_SNAPSHOT = "snapshot"
+	DBT_TASK_RUN      = "run"
+	DBT_TASK_TEST     = "test"
+)
+
+var DbtTasks = []string{DBT_TASK_SEED, DBT_TASK_SNAPSHOT, DBT_TASK_RUN, DBT_TASK_TEST}
+
 type DbtOptions struct {
+	// the directory of the dbt project, relative to the cloned repository if projectGitURL is not empty
 	ProjectPath   string `json:"projectPath"`
 	ProjectName   string `json:"projectName"`
 	ProjectTarget string `json:"projectTarget"`
-	// clone from git to projectPath if projectGitURL is not empty
+	// clone from git into an isolated working directory if projectGitURL is not empty
 	ProjectGitURL  string                 `json:"projectGitURL"`
 	ProjectVars    map[string]interface{} `json:"projectVars"`
 	SelectedModels []string               `json:"selectedModels"`
@@ -37,10 +48,15 @@ type DbtOptions struct {
 	NoDefer        bool                   `json:"noDefer"`
 	FullRefresh    bool                   `json:"fullRefresh"`
 	// deprecated, dbt run args
-	Args  []string `json:"args"`
+	Args []string `json:"args"`
+	// dbt commands to execute in order, any of seed, snapshot, run and test, default is run
 	Tasks []string `json:"tasks,omitempty"`
 }
 
 type DbtTaskData struct {
 	Options *DbtOptions
+	// ProjectPath is the resolved directory of the dbt project
+	ProjectPath string
+	// WorkDir is the temporary directory the project cloned into, it would be removed after dbt finished
+	WorkDir string
 }
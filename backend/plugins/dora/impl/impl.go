@@ -28,6 +28,7 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*Dora)(nil)
+var _ plugin.PluginCompatibility = (*Dora)(nil)
 var _ plugin.PluginTask = (*Dora)(nil)
 var _ plugin.PluginModel = (*Dora)(nil)
 var _ plugin.PluginMetric = (*Dora)(nil)
@@ -40,6 +41,14 @@ func (p Dora) Description() string {
 	return "collect some Dora data"
 }
 
+func (p Dora) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Dora) Dependencies() []string {
+	return nil
+}
+
 func (p Dora) Dashboards() []plugin.GrafanaDashboard {
 	return nil
 }
@@ -41,6 +41,9 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err := api.Decode(input.Body, &params, vld); err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not decode request parameters")
 	}
+	if err := connectionHelper.RejectSecretReferences(&params); err != nil {
+		return nil, err
+	}
 	authApiClient, err := api.NewApiClient(context.TODO(), params.Endpoint, nil, 0, params.Proxy, basicRes)
 	if err != nil {
 		return nil, err
@@ -111,11 +114,11 @@ func PatchConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/feishu/connections/{connectionId} [DELETE]
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.FeishuConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -145,7 +148,7 @@ func ListConnections(_ *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, err
 // @Router /plugins/feishu/connections/{connectionId} [GET]
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.FeishuConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
@@ -32,6 +32,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Feishu)(nil)
+var _ plugin.PluginCompatibility = (*Feishu)(nil)
 var _ plugin.PluginInit = (*Feishu)(nil)
 var _ plugin.PluginTask = (*Feishu)(nil)
 var _ plugin.PluginApi = (*Feishu)(nil)
@@ -42,8 +43,7 @@ var _ plugin.CloseablePluginTask = (*Feishu)(nil)
 type Feishu struct{}
 
 func (p Feishu) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (plugin Feishu) GetTablesInfo() []dal.Tabler {
@@ -57,6 +57,14 @@ func (p Feishu) Description() string {
 	return "To collect and enrich data from Feishu"
 }
 
+func (p Feishu) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Feishu) Dependencies() []string {
+	return nil
+}
+
 func (p Feishu) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectMeetingTopUserItemMeta,
@@ -70,12 +78,15 @@ func (p Feishu) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]i
 		return nil, err
 	}
 
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	connection := &models.FeishuConnection{}
-	err := connectionHelper.FirstById(connection, op.ConnectionId)
+	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, err
 	}
@@ -24,7 +24,7 @@ import (
 type TestConnectionRequest struct {
 	Endpoint  string `json:"endpoint" validate:"required,url"`
 	AppId     string `mapstructure:"app_id" validate:"required" json:"app_id"`
-	SecretKey string `mapstructure:"secret_key" validate:"required" json:"secret_key"`
+	SecretKey string `mapstructure:"secret_key" validate:"required" json:"secret_key" encrypt:"yes"`
 	Proxy     string `json:"proxy"`
 }
 
@@ -66,6 +66,7 @@ func NewFeishuApiClient(taskCtx plugin.TaskContext, connection *models.FeishuCon
 	// create async api client
 	asyncApiCLient, err := api.CreateAsyncApiClient(taskCtx, apiClient, &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 	})
 	if err != nil {
 		return nil, err
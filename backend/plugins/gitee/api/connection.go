@@ -46,6 +46,9 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err := vld.Struct(connection); err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not validate request parameters")
 	}
+	if err := connectionHelper.RejectSecretReferences(&connection); err != nil {
+		return nil, err
+	}
 	// test connection
 	apiClient, err := helper.NewApiClient(
 		context.TODO(),
@@ -120,11 +123,11 @@ func PatchConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/gitee/connections/{connectionId} [DELETE]
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.GiteeConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -154,6 +157,6 @@ func ListConnections(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/gitee/connections/{connectionId} [GET]
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.GiteeConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
@@ -19,6 +19,7 @@ package api
 
 import (
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/go-playground/validator/v10"
 )
@@ -27,11 +28,13 @@ var vld *validator.Validate
 var connectionHelper *api.ConnectionApiHelper
 var basicRes context.BasicRes
 
-func Init(br context.BasicRes) {
+func Init(br context.BasicRes) errors.Error {
 	basicRes = br
 	vld = validator.New()
-	connectionHelper = api.NewConnectionHelper(
+	var err errors.Error
+	connectionHelper, err = api.NewConnectionHelper(
 		basicRes,
 		vld,
 	)
+	return err
 }
@@ -31,6 +31,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Gitee)(nil)
+var _ plugin.PluginCompatibility = (*Gitee)(nil)
 var _ plugin.PluginInit = (*Gitee)(nil)
 var _ plugin.PluginTask = (*Gitee)(nil)
 var _ plugin.PluginApi = (*Gitee)(nil)
@@ -41,8 +42,7 @@ var _ plugin.CloseablePluginTask = (*Gitee)(nil)
 type Gitee string
 
 func (p Gitee) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Gitee) GetTablesInfo() []dal.Tabler {
@@ -69,6 +69,14 @@ func (p Gitee) Description() string {
 	return "To collect and enrich data from Gitee"
 }
 
+func (p Gitee) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Gitee) Dependencies() []string {
+	return nil
+}
+
 func (p Gitee) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectApiRepoMeta,
@@ -156,7 +164,7 @@ func (p Gitee) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]in
 	}
 
 	connection := &models.GiteeConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
@@ -28,20 +28,24 @@ import (
 )
 
 func NewGiteeApiClient(taskCtx plugin.TaskContext, connection *models.GiteeConnection) (*api.ApiAsyncClient, errors.Error) {
+	tokenPool, err := api.NewTokenPool(api.GitlabRateLimitHeaders, api.StaticTokenSources(connection.Token)...)
+	if err != nil {
+		return nil, err
+	}
 	apiClient, err := api.NewApiClient(taskCtx.GetContext(), connection.Endpoint, nil, 0, connection.Proxy, taskCtx)
 	if err != nil {
 		return nil, err
 	}
 
-	apiClient.SetBeforeFunction(func(req *http.Request) errors.Error {
+	tokenPool.SetupApiClient(apiClient, func(req *http.Request, token string) {
 		query := req.URL.Query()
-		query.Set("access_token", connection.Token)
+		query.Set("access_token", token)
 		req.URL.RawQuery = query.Encode()
-		return nil
 	})
 
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 		DynamicRateLimit: func(res *http.Response) (int, time.Duration, errors.Error) {
 			rateLimitHeader := res.Header.Get("RateLimit-Limit")
 			if rateLimitHeader == "" {
@@ -52,8 +56,8 @@ func NewGiteeApiClient(taskCtx plugin.TaskContext, connection *models.GiteeConne
 			if err != nil {
 				return 0, 0, errors.Default.Wrap(err, "failed to parse RateLimit-Limit header")
 			}
-			// seems like gitlab rate limit is on minute basis
-			return rateLimit, 1 * time.Minute, nil
+			// seems like gitlab rate limit is on minute basis, and the limit applies to each token
+			return rateLimit * tokenPool.Size(), 1 * time.Minute, nil
 		},
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
@@ -32,6 +32,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*GitExtractor)(nil)
+var _ plugin.PluginCompatibility = (*GitExtractor)(nil)
 var _ plugin.PluginTask = (*GitExtractor)(nil)
 var _ plugin.PluginModel = (*GitExtractor)(nil)
 
@@ -44,6 +45,14 @@ func (p GitExtractor) Description() string {
 	return "extract infos from git repository"
 }
 
+func (p GitExtractor) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p GitExtractor) Dependencies() []string {
+	return nil
+}
+
 // return all available subtasks, framework will run them for you in order
 func (p GitExtractor) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
@@ -63,6 +72,13 @@ func (p GitExtractor) PrepareTaskData(taskCtx plugin.TaskContext, options map[st
 	if err := op.Valid(); err != nil {
 		return nil, err
 	}
+	if op.CredentialPlugin != "" {
+		user, password, err := gitCredential(taskCtx, op.CredentialPlugin, op.ConnectionId)
+		if err != nil {
+			return nil, err
+		}
+		op.User, op.Password = user, password
+	}
 	storage := store.NewDatabase(taskCtx, op.RepoId)
 	repo, err := NewGitRepo(taskCtx.GetLogger(), storage, op)
 	if err != nil {
@@ -71,6 +87,19 @@ func (p GitExtractor) PrepareTaskData(taskCtx plugin.TaskContext, options map[st
 	return repo, nil
 }
 
+// gitCredential requests the credential for cloning from the plugin owning the connection
+func gitCredential(taskCtx plugin.TaskContext, pluginName string, connectionId uint64) (string, string, errors.Error) {
+	pluginMeta, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return "", "", err
+	}
+	provider, ok := pluginMeta.(plugin.GitCredentialProvider)
+	if !ok {
+		return "", "", errors.BadInput.New(fmt.Sprintf("plugin %s doesn't support GitCredentialProvider interface", pluginName))
+	}
+	return provider.GitCredential(taskCtx, connectionId)
+}
+
 func (p GitExtractor) Close(taskCtx plugin.TaskContext) errors.Error {
 	if repo, ok := taskCtx.GetData().(*parser.GitRepo); ok {
 		if err := repo.Close(); err != nil {
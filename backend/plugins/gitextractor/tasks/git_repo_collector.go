@@ -32,6 +32,9 @@ type GitExtractorOptions struct {
 	PrivateKey string `json:"privateKey"`
 	Passphrase string `json:"passphrase"`
 	Proxy      string `json:"proxy"`
+	// CredentialPlugin issues the user and password of the connection when the task runs, see plugin.GitCredentialProvider
+	CredentialPlugin string `json:"credentialPlugin"`
+	ConnectionId     uint64 `json:"connectionId"`
 }
 
 func (o GitExtractorOptions) Valid() errors.Error {
@@ -25,7 +25,6 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"strings"
 	"time"
 
 	"github.com/apache/incubator-devlake/core/models/domainlayer/didgen"
@@ -43,13 +42,14 @@ func MakePipelinePlan(subtaskMetas []plugin.SubTaskMeta, connectionId uint64, sc
 	if err != nil {
 		return nil, err
 	}
-	token := strings.Split(connection.Token, ",")[0]
+	tokenPool, err := tasks.NewTokenPool(connection)
+	if err != nil {
+		return nil, err
+	}
 	apiClient, err := helper.NewApiClient(
 		context.TODO(),
 		connection.Endpoint,
-		map[string]string{
-			"Authorization": fmt.Sprintf("Bearer %s", token),
-		},
+		nil,
 		10*time.Second,
 		connection.Proxy,
 		basicRes,
@@ -57,6 +57,7 @@ func MakePipelinePlan(subtaskMetas []plugin.SubTaskMeta, connectionId uint64, sc
 	if err != nil {
 		return nil, err
 	}
+	tokenPool.SetupApiClient(apiClient, helper.BearerAuthorization)
 	plan, err := makePipelinePlan(subtaskMetas, scope, apiClient, connection)
 	if err != nil {
 		return nil, err
@@ -166,25 +167,43 @@ func addGitex(entities []string,
 	stage plugin.PipelineStage,
 ) (plugin.PipelineStage, errors.Error) {
 	if utils.StringsContains(entities, plugin.DOMAIN_TYPE_CODE) {
-		// here is the tricky part, we have to obtain the repo id beforehand
-		token := strings.Split(connection.Token, ",")[0]
-		cloneUrl, err := errors.Convert01(url.Parse(repo.CloneUrl))
+		options, err := makeGitexOptions(connection, repo.CloneUrl, repo.GithubId)
 		if err != nil {
 			return nil, err
 		}
-		cloneUrl.User = url.UserPassword("git", token)
 		stage = append(stage, &plugin.PipelineTask{
-			Plugin: "gitextractor",
-			Options: map[string]interface{}{
-				"url":    cloneUrl.String(),
-				"repoId": didgen.NewDomainIdGenerator(&models.GithubRepo{}).Generate(connection.ID, repo.GithubId),
-				"proxy":  connection.Proxy,
-			},
+			Plugin:  "gitextractor",
+			Options: options,
 		})
 	}
 	return stage, nil
 }
 
+// makeGitexOptions returns the gitextractor options of the repo. Tokens of GitHub Apps expire in an hour, the plan
+// of an app connection carries the connection id instead, and gitextractor requests a token when the task runs
+func makeGitexOptions(connection *models.GithubConnection, repoCloneUrl string, githubId int) (map[string]interface{}, errors.Error) {
+	cloneUrl, err := errors.Convert01(url.Parse(repoCloneUrl))
+	if err != nil {
+		return nil, err
+	}
+	options := map[string]interface{}{
+		"repoId": didgen.NewDomainIdGenerator(&models.GithubRepo{}).Generate(connection.ID, githubId),
+		"proxy":  connection.Proxy,
+	}
+	if connection.IsAppKey() {
+		options["credentialPlugin"] = "github"
+		options["connectionId"] = connection.ID
+	} else {
+		token, err := tasks.GetToken(connection)
+		if err != nil {
+			return nil, err
+		}
+		cloneUrl.User = url.UserPassword("git", token)
+	}
+	options["url"] = cloneUrl.String()
+	return options, nil
+}
+
 func addGithub(subtaskMetas []plugin.SubTaskMeta, connection *models.GithubConnection, entities []string, stage plugin.PipelineStage, options map[string]interface{}) (plugin.PipelineStage, errors.Error) {
 	// construct github(graphql) task
 	if connection.EnableGraphql {
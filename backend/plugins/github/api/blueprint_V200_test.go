@@ -46,7 +46,7 @@ func TestMakeDataSourcePipelinePlanV200(t *testing.T) {
 			Proxy:            "",
 			RateLimitPerHour: 0,
 		},
-		AccessToken: helper.AccessToken{
+		GithubAuth: models.GithubAuth{
 			Token: "123",
 		},
 	}
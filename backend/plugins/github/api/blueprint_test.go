@@ -72,7 +72,7 @@ func TestMakePipelinePlan(t *testing.T) {
 			Proxy:            "",
 			RateLimitPerHour: 0,
 		},
-		AccessToken: helper.AccessToken{
+		GithubAuth: models.GithubAuth{
 			Token: "123",
 		},
 	}
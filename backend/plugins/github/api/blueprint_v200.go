@@ -32,16 +32,17 @@ import (
 	"github.com/apache/incubator-devlake/plugins/github/models"
 	"github.com/apache/incubator-devlake/plugins/github/tasks"
 	"github.com/go-playground/validator/v10"
-	"net/url"
-	"strings"
 	"time"
 )
 
 func MakeDataSourcePipelinePlanV200(subtaskMetas []plugin.SubTaskMeta, connectionId uint64, bpScopes []*plugin.BlueprintScopeV200, syncPolicy *plugin.BlueprintSyncPolicy) (plugin.PipelinePlan, []plugin.Scope, errors.Error) {
-	connectionHelper := helper.NewConnectionHelper(basicRes, validator.New())
+	connectionHelper, err := helper.NewConnectionHelper(basicRes, validator.New())
+	if err != nil {
+		return nil, nil, err
+	}
 	// get the connection info for url
 	connection := &models.GithubConnection{}
-	err := connectionHelper.FirstById(connection, connectionId)
+	err = connectionHelper.FirstById(connection, connectionId)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -123,21 +124,14 @@ func makeDataSourcePipelinePlanV200(
 
 		// add gitex stage
 		if utils.StringsContains(bpScope.Entities, plugin.DOMAIN_TYPE_CODE) {
-			cloneUrl, err := errors.Convert01(url.Parse(githubRepo.CloneUrl))
+			options, err := makeGitexOptions(connection, githubRepo.CloneUrl, githubRepo.GithubId)
 			if err != nil {
 				return nil, err
 			}
-			token := strings.Split(connection.Token, ",")[0]
-			cloneUrl.User = url.UserPassword("git", token)
 			stage = append(stage, &plugin.PipelineTask{
-				Plugin: "gitextractor",
-				Options: map[string]interface{}{
-					"url":    cloneUrl.String(),
-					"repoId": didgen.NewDomainIdGenerator(&models.GithubRepo{}).Generate(connection.ID, githubRepo.GithubId),
-					"proxy":  connection.Proxy,
-				},
+				Plugin:  "gitextractor",
+				Options: options,
 			})
-
 		}
 		plan[i] = stage
 	}
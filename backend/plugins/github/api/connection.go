@@ -24,8 +24,10 @@ import (
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/plugins/github/models"
+	"github.com/apache/incubator-devlake/plugins/github/tasks"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -50,6 +52,13 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	if err != nil {
 		return nil, err
 	}
+	if err := connectionHelper.RejectSecretReferences(&params); err != nil {
+		return nil, err
+	}
+
+	if params.IsAppKey() {
+		return testAppConnection(params)
+	}
 
 	tokens := strings.Split(params.Token, ",")
 
@@ -125,6 +134,34 @@ func TestConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 	return &plugin.ApiResourceOutput{Body: githubApiResponse, Status: http.StatusOK}, nil
 }
 
+// testAppConnection verifies the app could issue installation token and access the installation
+func testAppConnection(params models.TestConnectionRequest) (*plugin.ApiResourceOutput, errors.Error) {
+	tokenPool, err := tasks.NewTokenPool(&models.GithubConnection{
+		RestConnection: api.RestConnection{Endpoint: params.Endpoint, Proxy: params.Proxy},
+		GithubAuth:     params.GithubAuth,
+	})
+	if err != nil {
+		return nil, err
+	}
+	apiClient, err := api.NewApiClient(context.TODO(), params.Endpoint, nil, 3*time.Second, params.Proxy, basicRes)
+	if err != nil {
+		return nil, err
+	}
+	tokenPool.SetupApiClient(apiClient, api.BearerAuthorization)
+	res, err := apiClient.Get("installation/repositories", url.Values{"per_page": {"1"}}, nil)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("verify installation %d of app %s failed", params.InstallationId, params.AppId))
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.HttpStatus(res.StatusCode).New("unexpected status code while testing connection")
+	}
+	res.Body.Close()
+	githubApiResponse := GithubTestConnResponse{}
+	githubApiResponse.Success = true
+	githubApiResponse.Message = "success"
+	return &plugin.ApiResourceOutput{Body: githubApiResponse, Status: http.StatusOK}, nil
+}
+
 // @Summary create github connection
 // @Description Create github connection
 // @Tags plugins/github
@@ -168,11 +205,11 @@ func PatchConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/github/connections/{connectionId} [DELETE]
 func DeleteConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.GithubConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
-	err = connectionHelper.Delete(connection)
+	err = connectionHelper.Delete(connection, input)
 	return &plugin.ApiResourceOutput{Body: connection}, err
 }
 
@@ -202,7 +239,7 @@ func ListConnections(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput,
 // @Router /plugins/github/connections/{connectionId} [GET]
 func GetConnection(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
 	connection := &models.GithubConnection{}
-	err := connectionHelper.First(connection, input.Params)
+	err := connectionHelper.FirstUnresolved(connection, input.Params)
 	if err != nil {
 		return nil, err
 	}
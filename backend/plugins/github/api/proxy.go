@@ -20,13 +20,12 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/plugins/github/models"
+	"github.com/apache/incubator-devlake/plugins/github/tasks"
 	"io"
-	"strings"
 	"time"
 )
 
@@ -40,13 +39,14 @@ func Proxy(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Er
 	if err != nil {
 		return nil, err
 	}
-	tokens := strings.Split(connection.Token, ",")
+	tokenPool, err := tasks.NewTokenPool(connection)
+	if err != nil {
+		return nil, err
+	}
 	apiClient, err := helper.NewApiClient(
 		context.TODO(),
 		connection.Endpoint,
-		map[string]string{
-			"Authorization": fmt.Sprintf("Bearer %s", tokens[0]),
-		},
+		nil,
 		TimeOut,
 		connection.Proxy,
 		basicRes,
@@ -54,6 +54,7 @@ func Proxy(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Er
 	if err != nil {
 		return nil, err
 	}
+	tokenPool.SetupApiClient(apiClient, helper.BearerAuthorization)
 
 	resp, err := apiClient.Get(input.Params["path"], input.Query, nil)
 	if err != nil {
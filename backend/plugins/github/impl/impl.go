@@ -32,6 +32,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Github)(nil)
+var _ plugin.PluginCompatibility = (*Github)(nil)
 var _ plugin.PluginInit = (*Github)(nil)
 var _ plugin.PluginTask = (*Github)(nil)
 var _ plugin.PluginApi = (*Github)(nil)
@@ -39,6 +40,7 @@ var _ plugin.PluginModel = (*Github)(nil)
 var _ plugin.PluginBlueprintV100 = (*Github)(nil)
 var _ plugin.CloseablePluginTask = (*Github)(nil)
 var _ plugin.PluginSource = (*Github)(nil)
+var _ plugin.GitCredentialProvider = (*Github)(nil)
 
 type Github struct{}
 
@@ -55,8 +57,7 @@ func (p Github) TransformationRule() interface{} {
 }
 
 func (p Github) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Github) GetTablesInfo() []dal.Tabler {
@@ -90,6 +91,14 @@ func (p Github) Description() string {
 	return "To collect and enrich data from GitHub"
 }
 
+func (p Github) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Github) Dependencies() []string {
+	return nil
+}
+
 func (p Github) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectApiIssuesMeta,
@@ -146,10 +155,13 @@ func (p Github) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]i
 	if err != nil {
 		return nil, err
 	}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	connection := &models.GithubConnection{}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
@@ -244,6 +256,27 @@ func (p Github) Close(taskCtx plugin.TaskContext) errors.Error {
 	return nil
 }
 
+// GitCredential issues a token of the connection for gitextractor to clone the repo
+func (p Github) GitCredential(taskCtx plugin.TaskContext, connectionId uint64) (string, string, errors.Error) {
+	connectionHelper, err := helper.NewConnectionHelper(
+		taskCtx,
+		nil,
+	)
+	if err != nil {
+		return "", "", err
+	}
+	connection := &models.GithubConnection{}
+	err = connectionHelper.FirstById(connection, connectionId)
+	if err != nil {
+		return "", "", errors.Default.Wrap(err, "unable to get github connection by the given connection ID")
+	}
+	token, err := tasks.GetToken(connection)
+	if err != nil {
+		return "", "", err
+	}
+	return "git", token, nil
+}
+
 func EnrichOptions(taskCtx plugin.TaskContext,
 	op *tasks.GithubOptions,
 	apiClient *helper.ApiClient) errors.Error {
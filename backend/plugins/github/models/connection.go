@@ -21,15 +21,34 @@ import (
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 )
 
+const (
+	AUTH_METHOD_ACCESS_TOKEN = "AccessToken"
+	AUTH_METHOD_APP_KEY      = "AppKey"
+)
+
+// GithubAuth authenticates either by comma separated personal access tokens, or as a GitHub App installation
+type GithubAuth struct {
+	AuthMethod     string `mapstructure:"authMethod" json:"authMethod" validate:"omitempty,oneof=AccessToken AppKey"`
+	Token          string `mapstructure:"token" json:"token" validate:"required_unless=AuthMethod AppKey" encrypt:"yes"`
+	AppId          string `mapstructure:"appId" json:"appId" validate:"required_if=AuthMethod AppKey"`
+	InstallationId int64  `mapstructure:"installationId" json:"installationId" validate:"required_if=AuthMethod AppKey"`
+	SecretKey      string `mapstructure:"secretKey" json:"secretKey" validate:"required_if=AuthMethod AppKey" encrypt:"yes"`
+}
+
+// IsAppKey reports whether the connection authenticates as a GitHub App installation
+func (auth GithubAuth) IsAppKey() bool {
+	return auth.AuthMethod == AUTH_METHOD_APP_KEY
+}
+
 type TestConnectionRequest struct {
-	Endpoint string `json:"endpoint" validate:"required,url"`
-	Token    string `json:"token" validate:"required"`
-	Proxy    string `json:"proxy"`
+	Endpoint   string `json:"endpoint" validate:"required,url"`
+	Proxy      string `json:"proxy"`
+	GithubAuth `mapstructure:",squash"`
 }
 
 type GithubConnection struct {
 	helper.RestConnection `mapstructure:",squash"`
-	helper.AccessToken    `mapstructure:",squash"`
+	GithubAuth            `mapstructure:",squash"`
 	EnableGraphql         bool `mapstructure:"enableGraphql" json:"enableGraphql"`
 }
 
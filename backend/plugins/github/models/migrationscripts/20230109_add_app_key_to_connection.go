@@ -0,0 +1,52 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrationscripts
+
+import (
+	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+type githubConnection20230109 struct {
+	AuthMethod     string `gorm:"type:varchar(20)"`
+	AppId          string `gorm:"type:varchar(100)"`
+	InstallationId int64
+	SecretKey      string
+}
+
+func (githubConnection20230109) TableName() string {
+	return "_tool_github_connections"
+}
+
+type addAppKeyToConnection struct{}
+
+func (*addAppKeyToConnection) Up(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().AutoMigrate(&githubConnection20230109{})
+}
+
+func (*addAppKeyToConnection) Down(basicRes context.BasicRes) errors.Error {
+	return basicRes.GetDal().DropColumns("_tool_github_connections", "auth_method", "app_id", "installation_id", "secret_key")
+}
+
+func (*addAppKeyToConnection) Version() uint64 {
+	return 20230109000001
+}
+
+func (*addAppKeyToConnection) Name() string {
+	return "add app key auth to github connection"
+}
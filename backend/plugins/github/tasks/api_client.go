@@ -18,63 +18,69 @@ limitations under the License.
 package tasks
 
 import (
-	"fmt"
+	"context"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/plugins/github/models"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 )
 
+// NewTokenPool creates the pool of tokens the connection authenticates with
+func NewTokenPool(connection *models.GithubConnection) (*api.TokenPool, errors.Error) {
+	if connection.IsAppKey() {
+		source, err := api.NewGithubAppTokenSource(
+			connection.Endpoint,
+			connection.AppId,
+			connection.InstallationId,
+			connection.SecretKey,
+			connection.Proxy,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return api.NewTokenPool(api.GithubRateLimitHeaders, source)
+	}
+	return api.NewTokenPool(api.GithubRateLimitHeaders, api.StaticTokenSources(connection.Token)...)
+}
+
+// GetToken returns a token of the connection for clients not going through the api client, i.e. git clone
+func GetToken(connection *models.GithubConnection) (string, errors.Error) {
+	tokenPool, err := NewTokenPool(connection)
+	if err != nil {
+		return "", err
+	}
+	token, _, err := tokenPool.Acquire(context.TODO())
+	return token, err
+}
+
 func CreateApiClient(taskCtx plugin.TaskContext, connection *models.GithubConnection) (*api.ApiAsyncClient, errors.Error) {
-	// load configuration
-	tokens := strings.Split(connection.Token, ",")
-	tokenIndex := 0
+	tokenPool, err := NewTokenPool(connection)
+	if err != nil {
+		return nil, err
+	}
 	// create synchronize api client so we can calculate api rate limit dynamically
 	apiClient, err := api.NewApiClient(taskCtx.GetContext(), connection.Endpoint, nil, 0, connection.Proxy, taskCtx)
 	if err != nil {
 		return nil, err
 	}
-	// Rotates token on each request.
-	apiClient.SetBeforeFunction(func(req *http.Request) errors.Error {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %v", tokens[tokenIndex]))
-		// Set next token index
-		tokenIndex = (tokenIndex + 1) % len(tokens)
-		return nil
-	})
+	// distribute requests among tokens by their remaining quota
+	tokenPool.SetupApiClient(apiClient, api.BearerAuthorization)
 
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
 		Method:               http.MethodGet,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 		DynamicRateLimit: func(res *http.Response) (int, time.Duration, errors.Error) {
-			/* calculate by number of remaining requests
-			remaining, err := strconv.Atoi(res.Header.Get("X-RateLimit-Remaining"))
-			if err != nil {
-				return 0,0, errors.Default.New("failed to parse X-RateLimit-Remaining header: %w", err)
-			}
-			reset, err := strconv.Atoi(res.Header.Get("X-RateLimit-Reset"))
-			if err != nil {
-				return 0, 0, errors.Default.New("failed to parse X-RateLimit-Reset header: %w", err)
-			}
-			date, err := http.ParseTime(res.Header.Get("Date"))
-			if err != nil {
-				return 0, 0, errors.Default.New("failed to parse Date header: %w", err)
-			}
-			return remaining * len(tokens), time.Unix(int64(reset), 0).Sub(date), nil
-			*/
 			rateLimit, err := strconv.Atoi(res.Header.Get("X-RateLimit-Limit"))
 			if err != nil {
 				return 0, 0, errors.Default.Wrap(err, "failed to parse X-RateLimit-Limit header")
 			}
-			// even though different token could have different rate limit, but it is hard to support it
-			// so, we calculate the rate limit of a single token, and presume all tokens are the same, to
-			// simplify the algorithm for now
-			// TODO: consider different token has different rate-limit
-			return rateLimit * len(tokens), 1 * time.Hour, nil
+			// tokens not used yet are presumed to have the same limit as the one just used
+			return tokenPool.TotalLimit(rateLimit), 1 * time.Hour, nil
 		},
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
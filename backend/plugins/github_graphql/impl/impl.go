@@ -32,12 +32,12 @@ import (
 	"github.com/merico-dev/graphql"
 	"golang.org/x/oauth2"
 	"reflect"
-	"strings"
 	"time"
 )
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*GithubGraphql)(nil)
+var _ plugin.PluginCompatibility = (*GithubGraphql)(nil)
 var _ plugin.PluginTask = (*GithubGraphql)(nil)
 var _ plugin.PluginApi = (*GithubGraphql)(nil)
 var _ plugin.PluginModel = (*GithubGraphql)(nil)
@@ -62,6 +62,14 @@ func (p GithubGraphql) Description() string {
 	return "collect some GithubGraphql data"
 }
 
+func (p GithubGraphql) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p GithubGraphql) Dependencies() []string {
+	return []string{"github"}
+}
+
 func (p GithubGraphql) GetTablesInfo() []dal.Tabler {
 	return []dal.Tabler{}
 }
@@ -130,10 +138,13 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 	if err != nil {
 		return nil, err
 	}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	connection := &models.GithubConnection{}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
@@ -157,12 +168,14 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 		}
 	}
 
-	tokens := strings.Split(connection.Token, ",")
-	src := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: tokens[0]},
-	)
+	tokenPool, err := githubTasks.NewTokenPool(connection)
+	if err != nil {
+		return nil, err
+	}
+	src := &tokenPoolSource{ctx: taskCtx.GetContext(), tokenPool: tokenPool}
 	httpClient := oauth2.NewClient(taskCtx.GetContext(), src)
 	client := graphql.NewClient(connection.Endpoint+`graphql`, httpClient)
+	graphqlRateLimit := 0
 	graphqlClient, err := helper.CreateAsyncGraphqlClient(taskCtx, client, taskCtx.GetLogger(),
 		func(ctx context.Context, client *graphql.Client, logger log.Logger) (rateRemaining int, resetAt *time.Time, err errors.Error) {
 			var query GraphQueryRateLimit
@@ -175,6 +188,7 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 			}
 			logger.Info(`github graphql init success with remaining %d/%d and will reset at %s`,
 				query.RateLimit.Remaining, query.RateLimit.Limit, query.RateLimit.ResetAt)
+			graphqlRateLimit = int(query.RateLimit.Limit)
 			return int(query.RateLimit.Remaining), &query.RateLimit.ResetAt, nil
 		})
 	if err != nil {
@@ -185,6 +199,17 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 		v := reflect.ValueOf(q)
 		return int(v.Elem().FieldByName(`RateLimit`).FieldByName(`Cost`).Int())
 	})
+	// graphql points are limited separately from the rest api requests by github
+	if graphqlRateLimit > 0 {
+		err = graphqlClient.SetRateLimitBudget(
+			helper.RateLimitBudgetKey(connection, connection.ID)+":graphql",
+			graphqlRateLimit,
+			time.Hour,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	taskData := &githubTasks.GithubTaskData{
 		Options:       &op,
@@ -199,6 +224,21 @@ func (p GithubGraphql) PrepareTaskData(taskCtx plugin.TaskContext, options map[s
 	return taskData, nil
 }
 
+// tokenPoolSource feeds the graphql client with tokens from the pool, tokens expire in a minute so they
+// would be rotated and app installation tokens would be refreshed in time
+type tokenPoolSource struct {
+	ctx       context.Context
+	tokenPool *helper.TokenPool
+}
+
+func (s *tokenPoolSource) Token() (*oauth2.Token, error) {
+	token, _, err := s.tokenPool.Acquire(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{AccessToken: token, Expiry: time.Now().Add(time.Minute)}, nil
+}
+
 // PkgPath information lost when compiled as plugin(.so)
 func (p GithubGraphql) RootPkgPath() string {
 	return "github.com/apache/incubator-devlake/plugins/githubGraphql"
@@ -192,10 +192,11 @@ func TestMakeDataSourcePipelinePlanV200(t *testing.T) {
 			*dst = *testTransformationRule
 		}).Return(nil).Once()
 	})
-	connectionHelper = helper.NewConnectionHelper(
+	connectionHelper, err = helper.NewConnectionHelper(
 		basicRes,
 		validator.New(),
 	)
+	assert.Nil(t, err)
 
 	plans, scopes, err := MakePipelinePlanV200(testSubTaskMeta, testConnectionID, bpScopes, syncPolicy)
 	assert.Equal(t, err, nil)
@@ -33,6 +33,7 @@ import (
 
 var _ interface {
 	plugin.PluginMeta
+	plugin.PluginCompatibility
 	plugin.PluginInit
 	plugin.PluginTask
 	plugin.PluginModel
@@ -46,8 +47,7 @@ var _ interface {
 type Gitlab string
 
 func (p Gitlab) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Gitlab) Connection() interface{} {
@@ -92,6 +92,14 @@ func (p Gitlab) Description() string {
 	return "To collect and enrich data from Gitlab"
 }
 
+func (p Gitlab) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Gitlab) Dependencies() []string {
+	return nil
+}
+
 func (p Gitlab) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectApiIssuesMeta,
@@ -135,13 +143,16 @@ func (p Gitlab) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]i
 		return nil, errors.BadInput.New("connectionId is invalid")
 	}
 	connection := &models.GitlabConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err != nil {
+		return nil, err
+	}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, errors.BadInput.Wrap(err, "connection not found")
@@ -18,7 +18,6 @@ limitations under the License.
 package tasks
 
 import (
-	"fmt"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
@@ -29,18 +28,21 @@ import (
 )
 
 func NewGitlabApiClient(taskCtx plugin.TaskContext, connection *models.GitlabConnection) (*api.ApiAsyncClient, errors.Error) {
-	// create synchronize api client so we can calculate api rate limit dynamically
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %v", connection.Token),
+	tokenPool, err := api.NewTokenPool(api.GitlabRateLimitHeaders, api.StaticTokenSources(connection.Token)...)
+	if err != nil {
+		return nil, err
 	}
-	apiClient, err := api.NewApiClient(taskCtx.GetContext(), connection.Endpoint, headers, 0, connection.Proxy, taskCtx)
+	// create synchronize api client so we can calculate api rate limit dynamically
+	apiClient, err := api.NewApiClient(taskCtx.GetContext(), connection.Endpoint, nil, 0, connection.Proxy, taskCtx)
 	if err != nil {
 		return nil, err
 	}
+	tokenPool.SetupApiClient(apiClient, api.BearerAuthorization)
 
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 		DynamicRateLimit: func(res *http.Response) (int, time.Duration, errors.Error) {
 			rateLimitHeader := res.Header.Get("RateLimit-Limit")
 			if rateLimitHeader == "" {
@@ -53,10 +55,10 @@ func NewGitlabApiClient(taskCtx plugin.TaskContext, connection *models.GitlabCon
 			}
 			// seems like gitlab rate limit is on minute basis
 			if rateLimit > 200 {
-				return 200, 1 * time.Minute, nil
-			} else {
-				return rateLimit, 1 * time.Minute, nil
+				rateLimit = 200
 			}
+			// the limit applies to each token
+			return rateLimit * tokenPool.Size(), 1 * time.Minute, nil
 		},
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
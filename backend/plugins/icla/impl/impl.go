@@ -31,6 +31,7 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*Icla)(nil)
+var _ plugin.PluginCompatibility = (*Icla)(nil)
 var _ plugin.PluginInit = (*Icla)(nil)
 var _ plugin.PluginTask = (*Icla)(nil)
 var _ plugin.PluginApi = (*Icla)(nil)
@@ -44,6 +45,14 @@ func (p Icla) Description() string {
 	return "collect some Icla data"
 }
 
+func (p Icla) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Icla) Dependencies() []string {
+	return nil
+}
+
 func (p Icla) Init(basicRes context.BasicRes) errors.Error {
 	return nil
 }
@@ -34,6 +34,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Jenkins)(nil)
+var _ plugin.PluginCompatibility = (*Jenkins)(nil)
 var _ plugin.PluginInit = (*Jenkins)(nil)
 var _ plugin.PluginTask = (*Jenkins)(nil)
 var _ plugin.PluginApi = (*Jenkins)(nil)
@@ -45,8 +46,7 @@ var _ plugin.PluginSource = (*Jenkins)(nil)
 type Jenkins struct{}
 
 func (p Jenkins) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Jenkins) Connection() interface{} {
@@ -79,6 +79,14 @@ func (p Jenkins) Description() string {
 	return "To collect and enrich data from Jenkins"
 }
 
+func (p Jenkins) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Jenkins) Dependencies() []string {
+	return nil
+}
+
 func (p Jenkins) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.ConvertJobsMeta,
@@ -102,13 +110,16 @@ func (p Jenkins) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]
 	logger := taskCtx.GetLogger()
 	logger.Debug("%v", options)
 	connection := &models.JenkinsConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err != nil {
+		return nil, err
+	}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, err
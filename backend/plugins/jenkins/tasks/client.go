@@ -39,6 +39,7 @@ func CreateApiClient(taskCtx plugin.TaskContext, connection *models.JenkinsConne
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
 		taskCtx,
@@ -19,6 +19,7 @@ package api
 
 import (
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/errors"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/go-playground/validator/v10"
 )
@@ -27,11 +28,13 @@ var vld *validator.Validate
 var connectionHelper *helper.ConnectionApiHelper
 var basicRes context.BasicRes
 
-func Init(br context.BasicRes) {
+func Init(br context.BasicRes) errors.Error {
 	basicRes = br
 	vld = validator.New()
-	connectionHelper = helper.NewConnectionHelper(
+	var err errors.Error
+	connectionHelper, err = helper.NewConnectionHelper(
 		basicRes,
 		vld,
 	)
+	return err
 }
@@ -50,6 +50,7 @@ func CreateTransformationRule(input *plugin.ApiResourceInput) (*plugin.ApiResour
 		}
 		return nil, errors.BadInput.Wrap(err, "error on saving TransformationRule")
 	}
+	api.RecordAuditLog(basicRes, input.User, rule.TableName(), rule.ID, api.AUDIT_ACTION_CREATE, nil, api.TakeAuditSnapshot(&rule))
 	return &plugin.ApiResourceOutput{Body: rule, Status: http.StatusOK}, nil
 }
 
@@ -74,6 +75,7 @@ func UpdateTransformationRule(input *plugin.ApiResourceInput) (*plugin.ApiResour
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "error on saving TransformationRule")
 	}
+	before := api.TakeAuditSnapshot(&old)
 	err = api.DecodeMapStruct(input.Body, &old)
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "error decoding map into transformationRule")
@@ -86,6 +88,7 @@ func UpdateTransformationRule(input *plugin.ApiResourceInput) (*plugin.ApiResour
 		}
 		return nil, errors.BadInput.Wrap(err, "error on saving TransformationRule")
 	}
+	api.RecordAuditLog(basicRes, input.User, old.TableName(), old.ID, api.AUDIT_ACTION_UPDATE, before, api.TakeAuditSnapshot(&old))
 	return &plugin.ApiResourceOutput{Body: old, Status: http.StatusOK}, nil
 }
 
@@ -34,6 +34,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Jira)(nil)
+var _ plugin.PluginCompatibility = (*Jira)(nil)
 var _ plugin.PluginInit = (*Jira)(nil)
 var _ plugin.PluginTask = (*Jira)(nil)
 var _ plugin.PluginApi = (*Jira)(nil)
@@ -59,8 +60,7 @@ func (p Jira) TransformationRule() interface{} {
 }
 
 func (p *Jira) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Jira) GetTablesInfo() []dal.Tabler {
@@ -91,6 +91,14 @@ func (p Jira) Description() string {
 	return "To collect and enrich data from JIRA"
 }
 
+func (p Jira) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Jira) Dependencies() []string {
+	return nil
+}
+
 func (p Jira) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectStatusMeta,
@@ -155,10 +163,13 @@ func (p Jira) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]int
 		return nil, errors.BadInput.New("jira connectionId is invalid")
 	}
 	connection := &models.JiraConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	if err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not get connection API instance for Jira")
 	}
@@ -40,6 +40,7 @@ func NewJiraApiClient(taskCtx plugin.TaskContext, connection *models.JiraConnect
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
 		taskCtx,
@@ -20,7 +20,9 @@ package api
 import (
 	"encoding/csv"
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/go-playground/validator/v10"
 	"github.com/gocarina/gocsv"
 	"net/http"
 )
@@ -28,11 +30,17 @@ import (
 const maxMemory = 32 << 20 // 32 MB
 
 type Handlers struct {
-	store store
+	store     store
+	db        dal.Dal
+	validator *validator.Validate
 }
 
 func NewHandlers(basicRes context.BasicRes) *Handlers {
-	return &Handlers{store: NewDbStore(basicRes.GetDal(), basicRes)}
+	return &Handlers{
+		store:     NewDbStore(basicRes.GetDal(), basicRes),
+		db:        basicRes.GetDal(),
+		validator: validator.New(),
+	}
 }
 
 func (h *Handlers) unmarshal(r *http.Request, items interface{}) errors.Error {
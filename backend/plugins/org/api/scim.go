@@ -0,0 +1,633 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/org/tasks"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// users and teams pushed through SCIM are tagged with this source name
+const SCIM_SOURCE = "scim"
+
+const (
+	scimUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimTeamSchema         = "urn:devlake:params:scim:schemas:extension:2.0:Team"
+	scimListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+)
+
+var scimFilterPattern = regexp.MustCompile(`^(userName|externalId|displayName) eq "([^"]*)"$`)
+var scimMemberPathPattern = regexp.MustCompile(`^members\[value eq "([^"]*)"]$`)
+
+type scimValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimName struct {
+	Formatted string `json:"formatted,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id"`
+	ExternalId  string      `json:"externalId,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *scimName   `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []scimValue `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []scimValue `json:"groups,omitempty"`
+}
+
+type scimTeam struct {
+	ParentId     string `json:"parentId,omitempty"`
+	Alias        string `json:"alias,omitempty"`
+	SortingIndex int    `json:"sortingIndex,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string    `json:"schemas"`
+	Id          string      `json:"id"`
+	ExternalId  string      `json:"externalId,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []scimValue `json:"members,omitempty"`
+	Team        *scimTeam   `json:"urn:devlake:params:scim:schemas:extension:2.0:Team,omitempty" mapstructure:"urn:devlake:params:scim:schemas:extension:2.0:Team"`
+}
+
+type scimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type scimPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+type scimPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+// ListScimUsers returns users in SCIM 2.0 format
+// @Summary      List users through SCIM
+// @Description  list users, supports `userName eq "x"` and `externalId eq "x"` filters
+// @Tags 		 plugins/org
+// @Produce      json
+// @Param        filter      query  string  false  "filter"
+// @Param        startIndex  query  int     false  "1-based index of the first result"
+// @Param        count       query  int     false  "max number of results"
+// @Success      200  {object} scimListResponse
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Users [get]
+func (h *Handlers) ListScimUsers(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	clauses := []dal.Clause{dal.From(&crossdomain.User{}), dal.Where("deleted_at IS NULL")}
+	if filter := input.Query.Get("filter"); filter != "" {
+		m := scimFilterPattern.FindStringSubmatch(filter)
+		if m == nil {
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported filter %s", filter))
+		}
+		if m[1] == "displayName" {
+			clauses = append(clauses, dal.Where("name = ?", m[2]))
+		} else {
+			clauses = append(clauses, dal.Where("id = ?", m[2]))
+		}
+	}
+	total, err := h.db.Count(clauses...)
+	if err != nil {
+		return nil, err
+	}
+	startIndex, count := scimPage(input)
+	var users []crossdomain.User
+	err = h.db.All(&users, append(clauses, dal.Orderby("id"), dal.Offset(startIndex-1), dal.Limit(count))...)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]*scimUser, 0, len(users))
+	for i := range users {
+		u, err := h.toScimUser(&users[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, u)
+	}
+	return &plugin.ApiResourceOutput{Body: &scimListResponse{
+		Schemas:      []string{scimListResponseSchema},
+		TotalResults: int(total),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, Status: http.StatusOK}, nil
+}
+
+// GetScimUser returns a user in SCIM 2.0 format
+// @Summary      Get a user through SCIM
+// @Tags 		 plugins/org
+// @Produce      json
+// @Param        id  path  string  true  "user id"
+// @Success      200  {object} scimUser
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Users/{id} [get]
+func (h *Handlers) GetScimUser(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	user, err := h.findUser(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	u, err := h.toScimUser(user)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: u, Status: http.StatusOK}, nil
+}
+
+// PutScimUser creates or replaces a user, `POST` and `PUT` share the same handler
+// @Summary      Create or replace a user through SCIM
+// @Tags 		 plugins/org
+// @Accept       application/json
+// @Produce      json
+// @Param        body  body  scimUser  true  "json"
+// @Success      200  {object} scimUser
+// @Success      201  {object} scimUser
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Users [post]
+// @Router       /plugins/org/scim/v2/Users/{id} [put]
+func (h *Handlers) PutScimUser(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	var body scimUser
+	err := helper.Decode(input.Body, &body, nil)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid scim user")
+	}
+	id := input.Params["id"]
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+		id = body.ExternalId
+		if id == "" {
+			id = body.UserName
+		}
+	}
+	if id == "" {
+		return nil, errors.BadInput.New("either externalId or userName is required")
+	}
+	directoryUser := &tasks.DirectoryUser{Id: id, Name: body.DisplayName, Email: scimPrimaryValue(body.Emails)}
+	if directoryUser.Name == "" && body.Name != nil {
+		directoryUser.Name = body.Name.Formatted
+	}
+	for _, group := range body.Groups {
+		directoryUser.TeamIds = append(directoryUser.TeamIds, group.Value)
+	}
+	syncer := tasks.NewDirectorySyncer(h.db, SCIM_SOURCE)
+	err = syncer.UpsertUser(directoryUser)
+	if err != nil {
+		return nil, err
+	}
+	if body.Active != nil && !*body.Active {
+		err = syncer.DeleteUser(id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	output, err := h.GetScimUser(&plugin.ApiResourceInput{Params: map[string]string{"id": id}})
+	if err != nil {
+		return nil, err
+	}
+	output.Status = status
+	return output, nil
+}
+
+// PatchScimUser supports deactivating and reactivating a user, and replacing its display name
+// @Summary      Patch a user through SCIM
+// @Tags 		 plugins/org
+// @Accept       application/json
+// @Produce      json
+// @Param        id  path  string  true  "user id"
+// @Param        body  body  scimPatchRequest  true  "json"
+// @Success      200  {object} scimUser
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Users/{id} [patch]
+func (h *Handlers) PatchScimUser(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	user, err := h.findUser(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	var body scimPatchRequest
+	err = helper.Decode(input.Body, &body, nil)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid scim patch request")
+	}
+	deactivate := false
+	for _, op := range body.Operations {
+		if !strings.EqualFold(op.Op, "replace") && !strings.EqualFold(op.Op, "add") {
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported operation %s on user", op.Op))
+		}
+		values := map[string]interface{}{op.Path: op.Value}
+		if op.Path == "" {
+			var ok bool
+			if values, ok = op.Value.(map[string]interface{}); !ok {
+				return nil, errors.BadInput.New("value must be an object when path is omitted")
+			}
+		}
+		for path, value := range values {
+			switch path {
+			case "active":
+				// deactivation is applied after the other operations so that none of them gets skipped
+				active, _ := value.(bool)
+				deactivate = !active
+				if active {
+					user.DeletedAt = nil
+				}
+			case "displayName", "name.formatted":
+				user.Name, _ = value.(string)
+			case "emails":
+				var emails []scimValue
+				err = helper.Decode(value, &emails, nil)
+				if err != nil {
+					return nil, errors.BadInput.Wrap(err, "invalid emails")
+				}
+				user.Email = scimPrimaryValue(emails)
+			default:
+				return nil, errors.BadInput.New(fmt.Sprintf("unsupported path %s on user", path))
+			}
+		}
+	}
+	user.RawDataTable = tasks.RAW_DIRECTORY_TABLE
+	user.RawDataParams = SCIM_SOURCE
+	err = h.db.Update(user)
+	if err != nil {
+		return nil, err
+	}
+	if deactivate {
+		err = tasks.NewDirectorySyncer(h.db, SCIM_SOURCE).DeleteUser(user.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return h.GetScimUser(input)
+}
+
+// DeleteScimUser soft-deletes a user along with the team memberships created through SCIM
+// @Summary      Delete a user through SCIM
+// @Description  the user is soft-deleted, team memberships uploaded manually or synced from other sources are kept
+// @Tags 		 plugins/org
+// @Param        id  path  string  true  "user id"
+// @Success      204
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Users/{id} [delete]
+func (h *Handlers) DeleteScimUser(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	user, err := h.findUser(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	err = tasks.NewDirectorySyncer(h.db, SCIM_SOURCE).DeleteUser(user.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Status: http.StatusNoContent}, nil
+}
+
+// ListScimGroups returns teams as SCIM 2.0 groups
+// @Summary      List teams through SCIM
+// @Description  list teams, supports `displayName eq "x"` and `externalId eq "x"` filters
+// @Tags 		 plugins/org
+// @Produce      json
+// @Param        filter      query  string  false  "filter"
+// @Param        startIndex  query  int     false  "1-based index of the first result"
+// @Param        count       query  int     false  "max number of results"
+// @Success      200  {object} scimListResponse
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Groups [get]
+func (h *Handlers) ListScimGroups(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	clauses := []dal.Clause{dal.From(&crossdomain.Team{})}
+	if filter := input.Query.Get("filter"); filter != "" {
+		m := scimFilterPattern.FindStringSubmatch(filter)
+		if m == nil || m[1] == "userName" {
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported filter %s", filter))
+		}
+		if m[1] == "displayName" {
+			clauses = append(clauses, dal.Where("name = ?", m[2]))
+		} else {
+			clauses = append(clauses, dal.Where("id = ?", m[2]))
+		}
+	}
+	total, err := h.db.Count(clauses...)
+	if err != nil {
+		return nil, err
+	}
+	startIndex, count := scimPage(input)
+	var teams []crossdomain.Team
+	err = h.db.All(&teams, append(clauses, dal.Orderby("sorting_index, id"), dal.Offset(startIndex-1), dal.Limit(count))...)
+	if err != nil {
+		return nil, err
+	}
+	resources := make([]*scimGroup, 0, len(teams))
+	for i := range teams {
+		g, err := h.toScimGroup(&teams[i])
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, g)
+	}
+	return &plugin.ApiResourceOutput{Body: &scimListResponse{
+		Schemas:      []string{scimListResponseSchema},
+		TotalResults: int(total),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	}, Status: http.StatusOK}, nil
+}
+
+// GetScimGroup returns a team as SCIM 2.0 group
+// @Summary      Get a team through SCIM
+// @Tags 		 plugins/org
+// @Produce      json
+// @Param        id  path  string  true  "team id"
+// @Success      200  {object} scimGroup
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Groups/{id} [get]
+func (h *Handlers) GetScimGroup(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	team, err := h.findTeam(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	g, err := h.toScimGroup(team)
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: g, Status: http.StatusOK}, nil
+}
+
+// PutScimGroup creates or replaces a team and its members, `POST` and `PUT` share the same handler.
+// The parent team can be set through the `urn:devlake:params:scim:schemas:extension:2.0:Team` extension
+// @Summary      Create or replace a team through SCIM
+// @Tags 		 plugins/org
+// @Accept       application/json
+// @Produce      json
+// @Param        body  body  scimGroup  true  "json"
+// @Success      200  {object} scimGroup
+// @Success      201  {object} scimGroup
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Groups [post]
+// @Router       /plugins/org/scim/v2/Groups/{id} [put]
+func (h *Handlers) PutScimGroup(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	var body scimGroup
+	err := helper.Decode(input.Body, &body, nil)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid scim group")
+	}
+	id := input.Params["id"]
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+		id = body.ExternalId
+		if id == "" {
+			id = body.DisplayName
+		}
+	}
+	if id == "" {
+		return nil, errors.BadInput.New("either externalId or displayName is required")
+	}
+	team := &tasks.DirectoryTeam{Id: id, Name: body.DisplayName}
+	if body.Team != nil {
+		team.ParentId = body.Team.ParentId
+		team.Alias = body.Team.Alias
+		team.SortingIndex = body.Team.SortingIndex
+	}
+	syncer := tasks.NewDirectorySyncer(h.db, SCIM_SOURCE)
+	err = syncer.UpsertTeam(team)
+	if err != nil {
+		return nil, err
+	}
+	userIds := make([]string, 0, len(body.Members))
+	for _, member := range body.Members {
+		userIds = append(userIds, member.Value)
+	}
+	err = syncer.SetTeamUsers(id, userIds)
+	if err != nil {
+		return nil, err
+	}
+	output, err := h.GetScimGroup(&plugin.ApiResourceInput{Params: map[string]string{"id": id}})
+	if err != nil {
+		return nil, err
+	}
+	output.Status = status
+	return output, nil
+}
+
+// PatchScimGroup supports adding and removing members, and replacing the display name
+// @Summary      Patch a team through SCIM
+// @Tags 		 plugins/org
+// @Accept       application/json
+// @Produce      json
+// @Param        id  path  string  true  "team id"
+// @Param        body  body  scimPatchRequest  true  "json"
+// @Success      200  {object} scimGroup
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Groups/{id} [patch]
+func (h *Handlers) PatchScimGroup(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	team, err := h.findTeam(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	var body scimPatchRequest
+	err = helper.Decode(input.Body, &body, nil)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid scim patch request")
+	}
+	syncer := tasks.NewDirectorySyncer(h.db, SCIM_SOURCE)
+	for _, op := range body.Operations {
+		switch {
+		case op.Path == "members":
+			var members []scimValue
+			err = helper.Decode(op.Value, &members, nil)
+			if err != nil {
+				return nil, errors.BadInput.Wrap(err, "invalid members")
+			}
+			if strings.EqualFold(op.Op, "replace") {
+				err = syncer.SetTeamUsers(team.Id, nil)
+				if err != nil {
+					return nil, err
+				}
+			}
+			for _, member := range members {
+				if strings.EqualFold(op.Op, "remove") {
+					err = syncer.RemoveTeamUser(team.Id, member.Value)
+				} else {
+					err = syncer.AddTeamUser(team.Id, member.Value)
+				}
+				if err != nil {
+					return nil, err
+				}
+			}
+		case scimMemberPathPattern.MatchString(op.Path) && strings.EqualFold(op.Op, "remove"):
+			err = syncer.RemoveTeamUser(team.Id, scimMemberPathPattern.FindStringSubmatch(op.Path)[1])
+			if err != nil {
+				return nil, err
+			}
+		case op.Path == "displayName" && strings.EqualFold(op.Op, "replace"):
+			team.Name, _ = op.Value.(string)
+			team.RawDataTable = tasks.RAW_DIRECTORY_TABLE
+			team.RawDataParams = SCIM_SOURCE
+			err = h.db.Update(team)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.BadInput.New(fmt.Sprintf("unsupported operation %s %s on group", op.Op, op.Path))
+		}
+	}
+	return h.GetScimGroup(input)
+}
+
+// DeleteScimGroup deletes a team and its memberships
+// @Summary      Delete a team through SCIM
+// @Tags 		 plugins/org
+// @Param        id  path  string  true  "team id"
+// @Success      204
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/scim/v2/Groups/{id} [delete]
+func (h *Handlers) DeleteScimGroup(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	err := tasks.NewDirectorySyncer(h.db, SCIM_SOURCE).DeleteTeam(input.Params["id"])
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Status: http.StatusNoContent}, nil
+}
+
+func (h *Handlers) findUser(id string) (*crossdomain.User, errors.Error) {
+	user := &crossdomain.User{}
+	err := h.db.First(user, dal.Where("id = ?", id))
+	if err != nil {
+		if h.db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.Wrap(err, fmt.Sprintf("could not find user %s", id))
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (h *Handlers) findTeam(id string) (*crossdomain.Team, errors.Error) {
+	team := &crossdomain.Team{}
+	err := h.db.First(team, dal.Where("id = ?", id))
+	if err != nil {
+		if h.db.IsErrorNotFound(err) {
+			return nil, errors.NotFound.Wrap(err, fmt.Sprintf("could not find team %s", id))
+		}
+		return nil, err
+	}
+	return team, nil
+}
+
+func (h *Handlers) toScimUser(user *crossdomain.User) (*scimUser, errors.Error) {
+	var teamIds []string
+	err := h.db.Pluck("team_id", &teamIds, dal.From(&crossdomain.TeamUser{}), dal.Where("user_id = ?", user.Id))
+	if err != nil {
+		return nil, err
+	}
+	active := user.DeletedAt == nil
+	u := &scimUser{
+		Schemas:     []string{scimUserSchema},
+		Id:          user.Id,
+		ExternalId:  user.Id,
+		UserName:    user.Id,
+		Name:        &scimName{Formatted: user.Name},
+		DisplayName: user.Name,
+		Active:      &active,
+	}
+	if user.Email != "" {
+		u.Emails = []scimValue{{Value: user.Email, Primary: true}}
+	}
+	for _, teamId := range teamIds {
+		u.Groups = append(u.Groups, scimValue{Value: teamId})
+	}
+	return u, nil
+}
+
+func (h *Handlers) toScimGroup(team *crossdomain.Team) (*scimGroup, errors.Error) {
+	var userIds []string
+	err := h.db.Pluck("user_id", &userIds, dal.From(&crossdomain.TeamUser{}), dal.Where("team_id = ?", team.Id))
+	if err != nil {
+		return nil, err
+	}
+	g := &scimGroup{
+		Schemas:     []string{scimGroupSchema, scimTeamSchema},
+		Id:          team.Id,
+		ExternalId:  team.Id,
+		DisplayName: team.Name,
+		Team: &scimTeam{
+			ParentId:     team.ParentId,
+			Alias:        team.Alias,
+			SortingIndex: team.SortingIndex,
+		},
+	}
+	for _, userId := range userIds {
+		g.Members = append(g.Members, scimValue{Value: userId})
+	}
+	return g, nil
+}
+
+func scimPrimaryValue(values []scimValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+func scimPage(input *plugin.ApiResourceInput) (startIndex int, count int) {
+	startIndex, _ = strconv.Atoi(input.Query.Get("startIndex"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(input.Query.Get("count"))
+	if err != nil || count < 0 || count > 1000 {
+		count = 100
+	}
+	return startIndex, count
+}
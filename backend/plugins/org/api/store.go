@@ -23,6 +23,7 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/org/models"
 	"reflect"
 )
 
@@ -32,6 +33,8 @@ type store interface {
 	findAllAccounts() ([]account, errors.Error)
 	findAllUserAccounts() ([]userAccount, errors.Error)
 	findAllProjectMapping() ([]projectMapping, errors.Error)
+	findUserAccountCandidates(status string) ([]models.UserAccountCandidate, errors.Error)
+	reviewUserAccountCandidate(accountId, userId string, approved bool) errors.Error
 	deleteAll(i interface{}) errors.Error
 	save(items []interface{}) errors.Error
 }
@@ -104,6 +107,64 @@ func (d *dbStore) findAllProjectMapping() ([]projectMapping, errors.Error) {
 	var pm *projectMapping
 	return pm.fromDomainLayer(mapping), nil
 }
+func (d *dbStore) findUserAccountCandidates(status string) ([]models.UserAccountCandidate, errors.Error) {
+	var clauses []dal.Clause
+	if status != "" {
+		clauses = append(clauses, dal.Where("status = ?", status))
+	}
+	clauses = append(clauses, dal.Orderby("confidence DESC"))
+	var candidates []models.UserAccountCandidate
+	err := d.db.All(&candidates, clauses...)
+	if err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// reviewUserAccountCandidate marks the candidate as reviewed, approved candidates are copied into user_accounts
+func (d *dbStore) reviewUserAccountCandidate(accountId, userId string, approved bool) (err errors.Error) {
+	tx := d.db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	candidate := &models.UserAccountCandidate{}
+	err = tx.First(candidate, dal.Where("account_id = ? AND user_id = ?", accountId, userId), dal.Lock(true, false))
+	if err != nil {
+		if tx.IsErrorNotFound(err) {
+			return errors.NotFound.Wrap(err, "could not find the user account candidate")
+		}
+		return err
+	}
+	candidate.Status = models.CANDIDATE_REJECTED
+	if approved {
+		candidate.Status = models.CANDIDATE_APPROVED
+		err = tx.CreateOrUpdate(&crossdomain.UserAccount{
+			UserId:    candidate.UserId,
+			AccountId: candidate.AccountId,
+		})
+		if err != nil {
+			return err
+		}
+		// an account belongs to one user only, the other candidates are obsolete
+		err = tx.Delete(
+			&models.UserAccountCandidate{},
+			dal.Where("account_id = ? AND user_id != ? AND status = ?", accountId, userId, models.CANDIDATE_PENDING),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Update(candidate)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func (d *dbStore) deleteAll(i interface{}) errors.Error {
 	return d.db.Delete(i, dal.Where("1=1"))
 }
@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/org/models"
+	"net/http"
+)
+
+type reviewUserAccountCandidateRequest struct {
+	UserId   string `json:"userId" validate:"required"`
+	Approved bool   `json:"approved"`
+}
+
+// GetUserAccountCandidates returns the user/account links found by connectUserAccountsFuzzy
+// @Summary      Get user account candidates
+// @Description  get user/account links with low confidence, sorted by confidence
+// @Tags 		 plugins/org
+// @Produce      json
+// @Param        status    query     string  false  "PENDING, APPROVED or REJECTED"
+// @Success      200  {object} []models.UserAccountCandidate
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/user_account_candidates [get]
+func (h *Handlers) GetUserAccountCandidates(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	candidates, err := h.store.findUserAccountCandidates(input.Query.Get("status"))
+	if err != nil {
+		return nil, err
+	}
+	return &plugin.ApiResourceOutput{Body: candidates, Status: http.StatusOK}, nil
+}
+
+// ReviewUserAccountCandidate approves or rejects a user/account link, approved links are saved into user_accounts
+// @Summary      Review a user account candidate
+// @Description  approve or reject a user/account link
+// @Tags 		 plugins/org
+// @Accept       application/json
+// @Param        accountId  path  string  true  "account id"
+// @Param        body  body  reviewUserAccountCandidateRequest  true  "json"
+// @Success      200
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router       /plugins/org/user_account_candidates/{accountId} [patch]
+func (h *Handlers) ReviewUserAccountCandidate(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+	accountId := input.Params["accountId"]
+	if accountId == "" {
+		return nil, errors.BadInput.New("accountId is required")
+	}
+	var request reviewUserAccountCandidateRequest
+	err := helper.Decode(input.Body, &request, h.validator)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid request body")
+	}
+	err = h.store.reviewUserAccountCandidate(accountId, request.UserId, request.Approved)
+	if err != nil {
+		return nil, err
+	}
+	status := models.CANDIDATE_REJECTED
+	if request.Approved {
+		status = models.CANDIDATE_APPROVED
+	}
+	return &plugin.ApiResourceOutput{Body: map[string]string{"status": status}, Status: http.StatusOK}, nil
+}
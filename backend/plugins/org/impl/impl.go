@@ -18,19 +18,27 @@ limitations under the License.
 package impl
 
 import (
+	"fmt"
 	"github.com/apache/incubator-devlake/core/context"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/plugins/org/api"
+	"github.com/apache/incubator-devlake/plugins/org/models"
+	"github.com/apache/incubator-devlake/plugins/org/models/migrationscripts"
 	"github.com/apache/incubator-devlake/plugins/org/tasks"
+	"strings"
+	"time"
 )
 
 var _ plugin.PluginMeta = (*Org)(nil)
+var _ plugin.PluginCompatibility = (*Org)(nil)
 var _ plugin.PluginInit = (*Org)(nil)
 var _ plugin.PluginTask = (*Org)(nil)
 var _ plugin.PluginModel = (*Org)(nil)
+var _ plugin.PluginMigration = (*Org)(nil)
+var _ plugin.PluginMetric = (*Org)(nil)
 
 type Org struct {
 	handlers *api.Handlers
@@ -42,16 +50,49 @@ func (p *Org) Init(basicRes context.BasicRes) errors.Error {
 }
 
 func (p Org) GetTablesInfo() []dal.Tabler {
-	return []dal.Tabler{}
+	return []dal.Tabler{
+		&models.UserAccountCandidate{},
+	}
 }
 
 func (p Org) Description() string {
 	return "collect data related to team and organization"
 }
 
+func (p Org) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Org) Dependencies() []string {
+	return nil
+}
+
+func (p Org) RequiredDataEntities() (data []map[string]interface{}, err errors.Error) {
+	return []map[string]interface{}{
+		{"model": "teams"},
+		{"model": "team_users"},
+		{"model": "user_accounts"},
+	}, nil
+}
+
+func (p Org) IsProjectMetric() bool {
+	return false
+}
+
+func (p Org) RunAfter() ([]string, errors.Error) {
+	return []string{}, nil
+}
+
+func (p Org) Settings() interface{} {
+	return nil
+}
+
 func (p Org) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
+		tasks.SyncDirectoryMeta,
 		tasks.ConnectUserAccountsExactMeta,
+		tasks.ConnectUserAccountsFuzzyMeta,
+		tasks.CalculateTeamMetricsMeta,
 	}
 }
 
@@ -61,9 +102,39 @@ func (p Org) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]inte
 	if err != nil {
 		return nil, errors.BadInput.Wrap(err, "could not decode options")
 	}
+	if op.IdentityMatching == nil {
+		op.IdentityMatching = &tasks.IdentityMatchingOptions{}
+	}
+	if op.IdentityMatching.AutoApproveThreshold == 0 {
+		op.IdentityMatching.AutoApproveThreshold = 0.9
+	}
+	if op.IdentityMatching.ReviewThreshold == nil {
+		reviewThreshold := 0.6
+		op.IdentityMatching.ReviewThreshold = &reviewThreshold
+	}
+	if op.IdentityMatching.MaxNameDistance == 0 {
+		op.IdentityMatching.MaxNameDistance = 2
+	}
 	taskData := &tasks.TaskData{
 		Options: &op,
 	}
+	if op.TeamMetrics == nil {
+		op.TeamMetrics = &tasks.TeamMetricsOptions{}
+	}
+	if op.TeamMetrics.Period == "" {
+		op.TeamMetrics.Period = tasks.TEAM_METRICS_PERIOD_WEEK
+	}
+	if op.TeamMetrics.Period != tasks.TEAM_METRICS_PERIOD_DAY &&
+		op.TeamMetrics.Period != tasks.TEAM_METRICS_PERIOD_WEEK &&
+		op.TeamMetrics.Period != tasks.TEAM_METRICS_PERIOD_MONTH {
+		return nil, errors.BadInput.New(fmt.Sprintf("invalid value for `teamMetrics.period`: %s", op.TeamMetrics.Period))
+	}
+	if op.TeamMetrics.Since != "" {
+		taskData.TeamMetricsSince, err = errors.Convert01(time.Parse(time.RFC3339, op.TeamMetrics.Since))
+		if err != nil {
+			return nil, errors.BadInput.Wrap(err, "invalid value for `teamMetrics.since`")
+		}
+	}
 	return taskData, nil
 }
 
@@ -71,8 +142,12 @@ func (p Org) RootPkgPath() string {
 	return "github.com/apache/incubator-devlake/plugins/org"
 }
 
+func (p Org) MigrationScripts() []plugin.MigrationScript {
+	return migrationscripts.All()
+}
+
 func (p Org) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
-	return map[string]map[string]plugin.ApiResourceHandler{
+	resources := map[string]map[string]plugin.ApiResourceHandler{
 		"teams.csv": {
 			"GET": p.handlers.GetTeam,
 			"PUT": p.handlers.CreateTeam,
@@ -90,5 +165,39 @@ func (p Org) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
 			"GET": p.handlers.GetProjectMapping,
 			"PUT": p.handlers.CreateProjectMapping,
 		},
+		"user_account_candidates": {
+			"GET": p.handlers.GetUserAccountCandidates,
+		},
+		"user_account_candidates/:accountId": {
+			"PATCH": p.handlers.ReviewUserAccountCandidate,
+		},
+	}
+	scimResources := map[string]map[string]plugin.ApiResourceHandler{
+		"scim/v2/Users": {
+			"GET":  p.handlers.ListScimUsers,
+			"POST": p.handlers.PutScimUser,
+		},
+		"scim/v2/Users/:id": {
+			"GET":    p.handlers.GetScimUser,
+			"PUT":    p.handlers.PutScimUser,
+			"PATCH":  p.handlers.PatchScimUser,
+			"DELETE": p.handlers.DeleteScimUser,
+		},
+		"scim/v2/Groups": {
+			"GET":  p.handlers.ListScimGroups,
+			"POST": p.handlers.PutScimGroup,
+		},
+		"scim/v2/Groups/:id": {
+			"GET":    p.handlers.GetScimGroup,
+			"PUT":    p.handlers.PutScimGroup,
+			"PATCH":  p.handlers.PatchScimGroup,
+			"DELETE": p.handlers.DeleteScimGroup,
+		},
+	}
+	// resource types are case-insensitive in SCIM, identity providers use both `/Users` and `/users`
+	for path, handlers := range scimResources {
+		resources[path] = handlers
+		resources[strings.ToLower(path)] = handlers
 	}
+	return resources
 }
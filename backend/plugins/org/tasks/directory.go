@@ -0,0 +1,250 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"time"
+)
+
+// RAW_DIRECTORY_TABLE marks users and teams synced from a directory, the params column holds the source name
+const RAW_DIRECTORY_TABLE = "_raw_org_directory"
+
+// DirectoryUser is a user read from an external directory
+type DirectoryUser struct {
+	Id      string
+	Name    string
+	Email   string
+	TeamIds []string
+}
+
+// DirectoryTeam is a team, or group, read from an external directory
+type DirectoryTeam struct {
+	Id           string
+	Name         string
+	Alias        string
+	ParentId     string
+	SortingIndex int
+}
+
+// Directory is a full snapshot of the users and teams of a directory
+type Directory struct {
+	Users []DirectoryUser
+	Teams []DirectoryTeam
+}
+
+// DirectorySource reads users and teams from an external system, i.e. LDAP or a CSV file on a web server
+type DirectorySource interface {
+	Fetch(ctx context.Context) (*Directory, errors.Error)
+}
+
+// DirectorySyncer upserts users, teams and team memberships from a directory into the domain layer.
+// Records are tagged with the source name so users removed from the source can be soft-deleted
+// without touching users uploaded manually or synced from other sources.
+type DirectorySyncer struct {
+	db     dal.Dal
+	source string
+}
+
+// NewDirectorySyncer creates a DirectorySyncer for the named source
+func NewDirectorySyncer(db dal.Dal, source string) *DirectorySyncer {
+	return &DirectorySyncer{db: db, source: source}
+}
+
+func (s *DirectorySyncer) rawDataOrigin() common.NoPKModel {
+	model := common.NewNoPKModel()
+	model.RawDataTable = RAW_DIRECTORY_TABLE
+	model.RawDataParams = s.source
+	return model
+}
+
+// Sync applies the snapshot in a transaction: every user and team in it gets upserted and users previously
+// synced from the same source but missing from the snapshot are soft-deleted
+func (s *DirectorySyncer) Sync(directory *Directory) (err errors.Error) {
+	tx := s.db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback the directory sync")
+			}
+			if r != nil {
+				panic(r)
+			}
+		}
+	}()
+	err = (&DirectorySyncer{db: tx, source: s.source}).sync(directory)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *DirectorySyncer) sync(directory *Directory) errors.Error {
+	for i := range directory.Teams {
+		err := s.UpsertTeam(&directory.Teams[i])
+		if err != nil {
+			return err
+		}
+	}
+	userIds := make([]string, 0, len(directory.Users))
+	for i := range directory.Users {
+		err := s.UpsertUser(&directory.Users[i])
+		if err != nil {
+			return err
+		}
+		userIds = append(userIds, directory.Users[i].Id)
+	}
+	var leftUserIds []string
+	clauses := []dal.Clause{
+		dal.From(&crossdomain.User{}),
+		dal.Where("_raw_data_table = ? AND _raw_data_params = ? AND deleted_at IS NULL", RAW_DIRECTORY_TABLE, s.source),
+	}
+	if len(userIds) > 0 {
+		clauses = append(clauses, dal.Where("id NOT IN ?", userIds))
+	}
+	err := s.db.Pluck("id", &leftUserIds, clauses...)
+	if err != nil {
+		return err
+	}
+	for _, id := range leftUserIds {
+		err = s.DeleteUser(id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertTeam creates or updates the team
+func (s *DirectorySyncer) UpsertTeam(team *DirectoryTeam) errors.Error {
+	if team.Id == "" {
+		return errors.BadInput.New("team id is required")
+	}
+	return s.db.CreateOrUpdate(&crossdomain.Team{
+		DomainEntity: domainlayer.DomainEntity{Id: team.Id, NoPKModel: s.rawDataOrigin()},
+		Name:         team.Name,
+		Alias:        team.Alias,
+		ParentId:     team.ParentId,
+		SortingIndex: team.SortingIndex,
+	})
+}
+
+// UpsertUser creates or updates the user, restores it if it was soft-deleted, and replaces its team memberships
+func (s *DirectorySyncer) UpsertUser(user *DirectoryUser) errors.Error {
+	if user.Id == "" {
+		return errors.BadInput.New("user id is required")
+	}
+	err := s.db.CreateOrUpdate(&crossdomain.User{
+		DomainEntity: domainlayer.DomainEntity{Id: user.Id, NoPKModel: s.rawDataOrigin()},
+		Name:         user.Name,
+		Email:        user.Email,
+	})
+	if err != nil {
+		return err
+	}
+	return s.SetUserTeams(user.Id, user.TeamIds)
+}
+
+// SetUserTeams replaces the team memberships of the user synced from the source, memberships uploaded manually or
+// synced from other sources are kept
+func (s *DirectorySyncer) SetUserTeams(userId string, teamIds []string) errors.Error {
+	err := s.db.Delete(&crossdomain.TeamUser{}, dal.Where(
+		"user_id = ? AND _raw_data_table = ? AND _raw_data_params = ?", userId, RAW_DIRECTORY_TABLE, s.source,
+	))
+	if err != nil {
+		return err
+	}
+	for _, teamId := range teamIds {
+		err = s.AddTeamUser(teamId, userId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTeamUsers replaces the members of the team synced from the source, like SetUserTeams
+func (s *DirectorySyncer) SetTeamUsers(teamId string, userIds []string) errors.Error {
+	err := s.db.Delete(&crossdomain.TeamUser{}, dal.Where(
+		"team_id = ? AND _raw_data_table = ? AND _raw_data_params = ?", teamId, RAW_DIRECTORY_TABLE, s.source,
+	))
+	if err != nil {
+		return err
+	}
+	for _, userId := range userIds {
+		err = s.AddTeamUser(teamId, userId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddTeamUser adds the user to the team
+func (s *DirectorySyncer) AddTeamUser(teamId, userId string) errors.Error {
+	if teamId == "" || userId == "" {
+		return nil
+	}
+	return s.db.CreateOrUpdate(&crossdomain.TeamUser{
+		TeamId:    teamId,
+		UserId:    userId,
+		NoPKModel: s.rawDataOrigin(),
+	})
+}
+
+// RemoveTeamUser removes the user from the team
+func (s *DirectorySyncer) RemoveTeamUser(teamId, userId string) errors.Error {
+	return s.db.Delete(&crossdomain.TeamUser{}, dal.Where("team_id = ? AND user_id = ?", teamId, userId))
+}
+
+// DeleteUser soft-deletes the user so their historical accounts and metrics stay attributed,
+// team memberships synced from the source are removed while those uploaded manually or synced from other sources
+// are kept
+func (s *DirectorySyncer) DeleteUser(id string) errors.Error {
+	err := s.db.UpdateColumn(&crossdomain.User{}, "deleted_at", time.Now(), dal.Where("id = ?", id))
+	if err != nil {
+		return err
+	}
+	return s.SetUserTeams(id, nil)
+}
+
+// DeleteTeam deletes the team and its memberships, sub-teams are moved up to the parent of the team
+func (s *DirectorySyncer) DeleteTeam(id string) errors.Error {
+	team := &crossdomain.Team{}
+	err := s.db.First(team, dal.Where("id = ?", id))
+	if err != nil {
+		if s.db.IsErrorNotFound(err) {
+			return errors.NotFound.Wrap(err, "could not find the team")
+		}
+		return err
+	}
+	err = s.db.UpdateColumn(&crossdomain.Team{}, "parent_id", team.ParentId, dal.Where("parent_id = ?", id))
+	if err != nil {
+		return err
+	}
+	err = s.db.Delete(&crossdomain.TeamUser{}, dal.Where("team_id = ?", id))
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&crossdomain.Team{}, dal.Where("id = ?", id))
+}
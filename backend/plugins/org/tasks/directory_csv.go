@@ -0,0 +1,118 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/gocarina/gocsv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// csvUser and csvTeam share the format of users.csv and teams.csv accepted by the org plugin api
+type csvUser struct {
+	Id      string
+	Name    string
+	Email   string
+	TeamIds string
+}
+
+type csvTeam struct {
+	Id           string
+	Name         string
+	Alias        string
+	ParentId     string
+	SortingIndex int
+}
+
+// csvDownloadTimeout bounds each download so a stalled web server would not hang the task
+const csvDownloadTimeout = 2 * time.Minute
+
+// CsvDirectorySource downloads users.csv and teams.csv from web servers
+type CsvDirectorySource struct {
+	UsersUrl string
+	TeamsUrl string
+	// optional, sent as the Authorization header
+	Authorization string
+	client        *http.Client
+}
+
+// NewCsvDirectorySource creates a DirectorySource reading csv files from the given urls
+func NewCsvDirectorySource(options *DirectorySyncOptions) (*CsvDirectorySource, errors.Error) {
+	if options.UsersUrl == "" {
+		return nil, errors.BadInput.New("usersUrl is required for csv directory")
+	}
+	return &CsvDirectorySource{
+		UsersUrl:      options.UsersUrl,
+		TeamsUrl:      options.TeamsUrl,
+		Authorization: options.Authorization,
+		client:        &http.Client{Timeout: csvDownloadTimeout},
+	}, nil
+}
+
+func (s *CsvDirectorySource) Fetch(ctx context.Context) (*Directory, errors.Error) {
+	directory := &Directory{}
+	if s.TeamsUrl != "" {
+		var teams []csvTeam
+		err := s.download(ctx, s.TeamsUrl, &teams)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range teams {
+			directory.Teams = append(directory.Teams, DirectoryTeam(t))
+		}
+	}
+	var users []csvUser
+	err := s.download(ctx, s.UsersUrl, &users)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		user := DirectoryUser{Id: u.Id, Name: u.Name, Email: u.Email}
+		for _, teamId := range strings.Split(u.TeamIds, ";") {
+			if teamId != "" {
+				user.TeamIds = append(user.TeamIds, teamId)
+			}
+		}
+		directory.Users = append(directory.Users, user)
+	}
+	return directory, nil
+}
+
+func (s *CsvDirectorySource) download(ctx context.Context, url string, items interface{}) errors.Error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.BadInput.Wrap(err, fmt.Sprintf("invalid url %s", url))
+	}
+	if s.Authorization != "" {
+		req.Header.Set("Authorization", s.Authorization)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to download %s", url))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.HttpStatus(res.StatusCode).New(fmt.Sprintf("failed to download %s", url))
+	}
+	return errors.Convert(gocsv.UnmarshalCSV(csv.NewReader(res.Body), items))
+}
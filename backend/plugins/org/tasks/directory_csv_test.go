@@ -0,0 +1,62 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCsvDirectorySource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/users.csv":
+			_, _ = w.Write([]byte("Id,Name,Email,TeamIds\n1,Jane Smith,jane@example.com,1;2\n2,John Doe,john@example.com,\n"))
+		case "/teams.csv":
+			_, _ = w.Write([]byte("Id,Name,Alias,ParentId,SortingIndex\n1,Platform,PL,2,0\n2,Engineering,ENG,,1\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	source, err := NewCsvDirectorySource(&DirectorySyncOptions{
+		UsersUrl:      server.URL + "/users.csv",
+		TeamsUrl:      server.URL + "/teams.csv",
+		Authorization: "Bearer secret",
+	})
+	assert.Nil(t, err)
+	directory, err := source.Fetch(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []DirectoryUser{
+		{Id: "1", Name: "Jane Smith", Email: "jane@example.com", TeamIds: []string{"1", "2"}},
+		{Id: "2", Name: "John Doe", Email: "john@example.com"},
+	}, directory.Users)
+	assert.Equal(t, []DirectoryTeam{
+		{Id: "1", Name: "Platform", Alias: "PL", ParentId: "2", SortingIndex: 0},
+		{Id: "2", Name: "Engineering", Alias: "ENG", ParentId: "", SortingIndex: 1},
+	}, directory.Teams)
+
+	source.UsersUrl = server.URL + "/missing.csv"
+	_, err = source.Fetch(context.Background())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,154 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/go-ldap/ldap/v3"
+	"strings"
+)
+
+// LdapDirectorySource reads users and groups from LDAP or Active Directory, groups become teams and
+// nested groups become sub-teams
+type LdapDirectorySource struct {
+	options *DirectorySyncOptions
+}
+
+// NewLdapDirectorySource creates a DirectorySource searching the given LDAP server
+func NewLdapDirectorySource(options *DirectorySyncOptions) (*LdapDirectorySource, errors.Error) {
+	if options.LdapUrl == "" {
+		return nil, errors.BadInput.New("ldapUrl is required for ldap directory")
+	}
+	if options.UserBaseDn == "" {
+		return nil, errors.BadInput.New("userBaseDn is required for ldap directory")
+	}
+	if options.UserFilter == "" {
+		options.UserFilter = "(objectClass=person)"
+	}
+	if options.GroupFilter == "" {
+		options.GroupFilter = "(|(objectClass=group)(objectClass=groupOfNames))"
+	}
+	if options.UserIdAttribute == "" {
+		options.UserIdAttribute = "uid"
+	}
+	if options.UserNameAttribute == "" {
+		options.UserNameAttribute = "cn"
+	}
+	if options.UserEmailAttribute == "" {
+		options.UserEmailAttribute = "mail"
+	}
+	if options.GroupIdAttribute == "" {
+		options.GroupIdAttribute = "cn"
+	}
+	if options.GroupNameAttribute == "" {
+		options.GroupNameAttribute = "cn"
+	}
+	if options.GroupMemberAttribute == "" {
+		options.GroupMemberAttribute = "member"
+	}
+	if options.GroupParentAttribute == "" {
+		options.GroupParentAttribute = "memberOf"
+	}
+	return &LdapDirectorySource{options: options}, nil
+}
+
+func (s *LdapDirectorySource) Fetch(ctx context.Context) (*Directory, errors.Error) {
+	op := s.options
+	conn, err := ldap.DialURL(op.LdapUrl)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to connect to ldap server")
+	}
+	defer conn.Close()
+	if op.BindDn != "" {
+		err = conn.Bind(op.BindDn, op.BindPassword)
+		if err != nil {
+			return nil, errors.Unauthorized.Wrap(err, "failed to bind to ldap server")
+		}
+	}
+
+	userEntries, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		op.UserBaseDn, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		op.UserFilter,
+		[]string{op.UserIdAttribute, op.UserNameAttribute, op.UserEmailAttribute},
+		nil,
+	), 500)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to search ldap users")
+	}
+	if ctx.Err() != nil {
+		return nil, errors.Convert(ctx.Err())
+	}
+	directory := &Directory{}
+	userIndexes := make(map[string]int)
+	for _, entry := range userEntries.Entries {
+		id := entry.GetAttributeValue(op.UserIdAttribute)
+		if id == "" {
+			continue
+		}
+		userIndexes[strings.ToLower(entry.DN)] = len(directory.Users)
+		directory.Users = append(directory.Users, DirectoryUser{
+			Id:    id,
+			Name:  entry.GetAttributeValue(op.UserNameAttribute),
+			Email: entry.GetAttributeValue(op.UserEmailAttribute),
+		})
+	}
+	if op.GroupBaseDn == "" {
+		return directory, nil
+	}
+
+	groupEntries, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		op.GroupBaseDn, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		op.GroupFilter,
+		[]string{op.GroupIdAttribute, op.GroupNameAttribute, op.GroupMemberAttribute, op.GroupParentAttribute},
+		nil,
+	), 500)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "failed to search ldap groups")
+	}
+	groupIds := make(map[string]string)
+	for _, entry := range groupEntries.Entries {
+		groupIds[strings.ToLower(entry.DN)] = entry.GetAttributeValue(op.GroupIdAttribute)
+	}
+	for i, entry := range groupEntries.Entries {
+		teamId := groupIds[strings.ToLower(entry.DN)]
+		if teamId == "" {
+			continue
+		}
+		team := DirectoryTeam{
+			Id:           teamId,
+			Name:         entry.GetAttributeValue(op.GroupNameAttribute),
+			SortingIndex: i,
+		}
+		// a team has one parent only, pick the first parent group we know
+		for _, parentDn := range entry.GetAttributeValues(op.GroupParentAttribute) {
+			if parentId, ok := groupIds[strings.ToLower(parentDn)]; ok {
+				team.ParentId = parentId
+				break
+			}
+		}
+		directory.Teams = append(directory.Teams, team)
+		for _, memberDn := range entry.GetAttributeValues(op.GroupMemberAttribute) {
+			if userIndex, ok := userIndexes[strings.ToLower(memberDn)]; ok {
+				user := &directory.Users[userIndex]
+				user.TeamIds = append(user.TeamIds, teamId)
+			}
+		}
+	}
+	return directory, nil
+}
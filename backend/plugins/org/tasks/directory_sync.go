@@ -0,0 +1,79 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+var SyncDirectoryMeta = plugin.SubTaskMeta{
+	Name:             "syncDirectory",
+	EntryPoint:       SyncDirectory,
+	EnabledByDefault: false,
+	Description:      "sync users, teams and team memberships from LDAP or csv files on a web server",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+}
+
+func SyncDirectory(taskCtx plugin.SubTaskContext) errors.Error {
+	data := taskCtx.GetData().(*TaskData)
+	op := data.Options.DirectorySync
+	if op == nil {
+		taskCtx.GetLogger().Info("directorySync is not configured, skip")
+		return nil
+	}
+	source, err := NewDirectorySource(op)
+	if err != nil {
+		return err
+	}
+	directory, err := source.Fetch(taskCtx.GetContext())
+	if err != nil {
+		return err
+	}
+	if len(directory.Users) == 0 {
+		return errors.Default.New("no user was found in the directory, refuse to soft-delete all of the synced users")
+	}
+	taskCtx.GetLogger().Info("fetched %d users and %d teams from %s", len(directory.Users), len(directory.Teams), op.Name)
+	taskCtx.SetProgress(0, len(directory.Users)+len(directory.Teams))
+	err = NewDirectorySyncer(taskCtx.GetDal(), directorySourceName(op)).Sync(directory)
+	if err != nil {
+		return err
+	}
+	taskCtx.SetProgress(len(directory.Users)+len(directory.Teams), len(directory.Users)+len(directory.Teams))
+	return nil
+}
+
+// NewDirectorySource creates the DirectorySource for the configured directory type
+func NewDirectorySource(op *DirectorySyncOptions) (DirectorySource, errors.Error) {
+	switch op.Type {
+	case DIRECTORY_TYPE_CSV:
+		return NewCsvDirectorySource(op)
+	case DIRECTORY_TYPE_LDAP:
+		return NewLdapDirectorySource(op)
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported directory type %s", op.Type))
+	}
+}
+
+func directorySourceName(op *DirectorySyncOptions) string {
+	if op.Name != "" {
+		return fmt.Sprintf("%s:%s", op.Type, op.Name)
+	}
+	return op.Type
+}
@@ -0,0 +1,77 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectorySyncerSync(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	if !assert.Nil(t, err) {
+		return
+	}
+	d := dalgorm.NewDalgorm(db)
+	assert.Nil(t, d.AutoMigrate(&crossdomain.User{}))
+	assert.Nil(t, d.AutoMigrate(&crossdomain.Team{}))
+	assert.Nil(t, d.AutoMigrate(&crossdomain.TeamUser{}))
+
+	syncer := NewDirectorySyncer(d, "ldap:1")
+	assert.Nil(t, syncer.Sync(&Directory{
+		Users: []DirectoryUser{
+			{Id: "a", Name: "Alice", TeamIds: []string{"dev"}},
+			{Id: "b", Name: "Bob", TeamIds: []string{"dev"}},
+		},
+		Teams: []DirectoryTeam{{Id: "dev", Name: "Dev"}, {Id: "ops", Name: "Ops"}},
+	}))
+	// memberships uploaded manually must survive the next sync, even for deleted users
+	assert.Nil(t, d.Create(&crossdomain.TeamUser{TeamId: "ops", UserId: "a"}))
+	assert.Nil(t, d.Create(&crossdomain.TeamUser{TeamId: "ops", UserId: "b"}))
+
+	assert.Nil(t, syncer.Sync(&Directory{
+		Users: []DirectoryUser{{Id: "a", Name: "Alice", TeamIds: []string{"dev"}}},
+		Teams: []DirectoryTeam{{Id: "dev", Name: "Dev"}, {Id: "ops", Name: "Ops"}},
+	}))
+
+	alice := &crossdomain.User{}
+	assert.Nil(t, d.First(alice, dal.Where("id = ?", "a")))
+	assert.Nil(t, alice.DeletedAt)
+	bob := &crossdomain.User{}
+	assert.Nil(t, d.First(bob, dal.Where("id = ?", "b")))
+	assert.NotNil(t, bob.DeletedAt)
+
+	var teamUsers []crossdomain.TeamUser
+	assert.Nil(t, d.All(&teamUsers, dal.Orderby("user_id, team_id")))
+	if assert.Len(t, teamUsers, 3) {
+		assert.Equal(t, "dev", teamUsers[0].TeamId)
+		assert.Equal(t, "ops", teamUsers[1].TeamId)
+		assert.Equal(t, "a", teamUsers[1].UserId)
+		assert.Equal(t, "ops", teamUsers[2].TeamId)
+		assert.Equal(t, "b", teamUsers[2].UserId)
+	}
+}
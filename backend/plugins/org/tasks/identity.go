@@ -0,0 +1,153 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var githubNoreplyPattern = regexp.MustCompile(`^(?:\d+\+)?([a-z0-9-]+)@users\.noreply\.github\.com$`)
+
+// common diacritics and cyrillic letters, enough to match names typed on different keyboards
+var transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ą': "a", 'ă': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i",
+	'ł': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+}
+
+// NormalizeEmail lowercases the email, strips `+tag` suffixes from the local part and dots from gmail addresses
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return ""
+	}
+	local, domain := email[:at], email[at+1:]
+	if domain == "users.noreply.github.com" {
+		return email
+	}
+	if plus := strings.Index(local, "+"); plus > 0 {
+		local = local[:plus]
+	}
+	if domain == "googlemail.com" {
+		domain = "gmail.com"
+	}
+	if domain == "gmail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + domain
+}
+
+// EmailLocalPart returns the normalized part of email before `@`
+func EmailLocalPart(email string) string {
+	email = NormalizeEmail(email)
+	if at := strings.LastIndex(email, "@"); at > 0 {
+		return email[:at]
+	}
+	return ""
+}
+
+// ParseGithubNoreplyEmail extracts the github login from `12345+login@users.noreply.github.com`
+func ParseGithubNoreplyEmail(email string) string {
+	m := githubNoreplyPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(email)))
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// Transliterate lowercases the name and replaces accented and cyrillic letters by their latin counterparts
+func Transliterate(name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if t, ok := transliterations[r]; ok {
+			sb.WriteString(t)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// NormalizeName transliterates the name and drops everything but letters and digits, so
+// `José García`, `jose.garcia` and `jose-garcia` end up the same
+func NormalizeName(name string) string {
+	var sb strings.Builder
+	for _, r := range Transliterate(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Levenshtein returns the edit distance between a and b
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// NameSimilarity returns a score between 0 and 1 based on the edit distance of the normalized names
+func NameSimilarity(a, b string) float64 {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	longest := len([]rune(a))
+	if l := len([]rune(b)); l > longest {
+		longest = l
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
@@ -0,0 +1,229 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"unicode/utf8"
+
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+)
+
+// confidence of each kind of evidence, the best one wins
+const (
+	confidenceLinked        = 1.0
+	confidenceEmail         = 1.0
+	confidenceNoreplyLogin  = 0.9
+	confidenceFullName      = 0.9
+	confidenceUserName      = 0.85
+	confidenceSimilarName   = 0.8
+	confidenceEmailLocal    = 0.7
+	confidenceClusterFactor = 0.95
+	minFuzzyNameLength      = 4
+)
+
+// IdentityMatch is the user an account most likely belongs to
+type IdentityMatch struct {
+	UserId     string
+	Confidence float64
+	Reason     string
+}
+
+// IdentityMatcher links accounts to users by evidence of different strength, and propagates
+// links between accounts of different tools sharing the same email or login
+type IdentityMatcher struct {
+	users           []crossdomain.User
+	maxNameDistance int
+	emails          map[string]string
+	emailLocals     map[string]string
+	names           map[string]string
+}
+
+// NewIdentityMatcher indexes users by normalized email and name, ambiguous keys are dropped
+func NewIdentityMatcher(users []crossdomain.User, maxNameDistance int) *IdentityMatcher {
+	m := &IdentityMatcher{
+		users:           users,
+		maxNameDistance: maxNameDistance,
+		emails:          make(map[string]string),
+		emailLocals:     make(map[string]string),
+		names:           make(map[string]string),
+	}
+	for _, user := range users {
+		addUniqueKey(m.emails, NormalizeEmail(user.Email), user.Id)
+		addUniqueKey(m.emailLocals, EmailLocalPart(user.Email), user.Id)
+		addUniqueKey(m.names, NormalizeName(user.Name), user.Id)
+	}
+	return m
+}
+
+// MatchAll returns the best match for every account which is not in userAccounts yet, keyed by account id
+func (m *IdentityMatcher) MatchAll(accounts []crossdomain.Account, userAccounts []crossdomain.UserAccount) map[string]*IdentityMatch {
+	linked := make(map[string]string)
+	for _, ua := range userAccounts {
+		linked[ua.AccountId] = ua.UserId
+	}
+	clusters := newAccountClusters(accounts)
+	// the user each cluster belongs to, or nil if members point to different users
+	clusterMatches := make(map[string]*IdentityMatch)
+	conflicts := make(map[string]bool)
+	vote := func(root string, match *IdentityMatch) {
+		if conflicts[root] {
+			return
+		}
+		if current, ok := clusterMatches[root]; ok {
+			if current.UserId != match.UserId {
+				conflicts[root] = true
+				delete(clusterMatches, root)
+			} else if match.Confidence > current.Confidence {
+				clusterMatches[root] = match
+			}
+			return
+		}
+		clusterMatches[root] = match
+	}
+
+	matches := make(map[string]*IdentityMatch)
+	for i := range accounts {
+		account := &accounts[i]
+		root := clusters.find(account.Id)
+		if userId, ok := linked[account.Id]; ok {
+			vote(root, &IdentityMatch{UserId: userId, Confidence: confidenceLinked, Reason: "linked account"})
+			continue
+		}
+		if match := m.Match(account); match != nil {
+			matches[account.Id] = match
+			vote(root, match)
+		}
+	}
+	for i := range accounts {
+		account := &accounts[i]
+		if _, ok := linked[account.Id]; ok {
+			continue
+		}
+		clusterMatch, ok := clusterMatches[clusters.find(account.Id)]
+		if !ok {
+			continue
+		}
+		confidence := clusterMatch.Confidence * confidenceClusterFactor
+		if current, ok := matches[account.Id]; ok && (current.UserId == clusterMatch.UserId || current.Confidence >= confidence) {
+			continue
+		}
+		matches[account.Id] = &IdentityMatch{
+			UserId:     clusterMatch.UserId,
+			Confidence: confidence,
+			Reason:     "same identity as another account: " + clusterMatch.Reason,
+		}
+	}
+	return matches
+}
+
+// Match returns the user the account most likely belongs to, or nil if nothing looks alike
+func (m *IdentityMatcher) Match(account *crossdomain.Account) *IdentityMatch {
+	var best *IdentityMatch
+	consider := func(userId string, confidence float64, reason string) {
+		if userId != "" && (best == nil || confidence > best.Confidence) {
+			best = &IdentityMatch{UserId: userId, Confidence: confidence, Reason: reason}
+		}
+	}
+	if email := NormalizeEmail(account.Email); email != "" {
+		consider(m.emails[email], confidenceEmail, "email")
+		consider(m.emailLocals[EmailLocalPart(email)], confidenceEmailLocal, "email local part")
+	}
+	if login := ParseGithubNoreplyEmail(account.Email); login != "" {
+		consider(m.names[NormalizeName(login)], confidenceNoreplyLogin, "github noreply email")
+		consider(m.emailLocals[login], confidenceNoreplyLogin, "github noreply email")
+	}
+	consider(m.names[NormalizeName(account.FullName)], confidenceFullName, "full name")
+	consider(m.names[NormalizeName(account.UserName)], confidenceUserName, "user name")
+	if best != nil && best.Confidence >= confidenceSimilarName {
+		return best
+	}
+	for _, user := range m.users {
+		for _, name := range []string{account.FullName, account.UserName} {
+			if m.isSimilarName(name, user.Name) {
+				consider(user.Id, confidenceSimilarName*NameSimilarity(name, user.Name), "similar name")
+			}
+		}
+	}
+	return best
+}
+
+func (m *IdentityMatcher) isSimilarName(a, b string) bool {
+	a, b = NormalizeName(a), NormalizeName(b)
+	if utf8.RuneCountInString(a) < minFuzzyNameLength || utf8.RuneCountInString(b) < minFuzzyNameLength {
+		return false
+	}
+	return Levenshtein(a, b) <= m.maxNameDistance
+}
+
+func addUniqueKey(index map[string]string, key, id string) {
+	if key == "" {
+		return
+	}
+	if existing, ok := index[key]; ok && existing != id {
+		// keep the key but make it unusable, two users share it
+		index[key] = ""
+		return
+	}
+	index[key] = id
+}
+
+// accountClusters is a union-find of accounts sharing a normalized email or login
+type accountClusters struct {
+	parents map[string]string
+}
+
+func newAccountClusters(accounts []crossdomain.Account) *accountClusters {
+	c := &accountClusters{parents: make(map[string]string)}
+	owners := make(map[string]string)
+	for _, account := range accounts {
+		c.parents[account.Id] = account.Id
+		keys := []string{"email:" + NormalizeEmail(account.Email)}
+		if login := ParseGithubNoreplyEmail(account.Email); login != "" {
+			keys = append(keys, "login:"+NormalizeName(login))
+		}
+		if login := NormalizeName(account.UserName); login != "" {
+			keys = append(keys, "login:"+login)
+		}
+		for _, key := range keys {
+			if key == "email:" {
+				continue
+			}
+			if owner, ok := owners[key]; ok {
+				c.union(owner, account.Id)
+			} else {
+				owners[key] = account.Id
+			}
+		}
+	}
+	return c
+}
+
+func (c *accountClusters) find(id string) string {
+	for c.parents[id] != id {
+		c.parents[id] = c.parents[c.parents[id]]
+		id = c.parents[id]
+	}
+	return id
+}
+
+func (c *accountClusters) union(a, b string) {
+	ra, rb := c.find(a), c.find(b)
+	if ra != rb {
+		c.parents[rb] = ra
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "john@example.com", NormalizeEmail(" John+jira@Example.com "))
+	assert.Equal(t, "johndoe@gmail.com", NormalizeEmail("john.doe@googlemail.com"))
+	assert.Equal(t, "", NormalizeEmail("not-an-email"))
+}
+
+func TestParseGithubNoreplyEmail(t *testing.T) {
+	assert.Equal(t, "octocat", ParseGithubNoreplyEmail("583231+octocat@users.noreply.github.com"))
+	assert.Equal(t, "octocat", ParseGithubNoreplyEmail("octocat@users.noreply.github.com"))
+	assert.Equal(t, "", ParseGithubNoreplyEmail("octocat@github.com"))
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert.Equal(t, "josegarcia", NormalizeName("José García"))
+	assert.Equal(t, "josegarcia", NormalizeName("jose.garcia"))
+	assert.Equal(t, "ivanpetrov", NormalizeName("Иван Петров"))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, Levenshtein("kitten", "kitten"))
+	assert.Equal(t, 3, Levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, Levenshtein("", "abcd"))
+}
+
+func TestIdentityMatcher(t *testing.T) {
+	users := []crossdomain.User{
+		{DomainEntity: domainlayer.DomainEntity{Id: "1"}, Name: "José García", Email: "jose@example.com"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "2"}, Name: "Jane Smith", Email: "jane@example.com"},
+	}
+	accounts := []crossdomain.Account{
+		// exact email after normalization
+		{DomainEntity: domainlayer.DomainEntity{Id: "gitlab:1"}, Email: "Jose+gitlab@example.com"},
+		// misspelled name
+		{DomainEntity: domainlayer.DomainEntity{Id: "jira:1"}, FullName: "Jane Smyth"},
+		// linked to jane through the noreply email of another account
+		{DomainEntity: domainlayer.DomainEntity{Id: "github:1"}, UserName: "jsmith-dev"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "git:1"}, Email: "1234+jsmith-dev@users.noreply.github.com"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "git:2"}, Email: "nobody@example.org"},
+	}
+	userAccounts := []crossdomain.UserAccount{
+		{UserId: "2", AccountId: "github:1"},
+	}
+	matches := NewIdentityMatcher(users, 2).MatchAll(accounts, userAccounts)
+
+	assert.Equal(t, "1", matches["gitlab:1"].UserId)
+	assert.Equal(t, confidenceEmail, matches["gitlab:1"].Confidence)
+	assert.Equal(t, "2", matches["jira:1"].UserId)
+	assert.Less(t, matches["jira:1"].Confidence, confidenceSimilarName)
+	assert.Equal(t, "2", matches["git:1"].UserId)
+	assert.Equal(t, confidenceLinked*confidenceClusterFactor, matches["git:1"].Confidence)
+	assert.NotContains(t, matches, "github:1")
+	assert.NotContains(t, matches, "git:2")
+}
+
+func TestIsSimilarName(t *testing.T) {
+	matcher := NewIdentityMatcher(nil, 1)
+	assert.True(t, matcher.isSimilarName("Jane Smith", "Jane Smyth"))
+	// lengths are counted in characters rather than bytes
+	assert.False(t, matcher.isSimilarName("王小明", "王小華"))
+	assert.True(t, matcher.isSimilarName("王小明明", "王小華明"))
+}
@@ -17,12 +17,79 @@ limitations under the License.
 
 package tasks
 
+import (
+	"time"
+)
+
 type Options struct {
-	ConnectionId uint64 `json:"connectionId"`
+	ConnectionId     uint64                   `json:"connectionId"`
+	IdentityMatching *IdentityMatchingOptions `json:"identityMatching"`
+	DirectorySync    *DirectorySyncOptions    `json:"directorySync"`
+	TeamMetrics      *TeamMetricsOptions      `json:"teamMetrics"`
+}
+
+const (
+	TEAM_METRICS_PERIOD_DAY   = "day"
+	TEAM_METRICS_PERIOD_WEEK  = "week"
+	TEAM_METRICS_PERIOD_MONTH = "month"
+)
+
+// TeamMetricsOptions controls how calculateTeamMetrics buckets the metrics
+type TeamMetricsOptions struct {
+	// day, week or month
+	Period string `json:"period"`
+	// RFC3339 time, metrics of periods before this are kept as they were
+	Since string `json:"since"`
+}
+
+// IdentityMatchingOptions tunes how connectUserAccountsFuzzy links accounts to users
+type IdentityMatchingOptions struct {
+	// matches with confidence above this value are written into user_accounts directly
+	AutoApproveThreshold float64 `json:"autoApproveThreshold"`
+	// matches with confidence between ReviewThreshold and AutoApproveThreshold wait for an admin to review, 0.6 if
+	// unset while 0 puts every match up for review
+	ReviewThreshold *float64 `json:"reviewThreshold"`
+	// max edit distance between two normalized names to be considered similar
+	MaxNameDistance int `json:"maxNameDistance"`
+}
+
+const (
+	DIRECTORY_TYPE_CSV  = "csv"
+	DIRECTORY_TYPE_LDAP = "ldap"
+)
+
+// DirectorySyncOptions tells syncDirectory where to read users and teams from
+type DirectorySyncOptions struct {
+	// csv or ldap
+	Type string `json:"type"`
+	// identifies the directory, users removed from it are soft-deleted on the next sync
+	Name string `json:"name"`
+
+	// csv
+	UsersUrl      string `json:"usersUrl"`
+	TeamsUrl      string `json:"teamsUrl"`
+	Authorization string `json:"authorization"`
+
+	// ldap
+	LdapUrl              string `json:"ldapUrl"`
+	BindDn               string `json:"bindDn"`
+	BindPassword         string `json:"bindPassword"`
+	UserBaseDn           string `json:"userBaseDn"`
+	UserFilter           string `json:"userFilter"`
+	UserIdAttribute      string `json:"userIdAttribute"`
+	UserNameAttribute    string `json:"userNameAttribute"`
+	UserEmailAttribute   string `json:"userEmailAttribute"`
+	GroupBaseDn          string `json:"groupBaseDn"`
+	GroupFilter          string `json:"groupFilter"`
+	GroupIdAttribute     string `json:"groupIdAttribute"`
+	GroupNameAttribute   string `json:"groupNameAttribute"`
+	GroupMemberAttribute string `json:"groupMemberAttribute"`
+	GroupParentAttribute string `json:"groupParentAttribute"`
 }
 
 type TaskData struct {
-	Options *Options
+	Options          *Options
+	TeamMetricsSince time.Time
 }
 type Params struct {
 	ConnectionId uint64
@@ -0,0 +1,252 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"reflect"
+	"time"
+)
+
+var CalculateTeamMetricsMeta = plugin.SubTaskMeta{
+	Name:             "calculateTeamMetrics",
+	EntryPoint:       CalculateTeamMetrics,
+	EnabledByDefault: false,
+	Description:      "calculate PRs, commits, reviews, resolved issues and deployments per team and period",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+}
+
+// teamMetricRow is an entity done by a user at a point of time, i.e. a commit authored by the user
+type teamMetricRow struct {
+	EntityId string
+	UserId   string
+	Date     *time.Time
+}
+
+// teamMetricSource selects the rows of a metric, ordered by entity so the rows of the same entity come in a row,
+// and knows which counter of TeamMetric they go to
+type teamMetricSource struct {
+	name    string
+	clauses []dal.Clause
+	inc     func(metric *crossdomain.TeamMetric)
+}
+
+func teamMetricSources(since time.Time) []teamMetricSource {
+	return []teamMetricSource{
+		{
+			name: "prs opened",
+			clauses: []dal.Clause{
+				dal.Select("pr.id AS entity_id, ua.user_id, pr.created_date AS date"),
+				dal.From("pull_requests pr"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = pr.author_id"),
+				dal.Where("pr.created_date >= ?", since),
+				dal.Orderby("pr.id"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.PrsOpened++ },
+		},
+		{
+			name: "prs merged",
+			clauses: []dal.Clause{
+				dal.Select("pr.id AS entity_id, ua.user_id, pr.merged_date AS date"),
+				dal.From("pull_requests pr"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = pr.author_id"),
+				dal.Where("pr.merged_date >= ?", since),
+				dal.Orderby("pr.id"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.PrsMerged++ },
+		},
+		{
+			name: "commits",
+			clauses: []dal.Clause{
+				dal.Select("c.sha AS entity_id, ua.user_id, c.authored_date AS date"),
+				dal.From("commits c"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = c.author_id"),
+				dal.Where("c.authored_date >= ?", since),
+				dal.Orderby("c.sha"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.Commits++ },
+		},
+		{
+			name: "reviews",
+			clauses: []dal.Clause{
+				dal.Select("prc.id AS entity_id, ua.user_id, prc.created_date AS date"),
+				dal.From("pull_request_comments prc"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = prc.account_id"),
+				dal.Where("prc.type = ? AND prc.created_date >= ?", code.REVIEW, since),
+				dal.Orderby("prc.id"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.Reviews++ },
+		},
+		{
+			name: "issues resolved",
+			clauses: []dal.Clause{
+				dal.Select("i.id AS entity_id, ua.user_id, i.resolution_date AS date"),
+				dal.From("issues i"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = i.assignee_id"),
+				dal.Where("i.resolution_date >= ?", since),
+				dal.Orderby("i.id"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.IssuesResolved++ },
+		},
+		{
+			// a deployment counts for every team whose members authored a commit in it
+			name: "deployments",
+			clauses: []dal.Clause{
+				dal.Select("DISTINCT t.id AS entity_id, ua.user_id, t.finished_date AS date"),
+				dal.From("cicd_tasks t"),
+				dal.Join("JOIN cicd_pipeline_commits pc ON pc.pipeline_id = t.pipeline_id"),
+				dal.Join("JOIN commits c ON c.sha = pc.commit_sha"),
+				dal.Join("JOIN user_accounts ua ON ua.account_id = c.author_id"),
+				dal.Where("t.type = ? AND t.result = ? AND t.finished_date >= ?", devops.DEPLOYMENT, devops.SUCCESS, since),
+				dal.Orderby("t.id"),
+			},
+			inc: func(m *crossdomain.TeamMetric) { m.Deployments++ },
+		},
+	}
+}
+
+// CalculateTeamMetrics attributes entities to users through user_accounts, then to every team the users belong
+// to and all of their ancestors. An entity is counted once per team even if several members worked on it.
+func CalculateTeamMetrics(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*TaskData)
+	op := data.Options.TeamMetrics
+	since := TruncateToPeriod(data.TeamMetricsSince, op.Period)
+
+	var teams []crossdomain.Team
+	err := db.All(&teams)
+	if err != nil {
+		return err
+	}
+	var teamUsers []crossdomain.TeamUser
+	err = db.All(&teamUsers)
+	if err != nil {
+		return err
+	}
+	userTeams := ResolveUserTeams(teams, teamUsers)
+
+	metrics := make(map[string]*crossdomain.TeamMetric)
+	sources := teamMetricSources(since)
+	taskCtx.SetProgress(0, len(sources))
+	for _, source := range sources {
+		cursor, err := db.Cursor(source.clauses...)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to query %s", source.name))
+		}
+		// rows are ordered by entity, remembering the teams of the current entity is enough to count it once per team
+		var entityId string
+		var entityTeams map[string]struct{}
+		for cursor.Next() {
+			row := &teamMetricRow{}
+			err = db.Fetch(cursor, row)
+			if err != nil {
+				cursor.Close()
+				return err
+			}
+			if row.Date == nil {
+				continue
+			}
+			if entityTeams == nil || row.EntityId != entityId {
+				entityId = row.EntityId
+				entityTeams = make(map[string]struct{})
+			}
+			periodStart := TruncateToPeriod(*row.Date, op.Period)
+			for _, teamId := range userTeams[row.UserId] {
+				if _, ok := entityTeams[teamId]; ok {
+					continue
+				}
+				entityTeams[teamId] = struct{}{}
+				metricKey := fmt.Sprintf("%s|%d", teamId, periodStart.Unix())
+				metric, ok := metrics[metricKey]
+				if !ok {
+					metric = &crossdomain.TeamMetric{TeamId: teamId, Period: op.Period, PeriodStart: periodStart}
+					metrics[metricKey] = metric
+				}
+				source.inc(metric)
+			}
+		}
+		cursor.Close()
+		taskCtx.IncProgress(1)
+	}
+
+	err = db.Delete(&crossdomain.TeamMetric{}, dal.Where("period = ? AND period_start >= ?", op.Period, since))
+	if err != nil {
+		return err
+	}
+	batch, err := api.NewBatchSave(taskCtx, reflect.TypeOf(&crossdomain.TeamMetric{}), 500)
+	if err != nil {
+		return err
+	}
+	for _, metric := range metrics {
+		err = batch.Add(metric)
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Close()
+}
+
+// ResolveUserTeams returns all the teams each user belongs to, directly or through sub-teams
+func ResolveUserTeams(teams []crossdomain.Team, teamUsers []crossdomain.TeamUser) map[string][]string {
+	parents := make(map[string]string)
+	for _, team := range teams {
+		parents[team.Id] = team.ParentId
+	}
+	userTeams := make(map[string][]string)
+	for _, tu := range teamUsers {
+		// walk up to the root, the visited set protects us from loops in the hierarchy
+		visited := make(map[string]bool)
+		for teamId := tu.TeamId; teamId != "" && !visited[teamId]; teamId = parents[teamId] {
+			visited[teamId] = true
+			if !containsString(userTeams[tu.UserId], teamId) {
+				userTeams[tu.UserId] = append(userTeams[tu.UserId], teamId)
+			}
+		}
+	}
+	return userTeams
+}
+
+// TruncateToPeriod returns the start of the day, week (starting on Monday) or month t belongs to, in UTC
+func TruncateToPeriod(t time.Time, period string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case TEAM_METRICS_PERIOD_DAY:
+		return day
+	case TEAM_METRICS_PERIOD_MONTH:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	}
+}
+
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestResolveUserTeams(t *testing.T) {
+	teams := []crossdomain.Team{
+		{DomainEntity: domainlayer.DomainEntity{Id: "engineering"}},
+		{DomainEntity: domainlayer.DomainEntity{Id: "platform"}, ParentId: "engineering"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "infra"}, ParentId: "platform"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "loop-a"}, ParentId: "loop-b"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "loop-b"}, ParentId: "loop-a"},
+	}
+	teamUsers := []crossdomain.TeamUser{
+		{TeamId: "infra", UserId: "1"},
+		{TeamId: "platform", UserId: "1"},
+		{TeamId: "engineering", UserId: "2"},
+		{TeamId: "loop-a", UserId: "3"},
+	}
+	userTeams := ResolveUserTeams(teams, teamUsers)
+	assert.Equal(t, []string{"infra", "platform", "engineering"}, userTeams["1"])
+	assert.Equal(t, []string{"engineering"}, userTeams["2"])
+	assert.Equal(t, []string{"loop-a", "loop-b"}, userTeams["3"])
+}
+
+func TestTruncateToPeriod(t *testing.T) {
+	// a Wednesday
+	ts := time.Date(2023, 1, 4, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2023, 1, 4, 0, 0, 0, 0, time.UTC), TruncateToPeriod(ts, TEAM_METRICS_PERIOD_DAY))
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), TruncateToPeriod(ts, TEAM_METRICS_PERIOD_WEEK))
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), TruncateToPeriod(ts, TEAM_METRICS_PERIOD_MONTH))
+	// a Sunday belongs to the week started on the Monday before
+	sunday := time.Date(2023, 1, 8, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), TruncateToPeriod(sunday, TEAM_METRICS_PERIOD_WEEK))
+}
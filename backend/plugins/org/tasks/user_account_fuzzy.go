@@ -0,0 +1,141 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/plugins/org/models"
+	"reflect"
+)
+
+const fuzzyUserAccountsTable = "user_accounts_fuzzy"
+
+var ConnectUserAccountsFuzzyMeta = plugin.SubTaskMeta{
+	Name:             "connectUserAccountsFuzzy",
+	EntryPoint:       ConnectUserAccountsFuzzy,
+	EnabledByDefault: false,
+	Description:      "associate users and accounts by normalized emails, similar names and linked accounts of other tools",
+	DomainTypes:      []string{plugin.DOMAIN_TYPE_CROSS},
+}
+
+func ConnectUserAccountsFuzzy(taskCtx plugin.SubTaskContext) errors.Error {
+	db := taskCtx.GetDal()
+	data := taskCtx.GetData().(*TaskData)
+	opts := data.Options.IdentityMatching
+	// users removed from the directory are soft-deleted and no longer take accounts
+	var users []crossdomain.User
+	err := db.All(&users, dal.Where("deleted_at IS NULL"))
+	if err != nil {
+		return err
+	}
+	var accounts []crossdomain.Account
+	err = db.All(&accounts)
+	if err != nil {
+		return err
+	}
+	// links written by this subtask are matched again instead of counting as linked, otherwise the next run would
+	// skip their accounts and the divider would remove the links
+	var userAccounts []crossdomain.UserAccount
+	err = db.All(&userAccounts, dal.Where("_raw_data_table != ?", "_raw_"+fuzzyUserAccountsTable))
+	if err != nil {
+		return err
+	}
+	matches := NewIdentityMatcher(users, opts.MaxNameDistance).MatchAll(accounts, userAccounts)
+
+	// pending candidates are recomputed on every run, reviewed ones are kept
+	err = db.Delete(&models.UserAccountCandidate{}, dal.Where("status = ?", models.CANDIDATE_PENDING))
+	if err != nil {
+		return err
+	}
+	var rejected []models.UserAccountCandidate
+	err = db.All(&rejected, dal.Where("status = ?", models.CANDIDATE_REJECTED))
+	if err != nil {
+		return err
+	}
+	rejectedPairs := make(map[[2]string]bool)
+	for _, r := range rejected {
+		rejectedPairs[[2]string{r.AccountId, r.UserId}] = true
+	}
+
+	// accounts linked by this subtask are matched again, so links no longer matched are removed by the divider
+	clauses := []dal.Clause{
+		dal.Select("*"),
+		dal.From(&crossdomain.Account{}),
+		dal.Where("id NOT IN (SELECT account_id FROM user_accounts WHERE _raw_data_table != ?)", "_raw_"+fuzzyUserAccountsTable),
+	}
+	cursor, err := db.Cursor(clauses...)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close()
+
+	var converter *api.DataConverter
+	converter, err = api.NewDataConverter(api.DataConverterArgs{
+		InputRowType: reflect.TypeOf(crossdomain.Account{}),
+		Input:        cursor,
+		RawDataSubTaskArgs: api.RawDataSubTaskArgs{
+			Ctx: taskCtx,
+			Params: Params{
+				ConnectionId: data.Options.ConnectionId,
+			},
+			// links of the exact subtask are kept apart from those of this one
+			Table: fuzzyUserAccountsTable,
+		},
+
+		Convert: func(inputRow interface{}) ([]interface{}, errors.Error) {
+			account := inputRow.(*crossdomain.Account)
+			// links carry the origin of this subtask instead of the one of the account, which is copied into them by
+			// the converter, so they are told apart from the exact ones and the outdated ones are removed
+			account.RawDataOrigin = common.RawDataOrigin{RawDataTable: converter.GetTable(), RawDataParams: converter.GetParams()}
+			match, ok := matches[account.Id]
+			if !ok || rejectedPairs[[2]string{account.Id, match.UserId}] {
+				return nil, nil
+			}
+			if match.Confidence >= opts.AutoApproveThreshold {
+				return []interface{}{
+					&crossdomain.UserAccount{
+						UserId:    match.UserId,
+						AccountId: account.Id,
+					},
+				}, nil
+			}
+			if match.Confidence >= *opts.ReviewThreshold {
+				err := db.CreateOrUpdate(&models.UserAccountCandidate{
+					AccountId:  account.Id,
+					UserId:     match.UserId,
+					Confidence: match.Confidence,
+					Reason:     match.Reason,
+					Status:     models.CANDIDATE_PENDING,
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return converter.Execute()
+}
@@ -0,0 +1,82 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/runner"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/apache/incubator-devlake/plugins/org/models"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectUserAccountsFuzzy(t *testing.T) {
+	cfg := viper.New()
+	cfg.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	db, err := runner.NewGormDb(cfg, logruslog.Global)
+	require.Nil(t, err)
+	d := dalgorm.NewDalgorm(db)
+	for _, table := range []interface{}{&crossdomain.User{}, &crossdomain.Account{}, &crossdomain.UserAccount{}, &models.UserAccountCandidate{}} {
+		require.Nil(t, d.AutoMigrate(table))
+	}
+	deletedAt := time.Now()
+	require.Nil(t, d.Create(&[]*crossdomain.User{
+		{DomainEntity: domainlayer.DomainEntity{Id: "alice"}, Name: "Alice Smith", Email: "alice@example.com"},
+		// users removed from the directory take no accounts
+		{DomainEntity: domainlayer.DomainEntity{Id: "bob"}, Name: "Bob Jones", Email: "bob@example.com", DeletedAt: &deletedAt},
+	}))
+	require.Nil(t, d.Create(&[]*crossdomain.Account{
+		{DomainEntity: domainlayer.DomainEntity{Id: "github:GithubAccount:1:1"}, Email: "Alice@Example.com"},
+		{DomainEntity: domainlayer.DomainEntity{Id: "github:GithubAccount:1:2"}, Email: "bob@example.com"},
+	}))
+
+	reviewThreshold := 0.6
+	taskData := &TaskData{Options: &Options{
+		ConnectionId:     1,
+		IdentityMatching: &IdentityMatchingOptions{AutoApproveThreshold: 0.9, ReviewThreshold: &reviewThreshold, MaxNameDistance: 2},
+	}}
+	taskCtx := contextimpl.NewStandaloneSubTaskContext(context.Background(), runner.CreateBasicRes(cfg, logruslog.Global, db), "org", taskData)
+	require.Nil(t, ConnectUserAccountsFuzzy(taskCtx))
+
+	// links written by the first run survive the next one, which links a new account as well
+	require.Nil(t, d.Create(&crossdomain.User{DomainEntity: domainlayer.DomainEntity{Id: "carol"}, Name: "Carol White", Email: "carol@example.com"}))
+	require.Nil(t, d.Create(&crossdomain.Account{DomainEntity: domainlayer.DomainEntity{Id: "github:GithubAccount:1:3"}, Email: "carol@example.com"}))
+	require.Nil(t, ConnectUserAccountsFuzzy(taskCtx))
+	var userAccounts []crossdomain.UserAccount
+	require.Nil(t, d.All(&userAccounts, dal.Orderby("account_id")))
+	if assert.Len(t, userAccounts, 2) {
+		assert.Equal(t, "alice", userAccounts[0].UserId)
+		assert.Equal(t, "github:GithubAccount:1:1", userAccounts[0].AccountId)
+		assert.Equal(t, "carol", userAccounts[1].UserId)
+		assert.Equal(t, "github:GithubAccount:1:3", userAccounts[1].AccountId)
+		assert.Equal(t, "_raw_"+fuzzyUserAccountsTable, userAccounts[1].RawDataTable)
+	}
+	count, err := d.Count(dal.From(&models.UserAccountCandidate{}))
+	require.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
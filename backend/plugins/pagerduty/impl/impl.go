@@ -20,6 +20,7 @@ package impl
 import (
 	"fmt"
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
@@ -33,7 +34,9 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*PagerDuty)(nil)
+var _ plugin.PluginCompatibility = (*PagerDuty)(nil)
 var _ plugin.PluginInit = (*PagerDuty)(nil)
+var _ plugin.PluginModel = (*PagerDuty)(nil)
 var _ plugin.PluginTask = (*PagerDuty)(nil)
 var _ plugin.PluginApi = (*PagerDuty)(nil)
 var _ plugin.PluginBlueprintV100 = (*PagerDuty)(nil)
@@ -45,11 +48,28 @@ func (p PagerDuty) Description() string {
 	return "collect some PagerDuty data"
 }
 
-func (p PagerDuty) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
+func (p PagerDuty) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p PagerDuty) Dependencies() []string {
 	return nil
 }
 
+func (p PagerDuty) Init(basicRes context.BasicRes) errors.Error {
+	return api.Init(basicRes)
+}
+
+func (p PagerDuty) GetTablesInfo() []dal.Tabler {
+	return []dal.Tabler{
+		&models.PagerDutyConnection{},
+		&models.Assignment{},
+		&models.Incident{},
+		&models.Service{},
+		&models.User{},
+	}
+}
+
 func (p PagerDuty) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectIncidentsMeta,
@@ -63,10 +83,13 @@ func (p PagerDuty) PrepareTaskData(taskCtx plugin.TaskContext, options map[strin
 	if err != nil {
 		return nil, err
 	}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	connection := &models.PagerDutyConnection{}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
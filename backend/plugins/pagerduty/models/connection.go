@@ -30,7 +30,7 @@ type PagerDutyConnection struct {
 
 type TestConnectionRequest struct {
 	Endpoint string `json:"endpoint" validate:"required,url"`
-	Token    string `json:"token" validate:"required"`
+	Token    string `json:"token" validate:"required" encrypt:"yes"`
 	Proxy    string `json:"proxy"`
 }
 
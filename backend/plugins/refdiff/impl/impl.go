@@ -27,6 +27,7 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*RefDiff)(nil)
+var _ plugin.PluginCompatibility = (*RefDiff)(nil)
 var _ plugin.PluginTask = (*RefDiff)(nil)
 var _ plugin.PluginApi = (*RefDiff)(nil)
 var _ plugin.PluginModel = (*RefDiff)(nil)
@@ -41,6 +42,14 @@ func (p RefDiff) Description() string {
 	return "Calculate commits diff for specified ref pairs based on `commits` and `commit_parents` tables"
 }
 
+func (p RefDiff) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p RefDiff) Dependencies() []string {
+	return nil
+}
+
 func (p RefDiff) RequiredDataEntities() (data []map[string]interface{}, err errors.Error) {
 	return []map[string]interface{}{}, nil
 }
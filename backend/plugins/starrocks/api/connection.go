@@ -37,6 +37,7 @@ func PostStarRocksPipeline(input *plugin.ApiResourceInput) (*plugin.ApiResourceO
 type StarRocksPipelinePlan [][]struct {
 	Plugin  string `json:"plugin"`
 	Options struct {
+		Sink         string            `json:"sink"`
 		SourceType   string            `json:"source_type"`
 		SourceDsn    string            `json:"source_dsn"`
 		UpdateColumn string            `json:"update_column"`
@@ -52,5 +53,6 @@ type StarRocksPipelinePlan [][]struct {
 		OrderBy      map[string]string `json:"order_by"`
 		Extra        string            `json:"extra"`
 		DomainLayer  string            `json:"domain_layer"`
+		Path         string            `json:"path"`
 	} `json:"options"`
 }
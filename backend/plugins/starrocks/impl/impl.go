@@ -18,6 +18,7 @@ limitations under the License.
 package impl
 
 import (
+	"fmt"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
@@ -29,6 +30,7 @@ type StarRocks string
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*StarRocks)(nil)
+var _ plugin.PluginCompatibility = (*StarRocks)(nil)
 var _ plugin.PluginTask = (*StarRocks)(nil)
 var _ plugin.PluginModel = (*StarRocks)(nil)
 
@@ -44,8 +46,26 @@ func (s StarRocks) PrepareTaskData(taskCtx plugin.TaskContext, options map[strin
 	if err != nil {
 		return nil, err
 	}
-	if op.BeHost == "" {
-		op.BeHost = op.Host
+	switch op.Sink {
+	case "", tasks.SINK_STARROCKS:
+		op.Sink = tasks.SINK_STARROCKS
+		if op.BeHost == "" {
+			op.BeHost = op.Host
+		}
+	case tasks.SINK_CLICKHOUSE:
+		if op.Host == "" {
+			return nil, errors.BadInput.New("host is required for clickhouse sink")
+		}
+	case tasks.SINK_DUCKDB, tasks.SINK_PARQUET:
+		if op.Path == "" {
+			return nil, errors.BadInput.New(fmt.Sprintf("path is required for %s sink", op.Sink))
+		}
+		_, err = tasks.ResolveExportPath(taskCtx.GetConfig(tasks.EXPORT_DIR_ENV), op.Path)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("unsupported sink %s", op.Sink))
 	}
 	return &op, nil
 }
@@ -55,7 +75,15 @@ func (s StarRocks) GetTablesInfo() []dal.Tabler {
 }
 
 func (s StarRocks) Description() string {
-	return "Sync data from database to StarRocks"
+	return "Sync data from database to StarRocks, ClickHouse, DuckDB or Parquet files"
+}
+
+func (s StarRocks) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (s StarRocks) Dependencies() []string {
+	return nil
 }
 
 func (s StarRocks) RootPkgPath() string {
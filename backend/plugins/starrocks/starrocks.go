@@ -27,6 +27,8 @@ var PluginEntry impl.StarRocks
 
 func main() {
 	cmd := &cobra.Command{Use: "StarRocks"}
+	sink := cmd.Flags().StringP("sink", "s", "starrocks", "Sink type: starrocks, clickhouse, duckdb or parquet")
+	path := cmd.Flags().StringP("path", "", "", "Output directory of parquet sink or database file of duckdb sink, relative to STARROCKS_EXPORT_DIR")
 	sourceType := cmd.Flags().StringP("source_type", "st", "", "Source type")
 	sourceDsn := cmd.Flags().StringP("source_dsn", "sd", "", "Source dsn")
 	updateColumn := cmd.Flags().StringP("update_column", "uc", "", "Update column")
@@ -52,6 +54,8 @@ func main() {
 	orderBy := cmd.Flags().StringP("order_by", "o", "", "Source tables order by, default is primary key")
 	cmd.Run = func(cmd *cobra.Command, args []string) {
 		runner.DirectRun(cmd, args, PluginEntry, map[string]interface{}{
+			"sink":          sink,
+			"path":          path,
 			"source_type":   sourceType,
 			"source_dsn":    sourceDsn,
 			"update_column": updateColumn,
@@ -0,0 +1,185 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/starrocks/utils"
+)
+
+// ClickHouseSink loads data into ClickHouse by the HTTP interface
+type ClickHouseSink struct {
+	taskCtx  plugin.SubTaskContext
+	config   *StarRocksConfig
+	client   *http.Client
+	endpoint string
+	table    string
+	tmpTable string
+}
+
+var _ Sink = (*ClickHouseSink)(nil)
+
+// NewClickHouseSink creates a ClickHouseSink, `config.Port` is the HTTP port which defaults to 8123
+func NewClickHouseSink(taskCtx plugin.SubTaskContext, config *StarRocksConfig) (*ClickHouseSink, errors.Error) {
+	if config.Host == "" {
+		return nil, errors.BadInput.New("host is required for clickhouse sink")
+	}
+	port := config.Port
+	if port == 0 {
+		port = 8123
+	}
+	return &ClickHouseSink{
+		taskCtx:  taskCtx,
+		config:   config,
+		client:   &http.Client{Timeout: 10 * time.Minute},
+		endpoint: fmt.Sprintf("http://%s:%d/", config.Host, port),
+	}, nil
+}
+
+// query executes the sql, data would be sent as the request body along with the sql if not nil
+func (s *ClickHouseSink) query(sql string, data []byte) (string, errors.Error) {
+	params := url.Values{}
+	if s.config.Database != "" {
+		params.Set("database", s.config.Database)
+	}
+	params.Set("date_time_input_format", "best_effort")
+	body := []byte(sql)
+	if data != nil {
+		params.Set("query", sql)
+		body = data
+	}
+	req, err := http.NewRequest(http.MethodPost, s.endpoint+"?"+params.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return "", errors.Convert(err)
+	}
+	if s.config.User != "" {
+		req.SetBasicAuth(s.config.User, s.config.Password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", errors.Convert(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", errors.Convert(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.HttpStatus(resp.StatusCode).New(fmt.Sprintf("clickhouse query %s failed: %s", sql, string(b)))
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (s *ClickHouseSink) LastUpdated(table string, column string) (time.Time, bool, errors.Error) {
+	var updatedTo time.Time
+	clickhouseTable := exportedTableName(table)
+	exists, err := s.query(fmt.Sprintf("EXISTS TABLE `%s`", clickhouseTable), nil)
+	if err != nil || exists != "1" {
+		return updatedTo, false, err
+	}
+	millis, err := s.query(fmt.Sprintf("SELECT toUnixTimestamp64Milli(max(`%s`)) FROM `%s` FORMAT TabSeparated", column, clickhouseTable), nil)
+	if err != nil || millis == `\N` {
+		return updatedTo, err == nil, err
+	}
+	ms, e := strconv.ParseInt(millis, 10, 64)
+	if e != nil {
+		return updatedTo, false, errors.Convert(e)
+	}
+	return time.UnixMilli(ms), true, nil
+}
+
+func (s *ClickHouseSink) Begin(table string, columns []SinkColumn) errors.Error {
+	s.table = exportedTableName(table)
+	s.tmpTable = fmt.Sprintf("%s_tmp", s.table)
+	var pks []string
+	var columnDefs []string
+	for _, column := range columns {
+		dataType := utils.GetClickHouseDataType(column.Type)
+		if column.PrimaryKey {
+			// sorting keys can not be nullable
+			dataType = strings.TrimSuffix(strings.TrimPrefix(dataType, "Nullable("), ")")
+			pks = append(pks, fmt.Sprintf("`%s`", column.Name))
+		}
+		columnDefs = append(columnDefs, fmt.Sprintf("`%s` %s", column.Name, dataType))
+	}
+	orderBy := "tuple()"
+	if len(pks) > 0 {
+		orderBy = fmt.Sprintf("(%s)", strings.Join(pks, ", "))
+	}
+	extra := fmt.Sprintf("ENGINE = MergeTree ORDER BY %s", orderBy)
+	if s.config.Extra != nil {
+		if v, ok := s.config.Extra[table]; ok {
+			extra = v
+		}
+	}
+	_, err := s.query(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", s.tmpTable), nil)
+	if err != nil {
+		return err
+	}
+	tableSql := fmt.Sprintf("CREATE TABLE `%s` ( %s ) %s", s.tmpTable, strings.Join(columnDefs, ", "), extra)
+	s.taskCtx.GetLogger().Debug(tableSql)
+	_, err = s.query(tableSql, nil)
+	return err
+}
+
+func (s *ClickHouseSink) Write(rows []map[string]interface{}) errors.Error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, row := range rows {
+		err := encoder.Encode(row)
+		if err != nil {
+			return errors.Convert(err)
+		}
+	}
+	_, err := s.query(fmt.Sprintf("INSERT INTO `%s` FORMAT JSONEachRow", s.tmpTable), buf.Bytes())
+	return err
+}
+
+func (s *ClickHouseSink) Commit() (int64, errors.Error) {
+	// swap the tables atomically, the target table is created with the same structure if it doesn't exist yet
+	for _, sql := range []string{
+		fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` AS `%s`", s.table, s.tmpTable),
+		fmt.Sprintf("EXCHANGE TABLES `%s` AND `%s`", s.tmpTable, s.table),
+		fmt.Sprintf("DROP TABLE IF EXISTS `%s`", s.tmpTable),
+	} {
+		_, err := s.query(sql, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+	count, err := s.query(fmt.Sprintf("SELECT count() FROM `%s`", s.table), nil)
+	if err != nil {
+		return 0, err
+	}
+	return errors.Convert01(strconv.ParseInt(count, 10, 64))
+}
+
+func (s *ClickHouseSink) Close() errors.Error {
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+// DuckDbSink loads data into a DuckDB database file. Rows are staged into a parquet file first and then imported by
+// the duckdb cli, so no cgo driver is required
+type DuckDbSink struct {
+	*ParquetSink
+	path       string
+	bin        string
+	stagingDir string
+}
+
+var _ Sink = (*DuckDbSink)(nil)
+
+// NewDuckDbSink creates a DuckDbSink writing into the database file `config.Path` under the export directory, the
+// duckdb cli is configured by the server since the options come from pipeline plans
+func NewDuckDbSink(taskCtx plugin.SubTaskContext, config *StarRocksConfig) (*DuckDbSink, errors.Error) {
+	path, e := ResolveExportPath(taskCtx.GetConfig(EXPORT_DIR_ENV), config.Path)
+	if e != nil {
+		return nil, e
+	}
+	bin := taskCtx.GetConfig(DUCKDB_BIN_ENV)
+	if bin == "" {
+		bin = "duckdb"
+	}
+	_, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("duckdb cli %s not found", bin))
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	stagingDir, err := os.MkdirTemp("", "devlake-duckdb-")
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	parquetSink, e := NewParquetSink(taskCtx, stagingDir)
+	if e != nil {
+		return nil, e
+	}
+	return &DuckDbSink{
+		ParquetSink: parquetSink,
+		path:        path,
+		bin:         bin,
+		stagingDir:  stagingDir,
+	}, nil
+}
+
+// exec runs the sql with duckdb cli and returns the output in csv format without header
+func (s *DuckDbSink) exec(sql string, readonly bool) (string, errors.Error) {
+	args := []string{"-csv", "-noheader"}
+	if readonly {
+		args = append(args, "-readonly")
+	}
+	args = append(args, s.path, "-c", sql)
+	out, err := exec.Command(s.bin, args...).CombinedOutput()
+	if err != nil {
+		return "", errors.Default.Wrap(errors.Convert(err), fmt.Sprintf("duckdb %s failed: %s", sql, string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *DuckDbSink) LastUpdated(table string, column string) (time.Time, bool, errors.Error) {
+	var updatedTo time.Time
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return updatedTo, false, nil
+	}
+	duckdbTable := exportedTableName(table)
+	exists, err := s.exec(fmt.Sprintf(`SELECT count(*) FROM information_schema.tables WHERE table_name = '%s'`, duckdbTable), true)
+	if err != nil || exists == "0" {
+		return updatedTo, false, err
+	}
+	millis, err := s.exec(fmt.Sprintf(`SELECT epoch_ms(max("%s")) FROM "%s"`, column, duckdbTable), true)
+	if err != nil || millis == "" {
+		return updatedTo, err == nil, err
+	}
+	ms, e := strconv.ParseInt(millis, 10, 64)
+	if e != nil {
+		return updatedTo, false, errors.Convert(e)
+	}
+	return time.UnixMilli(ms), true, nil
+}
+
+func (s *DuckDbSink) Commit() (int64, errors.Error) {
+	count, err := s.ParquetSink.Commit()
+	if err != nil {
+		return 0, err
+	}
+	parquetFile := s.FilePath(s.table)
+	defer os.Remove(parquetFile)
+	_, err = s.exec(fmt.Sprintf(
+		`CREATE OR REPLACE TABLE "%s" AS SELECT * FROM read_parquet('%s')`,
+		exportedTableName(s.table),
+		strings.ReplaceAll(parquetFile, "'", "''"),
+	), false)
+	return count, err
+}
+
+func (s *DuckDbSink) Close() errors.Error {
+	err := s.ParquetSink.Close()
+	if err != nil {
+		return err
+	}
+	return errors.Convert(os.RemoveAll(s.stagingDir))
+}
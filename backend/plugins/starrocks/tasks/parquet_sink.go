@@ -0,0 +1,237 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/starrocks/utils"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// ParquetSink writes each table into `<dir>/<table>.parquet`, along with a `<table>.json` holding the number of rows
+// and the max value of the time columns which is used to detect whether the table is up to date
+type ParquetSink struct {
+	taskCtx plugin.SubTaskContext
+	dir     string
+	table   string
+	columns []SinkColumn
+	types   []string
+	file    *os.File
+	writer  *writer.CSVWriter
+	meta    *parquetMeta
+}
+
+type parquetMeta struct {
+	Rows        int64                `json:"rows"`
+	LastUpdated map[string]time.Time `json:"lastUpdated"`
+}
+
+var _ Sink = (*ParquetSink)(nil)
+
+// NewParquetSink creates a ParquetSink writing into the directory `dir`
+func NewParquetSink(taskCtx plugin.SubTaskContext, dir string) (*ParquetSink, errors.Error) {
+	if dir == "" {
+		return nil, errors.BadInput.New("path is required for parquet sink")
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	return &ParquetSink{taskCtx: taskCtx, dir: dir}, nil
+}
+
+// FilePath returns the path of the parquet file of the source table
+func (s *ParquetSink) FilePath(table string) string {
+	return filepath.Join(s.dir, exportedTableName(table)+".parquet")
+}
+
+func (s *ParquetSink) metaPath(table string) string {
+	return filepath.Join(s.dir, exportedTableName(table)+".json")
+}
+
+func (s *ParquetSink) LastUpdated(table string, column string) (time.Time, bool, errors.Error) {
+	var updatedTo time.Time
+	b, err := os.ReadFile(s.metaPath(table))
+	if os.IsNotExist(err) {
+		return updatedTo, false, nil
+	}
+	if err != nil {
+		return updatedTo, false, errors.Convert(err)
+	}
+	meta := &parquetMeta{}
+	err = json.Unmarshal(b, meta)
+	if err != nil {
+		return updatedTo, false, errors.Convert(err)
+	}
+	return meta.LastUpdated[column], true, nil
+}
+
+func (s *ParquetSink) Begin(table string, columns []SinkColumn) errors.Error {
+	s.abort()
+	s.table = table
+	s.columns = columns
+	s.types = make([]string, len(columns))
+	s.meta = &parquetMeta{LastUpdated: make(map[string]time.Time)}
+	md := make([]string, len(columns))
+	for i, column := range columns {
+		s.types[i] = utils.GetParquetDataType(column.Type)
+		md[i] = fmt.Sprintf("name=%s, %s, repetitiontype=OPTIONAL", column.Name, s.types[i])
+	}
+	var err error
+	s.file, err = os.Create(s.FilePath(table) + ".tmp")
+	if err != nil {
+		return errors.Convert(err)
+	}
+	s.writer, err = writer.NewCSVWriterFromWriter(md, s.file, 4)
+	return errors.Convert(err)
+}
+
+func (s *ParquetSink) Write(rows []map[string]interface{}) errors.Error {
+	for _, row := range rows {
+		rec := make([]interface{}, len(s.columns))
+		for i, column := range s.columns {
+			value, err := s.toParquetValue(column.Name, s.types[i], row[column.Name])
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("failed to convert column %s of %s", column.Name, s.table))
+			}
+			rec[i] = value
+		}
+		err := s.writer.Write(rec)
+		if err != nil {
+			return errors.Convert(err)
+		}
+		s.meta.Rows++
+	}
+	return nil
+}
+
+func (s *ParquetSink) Commit() (int64, errors.Error) {
+	err := s.writer.WriteStop()
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	err = s.file.Close()
+	s.file = nil
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	err = os.Rename(s.FilePath(s.table)+".tmp", s.FilePath(s.table))
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	b, err := json.Marshal(s.meta)
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	return s.meta.Rows, errors.Convert(os.WriteFile(s.metaPath(s.table), b, 0644))
+}
+
+func (s *ParquetSink) Close() errors.Error {
+	s.abort()
+	return nil
+}
+
+// abort removes the staging file of the unfinished table
+func (s *ParquetSink) abort() {
+	if s.file != nil {
+		_ = s.file.Close()
+		_ = os.Remove(s.file.Name())
+		s.file = nil
+	}
+}
+
+// toParquetValue converts the value scanned from the source database to the go type expected by parquet-go
+func (s *ParquetSink) toParquetValue(name string, parquetType string, value interface{}) (interface{}, error) {
+	if v, ok := value.(*[]string); ok {
+		if v == nil {
+			return nil, nil
+		}
+		value = *v
+	}
+	if value == nil {
+		return nil, nil
+	}
+	switch {
+	case strings.Contains(parquetType, "TIMESTAMP_MILLIS"), strings.Contains(parquetType, "DATE"):
+		t, ok := value.(time.Time)
+		if !ok {
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, fmt.Sprint(value))
+			if err != nil {
+				return nil, err
+			}
+		}
+		if t.After(s.meta.LastUpdated[name]) {
+			s.meta.LastUpdated[name] = t
+		}
+		if strings.Contains(parquetType, "DATE") {
+			return int32(t.Unix() / 86400), nil
+		}
+		return t.UnixMilli(), nil
+	case strings.Contains(parquetType, "BOOLEAN"):
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		}
+		return strconv.ParseBool(fmt.Sprint(value))
+	case strings.Contains(parquetType, "INT64"):
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return strconv.ParseInt(fmt.Sprint(value), 10, 64)
+	case strings.Contains(parquetType, "DOUBLE"):
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case float32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		}
+		return strconv.ParseFloat(fmt.Sprint(value), 64)
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []string, map[string]interface{}, []interface{}:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,94 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+)
+
+func TestParquetSink(t *testing.T) {
+	sink, err := NewParquetSink(nil, t.TempDir())
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer sink.Close()
+
+	_, ok, err := sink.LastUpdated("_tool_issues", "updated_date")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	updatedDate := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.Nil(t, sink.Begin("_tool_issues", []SinkColumn{
+		{Name: "id", Type: "varchar(255)", PrimaryKey: true},
+		{Name: "story_point", Type: "double"},
+		{Name: "is_done", Type: "tinyint(1)"},
+		{Name: "priority", Type: "bigint"},
+		{Name: "labels", Type: "text[]"},
+		{Name: "updated_date", Type: "datetime(3)"},
+	}))
+	labels := []string{"bug", "p1"}
+	assert.Nil(t, sink.Write([]map[string]interface{}{
+		{"id": "1", "story_point": 1.5, "is_done": int64(1), "priority": int64(3), "labels": &labels, "updated_date": updatedDate},
+		{"id": "2", "story_point": nil, "is_done": false, "priority": "5", "labels": nil, "updated_date": updatedDate.Add(-time.Hour)},
+	}))
+	count, err := sink.Commit()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	lastUpdated, ok, err := sink.LastUpdated("_tool_issues", "updated_date")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.True(t, updatedDate.Equal(lastUpdated))
+
+	file, e := local.NewLocalFileReader(sink.FilePath("_tool_issues"))
+	if !assert.Nil(t, e) {
+		return
+	}
+	defer file.Close()
+	pr, e := reader.NewParquetReader(file, nil, 1)
+	if !assert.Nil(t, e) {
+		return
+	}
+	defer pr.ReadStop()
+	assert.Equal(t, int64(2), pr.GetNumRows())
+	assert.Len(t, pr.SchemaHandler.SchemaElements, 7)
+}
+
+func TestResolveExportPath(t *testing.T) {
+	base := t.TempDir()
+	path, err := ResolveExportPath(base, "lake.duckdb")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(base, "lake.duckdb"), path)
+
+	path, err = ResolveExportPath(base, filepath.Join(base, "parquet", "issues"))
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(base, "parquet", "issues"), path)
+
+	for _, outside := range []string{"../lake.duckdb", "parquet/../../lake.duckdb", "/etc/passwd", ".."} {
+		_, err = ResolveExportPath(base, outside)
+		assert.NotNil(t, err, outside)
+	}
+	_, err = ResolveExportPath("", "lake.duckdb")
+	assert.NotNil(t, err)
+}
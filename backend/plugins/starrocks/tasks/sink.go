@@ -0,0 +1,120 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+const (
+	SINK_STARROCKS  = "starrocks"
+	SINK_CLICKHOUSE = "clickhouse"
+	SINK_DUCKDB     = "duckdb"
+	SINK_PARQUET    = "parquet"
+)
+
+const (
+	// EXPORT_DIR_ENV is the server side directory the parquet and duckdb sinks may write into, the `path` option is
+	// resolved against it
+	EXPORT_DIR_ENV = "STARROCKS_EXPORT_DIR"
+	// DUCKDB_BIN_ENV is the path of the duckdb cli used by the duckdb sink, default is `duckdb` in PATH
+	DUCKDB_BIN_ENV = "STARROCKS_DUCKDB_BIN"
+)
+
+// SinkColumn describes a column of the source table
+type SinkColumn struct {
+	Name string
+	// Type is the lower cased column type of the source database, i.e. `varchar(255)`, `datetime(3)`, `text[]`
+	Type       string
+	PrimaryKey bool
+}
+
+// IsArray returns true if the column is a postgres array
+func (c SinkColumn) IsArray() bool {
+	return strings.HasSuffix(c.Type, "[]")
+}
+
+// Sink is the destination the tables are exported to. A table is exported by calling `Begin`, `Write` for each
+// batch and `Commit`, the previous copy of the table must stay readable until `Commit` replaces it.
+type Sink interface {
+	// LastUpdated returns the max value of `column` of the exported table, ok is false if the table was never exported
+	LastUpdated(table string, column string) (lastUpdated time.Time, ok bool, err errors.Error)
+	// Begin prepares a staging table for the source `table`
+	Begin(table string, columns []SinkColumn) errors.Error
+	// Write appends a batch of rows to the staging table
+	Write(rows []map[string]interface{}) errors.Error
+	// Commit replaces the exported table with the staging table and returns the number of rows exported
+	Commit() (int64, errors.Error)
+	// Close releases the resources held by the sink
+	Close() errors.Error
+}
+
+// NewSink creates the Sink specified by `config.Sink`
+func NewSink(taskCtx plugin.SubTaskContext, config *StarRocksConfig) (Sink, errors.Error) {
+	switch config.Sink {
+	case "", SINK_STARROCKS:
+		return NewStarRocksSink(taskCtx, config)
+	case SINK_CLICKHOUSE:
+		return NewClickHouseSink(taskCtx, config)
+	case SINK_DUCKDB:
+		return NewDuckDbSink(taskCtx, config)
+	case SINK_PARQUET:
+		dir, err := ResolveExportPath(taskCtx.GetConfig(EXPORT_DIR_ENV), config.Path)
+		if err != nil {
+			return nil, err
+		}
+		return NewParquetSink(taskCtx, dir)
+	}
+	return nil, errors.BadInput.New(fmt.Sprintf("unsupported sink %s", config.Sink))
+}
+
+// exportedTableName returns the name of the exported table for the source table
+func exportedTableName(table string) string {
+	return strings.TrimLeft(table, "_")
+}
+
+// ResolveExportPath resolves the `path` option of file sinks against the export directory configured on the server,
+// paths escaping the directory are rejected since the option comes from pipeline plans
+func ResolveExportPath(baseDir string, path string) (string, errors.Error) {
+	if baseDir == "" {
+		return "", errors.BadInput.New(fmt.Sprintf("%s must be configured to export into files", EXPORT_DIR_ENV))
+	}
+	if path == "" {
+		return "", errors.BadInput.New("path is required")
+	}
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", errors.Convert(err)
+	}
+	resolved := path
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(baseDir, resolved)
+	}
+	resolved = filepath.Clean(resolved)
+	rel, err := filepath.Rel(baseDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", errors.BadInput.New(fmt.Sprintf("path %s is outside of %s", path, EXPORT_DIR_ENV))
+	}
+	return resolved, nil
+}
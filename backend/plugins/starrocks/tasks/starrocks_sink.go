@@ -0,0 +1,194 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tasks
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/plugins/starrocks/utils"
+)
+
+// StarRocksSink loads data into StarRocks by the stream load api of BE
+type StarRocksSink struct {
+	taskCtx   plugin.SubTaskContext
+	config    *StarRocksConfig
+	starrocks *sql.DB
+	table     string
+	tmpTable  string
+}
+
+var _ Sink = (*StarRocksSink)(nil)
+
+// NewStarRocksSink connects to the StarRocks FE with mysql protocol
+func NewStarRocksSink(taskCtx plugin.SubTaskContext, config *StarRocksConfig) (*StarRocksSink, errors.Error) {
+	starrocks, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local", config.User, config.Password, config.Host, config.Port, config.Database))
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
+	return &StarRocksSink{
+		taskCtx:   taskCtx,
+		config:    config,
+		starrocks: starrocks,
+	}, nil
+}
+
+func (s *StarRocksSink) LastUpdated(table string, column string) (time.Time, bool, errors.Error) {
+	var updatedTo time.Time
+	starrocksTable := exportedTableName(table)
+	rowsInStarRocks, err := s.starrocks.Query(fmt.Sprintf("select %s from %s order by %s desc limit 1", column, starrocksTable, column))
+	if err != nil {
+		if strings.Contains(err.Error(), "Unknown table") {
+			return updatedTo, false, nil
+		}
+		return updatedTo, false, errors.Convert(err)
+	}
+	defer rowsInStarRocks.Close()
+	if rowsInStarRocks.Next() {
+		err = rowsInStarRocks.Scan(&updatedTo)
+		if err != nil {
+			return updatedTo, false, errors.Convert(err)
+		}
+	}
+	return updatedTo, true, nil
+}
+
+func (s *StarRocksSink) Begin(table string, columns []SinkColumn) errors.Error {
+	s.table = exportedTableName(table)
+	s.tmpTable = fmt.Sprintf("%s_tmp", s.table)
+	var pks []string
+	var columnDefs []string
+	for _, column := range columns {
+		columnDefs = append(columnDefs, fmt.Sprintf("`%s` %s", column.Name, utils.GetStarRocksDataType(column.Type)))
+		if column.PrimaryKey {
+			pks = append(pks, fmt.Sprintf("`%s`", column.Name))
+		}
+	}
+	if len(pks) == 0 && len(columns) > 0 {
+		pks = append(pks, fmt.Sprintf("`%s`", columns[0].Name))
+	}
+	extra := fmt.Sprintf(`engine=olap distributed by hash(%s) properties("replication_num" = "1")`, strings.Join(pks, ", "))
+	if s.config.Extra != nil {
+		if v, ok := s.config.Extra[table]; ok {
+			extra = v
+		}
+	}
+	tableSql := fmt.Sprintf("drop table if exists %s; create table if not exists `%s` ( %s ) %s", s.tmpTable, s.tmpTable, strings.Join(columnDefs, ","), extra)
+	s.taskCtx.GetLogger().Debug(tableSql)
+	_, err := s.starrocks.Exec(tableSql)
+	return errors.Convert(err)
+}
+
+func (s *StarRocksSink) Write(rows []map[string]interface{}) errors.Error {
+	loadURL := fmt.Sprintf("http://%s:%d/api/%s/%s/_stream_load", s.config.BeHost, s.config.BePort, s.config.Database, s.tmpTable)
+	headers := map[string]string{
+		"format":            "json",
+		"strip_outer_array": "true",
+		"Expect":            "100-continue",
+		"ignore_json_size":  "true",
+		"Connection":        "close",
+	}
+	jsonData, err := json.Marshal(rows)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	client := http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequest(http.MethodPut, loadURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return errors.Convert(err)
+	}
+	req.SetBasicAuth(s.config.User, s.config.Password)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	if resp.StatusCode == 307 {
+		var location *url.URL
+		location, err = resp.Location()
+		if err != nil {
+			return errors.Convert(err)
+		}
+		req, err = http.NewRequest(http.MethodPut, location.String(), bytes.NewBuffer(jsonData))
+		if err != nil {
+			return errors.Convert(err)
+		}
+		req.SetBasicAuth(s.config.User, s.config.Password)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err = client.Do(req)
+	}
+	if err != nil {
+		return errors.Convert(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	var result map[string]interface{}
+	err = json.Unmarshal(b, &result)
+	if err != nil {
+		return errors.Convert(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		s.taskCtx.GetLogger().Error(nil, "[%d]: %s", resp.StatusCode, string(b))
+	}
+	if result["Status"] != "Success" {
+		s.taskCtx.GetLogger().Error(nil, "load %s failed: %s", s.table, string(b))
+	} else {
+		s.taskCtx.GetLogger().Debug("load %s success: %s", s.table, b)
+	}
+	return nil
+}
+
+func (s *StarRocksSink) Commit() (int64, errors.Error) {
+	// drop old table
+	_, err := s.starrocks.Exec(fmt.Sprintf("drop table if exists %s", s.table))
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	// rename tmp table to old table
+	_, err = s.starrocks.Exec(fmt.Sprintf("alter table %s rename %s", s.tmpTable, s.table))
+	if err != nil {
+		return 0, errors.Convert(err)
+	}
+	var starrocksCount int64
+	err = s.starrocks.QueryRow(fmt.Sprintf("select count(*) from %s", s.table)).Scan(&starrocksCount)
+	return starrocksCount, errors.Convert(err)
+}
+
+func (s *StarRocksSink) Close() errors.Error {
+	return errors.Convert(s.starrocks.Close())
+}
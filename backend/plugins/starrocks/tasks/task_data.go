@@ -18,6 +18,8 @@ limitations under the License.
 package tasks
 
 type StarRocksConfig struct {
+	// Sink is one of starrocks (default), clickhouse, duckdb and parquet
+	Sink         string
 	SourceType   string `mapstructure:"source_type"`
 	SourceDsn    string `mapstructure:"source_dsn"`
 	UpdateColumn string `mapstructure:"update_column"`
@@ -33,4 +35,7 @@ type StarRocksConfig struct {
 	OrderBy      map[string]string `mapstructure:"order_by"`
 	DomainLayer  string            `mapstructure:"domain_layer"`
 	Extra        map[string]string
+	// Path is the output directory of the parquet sink, or the database file of the duckdb sink, relative to the
+	// directory set by STARROCKS_EXPORT_DIR
+	Path string
 }
@@ -18,18 +18,12 @@ limitations under the License.
 package tasks
 
 import (
-	"bytes"
-	"database/sql"
-	"encoding/json"
 	"fmt"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/impls/dalgorm"
 	"github.com/apache/incubator-devlake/plugins/starrocks/utils"
-	"io"
-	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 	"time"
@@ -37,6 +31,7 @@ import (
 	"github.com/lib/pq"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -64,6 +59,11 @@ func LoadData(c plugin.SubTaskContext) errors.Error {
 			if err != nil {
 				return errors.Convert(err)
 			}
+		} else if config.SourceType == "sqlite" {
+			o, err = gorm.Open(sqlite.Open(config.SourceDsn))
+			if err != nil {
+				return errors.Convert(err)
+			}
 		} else {
 			return errors.NotFound.New(fmt.Sprintf("unsupported source type %s", config.SourceType))
 		}
@@ -106,146 +106,146 @@ func LoadData(c plugin.SubTaskContext) errors.Error {
 		}
 	}
 
-	starrocks, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local", config.User, config.Password, config.Host, config.Port, config.Database))
+	sink, err := NewSink(c, config)
 	if err != nil {
-		return errors.Convert(err)
+		return err
 	}
-	defer starrocks.Close()
+	defer sink.Close()
 
 	for _, table := range starrocksTables {
-		starrocksTable := strings.TrimLeft(table, "_")
-		starrocksTmpTable := fmt.Sprintf("%s_tmp", starrocksTable)
-		var columnMap map[string]string
-		var orderBy string
-		var skip bool
-		columnMap, orderBy, skip, err = createTmpTable(starrocks, db, starrocksTable, starrocksTmpTable, table, c, config)
-		if skip {
-			c.GetLogger().Info(fmt.Sprintf("table %s is up to date, so skip it", table))
-			continue
+		err = exportTable(c, db, sink, table, config)
+		if err != nil {
+			c.GetLogger().Error(err, "export table %s error", table)
+			return err
 		}
+	}
+	return nil
+}
+
+func exportTable(c plugin.SubTaskContext, db dal.Dal, sink Sink, table string, config *StarRocksConfig) errors.Error {
+	columns, orderBy, skip, err := getColumns(c, db, sink, table, config)
+	if err != nil {
+		return err
+	}
+	if skip {
+		c.GetLogger().Info(fmt.Sprintf("table %s is up to date, so skip it", table))
+		return nil
+	}
+	err = sink.Begin(table, columns)
+	if err != nil {
+		return err
+	}
+	if db.Dialect() == "postgres" {
+		err = db.Exec("begin transaction isolation level repeatable read")
 		if err != nil {
-			c.GetLogger().Error(err, "create table %s in starrocks error", table)
-			return errors.Convert(err)
+			return err
 		}
-		if db.Dialect() == "postgres" {
-			err = db.Exec("begin transaction isolation level repeatable read")
-			if err != nil {
-				return errors.Convert(err)
-			}
-		} else if db.Dialect() == "mysql" {
-			err = db.Exec("set session transaction isolation level repeatable read")
-			if err != nil {
-				return errors.Convert(err)
-			}
-			err = errors.Convert(db.Exec("start transaction"))
-			if err != nil {
-				return errors.Convert(err)
-			}
-		} else {
-			return errors.NotFound.New(fmt.Sprintf("unsupported dialect %s", db.Dialect()))
+	} else if db.Dialect() == "mysql" {
+		err = db.Exec("set session transaction isolation level repeatable read")
+		if err != nil {
+			return err
 		}
-		err = errors.Convert(loadData(starrocks, c, starrocksTable, starrocksTmpTable, table, columnMap, db, config, orderBy))
+		err = db.Exec("start transaction")
 		if err != nil {
-			return errors.Convert(err)
+			return err
 		}
-		err = errors.Convert(db.Exec("commit"))
+	} else if db.Dialect() == "sqlite" {
+		// sqlite transactions are always serializable
+		err = db.Exec("begin transaction")
 		if err != nil {
-			return errors.Convert(err)
+			return err
 		}
+	} else {
+		return errors.NotFound.New(fmt.Sprintf("unsupported dialect %s", db.Dialect()))
+	}
+	err = loadData(c, db, sink, table, columns, orderBy, config)
+	if err != nil {
+		return err
+	}
+	err = db.Exec("commit")
+	if err != nil {
+		return err
 	}
+	// check data count
+	sinkCount, err := sink.Commit()
+	if err != nil {
+		return err
+	}
+	sourceCount, err := db.Count(dal.From(table))
+	if err != nil {
+		return err
+	}
+	if sourceCount != sinkCount {
+		c.GetLogger().Warn(nil, "source count %d not equal to %s count %d", sourceCount, config.Sink, sinkCount)
+	}
+	c.GetLogger().Info("load %s to %s success", table, config.Sink)
 	return nil
 }
 
-func createTmpTable(starrocks *sql.DB, db dal.Dal, starrocksTable string, starrocksTmpTable string, table string, c plugin.SubTaskContext, config *StarRocksConfig) (map[string]string, string, bool, errors.Error) {
+// getColumns returns the columns of the source table and the order to copy the rows, skip would be true if the
+// exported table is up to date according to `config.UpdateColumn`
+func getColumns(c plugin.SubTaskContext, db dal.Dal, sink Sink, table string, config *StarRocksConfig) ([]SinkColumn, string, bool, errors.Error) {
 	columnMetas, err := db.GetColumns(&Table{name: table}, nil)
 	updateColumn := config.UpdateColumn
-	columnMap := make(map[string]string)
 	if err != nil {
 		if strings.Contains(err.Error(), "cached plan must not change result type") {
 			c.GetLogger().Warn(err, "skip err: cached plan must not change result type")
 			columnMetas, err = db.GetColumns(&Table{name: table}, nil)
 			if err != nil {
-				return nil, "", false, errors.Convert(err)
+				return nil, "", false, err
 			}
 		} else {
-			return nil, "", false, errors.Convert(err)
+			return nil, "", false, err
 		}
 	}
 
-	var pks []string
 	var orders []string
-	var columns []string
+	var columns []SinkColumn
 	var separator string
-	if db.Dialect() == "postgres" {
+	if db.Dialect() == "postgres" || db.Dialect() == "sqlite" {
 		separator = "\""
 	} else if db.Dialect() == "mysql" {
 		separator = "`"
 	} else {
 		return nil, "", false, errors.NotFound.New(fmt.Sprintf("unsupported dialect %s", db.Dialect()))
 	}
-	firstcm := ""
 	firstcmName := ""
 	for _, cm := range columnMetas {
 		name := cm.Name()
 		if name == updateColumn {
 			// check update column to detect skip or not
-			rows, err := db.Cursor(
-				dal.From(table),
-				dal.Select(updateColumn),
-				dal.Limit(1),
-				dal.Orderby(fmt.Sprintf("%s desc", updateColumn)),
-			)
+			var updatedFrom time.Time
+			updatedFrom, err = getUpdatedFrom(db, table, updateColumn)
 			if err != nil {
 				return nil, "", false, err
 			}
-			var updatedFrom time.Time
-			if rows.Next() {
-				err = errors.Convert(rows.Scan(&updatedFrom))
-				if err != nil {
-					return nil, "", false, err
-				}
+			updatedTo, ok, err := sink.LastUpdated(table, updateColumn)
+			if err != nil {
+				return nil, "", false, err
 			}
-			var starrocksErr error
-			rowsInStarRocks, starrocksErr := starrocks.Query(fmt.Sprintf("select %s from %s order by %s desc limit 1", updateColumn, starrocksTable, updateColumn))
-			if starrocksErr != nil {
-				if !strings.Contains(starrocksErr.Error(), "Unknown table") {
-					return nil, "", false, errors.Convert(starrocksErr)
-				}
-			} else {
-				var updatedTo time.Time
-				if rowsInStarRocks.Next() {
-					err = errors.Convert(rowsInStarRocks.Scan(&updatedTo))
-					if err != nil {
-						return nil, "", false, err
-					}
-				}
-				if updatedFrom.Equal(updatedTo) {
-					return nil, "", true, nil
-				}
+			if ok && updatedFrom.Truncate(time.Millisecond).Equal(updatedTo.Truncate(time.Millisecond)) {
+				return nil, "", true, nil
 			}
 		}
 		columnDatatype, ok := cm.ColumnType()
 		if !ok {
-			return columnMap, "", false, errors.Default.New(fmt.Sprintf("Get [%s] ColumeType Failed", name))
+			return nil, "", false, errors.Default.New(fmt.Sprintf("Get [%s] ColumeType Failed", name))
 		}
-		dataType := utils.GetStarRocksDataType(columnDatatype)
-		columnMap[name] = dataType
-		column := fmt.Sprintf("`%s` %s", name, dataType)
-		columns = append(columns, column)
 		isPrimaryKey, ok := cm.PrimaryKey()
-		if isPrimaryKey && ok {
-			pks = append(pks, fmt.Sprintf("`%s`", name))
+		column := SinkColumn{
+			Name:       name,
+			Type:       strings.ToLower(columnDatatype),
+			PrimaryKey: isPrimaryKey && ok,
+		}
+		columns = append(columns, column)
+		if column.PrimaryKey {
 			orders = append(orders, fmt.Sprintf("%s%s%s", separator, name, separator))
 		}
-		if firstcm == "" {
-			firstcm = fmt.Sprintf("`%s`", name)
+		if firstcmName == "" {
 			firstcmName = fmt.Sprintf("%s%s%s", separator, name, separator)
 		}
 	}
 
-	if len(pks) == 0 {
-		pks = append(pks, firstcm)
-	}
 	orderBy := strings.Join(orders, ", ")
 	if config.OrderBy != nil {
 		if v, ok := config.OrderBy[table]; ok {
@@ -255,26 +255,37 @@ func createTmpTable(starrocks *sql.DB, db dal.Dal, starrocksTable string, starro
 	if orderBy == "" {
 		orderBy = firstcmName
 	}
-	extra := fmt.Sprintf(`engine=olap distributed by hash(%s) properties("replication_num" = "1")`, strings.Join(pks, ", "))
-	if config.Extra != nil {
-		if v, ok := config.Extra[table]; ok {
-			extra = v
-		}
+	return columns, orderBy, false, nil
+}
+
+func getUpdatedFrom(db dal.Dal, table string, updateColumn string) (time.Time, errors.Error) {
+	var updatedFrom time.Time
+	rows, err := db.Cursor(
+		dal.From(table),
+		dal.Select(updateColumn),
+		dal.Limit(1),
+		dal.Orderby(fmt.Sprintf("%s desc", updateColumn)),
+	)
+	if err != nil {
+		return updatedFrom, err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		err = errors.Convert(rows.Scan(&updatedFrom))
 	}
-	tableSql := fmt.Sprintf("drop table if exists %s; create table if not exists `%s` ( %s ) %s", starrocksTmpTable, starrocksTmpTable, strings.Join(columns, ","), extra)
-	c.GetLogger().Debug(tableSql)
-	_, err = errors.Convert01(starrocks.Exec(tableSql))
-	return columnMap, orderBy, false, err
+	return updatedFrom, err
 }
 
-func loadData(starrocks *sql.DB, c plugin.SubTaskContext, starrocksTable, starrocksTmpTable, table string, columnMap map[string]string, db dal.Dal, config *StarRocksConfig, orderBy string) error {
+func loadData(c plugin.SubTaskContext, db dal.Dal, sink Sink, table string, columns []SinkColumn, orderBy string, config *StarRocksConfig) errors.Error {
+	arrays := make(map[string]bool)
+	for _, column := range columns {
+		arrays[column.Name] = column.IsArray()
+	}
 	offset := 0
-	var err error
 	for {
-		var rows dal.Rows
 		var data []map[string]interface{}
 		// select data from db
-		rows, err = db.Cursor(
+		rows, err := db.Cursor(
 			dal.From(table),
 			dal.Orderby(orderBy),
 			dal.Limit(config.BatchSize),
@@ -283,151 +294,64 @@ func loadData(starrocks *sql.DB, c plugin.SubTaskContext, starrocksTable, starro
 		if err != nil {
 			return err
 		}
-		cols, err := rows.Columns()
+		data, err = fetchRows(rows, arrays)
 		if err != nil {
 			return err
 		}
-		for rows.Next() {
-			row := make(map[string]interface{})
-			columns := make([]interface{}, len(cols))
-			columnPointers := make([]interface{}, len(cols))
-			for i := range columns {
-				dataType := columnMap[cols[i]]
-				if strings.HasPrefix(dataType, "array") {
-					var arr []string
-					columns[i] = &arr
-					columnPointers[i] = pq.Array(&arr)
-				} else {
-					columnPointers[i] = &columns[i]
-				}
-			}
-			err = rows.Scan(columnPointers...)
-			if err != nil {
-				return err
-			}
-			for i, colName := range cols {
-				row[colName] = columns[i]
-			}
-			data = append(data, row)
-		}
 		if len(data) == 0 {
 			c.GetLogger().Warn(nil, "no data found in table %s already, limit: %d, offset: %d, so break", table, config.BatchSize, offset)
 			break
 		}
 		// insert data to tmp table
-		loadURL := fmt.Sprintf("http://%s:%d/api/%s/%s/_stream_load", config.BeHost, config.BePort, config.Database, starrocksTmpTable)
-		headers := map[string]string{
-			"format":            "json",
-			"strip_outer_array": "true",
-			"Expect":            "100-continue",
-			"ignore_json_size":  "true",
-			"Connection":        "close",
-		}
-		jsonData, err := json.Marshal(data)
-		if err != nil {
-			return err
-		}
-		client := http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
-		}
-		req, err := http.NewRequest(http.MethodPut, loadURL, bytes.NewBuffer(jsonData))
-		if err != nil {
-			return err
-		}
-		req.SetBasicAuth(config.User, config.Password)
-		for k, v := range headers {
-			req.Header.Set(k, v)
-		}
-		resp, err := client.Do(req)
-		if err != nil {
-			return err
-		}
-		if resp.StatusCode == 307 {
-			var location *url.URL
-			location, err = resp.Location()
-			if err != nil {
-				return err
-			}
-			req, err = http.NewRequest(http.MethodPut, location.String(), bytes.NewBuffer(jsonData))
-			if err != nil {
-				return err
-			}
-			req.SetBasicAuth(config.User, config.Password)
-			for k, v := range headers {
-				req.Header.Set(k, v)
-			}
-			resp, err = client.Do(req)
-		}
-		if err != nil {
-			return err
-		}
-		b, err := io.ReadAll(resp.Body)
+		err = sink.Write(data)
 		if err != nil {
 			return err
 		}
-		var result map[string]interface{}
-		err = json.Unmarshal(b, &result)
-		if err != nil {
-			return err
-		}
-		if resp.StatusCode != http.StatusOK {
-			c.GetLogger().Error(nil, "[%s]: %s", resp.StatusCode, string(b))
-		}
-		if result["Status"] != "Success" {
-			c.GetLogger().Error(nil, "load %s failed: %s", table, string(b))
-		} else {
-			c.GetLogger().Debug("load %s success: %s, limit: %d, offset: %d", table, b, config.BatchSize, offset)
-		}
+		c.GetLogger().Debug("load %s success, limit: %d, offset: %d", table, config.BatchSize, offset)
 		offset += len(data)
 	}
-	// drop old table
-	_, err = starrocks.Exec(fmt.Sprintf("drop table if exists %s", starrocksTable))
-	if err != nil {
-		return err
-	}
-	// rename tmp table to old table
-	_, err = starrocks.Exec(fmt.Sprintf("alter table %s rename %s", starrocksTmpTable, starrocksTable))
-	if err != nil {
-		return err
-	}
-	// check data count
-	rows, err := db.Cursor(
-		dal.Select("count(*)"),
-		dal.From(table),
-	)
+	return nil
+}
+
+func fetchRows(rows dal.Rows, arrays map[string]bool) ([]map[string]interface{}, errors.Error) {
+	defer rows.Close()
+	cols, err := rows.Columns()
 	if err != nil {
-		return err
+		return nil, errors.Convert(err)
 	}
-	var sourceCount int
+	var data []map[string]interface{}
 	for rows.Next() {
-		err = rows.Scan(&sourceCount)
-		if err != nil {
-			return err
+		row := make(map[string]interface{})
+		columns := make([]interface{}, len(cols))
+		columnPointers := make([]interface{}, len(cols))
+		for i := range columns {
+			if arrays[cols[i]] {
+				var arr []string
+				columns[i] = &arr
+				columnPointers[i] = pq.Array(&arr)
+			} else {
+				columnPointers[i] = &columns[i]
+			}
 		}
-	}
-	rows, err = starrocks.Query(fmt.Sprintf("select count(*) from %s", starrocksTable))
-	if err != nil {
-		return err
-	}
-	var starrocksCount int
-	for rows.Next() {
-		err = rows.Scan(&starrocksCount)
+		err = rows.Scan(columnPointers...)
 		if err != nil {
-			return err
+			return nil, errors.Convert(err)
 		}
+		for i, colName := range cols {
+			// text columns are scanned as []byte by the mysql driver
+			if b, ok := columns[i].([]byte); ok {
+				columns[i] = string(b)
+			}
+			row[colName] = columns[i]
+		}
+		data = append(data, row)
 	}
-	if sourceCount != starrocksCount {
-		c.GetLogger().Warn(nil, "source count %d not equal to starrocks count %d", sourceCount, starrocksCount)
-	}
-	c.GetLogger().Info("load %s to starrocks success", table)
-	return nil
+	return data, nil
 }
 
 var LoadDataTaskMeta = plugin.SubTaskMeta{
 	Name:             "LoadData",
 	EntryPoint:       LoadData,
 	EnabledByDefault: true,
-	Description:      "Load data to StarRocks",
+	Description:      "Load data to StarRocks, ClickHouse, DuckDB or Parquet files",
 }
@@ -125,3 +125,47 @@ func GetStarRocksDataType(dataType string) string {
 	}
 	return starrocksDatatype
 }
+
+// GetClickHouseDataType analysis and return the data type of ClickHouse
+func GetClickHouseDataType(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	clickhouseDatatype := "String"
+	if hasPrefixes(dataType, "datetime", "timestamp") {
+		clickhouseDatatype = "DateTime64(3)"
+	} else if stringIn(dataType, "date") {
+		clickhouseDatatype = "Date32"
+	} else if strings.HasPrefix(dataType, "bigint") || stringIn(dataType, "bigserial") {
+		clickhouseDatatype = "Int64"
+	} else if stringIn(dataType, "int", "integer", "serial") || hasPrefixes(dataType, "int(", "mediumint") {
+		clickhouseDatatype = "Int64"
+	} else if stringIn(dataType, "tinyint(1)", "boolean", "bool") {
+		// json booleans are accepted as numbers
+		clickhouseDatatype = "UInt8"
+	} else if hasPrefixes(dataType, "smallint", "smallserial", "tinyint") {
+		clickhouseDatatype = "Int32"
+	} else if hasPrefixes(dataType, "real", "float", "double", "numeric", "decimal") {
+		clickhouseDatatype = "Float64"
+	} else if strings.HasSuffix(dataType, "[]") {
+		return fmt.Sprintf("Array(%s)", GetClickHouseDataType(strings.Split(dataType, "[]")[0]))
+	}
+	return fmt.Sprintf("Nullable(%s)", clickhouseDatatype)
+}
+
+// GetParquetDataType analysis and return the parquet type and converted type of the column metadata
+func GetParquetDataType(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	if hasPrefixes(dataType, "datetime", "timestamp") {
+		return "type=INT64, convertedtype=TIMESTAMP_MILLIS"
+	} else if stringIn(dataType, "date") {
+		return "type=INT32, convertedtype=DATE"
+	} else if stringIn(dataType, "tinyint(1)", "boolean", "bool") {
+		return "type=BOOLEAN"
+	} else if stringIn(dataType, "int", "integer", "serial", "bigserial", "smallserial") ||
+		hasPrefixes(dataType, "bigint", "int(", "mediumint", "smallint", "tinyint") && !strings.HasSuffix(dataType, "[]") {
+		return "type=INT64"
+	} else if hasPrefixes(dataType, "real", "float", "double", "numeric", "decimal") && !strings.HasSuffix(dataType, "[]") {
+		return "type=DOUBLE"
+	}
+	// arrays and json are stored as json strings
+	return "type=BYTE_ARRAY, convertedtype=UTF8"
+}
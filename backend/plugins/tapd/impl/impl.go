@@ -32,6 +32,7 @@ import (
 )
 
 var _ plugin.PluginMeta = (*Tapd)(nil)
+var _ plugin.PluginCompatibility = (*Tapd)(nil)
 var _ plugin.PluginInit = (*Tapd)(nil)
 var _ plugin.PluginTask = (*Tapd)(nil)
 var _ plugin.PluginApi = (*Tapd)(nil)
@@ -42,8 +43,7 @@ var _ plugin.CloseablePluginTask = (*Tapd)(nil)
 type Tapd struct{}
 
 func (p Tapd) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
-	return nil
+	return api.Init(basicRes)
 }
 
 func (p Tapd) GetTablesInfo() []dal.Tabler {
@@ -92,6 +92,14 @@ func (p Tapd) Description() string {
 	return "To collect and enrich data from Tapd"
 }
 
+func (p Tapd) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Tapd) Dependencies() []string {
+	return nil
+}
+
 func (p Tapd) SubTaskMetas() []plugin.SubTaskMeta {
 	return []plugin.SubTaskMeta{
 		tasks.CollectCompanyMeta,
@@ -172,10 +180,13 @@ func (p Tapd) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]int
 		return nil, errors.BadInput.New("connectionId is invalid")
 	}
 	connection := &models.TapdConnection{}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
 		return nil, err
@@ -41,6 +41,7 @@ func NewTapdApiClient(taskCtx plugin.TaskContext, connection *models.TapdConnect
 	// create rate limit calculator
 	rateLimiter := &api.ApiRateLimitCalculator{
 		UserRateLimitPerHour: connection.RateLimitPerHour,
+		BudgetKey:            api.RateLimitBudgetKey(connection, connection.ID),
 	}
 	asyncApiClient, err := api.CreateAsyncApiClient(
 		taskCtx,
@@ -23,12 +23,15 @@ import (
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/plugins/webhook/api"
+	"github.com/apache/incubator-devlake/plugins/webhook/models"
 )
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*Webhook)(nil)
+var _ plugin.PluginCompatibility = (*Webhook)(nil)
 var _ plugin.PluginInit = (*Webhook)(nil)
 var _ plugin.PluginApi = (*Webhook)(nil)
+var _ plugin.PluginApiDoc = (*Webhook)(nil)
 var _ plugin.PluginModel = (*Webhook)(nil)
 var _ plugin.PluginMigration = (*Webhook)(nil)
 
@@ -38,11 +41,18 @@ func (p Webhook) Description() string {
 	return "collect some Webhook data"
 }
 
-func (p Webhook) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
+func (p Webhook) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Webhook) Dependencies() []string {
 	return nil
 }
 
+func (p Webhook) Init(basicRes context.BasicRes) errors.Error {
+	return api.Init(basicRes)
+}
+
 func (p Webhook) GetTablesInfo() []dal.Tabler {
 	return []dal.Tabler{}
 }
@@ -88,3 +98,32 @@ func (p Webhook) ApiResources() map[string]map[string]plugin.ApiResourceHandler
 		},
 	}
 }
+
+func (p Webhook) ApiResourceDocs() map[string]map[string]*plugin.ApiResourceDoc {
+	return map[string]map[string]*plugin.ApiResourceDoc{
+		"connections": {
+			"POST": {Summary: "create a webhook", Request: &models.WebhookConnection{}, Response: &models.WebhookConnection{}},
+			"GET":  {Summary: "list webhooks", Response: []*api.WebhookConnectionResponse{}},
+		},
+		"connections/:connectionId": {
+			"GET":    {Summary: "get a webhook", Response: &api.WebhookConnectionResponse{}},
+			"PATCH":  {Summary: "update a webhook", Request: &models.WebhookConnection{}, Response: &models.WebhookConnection{}},
+			"DELETE": {Summary: "delete a webhook", Response: &models.WebhookConnection{}},
+		},
+		":connectionId/cicd_tasks": {
+			"POST": {Summary: "create a cicd task", Request: &api.WebhookTaskRequest{}},
+		},
+		":connectionId/cicd_pipeline/:pipelineName/finish": {
+			"POST": {Summary: "finish a cicd pipeline and generate the deployment records"},
+		},
+		":connectionId/deployments": {
+			"POST": {Summary: "create a deployment", Request: &api.WebhookDeployTaskRequest{}},
+		},
+		":connectionId/issues": {
+			"POST": {Summary: "create or update an issue", Request: &api.WebhookIssueRequest{}},
+		},
+		":connectionId/issue/:boardKey/:issueKey/close": {
+			"POST": {Summary: "close an issue"},
+		},
+	}
+}
@@ -20,6 +20,7 @@ package impl
 import (
 	"fmt"
 	"github.com/apache/incubator-devlake/core/context"
+	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
@@ -31,7 +32,9 @@ import (
 
 // make sure interface is implemented
 var _ plugin.PluginMeta = (*Zentao)(nil)
+var _ plugin.PluginCompatibility = (*Zentao)(nil)
 var _ plugin.PluginInit = (*Zentao)(nil)
+var _ plugin.PluginModel = (*Zentao)(nil)
 var _ plugin.PluginTask = (*Zentao)(nil)
 var _ plugin.PluginApi = (*Zentao)(nil)
 var _ plugin.PluginBlueprintV100 = (*Zentao)(nil)
@@ -43,11 +46,32 @@ func (p Zentao) Description() string {
 	return "collect some Zentao data"
 }
 
-func (p Zentao) Init(basicRes context.BasicRes) errors.Error {
-	api.Init(basicRes)
+func (p Zentao) RequiredApiVersion() string {
+	return plugin.ApiVersion
+}
+
+func (p Zentao) Dependencies() []string {
 	return nil
 }
 
+func (p Zentao) Init(basicRes context.BasicRes) errors.Error {
+	return api.Init(basicRes)
+}
+
+func (p Zentao) GetTablesInfo() []dal.Tabler {
+	return []dal.Tabler{
+		&models.ZentaoConnection{},
+		&models.ZentaoAccount{},
+		&models.ZentaoBug{},
+		&models.ZentaoDepartment{},
+		&models.ZentaoExecution{},
+		&models.ZentaoProduct{},
+		&models.ZentaoProject{},
+		&models.ZentaoStory{},
+		&models.ZentaoTask{},
+	}
+}
+
 func (p Zentao) SubTaskMetas() []plugin.SubTaskMeta {
 	// TODO add your sub task here
 	return []plugin.SubTaskMeta{
@@ -80,10 +104,13 @@ func (p Zentao) PrepareTaskData(taskCtx plugin.TaskContext, options map[string]i
 	if err != nil {
 		return nil, errors.Default.Wrap(err, "could not decode Zentao options")
 	}
-	connectionHelper := helper.NewConnectionHelper(
+	connectionHelper, err := helper.NewConnectionHelper(
 		taskCtx,
 		nil,
 	)
+	if err != nil {
+		return nil, errors.Convert(err)
+	}
 	connection := &models.ZentaoConnection{}
 	err = connectionHelper.FirstById(connection, op.ConnectionId)
 	if err != nil {
@@ -21,6 +21,7 @@ import (
 	"github.com/apache/incubator-devlake/core/config"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/apache/incubator-devlake/server/api/migration"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/services"
 	"net/http"
@@ -53,8 +54,19 @@ func CreateApiService() {
 	gin.SetMode(v.GetString("MODE"))
 	router := gin.Default()
 
+	// CORS CONFIG
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"PUT", "PATCH", "POST", "GET", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-API-Key"},
+		ExposeHeaders:    []string{"Content-Length"},
+		AllowCredentials: true,
+		MaxAge:           120 * time.Hour,
+	}))
+	router.Use(authenticate)
+
 	// Wait for user confirmation if db migration is needed
-	router.GET("/proceed-db-migration", func(ctx *gin.Context) {
+	router.GET("/proceed-db-migration", requireRole(services.ROLE_ADMIN), func(ctx *gin.Context) {
 		if !services.MigrationRequireConfirmation() {
 			shared.ApiOutputSuccess(ctx, nil, http.StatusOK)
 			return
@@ -66,6 +78,8 @@ func CreateApiService() {
 		}
 		shared.ApiOutputSuccess(ctx, nil, http.StatusOK)
 	})
+	// Migration scripts should be inspectable before the confirmation
+	router.GET("/migrations", requireRole(services.ROLE_VIEWER), migration.Get)
 	router.Use(func(ctx *gin.Context) {
 		if !services.MigrationRequireConfirmation() {
 			return
@@ -84,16 +98,6 @@ func CreateApiService() {
 		logruslog.Global.Printf("endpoint %v %v %v %v", httpMethod, absolutePath, handlerName, nuHandlers)
 	}
 
-	// CORS CONFIG
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"PUT", "PATCH", "POST", "GET", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           120 * time.Hour,
-	}))
-
 	RegisterRouter(router)
 	err := router.Run(v.GetString("PORT"))
 	if err != nil {
@@ -0,0 +1,65 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditlog
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PaginatedAuditLogs struct {
+	AuditLogs []*models.AuditLog `json:"auditLogs"`
+	Count     int64              `json:"count"`
+}
+
+// @Summary Get audit logs
+// @Description GET /audit-logs?resource=_devlake_blueprints&resourceId=1&page=1&pageSize=50
+// @Description list api calls modifying anything or rejected, and changes made to connections, blueprints,
+// @Description projects and transformation rules with sensitive fields redacted
+// @Tags framework/audit-logs
+// @Param actor query string false "actor"
+// @Param resource query string false "resource, the table name of the changed record"
+// @Param resourceId query string false "resourceId"
+// @Param action query string false "action: create, update or delete"
+// @Param since query string false "since, RFC3339 time"
+// @Param until query string false "until, RFC3339 time"
+// @Param page query int false "page"
+// @Param pageSize query int false "pageSize"
+// @Success 200  {object} PaginatedAuditLogs
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /audit-logs [get]
+func Index(c *gin.Context) {
+	var query services.AuditLogQuery
+	err := c.ShouldBindQuery(&query)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	auditLogs, count, err := services.GetAuditLogs(&query)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	shared.ApiOutputSuccess(c, PaginatedAuditLogs{AuditLogs: auditLogs, Count: count}, http.StatusOK)
+}
@@ -0,0 +1,103 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// authenticate identifies the caller of the request by the X-API-Key header or the bearer token, requests
+// without credentials are passed on and rejected by requireRole unless the route is public. Requests
+// modifying anything or rejected for lack of permission are recorded to the audit log.
+func authenticate(c *gin.Context) {
+	if c.Request.Method == http.MethodOptions {
+		return
+	}
+	bearerToken := ""
+	if authorization := c.GetHeader("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		bearerToken = strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	apiKey := c.GetHeader("X-API-Key")
+	if !services.AuthEnabled() || apiKey != "" || bearerToken != "" {
+		principal, err := services.Authenticate(apiKey, bearerToken)
+		if err != nil {
+			shared.ApiOutputError(c, err)
+			c.Abort()
+			services.RecordAudit(nil, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), c.ClientIP())
+			return
+		}
+		shared.SetPrincipal(c, principal)
+	}
+	c.Next()
+	status := c.Writer.Status()
+	if status == http.StatusUnauthorized || status == http.StatusForbidden || isMutation(c.Request.Method) {
+		services.RecordAudit(shared.GetPrincipal(c), c.Request.Method, c.Request.URL.Path, status, c.ClientIP())
+	}
+}
+
+func isMutation(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// authorize rejects the request if the caller was not granted the role
+func authorize(c *gin.Context, role string) bool {
+	principal := shared.GetPrincipal(c)
+	if principal == nil {
+		shared.ApiOutputError(c, errors.Unauthorized.New("missing api key or bearer token"))
+		c.Abort()
+		return false
+	}
+	if !principal.HasRole(role) {
+		shared.ApiOutputError(c, errors.Forbidden.New(fmt.Sprintf("%s role is required", role)))
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+// requireRole creates a middleware rejecting callers without the role
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authorize(c, role)
+	}
+}
+
+// pluginResourceRole returns the role required by a plugin api resource. Connections contain credentials,
+// so reading them requires the operator role and modifying them (along with transformation rules which
+// change how data is processed) requires the admin role. Other resources are readable by viewers and
+// writable by operators.
+func pluginResourceRole(method string, resourcePath string) string {
+	sensitive := strings.HasPrefix(resourcePath, "connections")
+	switch {
+	case method == http.MethodGet && sensitive:
+		return services.ROLE_OPERATOR
+	case method == http.MethodGet:
+		return services.ROLE_VIEWER
+	case sensitive || strings.HasPrefix(resourcePath, "transformation_rules"):
+		return services.ROLE_ADMIN
+	default:
+		return services.ROLE_OPERATOR
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ApiKeyCreated carries the generated key which would never be shown again
+type ApiKeyCreated struct {
+	models.ApiKey
+	Key string `json:"key"`
+}
+
+// @Summary Get the caller
+// @Description GET /auth/me
+// @Tags framework/auth
+// @Success 200  {object} services.Principal
+// @Failure 401  {object} shared.ApiBody "Unauthorized"
+// @Router /auth/me [get]
+func GetMe(c *gin.Context) {
+	shared.ApiOutputSuccess(c, shared.GetPrincipal(c), http.StatusOK)
+}
+
+// @Summary Create an api key
+// @Description POST /api-keys
+// @Tags framework/auth
+// @Accept application/json
+// @Param apiKey body models.ApiKey true "json"
+// @Success 201  {object} ApiKeyCreated
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /api-keys [post]
+func PostApiKey(c *gin.Context) {
+	apiKey := &models.ApiKey{}
+	err := c.ShouldBind(apiKey)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	key, err := services.CreateApiKey(apiKey, shared.GetPrincipal(c).Name)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error creating api key"))
+		return
+	}
+	shared.ApiOutputSuccess(c, ApiKeyCreated{ApiKey: *apiKey, Key: key}, http.StatusCreated)
+}
+
+// @Summary List api keys
+// @Description GET /api-keys
+// @Tags framework/auth
+// @Success 200  {object} []models.ApiKey
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /api-keys [get]
+func GetApiKeys(c *gin.Context) {
+	apiKeys, err := services.GetApiKeys()
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	shared.ApiOutputSuccess(c, apiKeys, http.StatusOK)
+}
+
+// @Summary Revoke an api key
+// @Description DELETE /api-keys/:apiKeyId
+// @Tags framework/auth
+// @Param apiKeyId path int true "api key id"
+// @Success 200
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Router /api-keys/{apiKeyId} [delete]
+func DeleteApiKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("apiKeyId"), 10, 64)
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "bad apiKeyId format supplied"))
+		return
+	}
+	err = services.DeleteApiKey(id)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error deleting api key"))
+		return
+	}
+	shared.ApiOutputSuccess(c, nil, http.StatusOK)
+}
@@ -66,7 +66,7 @@ func Post(c *gin.Context) {
 // @Param enable query bool false "enable"
 // @Param is_manual query bool false "is_manual"
 // @Param page query int false "page"
-// @Param page_size query int false "page_size"
+// @Param pageSize query int false "pageSize"
 // @Param label query string false "label"
 // @Success 200  {object} PaginatedBlueprint
 // @Failure 400  {object} shared.ApiBody "Bad Request"
@@ -154,7 +154,7 @@ func Patch(c *gin.Context) {
 		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
 		return
 	}
-	blueprint, err := services.PatchBlueprint(id, body)
+	blueprint, err := services.PatchBlueprint(id, body, shared.GetUser(c))
 	if err != nil {
 		shared.ApiOutputError(c, errors.Default.Wrap(err, "error patching the blueprint"))
 		return
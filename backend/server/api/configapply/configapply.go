@@ -0,0 +1,89 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configapply
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// ApplyFailure is the response of a failed apply, Result holds the changes made before the failure
+type ApplyFailure struct {
+	shared.ApiBody
+	Result *services.ConfigApplyResult `json:"result"`
+}
+
+// @Summary Apply a configuration document
+// @Description POST /config/apply?dryRun=true
+// @Description create or update connections, transformation rules, scopes, projects and blueprints described by
+// @Description the YAML or JSON document, resources matching the document are left untouched so the same
+// @Description document could be applied repeatedly. Nothing is changed when dryRun is true.
+// @Description Applying is not atomic, resources applied before a failure are kept and listed in the failed
+// @Description response, applying the same document again resumes safely from where it stopped.
+// @Tags framework/config
+// @Accept application/yaml
+// @Param document body services.ConfigDocument true "yaml or json"
+// @Param dryRun query bool false "compute the changes without applying them"
+// @Success 200  {object} services.ConfigApplyResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} ApplyFailure "Internal Error, along with the changes made before the failure"
+// @Router /config/apply [post]
+func Post(c *gin.Context) {
+	dryRun := false
+	if v := c.Query("dryRun"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, "invalid dryRun"))
+			return
+		}
+	}
+	blob, err := c.GetRawData()
+	if err != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+		return
+	}
+	doc, err := services.ParseConfigDocument(blob)
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	result, applyErr := services.ApplyConfig(doc, dryRun, shared.GetUser(c))
+	if applyErr != nil {
+		applyErr = errors.Default.Wrap(applyErr, "error applying the configuration")
+		if result == nil {
+			shared.ApiOutputError(c, applyErr)
+			return
+		}
+		// resources are applied one by one, those applied before the failure are kept
+		status := applyErr.GetType().GetHttpCode()
+		logruslog.Global.Error(applyErr, "HTTP %d error", status)
+		c.JSON(status, &ApplyFailure{
+			ApiBody: shared.ApiBody{Success: false, Message: applyErr.Error(), Causes: applyErr.Messages().Causes()},
+			Result:  result,
+		})
+		return
+	}
+	shared.ApiOutputSuccess(c, result, http.StatusOK)
+}
@@ -0,0 +1,67 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package encryption
+
+import (
+	"net/http"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// RotationRequest specifies how many rows should be re-encrypted per batch
+type RotationRequest struct {
+	BatchSize int `json:"batchSize"`
+}
+
+// @Summary Get ids of encryption keys
+// @Description GET /encryption/keys
+// @Tags framework/encryption
+// @Success 200  {object} services.EncryptionKeys
+// @Router /encryption/keys [get]
+func GetKeys(c *gin.Context) {
+	shared.ApiOutputSuccess(c, services.GetEncryptionKeys(), http.StatusOK)
+}
+
+// @Summary Re-encrypt all encrypted columns with the primary key
+// @Description POST /encryption/rotate
+// @Tags framework/encryption
+// @Accept application/json
+// @Param request body RotationRequest false "json"
+// @Success 200  {object} []services.EncryptionRotationResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /encryption/rotate [post]
+func PostRotate(c *gin.Context) {
+	request := &RotationRequest{}
+	if c.Request.ContentLength > 0 {
+		err := c.ShouldBindJSON(request)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, shared.BadRequestBody))
+			return
+		}
+	}
+	results, err := services.RotateEncryptionKey(request.BatchSize)
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error rotating encryption key"))
+		return
+	}
+	shared.ApiOutputSuccess(c, results, http.StatusOK)
+}
@@ -0,0 +1,72 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migration
+
+import (
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get migration scripts
+// @Description GET /migrations?dryRun=true
+// @Description list executed and pending migration scripts per plugin,
+// @Description or the SQL statements pending scripts would execute when dryRun is true
+// @Tags framework/migrations
+// @Param dryRun query bool false "print SQL statements of pending scripts without applying them"
+// @Success 200  {object} map[string][]plugin.MigrationScriptInfo
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /migrations [get]
+func Get(c *gin.Context) {
+	dryRun := false
+	if v := c.Query("dryRun"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			shared.ApiOutputError(c, errors.BadInput.Wrap(err, "invalid dryRun"))
+			return
+		}
+	}
+	if !dryRun {
+		shared.ApiOutputSuccess(c, services.GetMigrationScripts(), http.StatusOK)
+		return
+	}
+	results, err := services.DryRunMigration()
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error performing migration dry run"))
+		return
+	}
+	shared.ApiOutputSuccess(c, results, http.StatusOK)
+}
+
+// @Summary Get progresses of online migrations
+// @Description GET /migrations/online
+// @Description list progresses of the migration scripts running in the background since the server started
+// @Tags framework/migrations
+// @Success 200  {object} []migrationhelper.OnlineMigrationProgress
+// @Failure 400  {string} errcode.Error "Bad Request"
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /migrations/online [get]
+func GetOnline(c *gin.Context) {
+	shared.ApiOutputSuccess(c, services.GetOnlineMigrationProgresses(), http.StatusOK)
+}
@@ -0,0 +1,215 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/version"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/server/api/auditlog"
+	"github.com/apache/incubator-devlake/server/api/auth"
+	"github.com/apache/incubator-devlake/server/api/blueprints"
+	"github.com/apache/incubator-devlake/server/api/encryption"
+	"github.com/apache/incubator-devlake/server/api/openapi"
+	"github.com/apache/incubator-devlake/server/api/plugininfo"
+	"github.com/apache/incubator-devlake/server/api/project"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// publicRoutes can be called without credentials
+var publicRoutes = map[string]bool{
+	"GET /ping":         true,
+	"GET /version":      true,
+	"GET /openapi.json": true,
+}
+
+type coreApiDoc struct {
+	role string
+	doc  *plugin.ApiResourceDoc
+}
+
+// suffixRoutes are served within catch-all routes by routeSuffix, thus missing from the routes of gin
+var suffixRoutes = gin.RoutesInfo{
+	{Method: http.MethodGet, Path: "/projects/*projectName/export"},
+}
+
+var paginationQuery = map[string]string{"page": "page number", "pageSize": "page size"}
+
+// coreApiDocs documents the routes registered by the framework, keyed by `METHOD path`, they are kept in line
+// with the swagger annotations of the handlers
+var coreApiDocs = map[string]*coreApiDoc{
+	"GET /pipelines": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{
+		Summary: "list pipelines",
+		Query: map[string]string{
+			"status": "status", "pending": "pending", "page": "page number", "pageSize": "page size",
+			"blueprint_id": "blueprint id", "label": "label",
+		},
+		Response: &shared.ResponsePipelines{},
+	}},
+	"POST /pipelines":                           {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{Summary: "create and run a pipeline", Request: &models.NewPipeline{}, Response: &models.Pipeline{}}},
+	"GET /pipelines/:pipelineId":                {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get a pipeline", Response: &models.Pipeline{}}},
+	"DELETE /pipelines/:pipelineId":             {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{Summary: "cancel a pipeline"}},
+	"GET /pipelines/:pipelineId/tasks":          {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "list tasks of a pipeline"}},
+	"POST /pipelines/:pipelineId/rerun":         {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{Summary: "rerun the failed tasks of a pipeline", Response: []*models.Task{}}},
+	"GET /pipelines/:pipelineId/logging.tar.gz": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "download the logs of a pipeline as a tar.gz archive"}},
+	"POST /tasks/:taskId/rerun":                 {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{Summary: "rerun a task", Response: &models.Task{}}},
+	"GET /blueprints": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{
+		Summary: "list blueprints",
+		Query: map[string]string{
+			"enable": "enable", "is_manual": "is_manual", "page": "page number", "pageSize": "page size", "label": "label",
+		},
+		Response: &blueprints.PaginatedBlueprint{},
+	}},
+	"POST /blueprints":                       {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "create a blueprint", Request: &models.Blueprint{}, Response: &models.Blueprint{}}},
+	"GET /blueprints/:blueprintId":           {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get a blueprint", Response: &models.Blueprint{}}},
+	"PATCH /blueprints/:blueprintId":         {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "update a blueprint", Request: &models.Blueprint{}, Response: &models.Blueprint{}}},
+	"POST /blueprints/:blueprintId/trigger":  {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{Summary: "trigger a blueprint", Response: &models.Pipeline{}}},
+	"GET /blueprints/:blueprintId/pipelines": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "list pipelines of a blueprint", Response: &shared.ResponsePipelines{}}},
+	"GET /projects":                          {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "list projects", Query: paginationQuery, Response: &project.PaginatedProjects{}}},
+	"POST /projects":                         {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "create a project", Request: &models.ApiInputProject{}, Response: &models.ApiOutputProject{}}},
+	"GET /projects/*projectName":             {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get a project", Response: &models.ApiOutputProject{}}},
+	"PATCH /projects/*projectName":           {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "update a project", Request: &models.ApiInputProject{}, Response: &models.ApiOutputProject{}}},
+	"GET /ping":                              {"", &plugin.ApiResourceDoc{Summary: "check if the server is up"}},
+	"GET /version":                           {"", &plugin.ApiResourceDoc{Summary: "get the version of the server"}},
+	"GET /openapi.json":                      {"", &plugin.ApiResourceDoc{Summary: "get this document"}},
+	"GET /proceed-db-migration":              {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "confirm and execute pending migration scripts"}},
+	"GET /migrations": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{
+		Summary:  "list migration scripts",
+		Query:    map[string]string{"dryRun": "print SQL statements of pending scripts without applying them"},
+		Response: map[string][]plugin.MigrationScriptInfo{},
+	}},
+	"GET /migrations/online":     {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get the progress of online migrations", Response: []*migrationhelper.OnlineMigrationProgress{}}},
+	"GET /rate-limits":           {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get the rate limit budgets of data sources", Response: []*helper.RateLimitBudgetState{}}},
+	"POST /push/:tableName":      {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "push records into a table", Request: []map[string]interface{}{}}},
+	"GET /domainlayer/repos":     {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "list repos of the domain layer", Query: paginationQuery}},
+	"GET /auth/me":               {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get the current principal", Response: &services.Principal{}}},
+	"GET /api-keys":              {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "list api keys", Response: []*models.ApiKey{}}},
+	"POST /api-keys":             {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "create an api key", Request: &models.ApiKey{}, Response: &auth.ApiKeyCreated{}}},
+	"DELETE /api-keys/:apiKeyId": {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "delete an api key"}},
+	"GET /audit-logs": {services.ROLE_ADMIN, &plugin.ApiResourceDoc{
+		Summary: "list audit logs",
+		Query: map[string]string{
+			"actor": "actor", "resource": "the table name of the changed record", "resourceId": "resource id",
+			"action": "create, update or delete", "since": "RFC3339 time", "until": "RFC3339 time",
+			"page": "page number", "pageSize": "page size",
+		},
+		Response: &auditlog.PaginatedAuditLogs{},
+	}},
+	"GET /encryption/keys":    {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "list encryption keys", Response: &services.EncryptionKeys{}}},
+	"POST /encryption/rotate": {services.ROLE_ADMIN, &plugin.ApiResourceDoc{Summary: "re-encrypt secrets with the primary key", Request: &encryption.RotationRequest{}, Response: []*services.EncryptionRotationResult{}}},
+	"GET /projects/*projectName/export": {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{
+		Summary:     "export a project",
+		Description: "export the project with its blueprint, connections without secrets, scopes and transformation rules",
+		Query:       map[string]string{"domainData": "export the domain layer data of the project as well"},
+		Response:    &services.ProjectBundle{},
+	}},
+	"GET /project-bundles/*projectName": {services.ROLE_OPERATOR, &plugin.ApiResourceDoc{
+		Summary:     "export a project",
+		Description: "same as GET /projects/{projectName}/export, for projects whose names end with /export",
+		Query:       map[string]string{"domainData": "export the domain layer data of the project as well"},
+		Response:    &services.ProjectBundle{},
+	}},
+	"POST /projects/import": {services.ROLE_ADMIN, &plugin.ApiResourceDoc{
+		Summary:     "import a project",
+		Description: "import a project exported by another instance, existing resources differing from the bundle are reported as conflicts",
+		Query:       map[string]string{"dryRun": "compute the changes and conflicts without importing", "overwrite": "overwrite the conflicting resources"},
+		Request:     &services.ProjectImportInput{},
+		Response:    &services.ProjectImportResult{},
+	}},
+	"POST /config/apply": {services.ROLE_ADMIN, &plugin.ApiResourceDoc{
+		Summary:     "apply a configuration document",
+		Description: "The document is accepted in YAML or JSON, resources matching it are left untouched. Applying is not atomic, failed responses carry the changes made before the failure in `result` and applying the same document again resumes safely.",
+		Query:       map[string]string{"dryRun": "compute the changes without applying them"},
+		Request:     &services.ConfigDocument{},
+		Response:    &services.ConfigApplyResult{},
+	}},
+	"GET /plugininfo": {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "get subtasks and tables of plugins"}},
+	"GET /plugins":    {services.ROLE_VIEWER, &plugin.ApiResourceDoc{Summary: "list plugins", Response: plugininfo.PluginMetas{}}},
+}
+
+// BuildOpenApi documents all routes registered to the engine, apis of plugins are documented by their
+// PluginApiDoc or inferred from their PluginSource
+func BuildOpenApi(r *gin.Engine) (*openapi.Document, error) {
+	builder := openapi.NewBuilder("DevLake", version.Version, &shared.ApiBody{})
+	pluginDocs := make(map[string]map[string]map[string]*plugin.ApiResourceDoc)
+	for _, route := range append(r.Routes(), suffixRoutes...) {
+		if strings.HasPrefix(route.Path, "/swagger/") {
+			continue
+		}
+		key := fmt.Sprintf("%s %s", route.Method, route.Path)
+		if strings.HasPrefix(route.Path, "/plugins/") {
+			pluginName, resourcePath, _ := strings.Cut(strings.TrimPrefix(route.Path, "/plugins/"), "/")
+			docs, ok := pluginDocs[pluginName]
+			if !ok {
+				var err error
+				docs, err = services.GetPluginApiDocs(pluginName)
+				if err != nil {
+					return nil, err
+				}
+				pluginDocs[pluginName] = docs
+			}
+			builder.AddRoute(&openapi.Route{
+				Method: route.Method,
+				Path:   route.Path,
+				Tag:    "plugins/" + pluginName,
+				Role:   pluginResourceRole(route.Method, resourcePath),
+				Doc:    docs[resourcePath][route.Method],
+			})
+			continue
+		}
+		apiRoute := &openapi.Route{
+			Method: route.Method,
+			Path:   route.Path,
+			Tag:    strings.Split(strings.TrimPrefix(route.Path, "/"), "/")[0],
+			Public: publicRoutes[key],
+		}
+		if coreDoc, ok := coreApiDocs[key]; ok {
+			apiRoute.Role = coreDoc.role
+			apiRoute.Doc = coreDoc.doc
+		}
+		builder.AddRoute(apiRoute)
+	}
+	return builder.Build(), nil
+}
+
+// registerOpenApi serves the OpenAPI document at /openapi.json, it is built on the first request when all routes
+// are registered
+func registerOpenApi(r *gin.Engine) {
+	var once sync.Once
+	var document *openapi.Document
+	var err error
+	r.GET("/openapi.json", func(c *gin.Context) {
+		once.Do(func() {
+			document, err = BuildOpenApi(r)
+		})
+		if err != nil {
+			shared.ApiOutputError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, document)
+	})
+}
@@ -0,0 +1,372 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/plugin"
+)
+
+const OPENAPI_VERSION = "3.0.3"
+
+// Document is the root of an OpenAPI 3 document, only the parts we generate are modeled
+type Document struct {
+	OpenApi    string                           `json:"openapi"`
+	Info       *Info                            `json:"info"`
+	Tags       []*Tag                           `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	In     string `json:"in,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	OperationId string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security is set to an empty list for public operations
+	Security *[]map[string][]string `json:"security,omitempty"`
+	// Role is the role required to call the operation
+	Role string `json:"x-devlake-role,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Route is an api to be documented
+type Route struct {
+	Method string
+	// Path in the form of gin, e.g. `/plugins/jira/connections/:connectionId`
+	Path string
+	Tag  string
+	// Role is the role required to call the api, it is ignored for public apis
+	Role   string
+	Public bool
+	Doc    *plugin.ApiResourceDoc
+}
+
+// Builder assembles the Document from routes, schemas of bodies are generated from their Go types
+type Builder struct {
+	doc     *Document
+	schemas *schemaGenerator
+	errType interface{}
+}
+
+// NewBuilder creates a Builder, errorBody is the type of the body of failed responses
+func NewBuilder(title string, version string, errorBody interface{}) *Builder {
+	b := &Builder{
+		doc: &Document{
+			OpenApi: OPENAPI_VERSION,
+			Info:    &Info{Title: title, Version: version},
+			Paths:   make(map[string]map[string]*Operation),
+			Components: &Components{
+				SecuritySchemes: map[string]*SecurityScheme{
+					"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key"},
+					"bearerAuth": {Type: "http", Scheme: "bearer"},
+				},
+			},
+			Security: []map[string][]string{{"apiKey": {}}, {"bearerAuth": {}}},
+		},
+		schemas: newSchemaGenerator(),
+		errType: errorBody,
+	}
+	return b
+}
+
+var pathParamPattern = regexp.MustCompile(`^[:*](.+)$`)
+var operationIdPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// AddRoute documents the route, routes with the same method and path are documented once
+func (b *Builder) AddRoute(route *Route) {
+	segments := strings.Split(route.Path, "/")
+	var params []*Parameter
+	for i, segment := range segments {
+		if match := pathParamPattern.FindStringSubmatch(segment); match != nil {
+			segments[i] = "{" + match[1] + "}"
+			params = append(params, &Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	path := strings.Join(segments, "/")
+	method := strings.ToLower(route.Method)
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = make(map[string]*Operation)
+	}
+	if b.doc.Paths[path][method] != nil {
+		return
+	}
+	op := &Operation{
+		OperationId: strings.Trim(operationIdPattern.ReplaceAllString(method+"_"+path, "_"), "_"),
+		Parameters:  params,
+		Responses:   make(map[string]*Response),
+		Role:        route.Role,
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Public {
+		op.Role = ""
+		op.Security = &[]map[string][]string{}
+	} else if route.Role != "" {
+		op.Description = fmt.Sprintf("Requires the %s role.", route.Role)
+	}
+	doc := route.Doc
+	if doc == nil {
+		doc = &plugin.ApiResourceDoc{}
+	}
+	op.Summary = doc.Summary
+	if doc.Description != "" {
+		op.Description = strings.TrimSpace(doc.Description + "\n\n" + op.Description)
+	}
+	queryNames := make([]string, 0, len(doc.Query))
+	for name := range doc.Query {
+		queryNames = append(queryNames, name)
+	}
+	sort.Strings(queryNames)
+	for _, name := range queryNames {
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "query", Description: doc.Query[name], Schema: &Schema{Type: "string"}})
+	}
+	if doc.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: b.schemas.schemaOf(reflect.TypeOf(doc.Request))}},
+		}
+	}
+	success := &Response{Description: "success"}
+	if doc.Response != nil {
+		success.Content = map[string]*MediaType{"application/json": {Schema: b.schemas.schemaOf(reflect.TypeOf(doc.Response))}}
+	}
+	op.Responses[fmt.Sprint(http.StatusOK)] = success
+	if b.errType != nil {
+		op.Responses["default"] = &Response{
+			Description: "error",
+			Content:     map[string]*MediaType{"application/json": {Schema: b.schemas.schemaOf(reflect.TypeOf(b.errType))}},
+		}
+	}
+	b.doc.Paths[path][method] = op
+}
+
+// Build returns the Document with all routes added so far
+func (b *Builder) Build() *Document {
+	tags := make(map[string]bool)
+	for _, operations := range b.doc.Paths {
+		for _, op := range operations {
+			for _, tag := range op.Tags {
+				tags[tag] = true
+			}
+		}
+	}
+	b.doc.Tags = nil
+	for tag := range tags {
+		b.doc.Tags = append(b.doc.Tags, &Tag{Name: tag})
+	}
+	sort.Slice(b.doc.Tags, func(i, j int) bool { return b.doc.Tags[i].Name < b.doc.Tags[j].Name })
+	b.doc.Components.Schemas = b.schemas.schemas
+	return b.doc
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+const modulePrefix = "github.com/apache/incubator-devlake/"
+
+var schemaNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// schemaGenerator generates schemas by the rules of encoding/json, named structs go to components
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func implements(t reflect.Type, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	case implements(t, jsonMarshalerType):
+		// the encoding is up to the type
+		return &Schema{}
+	case implements(t, textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name, ok := g.names[t]
+		if !ok {
+			name = g.schemaName(t)
+			g.names[t] = name
+			// registered before generating properties so recursive types refer to themselves
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func (g *schemaGenerator) schemaName(t reflect.Type) string {
+	pkg := strings.ReplaceAll(strings.TrimPrefix(t.PkgPath(), modulePrefix), "/", ".")
+	base := schemaNamePattern.ReplaceAllString(pkg+"."+t.Name(), "_")
+	name := base
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	return schema
+}
+
+// addFields adds properties of the struct, fields of embedded structs are promoted like encoding/json does
+func (g *schemaGenerator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct && !implements(fieldType, jsonMarshalerType) {
+			g.addFields(schema, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := schema.Properties[name]; ok {
+			// fields of the outer struct win
+			continue
+		}
+		if strings.Contains(","+options+",", ",string,") {
+			schema.Properties[name] = &Schema{Type: "string"}
+		} else {
+			schema.Properties[name] = g.schemaOf(field.Type)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+type testBase struct {
+	Id        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type testNode struct {
+	testBase
+	Name     string                 `json:"name"`
+	Secret   string                 `json:"-"`
+	Count    int64                  `json:"count,string"`
+	Raw      json.RawMessage        `json:"raw"`
+	Data     []byte                 `json:"data"`
+	Labels   map[string]string      `json:"labels"`
+	Children []*testNode            `json:"children"`
+	Extra    interface{}            `json:"extra"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+	NoTag    bool
+	hidden   string
+}
+
+type testError struct {
+	Message string `json:"message"`
+}
+
+func TestBuilder(t *testing.T) {
+	builder := NewBuilder("test", "v1", &testError{})
+	builder.AddRoute(&Route{
+		Method: "GET",
+		Path:   "/plugins/test/connections/:connectionId/proxy/*path",
+		Tag:    "plugins/test",
+		Role:   "operator",
+		Doc: &plugin.ApiResourceDoc{
+			Summary:  "get nodes",
+			Query:    map[string]string{"page": "page number"},
+			Response: []*testNode{},
+		},
+	})
+	builder.AddRoute(&Route{Method: "POST", Path: "/nodes", Tag: "nodes", Role: "admin", Doc: &plugin.ApiResourceDoc{Request: &testNode{}}})
+	builder.AddRoute(&Route{Method: "GET", Path: "/ping", Tag: "ping", Public: true})
+	doc := builder.Build()
+
+	op := doc.Paths["/plugins/test/connections/{connectionId}/proxy/{path}"]["get"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "get_plugins_test_connections_connectionId_proxy_path", op.OperationId)
+		assert.Equal(t, []string{"plugins/test"}, op.Tags)
+		assert.Equal(t, "operator", op.Role)
+		assert.Nil(t, op.Security)
+		assert.Len(t, op.Parameters, 3)
+		assert.Equal(t, "connectionId", op.Parameters[0].Name)
+		assert.True(t, op.Parameters[0].Required)
+		assert.Equal(t, "query", op.Parameters[2].In)
+		schema := op.Responses["200"].Content["application/json"].Schema
+		assert.Equal(t, "array", schema.Type)
+		assert.Equal(t, "#/components/schemas/server.api.openapi.testNode", schema.Items.Ref)
+		assert.Equal(t, "#/components/schemas/server.api.openapi.testError", op.Responses["default"].Content["application/json"].Schema.Ref)
+	}
+	post := doc.Paths["/nodes"]["post"]
+	if assert.NotNil(t, post) {
+		assert.Equal(t, "#/components/schemas/server.api.openapi.testNode", post.RequestBody.Content["application/json"].Schema.Ref)
+		assert.Nil(t, post.Responses["200"].Content)
+	}
+	ping := doc.Paths["/ping"]["get"]
+	if assert.NotNil(t, ping) && assert.NotNil(t, ping.Security) {
+		assert.Empty(t, *ping.Security)
+		assert.Empty(t, ping.Role)
+	}
+	assert.Len(t, doc.Tags, 3)
+	assert.Equal(t, "nodes", doc.Tags[0].Name)
+
+	node := doc.Components.Schemas["server.api.openapi.testNode"]
+	if assert.NotNil(t, node) {
+		assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, node.Properties["id"])
+		assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, node.Properties["createdAt"])
+		assert.Equal(t, &Schema{Type: "string"}, node.Properties["name"])
+		assert.Equal(t, &Schema{Type: "string"}, node.Properties["count"])
+		assert.Equal(t, &Schema{}, node.Properties["raw"])
+		assert.Equal(t, &Schema{Type: "string", Format: "byte"}, node.Properties["data"])
+		assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{Type: "string"}}, node.Properties["labels"])
+		assert.Equal(t, "#/components/schemas/server.api.openapi.testNode", node.Properties["children"].Items.Ref)
+		assert.Equal(t, &Schema{}, node.Properties["extra"])
+		assert.Equal(t, &Schema{Type: "boolean"}, node.Properties["NoTag"])
+		assert.NotContains(t, node.Properties, "Secret")
+		assert.NotContains(t, node.Properties, "hidden")
+		assert.NotContains(t, node.Properties, "testBase")
+	}
+
+	_, err := json.Marshal(doc)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,74 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var pathParamPattern = regexp.MustCompile(`[:*][^/]+`)
+
+// TestCoreApiDocs makes sure every route of RegisterRouter is documented with the role its middleware requires
+func TestCoreApiDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	// a principal without any role gets rejected by requireRole, which tells the role it requires
+	r.Use(func(c *gin.Context) {
+		shared.SetPrincipal(c, &services.Principal{Name: "nobody"})
+	})
+	RegisterRouter(r)
+	for _, route := range append(r.Routes(), suffixRoutes...) {
+		if strings.HasPrefix(route.Path, "/plugins/") {
+			continue
+		}
+		key := fmt.Sprintf("%s %s", route.Method, route.Path)
+		doc, ok := coreApiDocs[key]
+		if !assert.True(t, ok, "%s is not documented in coreApiDocs", key) {
+			continue
+		}
+		if publicRoutes[key] {
+			assert.Equal(t, "", doc.role, key)
+			continue
+		}
+		path := pathParamPattern.ReplaceAllStringFunc(route.Path, func(param string) string {
+			if strings.HasPrefix(param, "*") {
+				return "sample"
+			}
+			return "1"
+		})
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest(route.Method, path, nil))
+		if !assert.Equal(t, http.StatusForbidden, res.Code, key) {
+			continue
+		}
+		body := &shared.ApiBody{}
+		assert.Nil(t, json.Unmarshal(res.Body.Bytes(), body))
+		assert.True(t, strings.HasPrefix(body.Message, fmt.Sprintf("%s role is required", doc.role)), "%s requires %s", key, body.Message)
+	}
+}
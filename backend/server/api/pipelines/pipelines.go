@@ -59,12 +59,12 @@ func Post(c *gin.Context) {
 }
 
 // @Summary Get list of pipelines
-// @Description GET /pipelines?status=TASK_RUNNING&pending=1&label=search_text&page=1&pagesize=10
+// @Description GET /pipelines?status=TASK_RUNNING&pending=1&label=search_text&page=1&pageSize=10
 // @Tags framework/pipelines
 // @Param status query string false "status"
 // @Param pending query int false "pending"
 // @Param page query int false "page"
-// @Param pagesize query int false "pagesize"
+// @Param pageSize query int false "pageSize"
 // @Param blueprint_id query int false "blueprint_id"
 // @Param label query string false "label"
 // @Success 200  {object} shared.ResponsePipelines
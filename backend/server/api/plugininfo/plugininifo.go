@@ -75,8 +75,12 @@ type PluginMetric struct {
 }
 
 type PluginMeta struct {
-	Plugin string       `json:"plugin"`
-	Metric PluginMetric `json:"metric"`
+	Plugin       string       `json:"plugin"`
+	Metric       PluginMetric `json:"metric"`
+	Status       string       `json:"status"`
+	Error        string       `json:"error,omitempty"`
+	ApiVersion   string       `json:"apiVersion,omitempty"`
+	Dependencies []string     `json:"dependencies,omitempty"`
 }
 
 type PluginMetas []PluginMeta
@@ -195,6 +199,7 @@ func Get(c *gin.Context) {
 
 // @Get name list of plugins
 // @Description GET /plugins
+// @Description plugins failed to load or disabled by DISABLED_PLUGINS are listed with their status as well
 // @Description RETURN SAMPLE
 // @Tags framework/plugins
 // @Success 200  {object} PluginMetas
@@ -205,6 +210,11 @@ func GetPluginMetas(c *gin.Context) {
 	err := plugin.TraversalPlugin(func(name string, p plugin.PluginMeta) errors.Error {
 		pluginMeta := PluginMeta{
 			Plugin: name,
+			Status: plugin.PLUGIN_STATUS_LOADED,
+		}
+		if status := plugin.GetPluginLoadStatus(name); status != nil {
+			pluginMeta.ApiVersion = status.ApiVersion
+			pluginMeta.Dependencies = status.Dependencies
 		}
 
 		// if this plugin has the plugin task info
@@ -234,6 +244,20 @@ func GetPluginMetas(c *gin.Context) {
 
 	if err != nil {
 		shared.ApiOutputError(c, errors.Default.Wrap(err, "error getting plugin info of plugins"))
+		return
+	}
+
+	for _, status := range plugin.AllPluginLoadStatuses() {
+		if status.Status == plugin.PLUGIN_STATUS_LOADED {
+			continue
+		}
+		metas = append(metas, PluginMeta{
+			Plugin:       status.Name,
+			Status:       status.Status,
+			Error:        status.Error,
+			ApiVersion:   status.ApiVersion,
+			Dependencies: status.Dependencies,
+		})
 	}
 
 	shared.ApiOutputSuccess(c, metas, http.StatusOK)
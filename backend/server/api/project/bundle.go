@@ -0,0 +1,136 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package project
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Export a project
+// @Description GET /projects/:projectName/export?domainData=true
+// @Description export the project with its blueprint, connections without secrets, scopes and transformation rules
+// @Description into a bundle to be imported into another instance, along with the domain layer data if asked to
+// @Tags framework/projects
+// @Param projectName path string true "project name"
+// @Param domainData query bool false "export the domain layer data of the project as well"
+// @Success 200  {object} services.ProjectBundle
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /projects/:projectName/export [get]
+func GetProjectExport(c *gin.Context) {
+	exportProject(c, strings.TrimSuffix(c.Param("projectName")[1:], "/export"))
+}
+
+// @Summary Export a project
+// @Description GET /project-bundles/:projectName?domainData=true
+// @Description same as GET /projects/:projectName/export, which is shadowed for projects whose names end with /export
+// @Tags framework/projects
+// @Param projectName path string true "project name"
+// @Param domainData query bool false "export the domain layer data of the project as well"
+// @Success 200  {object} services.ProjectBundle
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 404  {object} shared.ApiBody "Not Found"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /project-bundles/:projectName [get]
+func GetProjectBundle(c *gin.Context) {
+	exportProject(c, c.Param("projectName")[1:])
+}
+
+func exportProject(c *gin.Context, projectName string) {
+	withDomainData, err := boolQuery(c, "domainData")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	// the bundle is streamed into the response
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	err = services.ExportProject(projectName, withDomainData, c.Writer)
+	if err != nil {
+		err = errors.Default.Wrap(err, "error exporting project")
+		if c.Writer.Written() {
+			// the status is sent already, the incomplete bundle is cut short
+			shared.ApiOutputAbort(c, err)
+		} else {
+			shared.ApiOutputError(c, err)
+		}
+	}
+}
+
+// @Summary Import a project
+// @Description POST /projects/import?dryRun=true&overwrite=false
+// @Description create or update the project and the resources in the bundle exported by another instance, connections
+// @Description are matched by names and their ids in the domain layer data are remapped. Secrets of the connections
+// @Description not existing yet are given by `plugin/name`. Existing resources differing from the bundle are reported
+// @Description as conflicts and nothing is imported unless overwrite is true.
+// @Tags framework/projects
+// @Accept application/json
+// @Param input body services.ProjectImportInput true "json"
+// @Param dryRun query bool false "compute the changes and conflicts without importing"
+// @Param overwrite query bool false "overwrite the conflicting resources"
+// @Success 200  {object} services.ProjectImportResult
+// @Failure 400  {object} shared.ApiBody "Bad Request"
+// @Failure 409  {object} shared.ApiBody "Conflict"
+// @Failure 500  {object} shared.ApiBody "Internal Error"
+// @Router /projects/import [post]
+func PostProjectImport(c *gin.Context) {
+	dryRun, err := boolQuery(c, "dryRun")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	overwrite, err := boolQuery(c, "overwrite")
+	if err != nil {
+		shared.ApiOutputError(c, err)
+		return
+	}
+	input := &services.ProjectImportInput{}
+	// numbers of the domain data are kept as they are
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if e := decoder.Decode(input); e != nil {
+		shared.ApiOutputError(c, errors.BadInput.Wrap(e, shared.BadRequestBody))
+		return
+	}
+	result, err := services.ImportProject(input, dryRun, overwrite, shared.GetUser(c))
+	if err != nil {
+		shared.ApiOutputError(c, errors.Default.Wrap(err, "error importing project"))
+		return
+	}
+	shared.ApiOutputSuccess(c, result, http.StatusOK)
+}
+
+func boolQuery(c *gin.Context, name string) (bool, errors.Error) {
+	v := c.Query(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.BadInput.Wrap(err, "invalid "+name)
+	}
+	return b, nil
+}
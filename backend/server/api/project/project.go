@@ -125,7 +125,7 @@ func PatchProject(c *gin.Context) {
 		return
 	}
 
-	projectOutput, err := services.PatchProject(projectName, body)
+	projectOutput, err := services.PatchProject(projectName, body, shared.GetUser(c))
 	if err != nil {
 		shared.ApiOutputError(c, errors.BadInput.Wrap(err, "error patch project"))
 		return
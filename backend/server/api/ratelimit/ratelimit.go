@@ -0,0 +1,37 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"net/http"
+
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/apache/incubator-devlake/server/api/shared"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Get rate limit budgets
+// @Description GET /rate-limits
+// @Description list the rate limit budgets shared by api clients of the same connection within the server process
+// @Tags framework/ratelimits
+// @Success 200  {object} []helper.RateLimitBudgetState
+// @Failure 500  {string} errcode.Error "Internal Error"
+// @Router /rate-limits [get]
+func Get(c *gin.Context) {
+	shared.ApiOutputSuccess(c, helper.GetRateLimitBudgetStates(), http.StatusOK)
+}
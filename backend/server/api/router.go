@@ -20,13 +20,19 @@ package api
 import (
 	"fmt"
 	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/server/api/auditlog"
+	"github.com/apache/incubator-devlake/server/api/auth"
 	"github.com/apache/incubator-devlake/server/api/blueprints"
+	"github.com/apache/incubator-devlake/server/api/configapply"
 	"github.com/apache/incubator-devlake/server/api/domainlayer"
+	"github.com/apache/incubator-devlake/server/api/encryption"
+	"github.com/apache/incubator-devlake/server/api/migration"
 	"github.com/apache/incubator-devlake/server/api/ping"
 	"github.com/apache/incubator-devlake/server/api/pipelines"
 	"github.com/apache/incubator-devlake/server/api/plugininfo"
 	"github.com/apache/incubator-devlake/server/api/project"
 	"github.com/apache/incubator-devlake/server/api/push"
+	"github.com/apache/incubator-devlake/server/api/ratelimit"
 	"github.com/apache/incubator-devlake/server/api/shared"
 	"github.com/apache/incubator-devlake/server/api/task"
 	"github.com/apache/incubator-devlake/server/api/version"
@@ -38,39 +44,63 @@ import (
 )
 
 func RegisterRouter(r *gin.Engine) {
-	r.GET("/pipelines", pipelines.Index)
-	r.POST("/pipelines", pipelines.Post)
-	r.GET("/pipelines/:pipelineId", pipelines.Get)
-	r.PATCH("/blueprints/:blueprintId", blueprints.Patch)
-	r.POST("/blueprints/:blueprintId/trigger", blueprints.Trigger)
+	viewer := requireRole(services.ROLE_VIEWER)
+	operator := requireRole(services.ROLE_OPERATOR)
+	admin := requireRole(services.ROLE_ADMIN)
+
+	r.GET("/pipelines", viewer, pipelines.Index)
+	r.POST("/pipelines", operator, pipelines.Post)
+	r.GET("/pipelines/:pipelineId", viewer, pipelines.Get)
+	r.PATCH("/blueprints/:blueprintId", admin, blueprints.Patch)
+	r.POST("/blueprints/:blueprintId/trigger", operator, blueprints.Trigger)
 	// r.DELETE("/blueprints/:blueprintId", blueprints.Delete)
 
-	r.GET("/blueprints", blueprints.Index)
-	r.POST("/blueprints", blueprints.Post)
-	r.GET("/blueprints/:blueprintId", blueprints.Get)
-	r.GET("/blueprints/:blueprintId/pipelines", blueprints.GetBlueprintPipelines)
-	r.DELETE("/pipelines/:pipelineId", pipelines.Delete)
-	r.GET("/pipelines/:pipelineId/tasks", task.GetTaskByPipeline)
-	r.POST("/pipelines/:pipelineId/rerun", pipelines.PostRerun)
-	r.POST("/tasks/:taskId/rerun", task.PostRerun)
+	r.GET("/blueprints", viewer, blueprints.Index)
+	r.POST("/blueprints", admin, blueprints.Post)
+	r.GET("/blueprints/:blueprintId", viewer, blueprints.Get)
+	r.GET("/blueprints/:blueprintId/pipelines", viewer, blueprints.GetBlueprintPipelines)
+	r.DELETE("/pipelines/:pipelineId", operator, pipelines.Delete)
+	r.GET("/pipelines/:pipelineId/tasks", viewer, task.GetTaskByPipeline)
+	r.POST("/pipelines/:pipelineId/rerun", operator, pipelines.PostRerun)
+	r.POST("/tasks/:taskId/rerun", operator, task.PostRerun)
 
-	r.GET("/pipelines/:pipelineId/logging.tar.gz", pipelines.DownloadLogs)
+	r.GET("/pipelines/:pipelineId/logging.tar.gz", viewer, pipelines.DownloadLogs)
 
 	r.GET("/ping", ping.Get)
 	r.GET("/version", version.Get)
-	r.POST("/push/:tableName", push.Post)
-	r.GET("/domainlayer/repos", domainlayer.ReposIndex)
+	registerOpenApi(r)
+	r.GET("/migrations/online", viewer, migration.GetOnline)
+	r.GET("/rate-limits", viewer, ratelimit.Get)
+	// pushed records are written into the tables directly
+	r.POST("/push/:tableName", admin, push.Post)
+	r.GET("/domainlayer/repos", viewer, domainlayer.ReposIndex)
+
+	// auth api
+	r.GET("/auth/me", viewer, auth.GetMe)
+	r.GET("/api-keys", admin, auth.GetApiKeys)
+	r.POST("/api-keys", admin, auth.PostApiKey)
+	r.DELETE("/api-keys/:apiKeyId", admin, auth.DeleteApiKey)
+	r.GET("/audit-logs", admin, auditlog.Index)
+	r.GET("/encryption/keys", admin, encryption.GetKeys)
+	r.POST("/encryption/rotate", admin, encryption.PostRotate)
 
 	// plugin api
-	r.GET("/plugininfo", plugininfo.Get)
-	r.GET("/plugins", plugininfo.GetPluginMetas)
+	r.GET("/plugininfo", viewer, plugininfo.Get)
+	r.GET("/plugins", viewer, plugininfo.GetPluginMetas)
 
 	// project api
-	r.GET("/projects/*projectName", project.GetProject)
-	r.PATCH("/projects/*projectName", project.PatchProject)
+	// project names might contain slashes, so the export is served within the catch-all route, a project named
+	// `<name>/export` is shadowed by the export of `<name>`, whose bundle is served at /project-bundles as well
+	r.GET("/projects/*projectName", routeSuffix("/export", operator, project.GetProjectExport), viewer, project.GetProject)
+	r.PATCH("/projects/*projectName", admin, project.PatchProject)
 	//r.DELETE("/projects/:projectName", project.DeleteProject)
-	r.POST("/projects", project.PostProject)
-	r.GET("/projects", project.GetProjects)
+	r.POST("/projects", admin, project.PostProject)
+	r.GET("/projects", viewer, project.GetProjects)
+	r.POST("/projects/import", admin, project.PostProjectImport)
+	r.GET("/project-bundles/*projectName", operator, project.GetProjectBundle)
+
+	// configuration as code
+	r.POST("/config/apply", admin, configapply.Post)
 
 	// mount all api resources for all plugins
 	pluginsApiResources, err := services.GetPluginsApiResources()
@@ -83,15 +113,18 @@ func RegisterRouter(r *gin.Engine) {
 				r.Handle(
 					method,
 					fmt.Sprintf("/plugins/%s/%s", pluginName, resourcePath),
-					handlePluginCall(pluginName, h),
+					handlePluginCall(pluginName, pluginResourceRole(method, resourcePath), h),
 				)
 			}
 		}
 	}
 }
 
-func handlePluginCall(pluginName string, handler plugin.ApiResourceHandler) func(c *gin.Context) {
+func handlePluginCall(pluginName string, role string, handler plugin.ApiResourceHandler) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		if !authorize(c, role) {
+			return
+		}
 		var err error
 		input := &plugin.ApiResourceInput{}
 		if len(c.Params) > 0 {
@@ -101,6 +134,7 @@ func handlePluginCall(pluginName string, handler plugin.ApiResourceHandler) func
 			}
 		}
 		input.Query = c.Request.URL.Query()
+		input.User = shared.GetUser(c)
 		if c.Request.Body != nil {
 			if strings.HasPrefix(c.Request.Header.Get("Content-Type"), "multipart/form-data;") {
 				input.Request = c.Request
@@ -134,3 +168,19 @@ func handlePluginCall(pluginName string, handler plugin.ApiResourceHandler) func
 		}
 	}
 }
+
+// routeSuffix serves the requests of paths ending with the suffix by the handlers instead of the rest of the chain
+func routeSuffix(suffix string, handlers ...gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.HasSuffix(c.Request.URL.Path, suffix) {
+			return
+		}
+		for _, handler := range handlers {
+			handler(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+		c.Abort()
+	}
+}
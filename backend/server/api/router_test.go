@@ -0,0 +1,48 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteSuffix(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/projects/*projectName", routeSuffix("/export", func(c *gin.Context) {
+		c.String(http.StatusOK, "export %s", c.Param("projectName"))
+	}), func(c *gin.Context) {
+		c.String(http.StatusOK, "project %s", c.Param("projectName"))
+	})
+
+	for path, expected := range map[string]string{
+		"/projects/p":          "project /p",
+		"/projects/a/b":        "project /a/b",
+		"/projects/p/export":   "export /p/export",
+		"/projects/a/b/export": "export /a/b/export",
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, w.Body.String(), path)
+	}
+}
@@ -0,0 +1,48 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shared
+
+import (
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/server/services"
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// SetPrincipal attaches the authenticated caller to the request
+func SetPrincipal(c *gin.Context, principal *services.Principal) {
+	c.Set(principalKey, principal)
+}
+
+// GetPrincipal returns the authenticated caller of the request, nil if not authenticated
+func GetPrincipal(c *gin.Context) *services.Principal {
+	if v, ok := c.Get(principalKey); ok {
+		return v.(*services.Principal)
+	}
+	return nil
+}
+
+// GetUser returns the authenticated caller as the user changes are made by
+func GetUser(c *gin.Context) *common.User {
+	principal := GetPrincipal(c)
+	if principal == nil {
+		return nil
+	}
+	return &common.User{Name: principal.Name}
+}
@@ -0,0 +1,68 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/services"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func newEncryptionCmd() *cobra.Command {
+	encryptionCmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Inspect or rotate keys used to encrypt sensitive data",
+	}
+
+	encryptionCmd.AddCommand(&cobra.Command{
+		Use:   "keys",
+		Short: "List ids of configured encryption keys",
+		Run: func(_ *cobra.Command, _ []string) {
+			keys := services.GetEncryptionKeys()
+			fmt.Printf("primary: %s\n", keys.PrimaryKeyId)
+			fmt.Printf("keys: %s\n", strings.Join(keys.KeyIds, ", "))
+		},
+	})
+
+	var batchSize int
+	rotateCmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Re-encrypt all encrypted columns with the primary key",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			services.InitMigration()
+			if services.GetMigrator().HasPendingScripts() {
+				return errors.BadInput.New("there are pending migration scripts, please apply them before rotating")
+			}
+			results, err := services.RotateEncryptionKey(batchSize)
+			for _, result := range results {
+				fmt.Printf("%s (%s): re-encrypted %d of %d rows\n", result.Table, strings.Join(result.Columns, ", "), result.Reencrypted, result.Scanned)
+			}
+			if err != nil {
+				return errors.Default.Wrap(err, "error rotating encryption key")
+			}
+			return nil
+		},
+	}
+	rotateCmd.Flags().IntVar(&batchSize, "batch-size", services.DEFAULT_ROTATION_BATCH_SIZE, "number of rows re-encrypted per transaction")
+	encryptionCmd.AddCommand(rotateCmd)
+
+	return encryptionCmd
+}
@@ -22,12 +22,14 @@ import (
 	"github.com/apache/incubator-devlake/core/plugin"
 	_ "github.com/apache/incubator-devlake/core/version"
 	"github.com/apache/incubator-devlake/server/api"
+	"github.com/spf13/cobra"
 )
 
 func main() {
 	v := config.GetConfig()
 	encKey := v.GetString(plugin.EncodeKeyEnvStr)
-	if encKey == "" {
+	// generate the key only if none was provided by any means
+	if encKey == "" && v.GetString(plugin.EncodeKeysEnvStr) == "" && v.GetString(plugin.EncodeKeysFileEnvStr) == "" {
 		// Randomly generate a bunch of encryption keys and set them to config
 		encKey = plugin.RandomEncKey()
 		v.Set(plugin.EncodeKeyEnvStr, encKey)
@@ -36,5 +38,16 @@ func main() {
 			panic(err)
 		}
 	}
-	api.CreateApiService()
+	cmd := &cobra.Command{
+		Use:   "lake",
+		Short: "Run the DevLake server",
+		Run: func(_ *cobra.Command, _ []string) {
+			api.CreateApiService()
+		},
+	}
+	cmd.AddCommand(newMigrateCmd())
+	cmd.AddCommand(newEncryptionCmd())
+	if err := cmd.Execute(); err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,105 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/server/services"
+	"sort"
+
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Inspect or revert database migrations",
+	}
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "List executed and pending migration scripts per plugin",
+		Run: func(_ *cobra.Command, _ []string) {
+			services.InitMigration()
+			scripts := services.GetMigrationScripts()
+			for _, name := range sortedKeys(scripts) {
+				fmt.Printf("%s:\n", name)
+				for _, script := range scripts[name] {
+					status := "pending"
+					if script.Executed {
+						status = "executed at " + script.ExecutedAt.Format("2006-01-02 15:04:05")
+					}
+					fmt.Printf("  %d %s (%s)\n", script.Version, script.Name, status)
+				}
+			}
+		},
+	})
+
+	migrateCmd.AddCommand(&cobra.Command{
+		Use:   "dry-run",
+		Short: "Print SQL statements of pending migration scripts without applying them",
+		Run: func(_ *cobra.Command, _ []string) {
+			services.InitMigration()
+			results, err := services.DryRunMigration()
+			if err != nil {
+				panic(err)
+			}
+			for _, name := range sortedKeys(results) {
+				for _, result := range results[name] {
+					fmt.Printf("-- %s: %d %s\n", name, result.Version, result.Name)
+					for _, statement := range result.Statements {
+						fmt.Printf("%s;\n", statement)
+					}
+					if result.Error != "" {
+						fmt.Printf("-- dry run failed: %s\n", result.Error)
+					}
+				}
+			}
+		},
+	})
+
+	var version uint64
+	rollbackCmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Revert executed migration scripts newer than the specified version",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			services.InitMigration()
+			err := services.RollbackMigration(version)
+			if err != nil {
+				return errors.Default.Wrap(err, "error rolling back migration")
+			}
+			fmt.Printf("rolled back to version %d\n", version)
+			return nil
+		},
+	}
+	rollbackCmd.Flags().Uint64Var(&version, "to", 0, "revert scripts newer than this version, e.g. 20230101000001")
+	_ = rollbackCmd.MarkFlagRequired("to")
+	migrateCmd.AddCommand(rollbackCmd)
+
+	return migrateCmd
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,76 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// AuditLogQuery is a query for GetAuditLogs
+type AuditLogQuery struct {
+	Pagination
+	Actor      string     `form:"actor"`
+	Resource   string     `form:"resource"`
+	ResourceId string     `form:"resourceId"`
+	Action     string     `form:"action"`
+	Since      *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until      *time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// GetAuditLogs returns paginated audit logs, the latest first
+func GetAuditLogs(query *AuditLogQuery) ([]*models.AuditLog, int64, errors.Error) {
+	clauses := []dal.Clause{dal.From(&models.AuditLog{})}
+	if query.Actor != "" {
+		clauses = append(clauses, dal.Where("actor = ?", query.Actor))
+	}
+	if query.Resource != "" {
+		clauses = append(clauses, dal.Where("resource = ?", query.Resource))
+	}
+	if query.ResourceId != "" {
+		clauses = append(clauses, dal.Where("resource_id = ?", query.ResourceId))
+	}
+	if query.Action != "" {
+		clauses = append(clauses, dal.Where("action = ?", query.Action))
+	}
+	if query.Since != nil {
+		clauses = append(clauses, dal.Where("created_at >= ?", *query.Since))
+	}
+	if query.Until != nil {
+		clauses = append(clauses, dal.Where("created_at < ?", *query.Until))
+	}
+
+	count, err := db.Count(clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error counting audit logs")
+	}
+	clauses = append(clauses,
+		dal.Orderby("id DESC"),
+		dal.Offset(query.GetSkip()),
+		dal.Limit(query.GetPageSize()),
+	)
+	auditLogs := make([]*models.AuditLog, 0)
+	err = db.All(&auditLogs, clauses...)
+	if err != nil {
+		return nil, 0, errors.Default.Wrap(err, "error getting audit logs")
+	}
+	return auditLogs, count, nil
+}
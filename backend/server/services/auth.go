@@ -0,0 +1,193 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+)
+
+// Api requests are authenticated by api keys (X-API-Key header or bearer token prefixed with `dl_`) or
+// OIDC id tokens (bearer token) when AUTH_ENABLED=true. Every route requires one of the roles below,
+// a role includes all permissions of the roles before it.
+const (
+	ROLE_VIEWER   = "viewer"
+	ROLE_OPERATOR = "operator"
+	ROLE_ADMIN    = "admin"
+)
+
+const (
+	AUTH_METHOD_NONE    = "none"
+	AUTH_METHOD_API_KEY = "apikey"
+	AUTH_METHOD_OIDC    = "oidc"
+)
+
+const apiKeyPrefix = "dl_"
+
+var roleLevels = map[string]int{
+	ROLE_VIEWER:   1,
+	ROLE_OPERATOR: 2,
+	ROLE_ADMIN:    3,
+}
+
+// Principal is the authenticated caller of an api request
+type Principal struct {
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	AuthMethod string `json:"authMethod"`
+}
+
+// HasRole returns true if the principal is granted the role
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && roleLevels[p.Role] >= roleLevels[role]
+}
+
+// anonymousPrincipal is used for all requests when authentication is disabled
+var anonymousPrincipal = &Principal{Name: "anonymous", Role: ROLE_ADMIN, AuthMethod: AUTH_METHOD_NONE}
+
+// AuthEnabled returns true if api requests have to be authenticated
+func AuthEnabled() bool {
+	return cfg.GetBool("AUTH_ENABLED")
+}
+
+// Authenticate identifies the caller by the api key or the bearer token, one of which must be provided
+// when authentication is enabled
+func Authenticate(apiKey string, bearerToken string) (*Principal, errors.Error) {
+	if !AuthEnabled() {
+		return anonymousPrincipal, nil
+	}
+	if apiKey == "" && strings.HasPrefix(bearerToken, apiKeyPrefix) {
+		apiKey, bearerToken = bearerToken, ""
+	}
+	if apiKey != "" {
+		return authenticateApiKey(apiKey)
+	}
+	if bearerToken != "" {
+		return authenticateOidcToken(bearerToken)
+	}
+	return nil, errors.Unauthorized.New("missing api key or bearer token")
+}
+
+func authenticateApiKey(apiKey string) (*Principal, errors.Error) {
+	// the bootstrap key configured by the environment, to create the first api keys with
+	adminKey := cfg.GetString("AUTH_ADMIN_API_KEY")
+	if adminKey != "" && subtle.ConstantTimeCompare([]byte(adminKey), []byte(apiKey)) == 1 {
+		return &Principal{Name: "admin", Role: ROLE_ADMIN, AuthMethod: AUTH_METHOD_API_KEY}, nil
+	}
+	key := &models.ApiKey{}
+	err := db.First(key, dal.Where("key_hash = ?", hashApiKey(apiKey)))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return nil, errors.Unauthorized.New("invalid api key")
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.ExpiredAt != nil && key.ExpiredAt.Before(now) {
+		return nil, errors.Unauthorized.New("api key expired")
+	}
+	// recording the usage is not critical, do it without blocking the request
+	go func() {
+		err := db.UpdateColumn(&models.ApiKey{}, "last_used_at", now, dal.Where("id = ?", key.ID))
+		if err != nil {
+			logger.Warn(err, "failed to update last_used_at of api key %d", key.ID)
+		}
+	}()
+	return &Principal{Name: key.Name, Role: key.Role, AuthMethod: AUTH_METHOD_API_KEY}, nil
+}
+
+func hashApiKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateApiKey generates a new api key, the key itself is returned only once and never stored
+func CreateApiKey(apiKey *models.ApiKey, creator string) (string, errors.Error) {
+	apiKey.ID = 0
+	apiKey.Creator = creator
+	if err := VerifyStruct(apiKey); err != nil {
+		return "", err
+	}
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", errors.Convert(err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(secret)
+	apiKey.KeyHash = hashApiKey(key)
+	apiKey.Prefix = key[:len(apiKeyPrefix)+6]
+	err := db.Create(apiKey)
+	if err != nil {
+		return "", errors.Default.Wrap(err, "error creating api key")
+	}
+	return key, nil
+}
+
+// GetApiKeys returns all api keys
+func GetApiKeys() ([]*models.ApiKey, errors.Error) {
+	apiKeys := make([]*models.ApiKey, 0)
+	err := db.All(&apiKeys, dal.Orderby("id DESC"))
+	if err != nil {
+		return nil, errors.Default.Wrap(err, "error getting api keys")
+	}
+	return apiKeys, nil
+}
+
+// DeleteApiKey revokes the api key
+func DeleteApiKey(id uint64) errors.Error {
+	apiKey := &models.ApiKey{}
+	err := db.First(apiKey, dal.Where("id = ?", id))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return errors.NotFound.New(fmt.Sprintf("api key %d not found", id))
+		}
+		return err
+	}
+	return db.Delete(apiKey)
+}
+
+// RecordAudit saves the api call to the audit log, failures are logged instead of failing the request
+func RecordAudit(principal *Principal, method string, path string, status int, clientIp string) {
+	auditLog := &models.AuditLog{
+		Method:    method,
+		Path:      path,
+		Status:    status,
+		ClientIp:  clientIp,
+		CreatedAt: time.Now(),
+	}
+	if principal != nil {
+		auditLog.Actor = principal.Name
+		auditLog.Role = principal.Role
+		auditLog.AuthMethod = principal.AuthMethod
+	}
+	if len(auditLog.Path) > 255 {
+		auditLog.Path = auditLog.Path[:255]
+	}
+	err := db.Create(auditLog)
+	if err != nil {
+		logger.Error(err, "failed to record audit log for %s %s", method, path)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
 	"github.com/apache/incubator-devlake/core/plugin"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 	"github.com/apache/incubator-devlake/impls/logruslog"
@@ -207,12 +208,13 @@ func saveBlueprint(blueprint *models.Blueprint) (*models.Blueprint, errors.Error
 }
 
 // PatchBlueprint FIXME ...
-func PatchBlueprint(id uint64, body map[string]interface{}) (*models.Blueprint, errors.Error) {
+func PatchBlueprint(id uint64, body map[string]interface{}, user *common.User) (*models.Blueprint, errors.Error) {
 	// load record from db
 	blueprint, err := GetBlueprint(id)
 	if err != nil {
 		return nil, err
 	}
+	before := helper.TakeAuditSnapshot(parseDbBlueprint(blueprint))
 
 	originMode := blueprint.Mode
 	err = helper.DecodeMapStruct(body, blueprint)
@@ -228,6 +230,10 @@ func PatchBlueprint(id uint64, body map[string]interface{}) (*models.Blueprint,
 	if err != nil {
 		return nil, err
 	}
+	helper.RecordAuditLog(
+		basicRes, user, models.DbBlueprint{}.TableName(), id, helper.AUDIT_ACTION_UPDATE,
+		before, helper.TakeAuditSnapshot(parseDbBlueprint(blueprint)),
+	)
 
 	return blueprint, nil
 }
@@ -255,6 +261,10 @@ func ReloadBlueprints(c *cron.Cron) errors.Error {
 			return err
 		}
 		if _, err := c.AddFunc(blueprint.CronConfig, func() {
+			// cron jobs are triggered by the leader only when multiple instances share the same database
+			if !isLeader() {
+				return
+			}
 			pipeline, err := createPipelineByBlueprint(blueprint)
 			if err != nil {
 				blueprintLog.Error(err, "run cron job failed")
@@ -290,6 +300,7 @@ func createPipelineByBlueprint(blueprint *models.Blueprint) (*models.Pipeline, e
 	newPipeline.BlueprintId = blueprint.ID
 	newPipeline.Labels = blueprint.Labels
 	newPipeline.SkipOnFail = blueprint.SkipOnFail
+	newPipeline.Priority = blueprint.Priority
 	pipeline, err := CreatePipeline(&newPipeline)
 	// Return all created tasks to the User
 	if err != nil {
@@ -19,7 +19,6 @@ package services
 
 import (
 	"fmt"
-	"github.com/apache/incubator-devlake/core/config"
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
@@ -148,6 +147,7 @@ func parseBlueprint(dbBlueprint *models.DbBlueprint) *models.Blueprint {
 		CronConfig:  dbBlueprint.CronConfig,
 		IsManual:    dbBlueprint.IsManual,
 		SkipOnFail:  dbBlueprint.SkipOnFail,
+		Priority:    dbBlueprint.Priority,
 		Settings:    []byte(dbBlueprint.Settings),
 		Model:       dbBlueprint.Model,
 		Labels:      labelList,
@@ -169,6 +169,7 @@ func parseDbBlueprint(blueprint *models.Blueprint) *models.DbBlueprint {
 		CronConfig:  blueprint.CronConfig,
 		IsManual:    blueprint.IsManual,
 		SkipOnFail:  blueprint.SkipOnFail,
+		Priority:    blueprint.Priority,
 		Settings:    string(blueprint.Settings),
 		Model:       blueprint.Model,
 	}
@@ -185,13 +186,13 @@ func parseDbBlueprint(blueprint *models.Blueprint) *models.DbBlueprint {
 
 // encryptDbBlueprint
 func encryptDbBlueprint(dbBlueprint *models.DbBlueprint) (*models.DbBlueprint, errors.Error) {
-	encKey := config.GetConfig().GetString(plugin.EncodeKeyEnvStr)
-	planEncrypt, err := plugin.Encrypt(encKey, dbBlueprint.Plan)
+	keyring := plugin.GetEncryptionKeyring()
+	planEncrypt, err := keyring.Encrypt(dbBlueprint.Plan)
 	if err != nil {
 		return nil, err
 	}
 	dbBlueprint.Plan = planEncrypt
-	settingsEncrypt, err := plugin.Encrypt(encKey, dbBlueprint.Settings)
+	settingsEncrypt, err := keyring.Encrypt(dbBlueprint.Settings)
 	dbBlueprint.Settings = settingsEncrypt
 	if err != nil {
 		return nil, err
@@ -201,13 +202,13 @@ func encryptDbBlueprint(dbBlueprint *models.DbBlueprint) (*models.DbBlueprint, e
 
 // decryptDbBlueprint
 func decryptDbBlueprint(dbBlueprint *models.DbBlueprint) (*models.DbBlueprint, errors.Error) {
-	encKey := config.GetConfig().GetString(plugin.EncodeKeyEnvStr)
-	plan, err := plugin.Decrypt(encKey, dbBlueprint.Plan)
+	keyring := plugin.GetEncryptionKeyring()
+	plan, err := keyring.Decrypt(dbBlueprint.Plan)
 	if err != nil {
 		return nil, err
 	}
 	dbBlueprint.Plan = plan
-	settings, err := plugin.Decrypt(encKey, dbBlueprint.Settings)
+	settings, err := keyring.Decrypt(dbBlueprint.Settings)
 	dbBlueprint.Settings = settings
 	if err != nil {
 		return nil, err
@@ -0,0 +1,481 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/google/uuid"
+)
+
+// Multiple devlake instances could share the same database when CLUSTER_MODE=true. Instead of locking the
+// database, instances coordinate through leases stored in the _devlake_leases table:
+//  1. migration scripts are applied by the instance holding the `migration` lease, one instance at a time
+//  2. pending pipelines are claimed by instances with a conditional update, and kept alive by heartbeats
+//  3. the instance holding the `leader` lease triggers blueprint cron jobs, tracks temporal workflows and
+//     reschedules pipelines whose instance stopped heartbeating
+//  4. running pipelines are cancelled through _devlake_pipeline_cancel_requests, the instance running them
+//     acts on the requests with its heartbeats
+//
+// Leases and heartbeats are compared against the clock of the database rather than the ones of the instances.
+const (
+	LEASE_LEADER    = "leader"
+	LEASE_MIGRATION = "migration"
+)
+
+const leaseTTL = 30 * time.Second
+const heartbeatInterval = 10 * time.Second
+
+var clusterMode bool
+var instanceId string
+var leader atomic.Bool
+var blueprintsFingerprint string
+
+// localPipelines holds the ids of pipelines running in this instance
+var localPipelines sync.Map
+
+func initInstance() {
+	clusterMode = cfg.GetBool("CLUSTER_MODE")
+	hostName, err := os.Hostname()
+	if err != nil {
+		hostName = "devlake"
+	}
+	instanceId = fmt.Sprintf("%s-%s", hostName, uuid.New().String()[:8])
+	if !clusterMode {
+		return
+	}
+	if db.Dialect() == "sqlite" {
+		panic(errors.BadInput.New("CLUSTER_MODE is not supported by sqlite"))
+	}
+	if err := db.AutoMigrate(&models.Lease{}); err != nil {
+		panic(err)
+	}
+	if err := db.AutoMigrate(&models.PipelineCancelRequest{}); err != nil {
+		panic(err)
+	}
+	logger.Info("running in cluster mode as instance %s", instanceId)
+}
+
+// isLeader returns true if this instance is in charge of the cluster-wide scheduling, which is always
+// the case when running in standalone mode
+func isLeader() bool {
+	return !clusterMode || leader.Load()
+}
+
+// dbNow returns the current time of the database
+func dbNow() (time.Time, errors.Error) {
+	var expr string
+	switch db.Dialect() {
+	case "mysql":
+		expr = "UTC_TIMESTAMP(3)"
+	case "postgres":
+		expr = "CURRENT_TIMESTAMP"
+	default:
+		// sqlite serves a single instance, its clock is the one of the database
+		return time.Now(), nil
+	}
+	cursor, err := db.Cursor(dal.Select(expr), dal.From("(SELECT 1) AS db_now"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer cursor.Close()
+	var now time.Time
+	if !cursor.Next() {
+		return now, errors.Default.New("failed to read the time of the database")
+	}
+	if err := cursor.Scan(&now); err != nil {
+		return now, errors.Convert(err)
+	}
+	if db.Dialect() == "mysql" {
+		// UTC_TIMESTAMP carries no time zone, the driver parses it in the location of the connection
+		now = time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), time.UTC)
+	}
+	return now, nil
+}
+
+// tryAcquireLease obtains or renews the named lease, ok is false if it is held by another instance. The token
+// identifies this term of holding the lease, see Lease.Token
+func tryAcquireLease(name string) (token uint64, ok bool, err errors.Error) {
+	now, err := dbNow()
+	if err != nil {
+		return 0, false, err
+	}
+	err = db.CreateIfNotExist(&models.Lease{Name: name, ExpiresAt: now})
+	if err != nil {
+		return 0, false, err
+	}
+	// take the expired lease over, it changes hands so the token goes up
+	err = db.UpdateColumns(&models.Lease{}, []dal.DalSet{
+		{ColumnName: "holder", Value: instanceId},
+		{ColumnName: "token", Value: dal.Expr("token + 1")},
+		{ColumnName: "expires_at", Value: now.Add(leaseTTL)},
+	}, dal.Where("name = ? AND expires_at <= ?", name, now))
+	if err != nil {
+		return 0, false, err
+	}
+	// or renew the one held by this instance
+	err = db.UpdateColumn(
+		&models.Lease{},
+		"expires_at", now.Add(leaseTTL),
+		dal.Where("name = ? AND holder = ? AND expires_at > ?", name, instanceId, now),
+	)
+	if err != nil {
+		return 0, false, err
+	}
+	lease := &models.Lease{}
+	err = db.First(lease, dal.Where("name = ?", name))
+	if err != nil {
+		return 0, false, err
+	}
+	return lease.Token, lease.Holder == instanceId, nil
+}
+
+// releaseLease gives up the named lease so other instances could obtain it without waiting for the expiration
+func releaseLease(name string) errors.Error {
+	now, err := dbNow()
+	if err != nil {
+		return err
+	}
+	return db.UpdateColumn(&models.Lease{}, "expires_at", now, dal.Where("name = ? AND holder = ?", name, instanceId))
+}
+
+// holdLease blocks until the named lease is obtained and keeps renewing it until the returned function is called
+func holdLease(name string) func() {
+	for {
+		_, ok, err := tryAcquireLease(name)
+		if err != nil {
+			logger.Error(err, "failed to acquire lease %s", name)
+		}
+		if ok {
+			break
+		}
+		logger.Info("waiting for lease %s held by another instance", name)
+		time.Sleep(heartbeatInterval)
+	}
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if _, _, err := tryAcquireLease(name); err != nil {
+					logger.Error(err, "failed to renew lease %s", name)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		if err := releaseLease(name); err != nil {
+			logger.Error(err, "failed to release lease %s", name)
+		}
+	}
+}
+
+// runClusterLoop elects the leader, sends heartbeats for running pipelines, handles cancel requests and keeps
+// blueprint cron jobs in sync
+func runClusterLoop() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		token, ok, err := tryAcquireLease(LEASE_LEADER)
+		if err != nil {
+			logger.Error(err, "failed to acquire leader lease")
+		}
+		if ok != leader.Load() {
+			logger.Info("instance %s leadership changed, leader: %v", instanceId, ok)
+			leader.Store(ok)
+		}
+		err = heartbeatPipelines()
+		if err != nil {
+			logger.Error(err, "failed to send heartbeats for pipelines")
+		}
+		err = handleCancelRequests()
+		if err != nil {
+			logger.Error(err, "failed to handle pipeline cancel requests")
+		}
+		if ok {
+			err = reschedulePipelines(token)
+			if err != nil {
+				logger.Error(err, "failed to reschedule pipelines")
+			}
+		}
+		err = syncBlueprints()
+		if err != nil {
+			logger.Error(err, "failed to reload blueprints")
+		}
+	}
+}
+
+// claimPipeline marks the pending pipeline running by this instance, returns false if it was claimed by others, or
+// its blueprint reached the limit of running pipelines in the meantime
+func claimPipeline(pipelineId uint64) (bool, errors.Error) {
+	now, err := dbNow()
+	if err != nil {
+		return false, err
+	}
+	dbPipeline := &models.DbPipeline{}
+	err = db.First(dbPipeline, dal.Where("id = ?", pipelineId))
+	if err != nil {
+		return false, err
+	}
+	where := []dal.Clause{dal.Where("id = ? AND status IN ?", pipelineId, []string{models.TASK_CREATED, models.TASK_RERUN})}
+	if pipelineMaxParallelPerBlueprint > 0 && dbPipeline.BlueprintId > 0 {
+		// the limit is checked by the claim itself, so instances claiming pipelines of the blueprint at once could
+		// not exceed it. The running pipelines are counted in a derived table since mysql refuses subqueries on
+		// the table being updated
+		where = append(where, dal.Where(
+			"(SELECT count(*) FROM (SELECT id FROM _devlake_pipelines WHERE blueprint_id = ? AND status = ?) running) < ?",
+			dbPipeline.BlueprintId, models.TASK_RUNNING, pipelineMaxParallelPerBlueprint,
+		))
+	}
+	err = db.UpdateColumns(&models.DbPipeline{}, []dal.DalSet{
+		{ColumnName: "status", Value: models.TASK_RUNNING},
+		{ColumnName: "message", Value: ""},
+		{ColumnName: "began_at", Value: now},
+		{ColumnName: "instance", Value: instanceId},
+		{ColumnName: "heartbeat_at", Value: now},
+	}, where...)
+	if err != nil {
+		return false, err
+	}
+	// the pipeline is claimed only if the update above affected it, the instance is unique to this run
+	dbPipeline = &models.DbPipeline{}
+	err = db.First(dbPipeline, dal.Where("id = ?", pipelineId))
+	if err != nil {
+		return false, err
+	}
+	return dbPipeline.Status == models.TASK_RUNNING && dbPipeline.Instance == instanceId, nil
+}
+
+// heartbeatPipelines renews the heartbeats of pipelines running in this instance, and cancels the ones rescheduled
+// since this instance failed to send heartbeats in time, e.g. the database was unreachable for a while
+func heartbeatPipelines() errors.Error {
+	var pipelineIds []uint64
+	localPipelines.Range(func(key, _ any) bool {
+		pipelineIds = append(pipelineIds, key.(uint64))
+		return true
+	})
+	for _, pipelineId := range pipelineIds {
+		owned, err := heartbeatPipeline(pipelineId)
+		if err != nil {
+			return err
+		}
+		if owned {
+			continue
+		}
+		globalPipelineLog.Warn(nil, "pipeline #%d was taken over by another instance, cancelling the local run", pipelineId)
+		localPipelines.Delete(pipelineId)
+		err = cancelPipelineTasks(pipelineId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heartbeatPipeline renews the heartbeat of the pipeline while holding it, so it could not be rescheduled in between.
+// Returns false if the pipeline is not owned by this instance anymore
+func heartbeatPipeline(pipelineId uint64) (owned bool, err errors.Error) {
+	now, err := dbNow()
+	if err != nil {
+		return false, err
+	}
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	pipeline := &models.DbPipeline{}
+	err = tx.First(pipeline, dal.Where("id = ?", pipelineId), dal.Lock(true, false))
+	if err != nil {
+		return false, err
+	}
+	if pipeline.Instance != instanceId {
+		return false, tx.Commit()
+	}
+	err = tx.UpdateColumn(
+		&models.DbPipeline{},
+		"heartbeat_at", now,
+		dal.Where("id = ? AND status = ?", pipelineId, models.TASK_RUNNING),
+	)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// handleCancelRequests cancels the pipelines of this instance which were requested to be cancelled, and cleans up
+// the requests of pipelines no longer running
+func handleCancelRequests() errors.Error {
+	var pipelineIds []uint64
+	err := db.Pluck(
+		"r.pipeline_id",
+		&pipelineIds,
+		dal.From("_devlake_pipeline_cancel_requests r"),
+		dal.Join("JOIN _devlake_pipelines p ON p.id = r.pipeline_id"),
+		dal.Where("p.instance = ? AND p.status = ?", instanceId, models.TASK_RUNNING),
+	)
+	if err != nil {
+		return err
+	}
+	for _, pipelineId := range pipelineIds {
+		globalPipelineLog.Info("cancelling pipeline #%d as requested through another instance", pipelineId)
+		err = cancelPipelineTasks(pipelineId)
+		if err != nil {
+			return err
+		}
+		err = db.Delete(&models.PipelineCancelRequest{}, dal.Where("pipeline_id = ?", pipelineId))
+		if err != nil {
+			return err
+		}
+	}
+	return db.Delete(
+		&models.PipelineCancelRequest{},
+		dal.Where("pipeline_id NOT IN (SELECT id FROM _devlake_pipelines WHERE status = ?)", models.TASK_RUNNING),
+	)
+}
+
+// reschedulePipelines puts pipelines of crashed instances back to the queue, their unfinished tasks would be rerun.
+// It stops as soon as the leader lease identified by the token is lost
+func reschedulePipelines(token uint64) errors.Error {
+	now, err := dbNow()
+	if err != nil {
+		return err
+	}
+	staleBefore := now.Add(-leaseTTL)
+	var stalePipelines []*models.DbPipeline
+	err = db.All(
+		&stalePipelines,
+		dal.Where("status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?)", models.TASK_RUNNING, staleBefore),
+	)
+	if err != nil {
+		return err
+	}
+	for _, stalePipeline := range stalePipelines {
+		err = reschedulePipeline(stalePipeline, token, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reschedulePipeline reschedules the stale pipeline in a transaction holding the leader lease and the pipeline, so
+// neither a new leader nor a late heartbeat of the instance could interleave. Pipelines requested to be cancelled
+// get cancelled instead
+func reschedulePipeline(stalePipeline *models.DbPipeline, token uint64, now time.Time) (err errors.Error) {
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	lease := &models.Lease{}
+	err = tx.First(lease, dal.Where("name = ?", LEASE_LEADER), dal.Lock(true, false))
+	if err != nil {
+		return err
+	}
+	if lease.Holder != instanceId || lease.Token != token || !lease.ExpiresAt.After(now) {
+		return errors.Default.New(fmt.Sprintf("leader lease with token %d is lost, stop rescheduling", token))
+	}
+	pipeline := &models.DbPipeline{}
+	err = tx.First(pipeline, dal.Where("id = ?", stalePipeline.ID), dal.Lock(true, false))
+	if err != nil {
+		return err
+	}
+	if pipeline.Status != models.TASK_RUNNING || pipeline.Instance != stalePipeline.Instance ||
+		(pipeline.HeartbeatAt != nil && !pipeline.HeartbeatAt.Before(now.Add(-leaseTTL))) {
+		// finished or heartbeating again in the meantime
+		return tx.Commit()
+	}
+	cancelRequests, err := tx.Count(dal.From(&models.PipelineCancelRequest{}), dal.Where("pipeline_id = ?", pipeline.ID))
+	if err != nil {
+		return err
+	}
+	status := models.TASK_RERUN
+	message := fmt.Sprintf("rescheduled since instance %s stopped heartbeating", pipeline.Instance)
+	if cancelRequests > 0 {
+		status = models.TASK_CANCELLED
+		message = fmt.Sprintf("cancelled since instance %s stopped heartbeating", pipeline.Instance)
+	}
+	globalPipelineLog.Warn(nil, "instance %s of pipeline #%d stopped heartbeating, %s", pipeline.Instance, pipeline.ID, status)
+	err = tx.UpdateColumn(
+		&models.Task{},
+		"status", status,
+		dal.Where("pipeline_id = ? AND status = ?", pipeline.ID, models.TASK_RUNNING),
+	)
+	if err != nil {
+		return err
+	}
+	err = tx.UpdateColumns(&models.DbPipeline{}, []dal.DalSet{
+		{ColumnName: "status", Value: status},
+		{ColumnName: "instance", Value: ""},
+		{ColumnName: "message", Value: message},
+	}, dal.Where("id = ?", pipeline.ID))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// syncBlueprints reloads blueprint cron jobs when blueprints were modified through other instances
+func syncBlueprints() errors.Error {
+	count, err := db.Count(dal.From(&models.DbBlueprint{}))
+	if err != nil {
+		return err
+	}
+	var updatedAts []time.Time
+	err = db.Pluck(
+		"updated_at",
+		&updatedAts,
+		dal.From(&models.DbBlueprint{}),
+		dal.Orderby("updated_at DESC"),
+		dal.Limit(1),
+	)
+	if err != nil {
+		return err
+	}
+	fingerprint := fmt.Sprintf("%d:%v", count, updatedAts)
+	if fingerprint == blueprintsFingerprint {
+		return nil
+	}
+	cronLocker.Lock()
+	defer cronLocker.Unlock()
+	err = ReloadBlueprints(cronManager)
+	if err != nil {
+		return err
+	}
+	blueprintsFingerprint = fingerprint
+	return nil
+}
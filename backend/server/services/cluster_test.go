@@ -0,0 +1,215 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/impls/dalgorm"
+	"github.com/apache/incubator-devlake/impls/logruslog"
+	"github.com/go-playground/validator/v10"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupClusterTest points the services at a sqlite database and runs them as instance `a` of a cluster
+func setupClusterTest(t *testing.T) {
+	keyring, err := plugin.NewEncryptionKeyring(map[string]string{"default": "cluster-test-key"}, "default", "")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	dalgorm.Init(keyring)
+	c := viper.New()
+	c.Set("DB_URL", "sqlite://"+t.TempDir()+"/lake.db")
+	gormDb, err := runner.NewGormDb(c, logruslog.Global)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	originalDb, originalLogger, originalVld := db, logger, vld
+	originalClusterMode, originalInstanceId := clusterMode, instanceId
+	db, logger, vld = dalgorm.NewDalgorm(gormDb), logruslog.Global, validator.New()
+	clusterMode, instanceId = true, "a"
+	t.Cleanup(func() {
+		db, logger, vld = originalDb, originalLogger, originalVld
+		clusterMode, instanceId = originalClusterMode, originalInstanceId
+	})
+	for _, table := range []interface{}{&models.Lease{}, &models.PipelineCancelRequest{}, &models.DbPipeline{}, &models.DbPipelineLabel{}, &models.Task{}} {
+		assert.Nil(t, db.AutoMigrate(table))
+	}
+}
+
+// expireLease pretends the lease was not renewed in time
+func expireLease(t *testing.T, name string) {
+	assert.Nil(t, db.UpdateColumn(&models.Lease{}, "expires_at", time.Now().Add(-time.Second), dal.Where("name = ?", name)))
+}
+
+func TestTryAcquireLease(t *testing.T) {
+	setupClusterTest(t)
+
+	token, ok, err := tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), token)
+	// renewing keeps the token
+	token, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), token)
+
+	instanceId = "b"
+	_, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// the lease changes hands once expired
+	expireLease(t, LEASE_LEADER)
+	token, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), token)
+
+	instanceId = "a"
+	_, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// released leases are available right away
+	instanceId = "b"
+	assert.Nil(t, releaseLease(LEASE_LEADER))
+	instanceId = "a"
+	token, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(3), token)
+}
+
+func TestReschedulePipelines(t *testing.T) {
+	setupClusterTest(t)
+	token, ok, err := tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	stale := time.Now().Add(-2 * leaseTTL)
+	fresh := time.Now()
+	pipelines := []*models.DbPipeline{
+		{Model: common.Model{ID: 1}, Status: models.TASK_RUNNING, Instance: "crashed", HeartbeatAt: &stale},
+		{Model: common.Model{ID: 2}, Status: models.TASK_RUNNING, Instance: "alive", HeartbeatAt: &fresh},
+		{Model: common.Model{ID: 3}, Status: models.TASK_RUNNING, Instance: "crashed", HeartbeatAt: &stale},
+	}
+	for _, pipeline := range pipelines {
+		assert.Nil(t, db.Create(pipeline))
+		assert.Nil(t, db.Create(&models.Task{PipelineId: pipeline.ID, Status: models.TASK_RUNNING}))
+	}
+	assert.Nil(t, db.Create(&models.PipelineCancelRequest{PipelineId: 3}))
+
+	// a leader which lost the lease must not reschedule anything
+	instanceId = "b"
+	expireLease(t, LEASE_LEADER)
+	_, ok, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	instanceId = "a"
+	assert.NotNil(t, reschedulePipelines(token))
+	pipelineStatus := func(id uint64) string {
+		pipeline := &models.DbPipeline{}
+		assert.Nil(t, db.First(pipeline, dal.Where("id = ?", id)))
+		return pipeline.Status
+	}
+	assert.Equal(t, models.TASK_RUNNING, pipelineStatus(1))
+
+	// the current leader does
+	instanceId = "b"
+	token, _, err = tryAcquireLease(LEASE_LEADER)
+	assert.Nil(t, err)
+	assert.Nil(t, reschedulePipelines(token))
+	assert.Equal(t, models.TASK_RERUN, pipelineStatus(1))
+	assert.Equal(t, models.TASK_RUNNING, pipelineStatus(2))
+	assert.Equal(t, models.TASK_CANCELLED, pipelineStatus(3))
+	var taskStatuses []string
+	assert.Nil(t, db.Pluck("status", &taskStatuses, dal.From(&models.Task{}), dal.Orderby("pipeline_id")))
+	assert.Equal(t, []string{models.TASK_RERUN, models.TASK_RUNNING, models.TASK_CANCELLED}, taskStatuses)
+}
+
+func TestCancelPipelineOfAnotherInstance(t *testing.T) {
+	setupClusterTest(t)
+	now := time.Now()
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 1}, Status: models.TASK_RUNNING, Instance: "b", HeartbeatAt: &now}))
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 2}, Status: models.TASK_COMPLETED, Instance: "b"}))
+	assert.Nil(t, db.Create(&models.PipelineCancelRequest{PipelineId: 2}))
+
+	assert.Nil(t, CancelPipeline(1))
+	count, err := db.Count(dal.From(&models.PipelineCancelRequest{}), dal.Where("pipeline_id = ?", 1))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// instance a only cleans up the request of the finished pipeline
+	assert.Nil(t, handleCancelRequests())
+	var pipelineIds []uint64
+	assert.Nil(t, db.Pluck("pipeline_id", &pipelineIds, dal.From(&models.PipelineCancelRequest{})))
+	assert.Equal(t, []uint64{1}, pipelineIds)
+
+	// instance b runs the pipeline and acts on the request
+	instanceId = "b"
+	assert.Nil(t, handleCancelRequests())
+	count, err = db.Count(dal.From(&models.PipelineCancelRequest{}))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestHeartbeatPipelines(t *testing.T) {
+	setupClusterTest(t)
+	before := time.Now().Add(-time.Minute)
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 1}, Status: models.TASK_RUNNING, Instance: "a", HeartbeatAt: &before}))
+	// pipeline 2 was rescheduled and claimed by instance b while instance a failed to send heartbeats
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 2}, Status: models.TASK_RUNNING, Instance: "b", HeartbeatAt: &before}))
+	assert.Nil(t, db.Create(&models.Task{Model: common.Model{ID: 1}, PipelineId: 2, Status: models.TASK_RUNNING}))
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, runningTasks.Add(1, cancel))
+	t.Cleanup(func() {
+		_, _ = runningTasks.Remove(1)
+	})
+	localPipelines.Store(uint64(1), struct{}{})
+	localPipelines.Store(uint64(2), struct{}{})
+	t.Cleanup(func() {
+		localPipelines.Delete(uint64(1))
+		localPipelines.Delete(uint64(2))
+	})
+
+	assert.Nil(t, heartbeatPipelines())
+	pipeline := &models.DbPipeline{}
+	assert.Nil(t, db.First(pipeline, dal.Where("id = ?", 1)))
+	assert.True(t, pipeline.HeartbeatAt.After(before))
+	_, ok := localPipelines.Load(uint64(1))
+	assert.True(t, ok)
+
+	// the local run of the pipeline taken over gets cancelled, leaving the heartbeat of instance b alone
+	pipeline = &models.DbPipeline{}
+	assert.Nil(t, db.First(pipeline, dal.Where("id = ?", 2)))
+	assert.Equal(t, "b", pipeline.Instance)
+	assert.True(t, pipeline.HeartbeatAt.Equal(before))
+	_, ok = localPipelines.Load(uint64(2))
+	assert.False(t, ok)
+	assert.NotNil(t, ctx.Err())
+}
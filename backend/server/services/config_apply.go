@@ -0,0 +1,1044 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	CONFIG_ACTION_CREATE = "create"
+	CONFIG_ACTION_UPDATE = "update"
+	CONFIG_ACTION_NONE   = "none"
+
+	CONFIG_KIND_CONNECTION          = "connection"
+	CONFIG_KIND_TRANSFORMATION_RULE = "transformationRule"
+	CONFIG_KIND_SCOPE               = "scope"
+	CONFIG_KIND_PROJECT             = "project"
+	CONFIG_KIND_BLUEPRINT           = "blueprint"
+
+	maskedSecret = "******"
+)
+
+// ConfigDocument describes the desired state of connections, transformation rules, scopes, projects and
+// blueprints, which refer to each other by names. Resources missing from the document are left untouched.
+type ConfigDocument struct {
+	Connections         []*ConfigConnection         `yaml:"connections" json:"connections"`
+	TransformationRules []*ConfigTransformationRule `yaml:"transformationRules" json:"transformationRules"`
+	Scopes              []*ConfigScope              `yaml:"scopes" json:"scopes"`
+	Projects            []*ConfigProject            `yaml:"projects" json:"projects"`
+	Blueprints          []*ConfigBlueprint          `yaml:"blueprints" json:"blueprints"`
+}
+
+// ConfigConnection is a connection of the plugin identified by the name, other fields are sent to the connection
+// api of the plugin as they are, so secrets could be given as references, e.g. `token: env:GITHUB_TOKEN`
+type ConfigConnection struct {
+	Plugin string                 `yaml:"plugin" json:"plugin"`
+	Name   string                 `yaml:"name" json:"name"`
+	Fields map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// ConfigTransformationRule is a transformation rule of the plugin identified by the name
+type ConfigTransformationRule struct {
+	Plugin string                 `yaml:"plugin" json:"plugin"`
+	Name   string                 `yaml:"name" json:"name"`
+	Fields map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+// ConfigScope is a scope of the connection identified by its id field, e.g. `githubId` for the github plugin
+type ConfigScope struct {
+	Plugin             string                 `yaml:"plugin" json:"plugin"`
+	Connection         string                 `yaml:"connection" json:"connection"`
+	TransformationRule string                 `yaml:"transformationRule" json:"transformationRule"`
+	Fields             map[string]interface{} `yaml:",inline" json:"-"`
+}
+
+type ConfigProject struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	// Metrics are left untouched if nil
+	Metrics []*ConfigProjectMetric `yaml:"metrics" json:"metrics"`
+}
+
+type ConfigProjectMetric struct {
+	PluginName string `yaml:"pluginName" json:"pluginName"`
+	// PluginOption could be given as an object
+	PluginOption interface{} `yaml:"pluginOption" json:"pluginOption"`
+	Enable       bool        `yaml:"enable" json:"enable"`
+}
+
+// ConfigBlueprint is a blueprint of the NORMAL mode with settings of version 2.0.0
+type ConfigBlueprint struct {
+	Name             string                       `yaml:"name" json:"name"`
+	Project          string                       `yaml:"project" json:"project"`
+	Enable           bool                         `yaml:"enable" json:"enable"`
+	CronConfig       string                       `yaml:"cronConfig" json:"cronConfig"`
+	IsManual         bool                         `yaml:"isManual" json:"isManual"`
+	SkipOnFail       bool                         `yaml:"skipOnFail" json:"skipOnFail"`
+	Priority         int                          `yaml:"priority" json:"priority"`
+	Labels           []string                     `yaml:"labels" json:"labels"`
+	CreatedDateAfter *time.Time                   `yaml:"createdDateAfter" json:"createdDateAfter"`
+	Connections      []*ConfigBlueprintConnection `yaml:"connections" json:"connections"`
+}
+
+type ConfigBlueprintConnection struct {
+	Plugin     string                       `yaml:"plugin" json:"plugin"`
+	Connection string                       `yaml:"connection" json:"connection"`
+	Scopes     []*plugin.BlueprintScopeV200 `yaml:"scopes" json:"scopes"`
+}
+
+// MarshalJSON inlines the fields the same way as the YAML
+func (c ConfigConnection) MarshalJSON() ([]byte, error) {
+	return marshalInlineFields(c.Fields, map[string]interface{}{"plugin": c.Plugin, "name": c.Name})
+}
+
+func (c *ConfigConnection) UnmarshalJSON(blob []byte) error {
+	return unmarshalInlineFields(blob, &c.Fields, map[string]interface{}{"plugin": &c.Plugin, "name": &c.Name})
+}
+
+// MarshalJSON inlines the fields the same way as the YAML
+func (r ConfigTransformationRule) MarshalJSON() ([]byte, error) {
+	return marshalInlineFields(r.Fields, map[string]interface{}{"plugin": r.Plugin, "name": r.Name})
+}
+
+func (r *ConfigTransformationRule) UnmarshalJSON(blob []byte) error {
+	return unmarshalInlineFields(blob, &r.Fields, map[string]interface{}{"plugin": &r.Plugin, "name": &r.Name})
+}
+
+// MarshalJSON inlines the fields the same way as the YAML
+func (s ConfigScope) MarshalJSON() ([]byte, error) {
+	known := map[string]interface{}{"plugin": s.Plugin, "connection": s.Connection}
+	if s.TransformationRule != "" {
+		known["transformationRule"] = s.TransformationRule
+	}
+	return marshalInlineFields(s.Fields, known)
+}
+
+func (s *ConfigScope) UnmarshalJSON(blob []byte) error {
+	return unmarshalInlineFields(blob, &s.Fields, map[string]interface{}{
+		"plugin":             &s.Plugin,
+		"connection":         &s.Connection,
+		"transformationRule": &s.TransformationRule,
+	})
+}
+
+func marshalInlineFields(fields map[string]interface{}, known map[string]interface{}) ([]byte, error) {
+	merged := make(map[string]interface{}, len(fields)+len(known))
+	for name, value := range fields {
+		merged[name] = value
+	}
+	for name, value := range known {
+		merged[name] = value
+	}
+	return json.Marshal(merged)
+}
+
+// unmarshalInlineFields decodes the known fields into the pointers given and the others into fields
+func unmarshalInlineFields(blob []byte, fields *map[string]interface{}, known map[string]interface{}) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(blob, &raw); err != nil {
+		return err
+	}
+	*fields = make(map[string]interface{})
+	for name, value := range raw {
+		if target, ok := known[name]; ok {
+			if err := json.Unmarshal(value, target); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(value, &v); err != nil {
+			return err
+		}
+		(*fields)[name] = v
+	}
+	return nil
+}
+
+// ConfigChange is a change made, or to be made in dry run, to a resource
+type ConfigChange struct {
+	Kind   string      `json:"kind"`
+	Plugin string      `json:"plugin,omitempty"`
+	Name   string      `json:"name"`
+	Id     interface{} `json:"id,omitempty"`
+	Action string      `json:"action"`
+	// Fields are the desired values of the changed fields, secrets are masked
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+type ConfigApplyResult struct {
+	DryRun  bool            `json:"dryRun"`
+	Changes []*ConfigChange `json:"changes"`
+}
+
+// ParseConfigDocument parses the document in YAML or JSON
+func ParseConfigDocument(blob []byte) (*ConfigDocument, errors.Error) {
+	doc := &ConfigDocument{}
+	decoder := yaml.NewDecoder(bytes.NewReader(blob))
+	decoder.KnownFields(true)
+	err := decoder.Decode(doc)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, "invalid configuration document")
+	}
+	return doc, nil
+}
+
+// ApplyConfig brings the resources in line with the document in the order of connections, transformation rules,
+// scopes, projects and blueprints, resources already matching the document are not touched so applying the same
+// document again changes nothing. Changes are only computed in dry run, new ids are unknown thereby.
+// It is not atomic, resources applied before a failure are kept, applying the document again resumes from there.
+func ApplyConfig(doc *ConfigDocument, dryRun bool, user *common.User) (*ConfigApplyResult, errors.Error) {
+	applier := &configApplier{
+		dryRun:        dryRun,
+		user:          user,
+		result:        &ConfigApplyResult{DryRun: dryRun, Changes: make([]*ConfigChange, 0)},
+		connectionIds: make(map[string]uint64),
+		ruleIds:       make(map[string]uint64),
+		connections:   make(map[string]map[string]map[string]interface{}),
+	}
+	if err := applier.validate(doc); err != nil {
+		return nil, err
+	}
+	for _, connection := range doc.Connections {
+		if err := applier.applyConnection(connection); err != nil {
+			return applier.result, errors.Default.Wrap(err, fmt.Sprintf("failed to apply connection %s of %s", connection.Name, connection.Plugin))
+		}
+	}
+	for _, rule := range doc.TransformationRules {
+		if err := applier.applyTransformationRule(rule); err != nil {
+			return applier.result, errors.Default.Wrap(err, fmt.Sprintf("failed to apply transformation rule %s of %s", rule.Name, rule.Plugin))
+		}
+	}
+	for _, scope := range doc.Scopes {
+		if err := applier.applyScope(scope); err != nil {
+			return applier.result, errors.Default.Wrap(err, fmt.Sprintf("failed to apply scope of connection %s of %s", scope.Connection, scope.Plugin))
+		}
+	}
+	for _, project := range doc.Projects {
+		if err := applier.applyProject(project); err != nil {
+			return applier.result, errors.Default.Wrap(err, fmt.Sprintf("failed to apply project %s", project.Name))
+		}
+	}
+	for _, blueprint := range doc.Blueprints {
+		if err := applier.applyBlueprint(blueprint); err != nil {
+			return applier.result, errors.Default.Wrap(err, fmt.Sprintf("failed to apply blueprint %s", blueprint.Name))
+		}
+	}
+	return applier.result, nil
+}
+
+type configApplier struct {
+	dryRun bool
+	user   *common.User
+	result *ConfigApplyResult
+	// ids keyed by `plugin/name`, 0 for those to be created in dry run
+	connectionIds map[string]uint64
+	ruleIds       map[string]uint64
+	// existing connections keyed by plugin and then name, each plugin is listed once since listing decrypts
+	// every connection. Connections applied from the document are looked up by connectionIds afterwards,
+	// so the cached fields never go stale
+	connections map[string]map[string]map[string]interface{}
+}
+
+func configKey(pluginName string, name string) string {
+	return pluginName + "/" + name
+}
+
+// validate checks the document as a whole before anything is changed
+func (a *configApplier) validate(doc *ConfigDocument) errors.Error {
+	names := make(map[string]bool)
+	unique := func(kind string, key string) errors.Error {
+		if names[kind+":"+key] {
+			return errors.BadInput.New(fmt.Sprintf("duplicated %s %s", kind, key))
+		}
+		names[kind+":"+key] = true
+		return nil
+	}
+	for _, connection := range doc.Connections {
+		if connection.Plugin == "" || connection.Name == "" {
+			return errors.BadInput.New("plugin and name are required for connections")
+		}
+		if err := unique(CONFIG_KIND_CONNECTION, configKey(connection.Plugin, connection.Name)); err != nil {
+			return err
+		}
+	}
+	for _, rule := range doc.TransformationRules {
+		if rule.Plugin == "" || rule.Name == "" {
+			return errors.BadInput.New("plugin and name are required for transformation rules")
+		}
+		if err := unique(CONFIG_KIND_TRANSFORMATION_RULE, configKey(rule.Plugin, rule.Name)); err != nil {
+			return err
+		}
+	}
+	for _, scope := range doc.Scopes {
+		if scope.Plugin == "" || scope.Connection == "" {
+			return errors.BadInput.New("plugin and connection are required for scopes")
+		}
+	}
+	for _, project := range doc.Projects {
+		if project.Name == "" {
+			return errors.BadInput.New("name is required for projects")
+		}
+		if err := unique(CONFIG_KIND_PROJECT, project.Name); err != nil {
+			return err
+		}
+	}
+	for _, blueprint := range doc.Blueprints {
+		if blueprint.Name == "" {
+			return errors.BadInput.New("name is required for blueprints")
+		}
+		if err := unique(CONFIG_KIND_BLUEPRINT, blueprint.Name); err != nil {
+			return err
+		}
+		for _, connection := range blueprint.Connections {
+			if connection.Plugin == "" || connection.Connection == "" {
+				return errors.BadInput.New(fmt.Sprintf("plugin and connection are required for connections of blueprint %s", blueprint.Name))
+			}
+		}
+	}
+	return nil
+}
+
+func (a *configApplier) record(change *ConfigChange) {
+	a.result.Changes = append(a.result.Changes, change)
+}
+
+func (a *configApplier) applyConnection(connection *ConfigConnection) errors.Error {
+	source, err := getPluginSource(connection.Plugin)
+	if err != nil {
+		return err
+	}
+	fieldTypes := jsonFieldsOf(reflect.TypeOf(source.Connection()))
+	fields, err := normalizeConfigFields(connection.Fields, fieldTypes, "id", "name")
+	if err != nil {
+		return err
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_CONNECTION, Plugin: connection.Plugin, Name: connection.Name}
+	existing, err := a.findConnection(connection.Plugin, connection.Name)
+	if err != nil {
+		return err
+	}
+	key := configKey(connection.Plugin, connection.Name)
+	if existing == nil {
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = maskSecrets(fields, fieldTypes)
+		a.record(change)
+		if a.dryRun {
+			a.connectionIds[key] = 0
+			return nil
+		}
+		fields["name"] = connection.Name
+		created, err := callPluginApi(connection.Plugin, http.MethodPost, "connections", nil, fields, a.user)
+		if err != nil {
+			return err
+		}
+		a.connectionIds[key], err = idOf(created)
+		change.Id = a.connectionIds[key]
+		return err
+	}
+	connectionId, err := idOf(existing)
+	if err != nil {
+		return err
+	}
+	a.connectionIds[key] = connectionId
+	change.Id = connectionId
+	changed := diffConfigFields(fields, existing)
+	if len(changed) == 0 {
+		change.Action = CONFIG_ACTION_NONE
+		a.record(change)
+		return nil
+	}
+	change.Action = CONFIG_ACTION_UPDATE
+	change.Fields = maskSecrets(changed, fieldTypes)
+	a.record(change)
+	if a.dryRun {
+		return nil
+	}
+	params := map[string]string{"connectionId": fmt.Sprint(connectionId)}
+	_, err = callPluginApi(connection.Plugin, http.MethodPatch, "connections/:connectionId", params, changed, a.user)
+	return err
+}
+
+func (a *configApplier) applyTransformationRule(rule *ConfigTransformationRule) errors.Error {
+	source, err := getPluginSource(rule.Plugin)
+	if err != nil {
+		return err
+	}
+	if source.TransformationRule() == nil {
+		return errors.BadInput.New(fmt.Sprintf("plugin %s has no transformation rules", rule.Plugin))
+	}
+	fieldTypes := jsonFieldsOf(reflect.TypeOf(source.TransformationRule()))
+	fields, err := normalizeConfigFields(rule.Fields, fieldTypes, "id", "name")
+	if err != nil {
+		return err
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_TRANSFORMATION_RULE, Plugin: rule.Plugin, Name: rule.Name}
+	existing, err := findByName(source.TransformationRule(), rule.Name)
+	if err != nil {
+		return err
+	}
+	key := configKey(rule.Plugin, rule.Name)
+	if existing == nil {
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = fields
+		a.record(change)
+		if a.dryRun {
+			a.ruleIds[key] = 0
+			return nil
+		}
+		fields["name"] = rule.Name
+		created, err := callPluginApi(rule.Plugin, http.MethodPost, "transformation_rules", nil, fields, a.user)
+		if err != nil {
+			return err
+		}
+		a.ruleIds[key], err = idOf(created)
+		change.Id = a.ruleIds[key]
+		return err
+	}
+	ruleId, err := idOf(existing)
+	if err != nil {
+		return err
+	}
+	a.ruleIds[key] = ruleId
+	change.Id = ruleId
+	changed := diffConfigFields(fields, existing)
+	if len(changed) == 0 {
+		change.Action = CONFIG_ACTION_NONE
+		a.record(change)
+		return nil
+	}
+	change.Action = CONFIG_ACTION_UPDATE
+	change.Fields = changed
+	a.record(change)
+	if a.dryRun {
+		return nil
+	}
+	params := map[string]string{"id": fmt.Sprint(ruleId)}
+	_, err = callPluginApi(rule.Plugin, http.MethodPatch, "transformation_rules/:id", params, changed, a.user)
+	return err
+}
+
+func (a *configApplier) applyScope(scope *ConfigScope) errors.Error {
+	source, err := getPluginSource(scope.Plugin)
+	if err != nil {
+		return err
+	}
+	if source.Scope() == nil {
+		return errors.BadInput.New(fmt.Sprintf("plugin %s has no scopes", scope.Plugin))
+	}
+	scopeType := reflect.TypeOf(source.Scope())
+	fieldTypes := jsonFieldsOf(scopeType)
+	fields, err := normalizeConfigFields(scope.Fields, fieldTypes, "connectionId", "transformationRuleId")
+	if err != nil {
+		return err
+	}
+	idField, err := scopeIdField(scopeType)
+	if err != nil {
+		return err
+	}
+	scopeId, ok := fields[idField]
+	if !ok {
+		return errors.BadInput.New(fmt.Sprintf("%s is required for scopes of %s", idField, scope.Plugin))
+	}
+	change := &ConfigChange{Kind: CONFIG_KIND_SCOPE, Plugin: scope.Plugin, Name: fmt.Sprint(scopeId), Id: scopeId}
+	if name, ok := fields["name"].(string); ok && name != "" {
+		change.Name = name
+	}
+	connectionId, connectionKnown, err := a.resolveConnection(scope.Plugin, scope.Connection)
+	if err != nil {
+		return err
+	}
+	ruleKnown := true
+	if scope.TransformationRule != "" {
+		var ruleId uint64
+		ruleId, ruleKnown, err = a.resolveTransformationRule(source, scope.Plugin, scope.TransformationRule)
+		if err != nil {
+			return err
+		}
+		fields["transformationRuleId"] = float64(ruleId)
+	}
+	var existing map[string]interface{}
+	if connectionKnown {
+		existing, err = findScope(source.Scope(), connectionId, idField, scopeId)
+		if err != nil {
+			return err
+		}
+	}
+	if existing == nil {
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = fields
+		a.record(change)
+		if a.dryRun {
+			return nil
+		}
+		fields["connectionId"] = float64(connectionId)
+		params := map[string]string{"connectionId": fmt.Sprint(connectionId)}
+		body := map[string]interface{}{"data": []interface{}{fields}}
+		_, err = callPluginApi(scope.Plugin, http.MethodPut, "connections/:connectionId/scopes", params, body, a.user)
+		return err
+	}
+	changed := diffConfigFields(fields, existing)
+	if !ruleKnown {
+		// the rule is to be created in dry run
+		changed["transformationRuleId"] = scope.TransformationRule
+	}
+	if len(changed) == 0 {
+		change.Action = CONFIG_ACTION_NONE
+		a.record(change)
+		return nil
+	}
+	change.Action = CONFIG_ACTION_UPDATE
+	change.Fields = changed
+	a.record(change)
+	if a.dryRun {
+		return nil
+	}
+	resourcePath, paramName, err := scopeResourcePath(scope.Plugin)
+	if err != nil {
+		return err
+	}
+	params := map[string]string{"connectionId": fmt.Sprint(connectionId), paramName: fmt.Sprint(scopeId)}
+	_, err = callPluginApi(scope.Plugin, http.MethodPatch, resourcePath, params, changed, a.user)
+	return err
+}
+
+func (a *configApplier) applyProject(project *ConfigProject) errors.Error {
+	change := &ConfigChange{Kind: CONFIG_KIND_PROJECT, Name: project.Name}
+	desired := map[string]interface{}{"description": project.Description}
+	var metrics []models.BaseMetric
+	if project.Metrics != nil {
+		metrics = make([]models.BaseMetric, len(project.Metrics))
+		for i, metric := range project.Metrics {
+			option, err := metricOption(metric.PluginOption)
+			if err != nil {
+				return err
+			}
+			metrics[i] = models.BaseMetric{PluginName: metric.PluginName, PluginOption: option, Enable: metric.Enable}
+		}
+		sort.Slice(metrics, func(i, j int) bool { return metrics[i].PluginName < metrics[j].PluginName })
+		desired["metrics"] = metrics
+	}
+	existing, err := GetProject(project.Name)
+	if err != nil && err.GetType() != errors.NotFound {
+		return err
+	}
+	if existing == nil {
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = desired
+		a.record(change)
+		if a.dryRun {
+			return nil
+		}
+		input := &models.ApiInputProject{BaseProject: models.BaseProject{Name: project.Name, Description: project.Description}}
+		if metrics != nil {
+			input.Metrics = &metrics
+		}
+		_, err = CreateProject(input)
+		return err
+	}
+	current := map[string]interface{}{"description": existing.Description, "metrics": []models.BaseMetric{}}
+	if existing.Metrics != nil {
+		currentMetrics := *existing.Metrics
+		sort.Slice(currentMetrics, func(i, j int) bool { return currentMetrics[i].PluginName < currentMetrics[j].PluginName })
+		current["metrics"] = currentMetrics
+	}
+	changed := diffConfigValues(desired, current)
+	if len(changed) == 0 {
+		change.Action = CONFIG_ACTION_NONE
+		a.record(change)
+		return nil
+	}
+	change.Action = CONFIG_ACTION_UPDATE
+	change.Fields = changed
+	a.record(change)
+	if a.dryRun {
+		return nil
+	}
+	body := map[string]interface{}{"name": project.Name, "description": project.Description}
+	if metrics != nil {
+		body["metrics"] = metrics
+	}
+	// the project api updates the enable of its blueprint as well
+	enable := false
+	if existing.Blueprint != nil {
+		enable = existing.Blueprint.Enable
+	}
+	body["enable"] = enable
+	// the body is decoded the same way as the one from the project api
+	normalized := make(map[string]interface{})
+	if err = normalizeJson(body, &normalized); err != nil {
+		return err
+	}
+	_, err = PatchProject(project.Name, normalized, a.user)
+	return err
+}
+
+func (a *configApplier) applyBlueprint(blueprint *ConfigBlueprint) errors.Error {
+	change := &ConfigChange{Kind: CONFIG_KIND_BLUEPRINT, Name: blueprint.Name}
+	connections := make([]*plugin.BlueprintConnectionV200, len(blueprint.Connections))
+	connectionsKnown := true
+	for i, connection := range blueprint.Connections {
+		connectionId, known, err := a.resolveConnection(connection.Plugin, connection.Connection)
+		if err != nil {
+			return err
+		}
+		connectionsKnown = connectionsKnown && known
+		scopes := connection.Scopes
+		if scopes == nil {
+			scopes = make([]*plugin.BlueprintScopeV200, 0)
+		}
+		connections[i] = &plugin.BlueprintConnectionV200{Plugin: connection.Plugin, ConnectionId: connectionId, Scopes: scopes}
+	}
+	settings, err := errors.Convert01(json.Marshal(map[string]interface{}{
+		"version":          "2.0.0",
+		"createdDateAfter": blueprint.CreatedDateAfter,
+		"connections":      connections,
+	}))
+	if err != nil {
+		return err
+	}
+	labels := blueprint.Labels
+	if labels == nil {
+		labels = make([]string, 0)
+	}
+	desired := map[string]interface{}{
+		"projectName": blueprint.Project,
+		"enable":      blueprint.Enable,
+		"cronConfig":  blueprint.CronConfig,
+		"isManual":    blueprint.IsManual,
+		"skipOnFail":  blueprint.SkipOnFail,
+		"priority":    blueprint.Priority,
+		"labels":      labels,
+		"settings":    json.RawMessage(settings),
+	}
+	existing, err := findBlueprint(blueprint.Name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		change.Action = CONFIG_ACTION_CREATE
+		change.Fields = desired
+		a.record(change)
+		if a.dryRun {
+			return nil
+		}
+		created := &models.Blueprint{
+			Name:        blueprint.Name,
+			ProjectName: blueprint.Project,
+			Mode:        models.BLUEPRINT_MODE_NORMAL,
+			Enable:      blueprint.Enable,
+			CronConfig:  blueprint.CronConfig,
+			IsManual:    blueprint.IsManual,
+			SkipOnFail:  blueprint.SkipOnFail,
+			Priority:    blueprint.Priority,
+			Labels:      labels,
+			Settings:    settings,
+		}
+		err = CreateBlueprint(created)
+		change.Id = created.ID
+		return err
+	}
+	change.Id = existing.ID
+	if existing.Mode != models.BLUEPRINT_MODE_NORMAL {
+		return errors.BadInput.New(fmt.Sprintf("blueprint %s is not of the NORMAL mode", blueprint.Name))
+	}
+	current, err := toConfigMap(existing)
+	if err != nil {
+		return err
+	}
+	changed := diffConfigValues(desired, current)
+	if !connectionsKnown {
+		changed["settings"] = desired["settings"]
+	}
+	if len(changed) == 0 {
+		change.Action = CONFIG_ACTION_NONE
+		a.record(change)
+		return nil
+	}
+	change.Action = CONFIG_ACTION_UPDATE
+	change.Fields = changed
+	a.record(change)
+	if a.dryRun {
+		return nil
+	}
+	_, err = PatchBlueprint(existing.ID, changed, a.user)
+	return err
+}
+
+// resolveConnection returns the id of the connection, known is false for connections to be created in dry run
+func (a *configApplier) resolveConnection(pluginName string, name string) (id uint64, known bool, err errors.Error) {
+	if id, ok := a.connectionIds[configKey(pluginName, name)]; ok {
+		return id, id != 0, nil
+	}
+	existing, err := a.findConnection(pluginName, name)
+	if err != nil {
+		return 0, false, err
+	}
+	if existing == nil {
+		return 0, false, errors.BadInput.New(fmt.Sprintf("connection %s of %s not found", name, pluginName))
+	}
+	id, err = idOf(existing)
+	return id, err == nil, err
+}
+
+// resolveTransformationRule returns the id of the rule, known is false for rules to be created in dry run
+func (a *configApplier) resolveTransformationRule(source plugin.PluginSource, pluginName string, name string) (id uint64, known bool, err errors.Error) {
+	if id, ok := a.ruleIds[configKey(pluginName, name)]; ok {
+		return id, id != 0, nil
+	}
+	existing, err := findByName(source.TransformationRule(), name)
+	if err != nil {
+		return 0, false, err
+	}
+	if existing == nil {
+		return 0, false, errors.BadInput.New(fmt.Sprintf("transformation rule %s of %s not found", name, pluginName))
+	}
+	id, err = idOf(existing)
+	return id, err == nil, err
+}
+
+func getPluginSource(pluginName string) (plugin.PluginSource, errors.Error) {
+	pluginEntry, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("plugin %s not found", pluginName))
+	}
+	source, ok := pluginEntry.(plugin.PluginSource)
+	if !ok || source.Connection() == nil {
+		return nil, errors.BadInput.New(fmt.Sprintf("plugin %s has no connections", pluginName))
+	}
+	return source, nil
+}
+
+// callPluginApi calls the api of the plugin the same way as an http request does and returns the body of a record
+func callPluginApi(
+	pluginName string,
+	method string,
+	resourcePath string,
+	params map[string]string,
+	body map[string]interface{},
+	user *common.User,
+) (map[string]interface{}, errors.Error) {
+	pluginEntry, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	pluginApi, ok := pluginEntry.(plugin.PluginApi)
+	if !ok {
+		return nil, errors.BadInput.New(fmt.Sprintf("plugin %s has no apis", pluginName))
+	}
+	handler := pluginApi.ApiResources()[resourcePath][method]
+	if handler == nil {
+		return nil, errors.BadInput.New(fmt.Sprintf("plugin %s has no api %s %s", pluginName, method, resourcePath))
+	}
+	// the body is passed in the form decoded from json by the router
+	normalized := make(map[string]interface{})
+	if err = normalizeJson(body, &normalized); err != nil {
+		return nil, err
+	}
+	output, err := handler(&plugin.ApiResourceInput{Params: params, Body: normalized, User: user})
+	if err != nil {
+		return nil, err
+	}
+	if output == nil || output.Body == nil {
+		return nil, nil
+	}
+	if output.Status >= http.StatusBadRequest {
+		return nil, errors.HttpStatus(output.Status).New(fmt.Sprint(output.Body))
+	}
+	// only single records are of use, e.g. scopes saved by PUT are responded as a list
+	if kind := reflect.Indirect(reflect.ValueOf(output.Body)).Kind(); kind != reflect.Struct && kind != reflect.Map {
+		return nil, nil
+	}
+	return toConfigMap(output.Body)
+}
+
+// findConnection returns the existing connection of the plugin with the name, nil if not found
+func (a *configApplier) findConnection(pluginName string, name string) (map[string]interface{}, errors.Error) {
+	connections, ok := a.connections[pluginName]
+	if !ok {
+		var err errors.Error
+		connections, err = listConnections(pluginName)
+		if err != nil {
+			return nil, err
+		}
+		a.connections[pluginName] = connections
+	}
+	return connections[name], nil
+}
+
+func findConnection(pluginName string, name string) (map[string]interface{}, errors.Error) {
+	connections, err := listConnections(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	return connections[name], nil
+}
+
+// listConnections returns all connections of the plugin keyed by name
+func listConnections(pluginName string) (map[string]map[string]interface{}, errors.Error) {
+	source, err := getPluginSource(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	connections := reflect.New(reflect.SliceOf(reflect.TypeOf(source.Connection()).Elem()))
+	// connections are decrypted but secret references are kept
+	connectionHelper, err := helper.NewConnectionHelper(basicRes, nil)
+	if err != nil {
+		return nil, err
+	}
+	err = connectionHelper.List(connections.Interface())
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]map[string]interface{}, connections.Elem().Len())
+	for i := 0; i < connections.Elem().Len(); i++ {
+		connection, err := toConfigMap(connections.Elem().Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		if name, ok := connection["name"].(string); ok {
+			byName[name] = connection
+		}
+	}
+	return byName, nil
+}
+
+func findByName(model interface{}, name string) (map[string]interface{}, errors.Error) {
+	record := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+	err := db.First(record, dal.Where("name = ?", name))
+	if db.IsErrorNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toConfigMap(record)
+}
+
+func findScope(model interface{}, connectionId uint64, idField string, scopeId interface{}) (map[string]interface{}, errors.Error) {
+	record := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+	// primary keys set to the record are used as the conditions
+	err := normalizeJson(map[string]interface{}{"connectionId": connectionId, idField: scopeId}, record)
+	if err != nil {
+		return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid %s", idField))
+	}
+	err = db.First(record)
+	if db.IsErrorNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toConfigMap(record)
+}
+
+func findBlueprint(name string) (*models.Blueprint, errors.Error) {
+	var dbBlueprints []*models.DbBlueprint
+	err := db.All(&dbBlueprints, dal.Where("name = ?", name))
+	if err != nil {
+		return nil, err
+	}
+	switch len(dbBlueprints) {
+	case 0:
+		return nil, nil
+	case 1:
+		return GetBlueprint(dbBlueprints[0].ID)
+	default:
+		return nil, errors.BadInput.New(fmt.Sprintf("there are %d blueprints named %s", len(dbBlueprints), name))
+	}
+}
+
+// scopeIdField returns the json name of the primary key of the scope other than the connectionId
+func scopeIdField(scopeType reflect.Type) (string, errors.Error) {
+	var names []string
+	for name, field := range jsonFieldsOf(scopeType) {
+		if name != "connectionId" && strings.Contains(strings.ToLower(field.Tag.Get("gorm")), "primarykey") {
+			names = append(names, name)
+		}
+	}
+	if len(names) != 1 {
+		return "", errors.BadInput.New(fmt.Sprintf("unsupported scope %s", scopeType.String()))
+	}
+	return names[0], nil
+}
+
+// scopeResourcePath returns the path of the api of a single scope, the name of the scope id varies among plugins
+func scopeResourcePath(pluginName string) (resourcePath string, paramName string, err errors.Error) {
+	pluginEntry, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return "", "", err
+	}
+	if pluginApi, ok := pluginEntry.(plugin.PluginApi); ok {
+		for path := range pluginApi.ApiResources() {
+			if strings.HasPrefix(path, "connections/:connectionId/scopes/:") && strings.Count(path, "/") == 3 {
+				return path, strings.TrimPrefix(path, "connections/:connectionId/scopes/:"), nil
+			}
+		}
+	}
+	return "", "", errors.BadInput.New(fmt.Sprintf("plugin %s has no api to update a scope", pluginName))
+}
+
+// jsonFieldsOf returns the fields of the struct by their json names, fields of embedded structs are promoted
+func jsonFieldsOf(t reflect.Type) map[string]reflect.StructField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := make(map[string]reflect.StructField)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for embeddedName, embedded := range jsonFieldsOf(fieldType) {
+				if _, ok := fields[embeddedName]; !ok {
+					fields[embeddedName] = embedded
+				}
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// normalizeConfigFields turns the fields into the form decoded from json, unknown and reserved fields are rejected
+func normalizeConfigFields(fields map[string]interface{}, fieldTypes map[string]reflect.StructField, reserved ...string) (map[string]interface{}, errors.Error) {
+	for name := range fields {
+		if _, ok := fieldTypes[name]; !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("unknown field %s", name))
+		}
+		for _, r := range reserved {
+			if name == r {
+				return nil, errors.BadInput.New(fmt.Sprintf("field %s is managed by the server", name))
+			}
+		}
+	}
+	normalized := make(map[string]interface{})
+	if len(fields) == 0 {
+		return normalized, nil
+	}
+	return normalized, normalizeJson(fields, &normalized)
+}
+
+func maskSecrets(fields map[string]interface{}, fieldTypes map[string]reflect.StructField) map[string]interface{} {
+	masked := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		if isSecretField(fieldTypes[name]) {
+			value = maskedSecret
+		}
+		masked[name] = value
+	}
+	return masked
+}
+
+func isSecretField(field reflect.StructField) bool {
+	encrypt := field.Tag.Get("encrypt")
+	return encrypt == "yes" || encrypt == "true"
+}
+
+// diffConfigFields returns the desired fields differing from the existing ones
+func diffConfigFields(desired map[string]interface{}, existing map[string]interface{}) map[string]interface{} {
+	changed := make(map[string]interface{})
+	for name, value := range desired {
+		if !reflect.DeepEqual(value, existing[name]) {
+			changed[name] = value
+		}
+	}
+	return changed
+}
+
+// diffConfigValues is diffConfigFields for values not normalized yet
+func diffConfigValues(desired map[string]interface{}, existing map[string]interface{}) map[string]interface{} {
+	changed := make(map[string]interface{})
+	for name, value := range desired {
+		var normalizedDesired, normalizedExisting interface{}
+		if normalizeJson(value, &normalizedDesired) != nil || normalizeJson(existing[name], &normalizedExisting) != nil ||
+			!reflect.DeepEqual(normalizedDesired, normalizedExisting) {
+			changed[name] = value
+		}
+	}
+	return changed
+}
+
+func metricOption(option interface{}) (string, errors.Error) {
+	switch o := option.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return o, nil
+	default:
+		blob, err := json.Marshal(o)
+		if err != nil {
+			return "", errors.BadInput.Wrap(err, "invalid pluginOption")
+		}
+		return string(blob), nil
+	}
+}
+
+func idOf(record map[string]interface{}) (uint64, errors.Error) {
+	id, ok := record["id"].(float64)
+	if !ok || id <= 0 {
+		return 0, errors.Default.New("missing id in the response")
+	}
+	return uint64(id), nil
+}
+
+func toConfigMap(v interface{}) (map[string]interface{}, errors.Error) {
+	m := make(map[string]interface{})
+	return m, normalizeJson(v, &m)
+}
+
+// normalizeJson encodes v into json and decodes it into result
+func normalizeJson(v interface{}, result interface{}) errors.Error {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return errors.Default.Wrap(err, "failed to encode into json")
+	}
+	return errors.Convert(json.Unmarshal(blob, result))
+}
@@ -0,0 +1,354 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	contextimpl "github.com/apache/incubator-devlake/impls/context"
+	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfigDocument(t *testing.T) {
+	doc, err := ParseConfigDocument([]byte(`
+connections:
+  - plugin: github
+    name: github
+    endpoint: https://api.github.com/
+    token: secret
+scopes:
+  - plugin: github
+    connection: github
+    githubId: 1
+blueprints:
+  - name: bp
+    project: p
+    connections:
+      - plugin: github
+        connection: github
+        scopes:
+          - id: "1"
+`))
+	assert.Nil(t, err)
+	assert.Equal(t, "github", doc.Connections[0].Name)
+	assert.Equal(t, map[string]interface{}{"endpoint": "https://api.github.com/", "token": "secret"}, doc.Connections[0].Fields)
+	assert.Equal(t, map[string]interface{}{"githubId": 1}, doc.Scopes[0].Fields)
+	assert.Equal(t, "1", doc.Blueprints[0].Connections[0].Scopes[0].Id)
+
+	_, err = ParseConfigDocument([]byte("projects:\n  - name: p\n    unknown: 1\n"))
+	assert.NotNil(t, err)
+}
+
+type testConfigBase struct {
+	Id   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type testConfigConnection struct {
+	testConfigBase
+	Endpoint string `json:"endpoint"`
+	Token    string `json:"token" encrypt:"yes"`
+	Ignored  string `json:"-"`
+	internal string
+}
+
+func TestConfigFields(t *testing.T) {
+	fieldTypes := jsonFieldsOf(reflect.TypeOf(&testConfigConnection{}))
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, name)
+	}
+	assert.ElementsMatch(t, []string{"id", "name", "endpoint", "token"}, names)
+
+	fields, err := normalizeConfigFields(map[string]interface{}{"endpoint": "e", "token": "t"}, fieldTypes, "id", "name")
+	assert.Nil(t, err)
+	_, err = normalizeConfigFields(map[string]interface{}{"id": 1}, fieldTypes, "id", "name")
+	assert.NotNil(t, err)
+	_, err = normalizeConfigFields(map[string]interface{}{"internal": 1}, fieldTypes, "id", "name")
+	assert.NotNil(t, err)
+
+	assert.Equal(t, map[string]interface{}{"endpoint": "e", "token": maskedSecret}, maskSecrets(fields, fieldTypes))
+	assert.Equal(t, map[string]interface{}{"token": "t"}, diffConfigFields(fields, map[string]interface{}{"id": float64(1), "endpoint": "e", "token": "old"}))
+	assert.Empty(t, diffConfigValues(map[string]interface{}{"n": 1, "l": []string{"a"}}, map[string]interface{}{"n": float64(1), "l": []interface{}{"a"}}))
+}
+
+type testConfigPluginConnection struct {
+	helper.RestConnection `mapstructure:",squash"`
+	helper.AccessToken    `mapstructure:",squash"`
+}
+
+func (testConfigPluginConnection) TableName() string {
+	return "_tool_configtest_connections"
+}
+
+type testConfigPluginScope struct {
+	ConnectionId         uint64 `json:"connectionId" gorm:"primaryKey"`
+	Id                   string `json:"id" gorm:"primaryKey;type:varchar(255)"`
+	Name                 string `json:"name"`
+	TransformationRuleId uint64 `json:"transformationRuleId"`
+}
+
+func (testConfigPluginScope) TableName() string {
+	return "_tool_configtest_scopes"
+}
+
+// testConfigPlugin manages its connections the same way as the data source plugins do
+type testConfigPlugin struct{}
+
+func (p testConfigPlugin) Description() string {
+	return "plugin for testing configuration documents"
+}
+
+func (p testConfigPlugin) RootPkgPath() string {
+	return "github.com/apache/incubator-devlake/server/services"
+}
+
+func (p testConfigPlugin) Connection() interface{} {
+	return &testConfigPluginConnection{}
+}
+
+func (p testConfigPlugin) Scope() interface{} {
+	return &testConfigPluginScope{}
+}
+
+func (p testConfigPlugin) TransformationRule() interface{} {
+	return nil
+}
+
+func (p testConfigPlugin) ApiResources() map[string]map[string]plugin.ApiResourceHandler {
+	connectionHelper, err := helper.NewConnectionHelper(basicRes, nil)
+	if err != nil {
+		panic(err)
+	}
+	return map[string]map[string]plugin.ApiResourceHandler{
+		"connections": {
+			"POST": func(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+				connection := &testConfigPluginConnection{}
+				err := connectionHelper.Create(connection, input)
+				if err != nil {
+					return nil, err
+				}
+				return &plugin.ApiResourceOutput{Body: connection, Status: http.StatusOK}, nil
+			},
+		},
+		"connections/:connectionId/scopes": {
+			"PUT": func(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+				var scopes []*testConfigPluginScope
+				err := normalizeJson(input.Body["data"], &scopes)
+				if err != nil {
+					return nil, err
+				}
+				for _, scope := range scopes {
+					if err = db.CreateOrUpdate(scope); err != nil {
+						return nil, err
+					}
+				}
+				return &plugin.ApiResourceOutput{Body: scopes, Status: http.StatusOK}, nil
+			},
+		},
+		"connections/:connectionId": {
+			"PATCH": func(input *plugin.ApiResourceInput) (*plugin.ApiResourceOutput, errors.Error) {
+				connection := &testConfigPluginConnection{}
+				err := connectionHelper.Patch(connection, input)
+				if err != nil {
+					return nil, err
+				}
+				return &plugin.ApiResourceOutput{Body: connection, Status: http.StatusOK}, nil
+			},
+		},
+	}
+}
+
+func (p testConfigPlugin) MakeDataSourcePipelinePlanV200(
+	connectionId uint64,
+	scopes []*plugin.BlueprintScopeV200,
+	syncPolicy plugin.BlueprintSyncPolicy,
+) (plugin.PipelinePlan, []plugin.Scope, errors.Error) {
+	return plugin.PipelinePlan{}, nil, nil
+}
+
+const testConfigDocument = `
+connections:
+  - plugin: configtest
+    name: c
+    endpoint: https://example.com/
+    token: secret
+scopes:
+  - plugin: configtest
+    connection: c
+    id: "1"
+    name: repo
+projects:
+  - name: p
+    description: project
+blueprints:
+  - name: bp
+    project: p
+    isManual: true
+    connections:
+      - plugin: configtest
+        connection: c
+        scopes:
+          - id: "1"
+`
+
+// setupConfigApplyTest points the services at a sqlite database with the fake plugin `configtest` registered
+func setupConfigApplyTest(t *testing.T) {
+	setupClusterTest(t)
+	cfg := viper.New()
+	cfg.Set(plugin.EncodeKeyEnvStr, "config-test-key")
+	keyring, err := plugin.LoadEncryptionKeyring(cfg.GetString)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	// the application wide keyring can't be read before it is set, it is left to the following tests
+	plugin.SetEncryptionKeyring(keyring)
+	originalBasicRes, originalCronManager := basicRes, cronManager
+	basicRes, cronManager = contextimpl.NewDefaultBasicRes(cfg, logger, db), cron.New()
+	t.Cleanup(func() {
+		basicRes, cronManager = originalBasicRes, originalCronManager
+	})
+	assert.Nil(t, plugin.RegisterPlugin("configtest", testConfigPlugin{}))
+	for _, table := range []interface{}{
+		&testConfigPluginConnection{}, &testConfigPluginScope{}, &models.Project{}, &models.ProjectMetricSetting{}, &models.DbBlueprint{},
+		&models.DbBlueprintLabel{}, &models.AuditLog{}, &crossdomain.ProjectMapping{},
+	} {
+		assert.Nil(t, db.AutoMigrate(table))
+	}
+}
+
+func configActions(result *ConfigApplyResult) map[string]string {
+	actions := make(map[string]string)
+	for _, change := range result.Changes {
+		actions[change.Kind+":"+change.Name] = change.Action
+	}
+	return actions
+}
+
+func TestApplyConfigTwice(t *testing.T) {
+	setupConfigApplyTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	assert.Nil(t, err)
+
+	result, err := ApplyConfig(doc, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_CREATE,
+		"scope:repo":   CONFIG_ACTION_CREATE,
+		"project:p":    CONFIG_ACTION_CREATE,
+		"blueprint:bp": CONFIG_ACTION_CREATE,
+	}, configActions(result))
+	assert.Equal(t, maskedSecret, result.Changes[0].Fields["token"])
+
+	// applying the same document again changes nothing
+	result, err = ApplyConfig(doc, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_NONE,
+		"scope:repo":   CONFIG_ACTION_NONE,
+		"project:p":    CONFIG_ACTION_NONE,
+		"blueprint:bp": CONFIG_ACTION_NONE,
+	}, configActions(result))
+
+	// only the changed resource is updated
+	doc.Projects[0].Description = "changed"
+	result, err = ApplyConfig(doc, false, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_NONE,
+		"scope:repo":   CONFIG_ACTION_NONE,
+		"project:p":    CONFIG_ACTION_UPDATE,
+		"blueprint:bp": CONFIG_ACTION_NONE,
+	}, configActions(result))
+	project, err := GetProject("p")
+	assert.Nil(t, err)
+	assert.Equal(t, "changed", project.Description)
+}
+
+func TestApplyConfigDryRun(t *testing.T) {
+	setupConfigApplyTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	assert.Nil(t, err)
+	counts := func() []int64 {
+		result := make([]int64, 0)
+		for _, table := range []interface{}{&testConfigPluginConnection{}, &testConfigPluginScope{}, &models.Project{}, &models.DbBlueprint{}, &models.AuditLog{}} {
+			count, err := db.Count(dal.From(table))
+			assert.Nil(t, err)
+			result = append(result, count)
+		}
+		return result
+	}
+
+	result, err := ApplyConfig(doc, true, nil)
+	assert.Nil(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_CREATE,
+		"scope:repo":   CONFIG_ACTION_CREATE,
+		"project:p":    CONFIG_ACTION_CREATE,
+		"blueprint:bp": CONFIG_ACTION_CREATE,
+	}, configActions(result))
+	assert.Equal(t, []int64{0, 0, 0, 0, 0}, counts())
+
+	_, err = ApplyConfig(doc, false, nil)
+	assert.Nil(t, err)
+	before := counts()
+	doc.Connections[0].Fields["endpoint"] = "https://changed.example.com/"
+	doc.Projects[0].Description = "changed"
+	result, err = ApplyConfig(doc, true, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_UPDATE,
+		"scope:repo":   CONFIG_ACTION_NONE,
+		"project:p":    CONFIG_ACTION_UPDATE,
+		"blueprint:bp": CONFIG_ACTION_NONE,
+	}, configActions(result))
+	assert.Equal(t, before, counts())
+	connection, err := findConnection("configtest", "c")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com/", connection["endpoint"])
+	project, err := GetProject("p")
+	assert.Nil(t, err)
+	assert.Equal(t, "project", project.Description)
+}
+
+func TestApplyConfigPartialResult(t *testing.T) {
+	setupConfigApplyTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	assert.Nil(t, err)
+	doc.Blueprints[0].Connections[0].Connection = "missing"
+
+	// changes made before the failure are reported along with the error
+	result, err := ApplyConfig(doc, false, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_CREATE,
+		"scope:repo":   CONFIG_ACTION_CREATE,
+		"project:p":    CONFIG_ACTION_CREATE,
+	}, configActions(result))
+}
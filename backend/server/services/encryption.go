@@ -0,0 +1,256 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"gorm.io/gorm/schema"
+)
+
+// DEFAULT_ROTATION_BATCH_SIZE is the number of rows re-encrypted per batch
+const DEFAULT_ROTATION_BATCH_SIZE = 500
+
+// EncryptionKeys describes the keyring without exposing the keys themselves
+type EncryptionKeys struct {
+	PrimaryKeyId string   `json:"primaryKeyId"`
+	KeyIds       []string `json:"keyIds"`
+}
+
+// EncryptedTable describes a table with encrypted columns
+type EncryptedTable struct {
+	Table   string
+	Columns []string
+}
+
+// EncryptionRotationResult is the outcome of re-encrypting a table
+type EncryptionRotationResult struct {
+	Table       string   `json:"table"`
+	Columns     []string `json:"columns"`
+	Scanned     int      `json:"scanned"`
+	Reencrypted int      `json:"reencrypted"`
+}
+
+// GetEncryptionKeys returns ids of keys in the keyring
+func GetEncryptionKeys() *EncryptionKeys {
+	keyring := plugin.GetEncryptionKeyring()
+	return &EncryptionKeys{
+		PrimaryKeyId: keyring.PrimaryKeyId(),
+		KeyIds:       keyring.KeyIds(),
+	}
+}
+
+// RotateEncryptionKey re-encrypts every encrypted column with the primary key in batches,
+// values already encrypted by the primary key are left untouched so it is safe to run it again
+// after a failure
+func RotateEncryptionKey(batchSize int) ([]*EncryptionRotationResult, errors.Error) {
+	if batchSize <= 0 {
+		batchSize = DEFAULT_ROTATION_BATCH_SIZE
+	}
+	keyring := plugin.GetEncryptionKeyring()
+	var results []*EncryptionRotationResult
+	for _, table := range GetEncryptedTables() {
+		result, err := reencryptTable(db, keyring, table, batchSize)
+		if result != nil {
+			results = append(results, result)
+		}
+		if err != nil {
+			return results, err
+		}
+		logger.Info("re-encrypted %d of %d rows in %s", result.Reencrypted, result.Scanned, table.Table)
+	}
+	return results, nil
+}
+
+// GetEncryptedTables collects tables with encrypted columns from the framework and all loaded plugins
+func GetEncryptedTables() []*EncryptedTable {
+	tablers := []dal.Tabler{
+		&models.DbBlueprint{},
+		&models.DbPipeline{},
+		&models.Task{},
+	}
+	for _, pluginInst := range plugin.AllPlugins() {
+		if pluginModel, ok := pluginInst.(plugin.PluginModel); ok {
+			tablers = append(tablers, pluginModel.GetTablesInfo()...)
+		}
+		if pluginSource, ok := pluginInst.(plugin.PluginSource); ok {
+			if connection, ok := pluginSource.Connection().(dal.Tabler); ok {
+				tablers = append(tablers, connection)
+			}
+		}
+	}
+	tables := make(map[string]*EncryptedTable)
+	for _, model := range tablers {
+		columns := encryptedColumns(reflect.TypeOf(model))
+		if len(columns) == 0 {
+			continue
+		}
+		tables[model.TableName()] = &EncryptedTable{Table: model.TableName(), Columns: columns}
+	}
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := make([]*EncryptedTable, 0, len(names))
+	for _, name := range names {
+		result = append(result, tables[name])
+	}
+	return result
+}
+
+// encryptedColumns returns columns of fields tagged with `encrypt:"yes"` or `gorm:"serializer:encdec"`
+func encryptedColumns(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		gormTag := schema.ParseTagSetting(field.Tag.Get("gorm"), ";")
+		if _, ignored := gormTag["-"]; ignored {
+			continue
+		}
+		if field.Anonymous {
+			columns = append(columns, encryptedColumns(field.Type)...)
+			continue
+		}
+		if !field.IsExported() || field.Type.Kind() != reflect.String {
+			continue
+		}
+		encryptTag := field.Tag.Get("encrypt")
+		if encryptTag != "yes" && encryptTag != "true" && !strings.EqualFold(gormTag["SERIALIZER"], "encdec") {
+			continue
+		}
+		column := gormTag["COLUMN"]
+		if column == "" {
+			column = schema.NamingStrategy{}.ColumnName("", field.Name)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func reencryptTable(db dal.Dal, keyring *plugin.EncryptionKeyring, table *EncryptedTable, batchSize int) (*EncryptionRotationResult, errors.Error) {
+	result := &EncryptionRotationResult{Table: table.Table, Columns: table.Columns}
+	var lastId uint64
+	for {
+		scanned, err := reencryptBatch(db, keyring, table, batchSize, &lastId, result)
+		if err != nil {
+			return result, err
+		}
+		if scanned == 0 {
+			return result, nil
+		}
+	}
+}
+
+// reencryptBatch reads and rewrites the rows after lastId within one transaction, rows are locked while being read
+// and only updated when they still hold the ciphertext that was read, so concurrent writes are never overwritten
+func reencryptBatch(
+	db dal.Dal,
+	keyring *plugin.EncryptionKeyring,
+	table *EncryptedTable,
+	batchSize int,
+	lastId *uint64,
+	result *EncryptionRotationResult,
+) (scanned int, err errors.Error) {
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				err = errors.Default.Wrap(rollbackErr, "failed to rollback")
+			}
+		}
+	}()
+	cursor, err := tx.Cursor(
+		dal.Select("id, "+strings.Join(table.Columns, ", ")),
+		dal.From(table.Table),
+		dal.Where("id > ?", *lastId),
+		dal.Orderby("id"),
+		dal.Limit(batchSize),
+		dal.Lock(true, false),
+	)
+	if err != nil {
+		return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to read %s", table.Table))
+	}
+	var ids []uint64
+	var rows [][]sql.NullString
+	for cursor.Next() {
+		var id uint64
+		values := make([]sql.NullString, len(table.Columns))
+		dest := []interface{}{&id}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if scanErr := cursor.Scan(dest...); scanErr != nil {
+			cursor.Close()
+			return 0, errors.Convert(scanErr)
+		}
+		ids = append(ids, id)
+		rows = append(rows, values)
+	}
+	cursor.Close()
+	for i, id := range ids {
+		var sets, conditions []string
+		var setParams, conditionParams []interface{}
+		for j, column := range table.Columns {
+			value := rows[i][j]
+			if !value.Valid || value.String == "" {
+				continue
+			}
+			reencrypted, changed, decryptErr := keyring.Reencrypt(value.String)
+			if decryptErr != nil {
+				return 0, errors.Default.Wrap(decryptErr, fmt.Sprintf("failed to decrypt %s.%s of row %d", table.Table, column, id))
+			}
+			if changed {
+				sets = append(sets, column+" = ?")
+				setParams = append(setParams, reencrypted)
+				conditions = append(conditions, column+" = ?")
+				conditionParams = append(conditionParams, value.String)
+			}
+		}
+		if len(sets) > 0 {
+			// update with raw sql to keep the serializer from encrypting the value again, rows rewritten since
+			// they were read no longer match the old ciphertext and are skipped
+			params := append(append(setParams, id), conditionParams...)
+			err = tx.Exec(
+				fmt.Sprintf("UPDATE %s SET %s WHERE id = ? AND %s", table.Table, strings.Join(sets, ", "), strings.Join(conditions, " AND ")),
+				params...,
+			)
+			if err != nil {
+				return 0, errors.Default.Wrap(err, fmt.Sprintf("failed to update row %d of %s", id, table.Table))
+			}
+			result.Reencrypted++
+		}
+		result.Scanned++
+		*lastId = id
+	}
+	return len(ids), tx.Commit()
+}
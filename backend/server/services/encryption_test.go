@@ -0,0 +1,69 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+	"github.com/stretchr/testify/assert"
+)
+
+type testEncryptedConnection struct {
+	helper.RestConnection `mapstructure:",squash"`
+	helper.AccessToken    `mapstructure:",squash"`
+	AppSecret             string `encrypt:"yes" gorm:"column:app_secret_value"`
+	Ignored               string `encrypt:"yes" gorm:"-"`
+}
+
+func TestEncryptedColumns(t *testing.T) {
+	assert.Equal(t, []string{"token", "app_secret_value"}, encryptedColumns(reflect.TypeOf(&testEncryptedConnection{})))
+	assert.Equal(t, []string{"plan", "settings"}, encryptedColumns(reflect.TypeOf(&models.DbBlueprint{})))
+	assert.Equal(t, []string{"options"}, encryptedColumns(reflect.TypeOf(&models.Task{})))
+}
+
+func TestReencryptTable(t *testing.T) {
+	setupClusterTest(t)
+	oldKeyring, err := plugin.NewEncryptionKeyring(map[string]string{"old": "old-key"}, "old", "")
+	assert.Nil(t, err)
+	keyring, err := plugin.NewEncryptionKeyring(map[string]string{"old": "old-key", "new": "new-key"}, "new", "")
+	assert.Nil(t, err)
+	assert.Nil(t, db.Exec("CREATE TABLE _test_secrets (id INTEGER PRIMARY KEY, secret TEXT)"))
+	stale, err := oldKeyring.Encrypt("stale")
+	assert.Nil(t, err)
+	current, err := keyring.Encrypt("current")
+	assert.Nil(t, err)
+	assert.Nil(t, db.Exec("INSERT INTO _test_secrets (id, secret) VALUES (1, ?), (2, NULL), (3, ?)", stale, current))
+
+	result, err := reencryptTable(db, keyring, &EncryptedTable{Table: "_test_secrets", Columns: []string{"secret"}}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, result.Scanned)
+	assert.Equal(t, 1, result.Reencrypted)
+
+	var secrets []string
+	assert.Nil(t, db.Pluck("secret", &secrets, dal.From("_test_secrets"), dal.Where("secret IS NOT NULL"), dal.Orderby("id")))
+	assert.Equal(t, current, secrets[1])
+	assert.False(t, keyring.NeedsRotation(secrets[0]))
+	plainText, err := keyring.Decrypt(secrets[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "stale", plainText)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/apache/incubator-devlake/core/models/migrationscripts"
 	"github.com/apache/incubator-devlake/core/plugin"
 	"github.com/apache/incubator-devlake/core/runner"
+	"github.com/apache/incubator-devlake/helpers/remoteplugin"
 	"github.com/apache/incubator-devlake/impls/dalgorm"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 	"github.com/go-playground/validator/v10"
@@ -42,6 +43,7 @@ var migrator plugin.Migrator
 var cronManager *cron.Cron
 var cronLocker sync.Mutex
 var vld *validator.Validate
+var releaseMigrationLease func()
 
 const failToCreateCronJob = "created cron job failed"
 
@@ -55,6 +57,12 @@ func InitResources() {
 	cfg = basicRes.GetConfigReader()
 	logger = basicRes.GetLogger()
 	db = basicRes.GetDal()
+	initInstance()
+
+	// instances sharing the same database have to apply migration scripts one at a time
+	if clusterMode {
+		releaseMigrationLease = holdLease(LEASE_MIGRATION)
+	}
 
 	// initialize db migrator
 	migrator, err = runner.InitMigrator(basicRes)
@@ -75,20 +83,26 @@ func GetMigrator() plugin.Migrator {
 	return migrator
 }
 
-// Init the services module
-func Init() {
+// InitMigration initializes resources, loads plugins and registers all migration scripts without applying them
+func InitMigration() {
 	InitResources()
 
 	// lock the database to avoid multiple devlake instances from sharing the same one
-	lockDb()
+	if !clusterMode {
+		lockDb()
+	}
 
-	var err error
 	// now, load the plugins
-	err = runner.LoadPlugins(basicRes)
+	err := runner.LoadPlugins(basicRes)
 	if err != nil {
 		logger.Error(err, "failed to load plugins")
 		panic(err)
 	}
+	err = remoteplugin.LoadRemotePlugins(basicRes)
+	if err != nil {
+		logger.Error(err, "failed to load remote plugins")
+		panic(err)
+	}
 
 	// pull migration scripts from plugins to migrator
 	for pluginName, pluginInst := range plugin.AllPlugins() {
@@ -96,11 +110,16 @@ func Init() {
 			migrator.Register(migratable.MigrationScripts(), pluginName)
 		}
 	}
+}
+
+// Init the services module
+func Init() {
+	InitMigration()
 
 	// check if there are pending migration
 	forceMigration := cfg.GetBool("FORCE_MIGRATION")
 	if !migrator.HasPendingScripts() || forceMigration {
-		err = ExecuteMigration()
+		err := ExecuteMigration()
 		if err != nil {
 			panic(err)
 		}
@@ -115,6 +134,15 @@ func ExecuteMigration() errors.Error {
 	if err != nil {
 		return err
 	}
+	if releaseMigrationLease != nil {
+		// online scripts keep running in the background, other instances must not apply them meanwhile
+		release := releaseMigrationLease
+		releaseMigrationLease = nil
+		go func() {
+			migrator.Wait()
+			release()
+		}()
+	}
 
 	// cronjob for blueprint triggering
 	location := cron.WithLocation(time.UTC)
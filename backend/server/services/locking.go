@@ -18,6 +18,7 @@ limitations under the License.
 package services
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -51,6 +52,15 @@ func lockDatabase(db dal.Dal) errors.Error {
 		return err
 	}
 	// 2. obtain the lock
+	if db.Dialect() == "sqlite" {
+		// sqlite locks the whole database file for any write, a long last transaction would block ourselves
+		err = lockSqliteDatabase(db)
+		if err != nil {
+			return err
+		}
+		lockingHistory.Succeeded = true
+		return db.Update(lockingHistory)
+	}
 	err = db.AutoMigrate(&models.LockingStub{})
 	if err != nil {
 		return err
@@ -66,6 +76,8 @@ func lockDatabase(db dal.Dal) errors.Error {
 			c <- lockingTx.Exec("LOCK TABLE _devlake_locking_stub WRITE")
 		case "postgres":
 			c <- lockingTx.Exec("LOCK TABLE _devlake_locking_stub IN EXCLUSIVE MODE")
+		default:
+			c <- errors.Default.New(fmt.Sprintf("unsupported dialect %s", db.Dialect()))
 		}
 	}()
 
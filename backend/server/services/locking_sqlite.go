@@ -0,0 +1,60 @@
+//go:build !windows
+
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// sqliteLockFile holds the advisory lock for the whole life of the process
+var sqliteLockFile *os.File
+
+// lockSqliteDatabase prevents multiple devlake instances from sharing the same sqlite database file by acquiring an
+// exclusive advisory lock on a sibling `.lock` file. The database is always a file since runner.SqliteDsn rejects
+// in-memory ones.
+func lockSqliteDatabase(db dal.Dal) errors.Error {
+	var files []string
+	err := db.Pluck("file", &files, dal.From("pragma_database_list"), dal.Where("name = ?", "main"))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 || files[0] == "" {
+		return errors.Default.New("failed to locate the sqlite database file to lock")
+	}
+	f, e := os.OpenFile(files[0]+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if e != nil {
+		return errors.Convert(e)
+	}
+	e = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if e != nil {
+		f.Close()
+		return errors.Default.Wrap(
+			errors.Convert(e),
+			fmt.Sprintf("locking %s.lock failed, the database might be locked by another devlake instance", files[0]),
+		)
+	}
+	sqliteLockFile = f
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/apache/incubator-devlake/helpers/migrationhelper"
+)
+
+// GetMigrationScripts returns all registered migration scripts grouped by plugin, the framework
+// scripts are listed under `Framework`
+func GetMigrationScripts() map[string][]*plugin.MigrationScriptInfo {
+	scripts := make(map[string][]*plugin.MigrationScriptInfo)
+	for _, script := range migrator.Scripts() {
+		scripts[script.Comment] = append(scripts[script.Comment], script)
+	}
+	return scripts
+}
+
+// DryRunMigration returns the SQL statements of pending migration scripts grouped by plugin
+func DryRunMigration() (map[string][]*plugin.MigrationDryRunResult, errors.Error) {
+	results, err := migrator.DryRun()
+	if err != nil {
+		return nil, err
+	}
+	grouped := make(map[string][]*plugin.MigrationDryRunResult)
+	for _, result := range results {
+		grouped[result.Comment] = append(grouped[result.Comment], result)
+	}
+	return grouped, nil
+}
+
+// RollbackMigration reverts all executed migration scripts newer than the specified version
+func RollbackMigration(version uint64) errors.Error {
+	if version == 0 {
+		return errors.BadInput.New("version is required")
+	}
+	return migrator.Rollback(version)
+}
+
+// GetOnlineMigrationProgresses returns the progresses of online migrations since the server started
+func GetOnlineMigrationProgresses() []*migrationhelper.OnlineMigrationProgress {
+	return migrationhelper.GetOnlineMigrationProgresses()
+}
@@ -0,0 +1,279 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+)
+
+// OIDC id tokens are verified against the signing keys published by the issuer (OIDC_ISSUER), and must be
+// issued to OIDC_CLIENT_ID. The role of the caller is mapped from the OIDC_ROLE_CLAIM claim (groups by
+// default) by OIDC_ADMIN_GROUPS and OIDC_OPERATOR_GROUPS, callers in neither get OIDC_DEFAULT_ROLE.
+const oidcClockSkew = time.Minute
+const oidcKeysTTL = time.Hour
+
+var oidcHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+}
+
+type oidcKeySet struct {
+	mu        sync.Mutex
+	issuer    string
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	client    *http.Client
+}
+
+var oidcKeys *oidcKeySet
+var oidcKeysOnce sync.Once
+
+func getOidcKeySet() *oidcKeySet {
+	oidcKeysOnce.Do(func() {
+		oidcKeys = &oidcKeySet{
+			issuer: strings.TrimSuffix(cfg.GetString("OIDC_ISSUER"), "/"),
+			client: &http.Client{Timeout: 10 * time.Second},
+		}
+	})
+	return oidcKeys
+}
+
+// key returns the signing key by id, keys are fetched again when the id is unknown since the issuer
+// may have rotated them, but not more than once a minute
+func (s *oidcKeySet) key(kid string) (*rsa.PublicKey, errors.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[kid]
+	if ok && time.Since(s.fetchedAt) < oidcKeysTTL {
+		return key, nil
+	}
+	if !ok && time.Since(s.fetchedAt) < time.Minute {
+		return nil, errors.Unauthorized.New(fmt.Sprintf("unknown signing key %s", kid))
+	}
+	keys, err := s.fetch()
+	if err != nil {
+		return nil, err
+	}
+	s.keys, s.fetchedAt = keys, time.Now()
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, errors.Unauthorized.New(fmt.Sprintf("unknown signing key %s", kid))
+	}
+	return key, nil
+}
+
+func (s *oidcKeySet) fetch() (map[string]*rsa.PublicKey, errors.Error) {
+	var discovery struct {
+		JwksUri string `json:"jwks_uri"`
+	}
+	err := s.getJson(s.issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = s.getJson(discovery.JwksUri, &jwks)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, e1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, e2 := base64.RawURLEncoding.DecodeString(k.E)
+		if e1 != nil || e2 != nil {
+			logger.Warn(nil, "skipped malformed signing key %s of %s", k.Kid, s.issuer)
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	return keys, nil
+}
+
+func (s *oidcKeySet) getJson(url string, v interface{}) errors.Error {
+	res, err := s.client.Get(url)
+	if err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("failed to request %s", url))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return errors.Default.New(fmt.Sprintf("unexpected status %d from %s", res.StatusCode, url))
+	}
+	return errors.Convert(json.NewDecoder(res.Body).Decode(v))
+}
+
+func authenticateOidcToken(token string) (*Principal, errors.Error) {
+	keySet := getOidcKeySet()
+	if keySet.issuer == "" {
+		return nil, errors.Unauthorized.New("bearer token is not accepted since OIDC_ISSUER is not configured")
+	}
+	claims, err := verifyOidcToken(token, keySet.issuer, cfg.GetString("OIDC_CLIENT_ID"), keySet.key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	role := oidcRole(claims)
+	if role == "" {
+		return nil, errors.Forbidden.New("no role granted to the caller")
+	}
+	name, _ := claims["email"].(string)
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+	return &Principal{Name: name, Role: role, AuthMethod: AUTH_METHOD_OIDC}, nil
+}
+
+// verifyOidcToken checks the signature and the standard claims of the id token and returns its claims
+func verifyOidcToken(
+	token string,
+	issuer string,
+	audience string,
+	getKey func(kid string) (*rsa.PublicKey, errors.Error),
+	now time.Time,
+) (map[string]interface{}, errors.Error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Unauthorized.New("malformed bearer token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJwtPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	hash, ok := oidcHashes[header.Alg]
+	if !ok {
+		return nil, errors.Unauthorized.New(fmt.Sprintf("unsupported signing algorithm %s", header.Alg))
+	}
+	key, err := getKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, e := base64.RawURLEncoding.DecodeString(parts[2])
+	if e != nil {
+		return nil, errors.Unauthorized.New("malformed signature")
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, hash, hasher.Sum(nil), signature) != nil {
+		return nil, errors.Unauthorized.New("invalid signature")
+	}
+	claims := make(map[string]interface{})
+	if err := decodeJwtPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != issuer {
+		return nil, errors.Unauthorized.New(fmt.Sprintf("unexpected issuer %s", iss))
+	}
+	if !oidcAudienceContains(claims["aud"], audience) {
+		return nil, errors.Unauthorized.New("token is not issued to devlake")
+	}
+	exp, _ := claims["exp"].(float64)
+	if now.Add(-oidcClockSkew).After(time.Unix(int64(exp), 0)) {
+		return nil, errors.Unauthorized.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, errors.Unauthorized.New("token not valid yet")
+	}
+	return claims, nil
+}
+
+func decodeJwtPart(part string, v interface{}) errors.Error {
+	decoded, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.Unauthorized.New("malformed bearer token")
+	}
+	if json.Unmarshal(decoded, v) != nil {
+		return errors.Unauthorized.New("malformed bearer token")
+	}
+	return nil
+}
+
+func oidcAudienceContains(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// oidcRole returns the highest role granted by the groups of the caller
+func oidcRole(claims map[string]interface{}) string {
+	claim := cfg.GetString("OIDC_ROLE_CLAIM")
+	if claim == "" {
+		claim = "groups"
+	}
+	// empty names are skipped on both sides, otherwise an unset list of groups would match callers in an empty group
+	groups := make(map[string]bool)
+	addGroup := func(group string) {
+		if group = strings.TrimSpace(group); group != "" {
+			groups[group] = true
+		}
+	}
+	switch values := claims[claim].(type) {
+	case string:
+		addGroup(values)
+	case []interface{}:
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				addGroup(s)
+			}
+		}
+	}
+	for _, mapping := range []struct{ config, role string }{
+		{"OIDC_ADMIN_GROUPS", ROLE_ADMIN},
+		{"OIDC_OPERATOR_GROUPS", ROLE_OPERATOR},
+	} {
+		for _, group := range strings.Split(cfg.GetString(mapping.config), ",") {
+			if group = strings.TrimSpace(group); group != "" && groups[group] {
+				return mapping.role
+			}
+		}
+	}
+	role := cfg.GetString("OIDC_DEFAULT_ROLE")
+	if _, ok := roleLevels[role]; !ok {
+		return ""
+	}
+	return role
+}
@@ -0,0 +1,113 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hasher.Sum(nil))
+	assert.Nil(t, err)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyOidcToken(t *testing.T) {
+	key, e := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, e)
+	other, e := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, e)
+	getKey := func(kid string) (*rsa.PublicKey, errors.Error) {
+		if kid != "k1" {
+			return nil, errors.Unauthorized.New("unknown key")
+		}
+		return &key.PublicKey, nil
+	}
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   "https://idp.example.com/",
+			"aud":   []string{"devlake", "other"},
+			"exp":   now.Add(time.Hour).Unix(),
+			"sub":   "u1",
+			"email": "u1@example.com",
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	verified, err := verifyOidcToken(signTestToken(t, key, claims(nil)), "https://idp.example.com", "devlake", getKey, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "u1@example.com", verified["email"])
+
+	for name, token := range map[string]string{
+		"wrong key":      signTestToken(t, other, claims(nil)),
+		"wrong issuer":   signTestToken(t, key, claims(map[string]interface{}{"iss": "https://evil.example.com"})),
+		"wrong audience": signTestToken(t, key, claims(map[string]interface{}{"aud": "other"})),
+		"expired":        signTestToken(t, key, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not yet valid":  signTestToken(t, key, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"malformed":      "a.b",
+	} {
+		_, err = verifyOidcToken(token, "https://idp.example.com", "devlake", getKey, now)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestPrincipalHasRole(t *testing.T) {
+	operator := &Principal{Name: "u1", Role: ROLE_OPERATOR}
+	assert.True(t, operator.HasRole(ROLE_VIEWER))
+	assert.True(t, operator.HasRole(ROLE_OPERATOR))
+	assert.False(t, operator.HasRole(ROLE_ADMIN))
+	assert.False(t, (&Principal{Name: "u2", Role: "unknown"}).HasRole(ROLE_VIEWER))
+	assert.False(t, (*Principal)(nil).HasRole(ROLE_VIEWER))
+}
+
+func TestOidcRole(t *testing.T) {
+	originalCfg := cfg
+	c := viper.New()
+	cfg = c
+	t.Cleanup(func() {
+		cfg = originalCfg
+	})
+	c.Set("OIDC_ADMIN_GROUPS", "admins, ")
+	c.Set("OIDC_DEFAULT_ROLE", ROLE_VIEWER)
+
+	assert.Equal(t, ROLE_ADMIN, oidcRole(map[string]interface{}{"groups": []interface{}{"devs", "admins"}}))
+	assert.Equal(t, ROLE_VIEWER, oidcRole(map[string]interface{}{"groups": "devs"}))
+	// neither the unset operator groups nor the trailing comma of admin groups match empty groups of the caller
+	assert.Equal(t, ROLE_VIEWER, oidcRole(map[string]interface{}{"groups": []interface{}{""}}))
+	assert.Equal(t, ROLE_VIEWER, oidcRole(map[string]interface{}{"groups": " "}))
+	assert.Equal(t, ROLE_VIEWER, oidcRole(map[string]interface{}{}))
+}
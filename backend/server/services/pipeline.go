@@ -71,7 +71,7 @@ func pipelineServiceInit() {
 			panic(err)
 		}
 		watchTemporalPipelines()
-	} else {
+	} else if !clusterMode {
 		// standalone mode: reset pipeline status
 		err := db.UpdateColumn(
 			&models.DbPipeline{},
@@ -95,6 +95,10 @@ func pipelineServiceInit() {
 	if err != nil {
 		panic(err)
 	}
+	if clusterMode {
+		// pipelines of crashed instances would be taken over by the leader
+		go runClusterLoop()
+	}
 
 	var pipelineMaxParallel = cfg.GetInt64("PIPELINE_MAX_PARALLEL")
 	if pipelineMaxParallel < 0 {
@@ -104,6 +108,8 @@ func pipelineServiceInit() {
 		globalPipelineLog.Warn(nil, `pipelineMaxParallel=0 means pipeline will be run No Limit`)
 		pipelineMaxParallel = 10000
 	}
+	pipelineMaxParallelPerConnection = cfg.GetInt("PIPELINE_MAX_PARALLEL_PER_CONNECTION")
+	pipelineMaxParallelPerBlueprint = cfg.GetInt("PIPELINE_MAX_PARALLEL_PER_BLUEPRINT")
 	// run pipeline with independent goroutine
 	go RunPipelineInQueue(pipelineMaxParallel)
 }
@@ -137,6 +143,10 @@ func GetPipelines(query *PipelineQuery) ([]*models.Pipeline, int64, errors.Error
 		pipeline := parsePipeline(dbPipeline)
 		pipelines = append(pipelines, pipeline)
 	}
+	err = fillQueuePositions(pipelines)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	return pipelines, i, nil
 }
@@ -185,40 +195,24 @@ func RunPipelineInQueue(pipelineMaxParallel int64) {
 		dbPipeline := &models.DbPipeline{}
 		for {
 			cronLocker.Lock()
-			// prepare query to find an appropriate pipeline to execute
-			err := db.First(dbPipeline,
-				dal.Where("status IN ?", []string{models.TASK_CREATED, models.TASK_RERUN}),
-				dal.Join(
-					`left join _devlake_pipeline_labels ON
-						_devlake_pipeline_labels.pipeline_id = _devlake_pipelines.id AND
-						_devlake_pipeline_labels.name LIKE 'parallel/%' AND
-						_devlake_pipeline_labels.name in ?`,
-					runningParallelLabels,
-				),
-				dal.Groupby("id"),
-				dal.Having("count(_devlake_pipeline_labels.name)=0"),
-				dal.Select("id"),
-				dal.Orderby("id ASC"),
-				dal.Limit(1),
-			)
-			if err != nil && !db.IsErrorNotFound(err) {
+			// find an appropriate pipeline to execute
+			pipelineId, err := nextPendingPipeline(runningParallelLabels)
+			claimed := false
+			if err == nil && pipelineId != 0 {
+				// mark the pipeline running, it might have been claimed by another instance in the meantime
+				dbPipeline.ID = pipelineId
+				claimed, err = claimPipeline(pipelineId)
+			}
+			if err != nil {
 				globalPipelineLog.Error(err, "dequeue failed")
 			}
 			cronLocker.Unlock()
-			if !db.IsErrorNotFound(err) {
+			if claimed {
 				break
 			}
-			time.Sleep(time.Second)
-		}
-
-		// mark the pipeline running
-		err = db.UpdateColumns(&models.DbPipeline{}, []dal.DalSet{
-			{ColumnName: "status", Value: models.TASK_RUNNING},
-			{ColumnName: "message", Value: ""},
-			{ColumnName: "began_at", Value: time.Now()},
-		}, dal.Where("id = ?", dbPipeline.ID))
-		if err != nil {
-			panic(err)
+			if err != nil || pipelineId == 0 {
+				time.Sleep(time.Second)
+			}
 		}
 
 		// load pipeline
@@ -261,6 +255,10 @@ func watchTemporalPipelines() {
 	go func() {
 		// run forever
 		for range ticker.C {
+			// only the leader keeps track of workflows when multiple instances share the same database
+			if !isLeader() {
+				continue
+			}
 			// load all running pipeline from database
 			runningDbPipelines := make([]models.DbPipeline, 0)
 			err := db.All(&runningDbPipelines, dal.Where("status = ?", models.TASK_RUNNING))
@@ -407,6 +405,15 @@ func CancelPipeline(pipelineId uint64) errors.Error {
 	if temporalClient != nil {
 		return errors.Convert(temporalClient.CancelWorkflow(context.Background(), getTemporalWorkflowId(pipelineId), ""))
 	}
+	if clusterMode && pipeline.Instance != instanceId {
+		// the tasks are running in another instance, which acts on the request with its next heartbeat
+		return db.CreateOrUpdate(&models.PipelineCancelRequest{PipelineId: pipelineId})
+	}
+	return cancelPipelineTasks(pipelineId)
+}
+
+// cancelPipelineTasks cancels the unfinished tasks of the pipeline running in this instance
+func cancelPipelineTasks(pipelineId uint64) errors.Error {
 	pendingTasks, count, err := GetTasks(&TaskQuery{PipelineId: pipelineId, Pending: 1, Pagination: Pagination{PageSize: -1}})
 	if err != nil {
 		return errors.Convert(err)
@@ -58,6 +58,7 @@ func CreateDbPipeline(newPipeline *models.NewPipeline) (*models.DbPipeline, erro
 		SpentSeconds:  0,
 		Plan:          string(planByte),
 		SkipOnFail:    newPipeline.SkipOnFail,
+		Priority:      newPipeline.Priority,
 	}
 	if newPipeline.BlueprintId != 0 {
 		dbPipeline.BlueprintId = newPipeline.BlueprintId
@@ -212,6 +213,9 @@ func parsePipeline(dbPipeline *models.DbPipeline) *models.Pipeline {
 		Stage:         dbPipeline.Stage,
 		SkipOnFail:    dbPipeline.SkipOnFail,
 		Labels:        labelList,
+		Instance:      dbPipeline.Instance,
+		HeartbeatAt:   dbPipeline.HeartbeatAt,
+		Priority:      dbPipeline.Priority,
 	}
 	return &pipeline
 }
@@ -233,6 +237,9 @@ func parseDbPipeline(pipeline *models.Pipeline) *models.DbPipeline {
 		SpentSeconds:  pipeline.SpentSeconds,
 		Stage:         pipeline.Stage,
 		SkipOnFail:    pipeline.SkipOnFail,
+		Instance:      pipeline.Instance,
+		HeartbeatAt:   pipeline.HeartbeatAt,
+		Priority:      pipeline.Priority,
 	}
 	dbPipeline.Labels = []models.DbPipelineLabel{}
 	for _, label := range pipeline.Labels {
@@ -247,8 +254,8 @@ func parseDbPipeline(pipeline *models.Pipeline) *models.DbPipeline {
 
 // encryptDbPipeline encrypts dbPipeline.Plan
 func encryptDbPipeline(dbPipeline *models.DbPipeline) (*models.DbPipeline, errors.Error) {
-	encKey := cfg.GetString(plugin.EncodeKeyEnvStr)
-	planEncrypt, err := plugin.Encrypt(encKey, dbPipeline.Plan)
+	keyring := plugin.GetEncryptionKeyring()
+	planEncrypt, err := keyring.Encrypt(dbPipeline.Plan)
 	if err != nil {
 		return nil, err
 	}
@@ -258,8 +265,8 @@ func encryptDbPipeline(dbPipeline *models.DbPipeline) (*models.DbPipeline, error
 
 // encryptDbPipeline decrypts dbPipeline.Plan
 func decryptDbPipeline(dbPipeline *models.DbPipeline) (*models.DbPipeline, errors.Error) {
-	encKey := cfg.GetString(plugin.EncodeKeyEnvStr)
-	plan, err := plugin.Decrypt(encKey, dbPipeline.Plan)
+	keyring := plugin.GetEncryptionKeyring()
+	plan, err := keyring.Decrypt(dbPipeline.Plan)
 	if err != nil {
 		return nil, err
 	}
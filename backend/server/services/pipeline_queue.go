@@ -0,0 +1,192 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"fmt"
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+// pipelineQueueOrder is the order pending pipelines get picked: higher priority first, then first come first serve
+const pipelineQueueOrder = "priority DESC, id ASC"
+
+// pipelineMaxParallelPerConnection limits the number of running pipelines using the same connection, 0 for no limit
+var pipelineMaxParallelPerConnection int
+
+// pipelineMaxParallelPerBlueprint limits the number of running pipelines of the same blueprint, 0 for no limit
+var pipelineMaxParallelPerBlueprint int
+
+type pipelineConnection struct {
+	PipelineId   uint64
+	Plugin       string
+	ConnectionId uint64
+}
+
+// key identifies the connection by its table like the rate limit budgets do, so plugins sharing the same connection
+// table, i.e. github and github_graphql, count against the same limit
+func (pc *pipelineConnection) key() string {
+	if pluginInst, err := plugin.GetPlugin(pc.Plugin); err == nil {
+		if source, ok := pluginInst.(plugin.PluginSource); ok {
+			if connection, ok := source.Connection().(interface{ TableName() string }); ok {
+				return helper.RateLimitBudgetKey(connection, pc.ConnectionId)
+			}
+		}
+	}
+	return fmt.Sprintf("%s:%d", pc.Plugin, pc.ConnectionId)
+}
+
+// nextPendingPipeline returns the id of the pipeline to run next, or 0 if there is none. Pipelines sharing
+// `parallel/` labels with the running ones, or of blueprints or using connections that have reached the limits,
+// are skipped
+func nextPendingPipeline(runningParallelLabels []string) (uint64, errors.Error) {
+	var candidates []*models.DbPipeline
+	err := db.All(&candidates,
+		dal.Where("status IN ?", []string{models.TASK_CREATED, models.TASK_RERUN}),
+		dal.Join(
+			`left join _devlake_pipeline_labels ON
+				_devlake_pipeline_labels.pipeline_id = _devlake_pipelines.id AND
+				_devlake_pipeline_labels.name LIKE 'parallel/%' AND
+				_devlake_pipeline_labels.name in ?`,
+			runningParallelLabels,
+		),
+		dal.Groupby("id"),
+		dal.Having("count(_devlake_pipeline_labels.name)=0"),
+		dal.Select("id, blueprint_id"),
+		dal.Orderby(pipelineQueueOrder),
+		dal.Limit(100),
+	)
+	if err != nil {
+		return 0, err
+	}
+	candidates, err = skipBusyBlueprints(candidates)
+	if err != nil {
+		return 0, err
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	if pipelineMaxParallelPerConnection <= 0 {
+		return candidates[0].ID, nil
+	}
+
+	// count running pipelines by connection, across all instances sharing the database
+	var runningConnections []*pipelineConnection
+	err = db.All(&runningConnections,
+		dal.Select("DISTINCT _devlake_tasks.pipeline_id, _devlake_tasks.plugin, _devlake_tasks.connection_id"),
+		dal.From("_devlake_tasks"),
+		dal.Join("JOIN _devlake_pipelines ON _devlake_pipelines.id = _devlake_tasks.pipeline_id"),
+		dal.Where("_devlake_pipelines.status = ? AND _devlake_tasks.connection_id > 0", models.TASK_RUNNING),
+	)
+	if err != nil {
+		return 0, err
+	}
+	runningCounts := make(map[string]int)
+	for _, rc := range runningConnections {
+		runningCounts[rc.key()]++
+	}
+
+	// pick the first candidate whose connections are all available
+	candidateIds := make([]uint64, len(candidates))
+	for i, candidate := range candidates {
+		candidateIds[i] = candidate.ID
+	}
+	var candidateConnections []*pipelineConnection
+	err = db.All(&candidateConnections,
+		dal.Select("DISTINCT pipeline_id, plugin, connection_id"),
+		dal.From("_devlake_tasks"),
+		dal.Where("pipeline_id IN ? AND connection_id > 0", candidateIds),
+	)
+	if err != nil {
+		return 0, err
+	}
+	saturated := make(map[uint64]bool)
+	for _, cc := range candidateConnections {
+		if runningCounts[cc.key()] >= pipelineMaxParallelPerConnection {
+			saturated[cc.PipelineId] = true
+		}
+	}
+	for _, candidate := range candidates {
+		if !saturated[candidate.ID] {
+			return candidate.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// skipBusyBlueprints leaves out the candidates of blueprints having reached the limit of running pipelines
+func skipBusyBlueprints(candidates []*models.DbPipeline) ([]*models.DbPipeline, errors.Error) {
+	if pipelineMaxParallelPerBlueprint <= 0 || len(candidates) == 0 {
+		return candidates, nil
+	}
+	var runningBlueprints []*struct {
+		BlueprintId uint64
+		Running     int
+	}
+	err := db.All(&runningBlueprints,
+		dal.Select("blueprint_id, count(*) AS running"),
+		dal.From(&models.DbPipeline{}),
+		dal.Where("status = ? AND blueprint_id > 0", models.TASK_RUNNING),
+		dal.Groupby("blueprint_id"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	runningCounts := make(map[uint64]int)
+	for _, rb := range runningBlueprints {
+		runningCounts[rb.BlueprintId] = rb.Running
+	}
+	available := make([]*models.DbPipeline, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.BlueprintId == 0 || runningCounts[candidate.BlueprintId] < pipelineMaxParallelPerBlueprint {
+			available = append(available, candidate)
+		}
+	}
+	return available, nil
+}
+
+// fillQueuePositions sets the QueuePosition of the pending pipelines
+func fillQueuePositions(pipelines []*models.Pipeline) errors.Error {
+	pending := false
+	for _, pipeline := range pipelines {
+		pending = pending || pipeline.Status == models.TASK_CREATED || pipeline.Status == models.TASK_RERUN
+	}
+	if !pending {
+		return nil
+	}
+	var pendingIds []uint64
+	err := db.Pluck("id", &pendingIds,
+		dal.From(&models.DbPipeline{}),
+		dal.Where("status IN ?", []string{models.TASK_CREATED, models.TASK_RERUN}),
+		dal.Orderby(pipelineQueueOrder),
+	)
+	if err != nil {
+		return err
+	}
+	positions := make(map[uint64]int, len(pendingIds))
+	for i, id := range pendingIds {
+		positions[id] = i + 1
+	}
+	for _, pipeline := range pipelines {
+		pipeline.QueuePosition = positions[pipeline.ID]
+	}
+	return nil
+}
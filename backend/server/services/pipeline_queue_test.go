@@ -0,0 +1,144 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"testing"
+
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/plugin"
+	"github.com/stretchr/testify/assert"
+)
+
+type queueTestConnection struct{}
+
+func (queueTestConnection) TableName() string {
+	return "_tool_queue_test_connections"
+}
+
+// queueTestSource is a plugin owning queueTestConnection, registered under two names to share the connections
+type queueTestSource struct{}
+
+func (queueTestSource) Description() string             { return "queue test" }
+func (queueTestSource) RootPkgPath() string             { return "queue_test" }
+func (queueTestSource) Connection() interface{}         { return &queueTestConnection{} }
+func (queueTestSource) Scope() interface{}              { return nil }
+func (queueTestSource) TransformationRule() interface{} { return nil }
+
+type queueTestPlain struct{}
+
+func (queueTestPlain) Description() string { return "queue test" }
+func (queueTestPlain) RootPkgPath() string { return "queue_test" }
+
+func TestNextPendingPipeline(t *testing.T) {
+	setupClusterTest(t)
+	assert.Nil(t, plugin.RegisterPlugin("queue_test_source", queueTestSource{}))
+	assert.Nil(t, plugin.RegisterPlugin("queue_test_source_graphql", queueTestSource{}))
+	assert.Nil(t, plugin.RegisterPlugin("queue_test_plain", queueTestPlain{}))
+	originalLimit := pipelineMaxParallelPerConnection
+	pipelineMaxParallelPerConnection = 1
+	t.Cleanup(func() {
+		pipelineMaxParallelPerConnection = originalLimit
+	})
+
+	pipelines := []struct {
+		status       string
+		priority     int
+		plugin       string
+		connectionId uint64
+	}{
+		{models.TASK_RUNNING, 0, "queue_test_source", 1},
+		// shares the connection table with the running pipeline
+		{models.TASK_CREATED, 0, "queue_test_source_graphql", 1},
+		{models.TASK_CREATED, 0, "queue_test_source", 2},
+		{models.TASK_RERUN, 5, "queue_test_source", 1},
+		// same connection id of another connection table
+		{models.TASK_CREATED, 1, "queue_test_plain", 1},
+	}
+	for i, p := range pipelines {
+		id := uint64(i + 1)
+		assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: id}, Status: p.status, Priority: p.priority}))
+		assert.Nil(t, db.Create(&models.Task{PipelineId: id, Plugin: p.plugin, ConnectionId: p.connectionId, Status: p.status}))
+	}
+
+	// #4 has the highest priority but its connection is busy
+	next, err := nextPendingPipeline(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(5), next)
+
+	// #5 shares a parallel label with a running pipeline, #2 is on a busy connection as well
+	assert.Nil(t, db.Create(&models.DbPipelineLabel{PipelineId: 5, Name: "parallel/nightly"}))
+	next, err = nextPendingPipeline([]string{"parallel/nightly"})
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), next)
+
+	// without the limit the priority decides
+	pipelineMaxParallelPerConnection = 0
+	next, err = nextPendingPipeline(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4), next)
+}
+
+func TestFillQueuePositions(t *testing.T) {
+	setupClusterTest(t)
+	statuses := []string{models.TASK_RUNNING, models.TASK_CREATED, models.TASK_RERUN, models.TASK_CREATED, models.TASK_COMPLETED}
+	priorities := []int{0, 0, 0, 3, 9}
+	var pipelines []*models.Pipeline
+	for i, status := range statuses {
+		id := uint64(i + 1)
+		assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: id}, Status: status, Priority: priorities[i]}))
+		pipelines = append(pipelines, &models.Pipeline{Model: common.Model{ID: id}, Status: status})
+	}
+	assert.Nil(t, fillQueuePositions(pipelines))
+	var positions []int
+	for _, pipeline := range pipelines {
+		positions = append(positions, pipeline.QueuePosition)
+	}
+	assert.Equal(t, []int{0, 2, 3, 1, 0}, positions)
+}
+
+func TestPipelineMaxParallelPerBlueprint(t *testing.T) {
+	setupClusterTest(t)
+	originalLimit := pipelineMaxParallelPerBlueprint
+	pipelineMaxParallelPerBlueprint = 1
+	t.Cleanup(func() {
+		pipelineMaxParallelPerBlueprint = originalLimit
+	})
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 1}, BlueprintId: 1, Status: models.TASK_RUNNING}))
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 2}, BlueprintId: 1, Status: models.TASK_CREATED, Priority: 1}))
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 3}, BlueprintId: 2, Status: models.TASK_CREATED}))
+	assert.Nil(t, db.Create(&models.DbPipeline{Model: common.Model{ID: 4}, Status: models.TASK_CREATED}))
+
+	// #2 has the higher priority but its blueprint is busy
+	next, err := nextPendingPipeline(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), next)
+
+	// the claim checks the limit as well, i.e. #1 was claimed by another instance in the meantime
+	claimed, err := claimPipeline(2)
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+	claimed, err = claimPipeline(3)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+	// pipelines without blueprints are not limited
+	claimed, err = claimPipeline(4)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+}
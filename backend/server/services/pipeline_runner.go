@@ -98,6 +98,8 @@ func GetPipelineLogger(pipeline *models.Pipeline) log.Logger {
 
 // runPipeline start a pipeline actually
 func runPipeline(pipelineId uint64) errors.Error {
+	localPipelines.Store(pipelineId, struct{}{})
+	defer localPipelines.Delete(pipelineId)
 	ppl, err := GetPipeline(pipelineId)
 	if err != nil {
 		return err
@@ -135,7 +137,14 @@ func runPipeline(pipelineId uint64) errors.Error {
 		globalPipelineLog.Error(err, "compute pipeline status failed")
 		return err
 	}
-	err = db.Update(dbPipeline)
+	// leave the pipeline alone if it was taken over by another instance in the meantime
+	err = db.UpdateColumns(dbPipeline, []dal.DalSet{
+		{ColumnName: "finished_at", Value: dbPipeline.FinishedAt},
+		{ColumnName: "spent_seconds", Value: dbPipeline.SpentSeconds},
+		{ColumnName: "message", Value: dbPipeline.Message},
+		{ColumnName: "error_name", Value: dbPipeline.ErrorName},
+		{ColumnName: "status", Value: dbPipeline.Status},
+	}, dal.Where("instance = ?", instanceId))
 	if err != nil {
 		globalPipelineLog.Error(err, "update pipeline state failed")
 		return err
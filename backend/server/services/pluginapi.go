@@ -18,6 +18,9 @@ limitations under the License.
 package services
 
 import (
+	"reflect"
+	"strings"
+
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/plugin"
 )
@@ -43,3 +46,88 @@ func GetPluginsApiResources() (map[string]map[string]map[string]plugin.ApiResour
 	}
 	return res, nil
 }
+
+// GetPluginApiDocs returns the docs of the api resources of the plugin keyed the same way as ApiResources, docs
+// of the conventional resources of a PluginSource are inferred and overridden by those provided by PluginApiDoc
+func GetPluginApiDocs(pluginName string) (map[string]map[string]*plugin.ApiResourceDoc, errors.Error) {
+	pluginEntry, err := plugin.GetPlugin(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]map[string]*plugin.ApiResourceDoc)
+	pluginApi, isApi := pluginEntry.(plugin.PluginApi)
+	if !isApi {
+		return docs, nil
+	}
+	if pluginSource, ok := pluginEntry.(plugin.PluginSource); ok {
+		docs = inferPluginSourceApiDocs(pluginSource, pluginApi.ApiResources())
+	}
+	if pluginApiDoc, ok := pluginEntry.(plugin.PluginApiDoc); ok {
+		for resourcePath, methodDocs := range pluginApiDoc.ApiResourceDocs() {
+			if docs[resourcePath] == nil {
+				docs[resourcePath] = make(map[string]*plugin.ApiResourceDoc)
+			}
+			for method, doc := range methodDocs {
+				docs[resourcePath][method] = doc
+			}
+		}
+	}
+	return docs, nil
+}
+
+func inferPluginSourceApiDocs(pluginSource plugin.PluginSource, resources map[string]map[string]plugin.ApiResourceHandler) map[string]map[string]*plugin.ApiResourceDoc {
+	docs := make(map[string]map[string]*plugin.ApiResourceDoc)
+	listOf := func(v interface{}) interface{} {
+		return reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf(v)), 0, 0).Interface()
+	}
+	if connection := pluginSource.Connection(); connection != nil {
+		docs["test"] = map[string]*plugin.ApiResourceDoc{
+			"POST": {Summary: "test a connection", Request: connection},
+		}
+		docs["connections"] = map[string]*plugin.ApiResourceDoc{
+			"GET":  {Summary: "list connections", Response: listOf(connection)},
+			"POST": {Summary: "create a connection", Request: connection, Response: connection},
+		}
+		docs["connections/:connectionId"] = map[string]*plugin.ApiResourceDoc{
+			"GET":    {Summary: "get a connection", Response: connection},
+			"PATCH":  {Summary: "update a connection", Request: connection, Response: connection},
+			"DELETE": {Summary: "delete a connection", Response: connection},
+		}
+	}
+	if scope := pluginSource.Scope(); scope != nil {
+		// scopes are put in the form of {"data": [...]}
+		putRequest := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: "Data",
+			Type: reflect.SliceOf(reflect.TypeOf(scope)),
+			Tag:  `json:"data"`,
+		}})).Interface()
+		docs["connections/:connectionId/scopes"] = map[string]*plugin.ApiResourceDoc{
+			"GET": {
+				Summary:  "list scopes of a connection",
+				Query:    map[string]string{"page": "page number", "pageSize": "page size"},
+				Response: listOf(scope),
+			},
+			"PUT": {Summary: "create or update scopes of a connection", Request: putRequest, Response: listOf(scope)},
+		}
+		// the name of the scope id varies among plugins
+		for resourcePath := range resources {
+			if strings.HasPrefix(resourcePath, "connections/:connectionId/scopes/:") && strings.Count(resourcePath, "/") == 3 {
+				docs[resourcePath] = map[string]*plugin.ApiResourceDoc{
+					"GET":   {Summary: "get a scope", Response: scope},
+					"PATCH": {Summary: "update a scope", Request: scope, Response: scope},
+				}
+			}
+		}
+	}
+	if rule := pluginSource.TransformationRule(); rule != nil {
+		docs["transformation_rules"] = map[string]*plugin.ApiResourceDoc{
+			"GET":  {Summary: "list transformation rules", Query: map[string]string{"page": "page number", "pageSize": "page size"}, Response: listOf(rule)},
+			"POST": {Summary: "create a transformation rule", Request: rule, Response: rule},
+		}
+		docs["transformation_rules/:id"] = map[string]*plugin.ApiResourceDoc{
+			"GET":   {Summary: "get a transformation rule", Response: rule},
+			"PATCH": {Summary: "update a transformation rule", Request: rule, Response: rule},
+		}
+	}
+	return docs
+}
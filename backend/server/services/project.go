@@ -22,6 +22,7 @@ import (
 	"github.com/apache/incubator-devlake/core/dal"
 	"github.com/apache/incubator-devlake/core/errors"
 	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
 	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
 	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
 )
@@ -129,7 +130,7 @@ func GetProject(name string) (*models.ApiOutputProject, errors.Error) {
 }
 
 // PatchProject FIXME ...
-func PatchProject(name string, body map[string]interface{}) (*models.ApiOutputProject, errors.Error) {
+func PatchProject(name string, body map[string]interface{}, user *common.User) (*models.ApiOutputProject, errors.Error) {
 	projectInput := &models.ApiInputProject{}
 
 	// load input
@@ -137,6 +138,10 @@ func PatchProject(name string, body map[string]interface{}) (*models.ApiOutputPr
 	if err != nil {
 		return nil, err
 	}
+	before, err := GetProject(name)
+	if err != nil {
+		return nil, err
+	}
 
 	// wrap all operation inside a transaction
 	tx := db.Begin()
@@ -251,7 +256,16 @@ func PatchProject(name string, body map[string]interface{}) (*models.ApiOutputPr
 	}
 
 	// all good, render output
-	return makeProjectOutput(&projectInput.BaseProject)
+	// the transaction would be rolled back by the deferred function if err is set
+	projectOutput, outputErr := makeProjectOutput(&projectInput.BaseProject)
+	if outputErr != nil {
+		return nil, outputErr
+	}
+	helper.RecordAuditLog(
+		basicRes, user, project.TableName(), name, helper.AUDIT_ACTION_UPDATE,
+		helper.TakeAuditSnapshot(before), helper.TakeAuditSnapshot(projectOutput),
+	)
+	return projectOutput, nil
 }
 
 func refreshProjectMetrics(tx dal.Transaction, projectInput *models.ApiInputProject) errors.Error {
@@ -0,0 +1,798 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/devops"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/domaininfo"
+	"github.com/apache/incubator-devlake/core/plugin"
+	helper "github.com/apache/incubator-devlake/helpers/pluginhelper/api"
+)
+
+const PROJECT_BUNDLE_VERSION = "1.0.0"
+
+// fields of the records which are managed by the server rather than the configuration
+var (
+	connectionFieldsNotExported = []string{"id", "name", "createdAt", "updatedAt"}
+	ruleFieldsNotExported       = []string{"id", "name", "createdAt", "updatedAt"}
+	scopeFieldsNotExported      = []string{
+		"connectionId", "transformationRuleId", "createdAt", "updatedAt",
+		"_raw_data_params", "_raw_data_table", "_raw_data_id", "_raw_data_remark",
+	}
+)
+
+// ProjectBundle is a project along with its blueprint, connections, scopes and transformation rules, which refer
+// to each other by names so the bundle could be imported into another instance by ImportProject
+type ProjectBundle struct {
+	Version    string          `json:"version"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	Project    string          `json:"project"`
+	Config     *ConfigDocument `json:"config"`
+	// ConnectionIds are ids of the connections in the exporting instance keyed by `plugin/name`, ids in the domain
+	// data are remapped by them on import
+	ConnectionIds map[string]uint64 `json:"connectionIds"`
+	// Secrets are names of the secret fields stripped from the connections keyed by `plugin/name`
+	Secrets map[string][]string `json:"secrets"`
+	// DomainData are rows of the domain layer tables of the project keyed by table names, only exported on demand
+	DomainData map[string][]map[string]interface{} `json:"domainData,omitempty"`
+}
+
+type ProjectImportInput struct {
+	Bundle *ProjectBundle `json:"bundle"`
+	// Secrets are values of the secret fields of the connections keyed by `plugin/name`, they are only required by
+	// connections not existing in this instance
+	Secrets map[string]map[string]interface{} `json:"secrets"`
+}
+
+type ProjectImportResult struct {
+	DryRun  bool            `json:"dryRun"`
+	Changes []*ConfigChange `json:"changes"`
+	// Conflicts are the existing resources of the same names differing from the bundle
+	Conflicts []*ConfigChange `json:"conflicts"`
+	// DomainRows are numbers of the rows of domain layer tables imported keyed by table names
+	DomainRows map[string]int `json:"domainRows"`
+}
+
+// ExportProject writes the bundle of the project with its blueprint, connections without secrets, scopes and
+// transformation rules into w, along with the domain layer data of its scopes if asked to. Nothing is written if the
+// project could not be exported, while failures of the domain data leave the bundle written so far incomplete.
+func ExportProject(name string, withDomainData bool, w io.Writer) errors.Error {
+	bundle, err := exportProjectBundle(name)
+	if err != nil {
+		return err
+	}
+	blob, err := errors.Convert01(json.Marshal(bundle))
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(w)
+	if withDomainData {
+		// the domain data are appended to the bundle table by table instead of being loaded at once
+		_, _ = writer.Write(blob[:len(blob)-1])
+		_, _ = writer.WriteString(`,"domainData":{`)
+		if err = exportDomainData(bundle.Project, writer); err != nil {
+			return err
+		}
+		_, _ = writer.WriteString("}}")
+	} else {
+		_, _ = writer.Write(blob)
+	}
+	// errors of writes are kept by the writer till flushing
+	return errors.Convert(writer.Flush())
+}
+
+func exportProjectBundle(name string) (*ProjectBundle, errors.Error) {
+	project, err := GetProject(name)
+	if err != nil {
+		return nil, err
+	}
+	exporter := &projectExporter{
+		bundle: &ProjectBundle{
+			Version:       PROJECT_BUNDLE_VERSION,
+			ExportedAt:    time.Now(),
+			Project:       project.Name,
+			Config:        &ConfigDocument{},
+			ConnectionIds: make(map[string]uint64),
+			Secrets:       make(map[string][]string),
+		},
+		ruleNames: make(map[string]string),
+	}
+	configProject := &ConfigProject{
+		Name:        project.Name,
+		Description: project.Description,
+		Metrics:     make([]*ConfigProjectMetric, 0),
+	}
+	if project.Metrics != nil {
+		for _, metric := range *project.Metrics {
+			configProject.Metrics = append(configProject.Metrics, &ConfigProjectMetric{
+				PluginName:   metric.PluginName,
+				PluginOption: metric.PluginOption,
+				Enable:       metric.Enable,
+			})
+		}
+	}
+	exporter.bundle.Config.Projects = []*ConfigProject{configProject}
+	if project.Blueprint != nil {
+		if err = exporter.exportBlueprint(project.Blueprint); err != nil {
+			return nil, err
+		}
+	}
+	return exporter.bundle, nil
+}
+
+type projectExporter struct {
+	bundle *ProjectBundle
+	// names of the exported rules keyed by `plugin/id`
+	ruleNames map[string]string
+}
+
+func (e *projectExporter) exportBlueprint(blueprint *models.Blueprint) errors.Error {
+	if blueprint.Mode != models.BLUEPRINT_MODE_NORMAL {
+		return errors.BadInput.New(fmt.Sprintf("blueprint %s of the %s mode could not be exported", blueprint.Name, blueprint.Mode))
+	}
+	settings := &models.BlueprintSettings{}
+	if err := errors.Convert(json.Unmarshal(blueprint.Settings, settings)); err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("invalid settings of blueprint %s", blueprint.Name))
+	}
+	if settings.Version != "2.0.0" {
+		return errors.BadInput.New(fmt.Sprintf("blueprint %s of settings version %s could not be exported", blueprint.Name, settings.Version))
+	}
+	var connections []*plugin.BlueprintConnectionV200
+	if err := errors.Convert(json.Unmarshal(settings.Connections, &connections)); err != nil {
+		return errors.Default.Wrap(err, fmt.Sprintf("invalid connections of blueprint %s", blueprint.Name))
+	}
+	configBlueprint := &ConfigBlueprint{
+		Name:             blueprint.Name,
+		Project:          blueprint.ProjectName,
+		Enable:           blueprint.Enable,
+		CronConfig:       blueprint.CronConfig,
+		IsManual:         blueprint.IsManual,
+		SkipOnFail:       blueprint.SkipOnFail,
+		Priority:         blueprint.Priority,
+		Labels:           blueprint.Labels,
+		CreatedDateAfter: settings.CreatedDateAfter,
+		Connections:      make([]*ConfigBlueprintConnection, 0, len(connections)),
+	}
+	for _, connection := range connections {
+		connectionName, err := e.exportConnection(connection.Plugin, connection.ConnectionId)
+		if err != nil {
+			return err
+		}
+		for _, scope := range connection.Scopes {
+			if err = e.exportScope(connection.Plugin, connection.ConnectionId, connectionName, scope.Id); err != nil {
+				return err
+			}
+		}
+		configBlueprint.Connections = append(configBlueprint.Connections, &ConfigBlueprintConnection{
+			Plugin:     connection.Plugin,
+			Connection: connectionName,
+			Scopes:     connection.Scopes,
+		})
+	}
+	e.bundle.Config.Blueprints = []*ConfigBlueprint{configBlueprint}
+	return nil
+}
+
+// exportConnection exports the connection without secrets and returns its name
+func (e *projectExporter) exportConnection(pluginName string, connectionId uint64) (string, errors.Error) {
+	source, err := getPluginSource(pluginName)
+	if err != nil {
+		return "", err
+	}
+	connection := reflect.New(reflect.TypeOf(source.Connection()).Elem()).Interface()
+	params := map[string]string{"connectionId": fmt.Sprint(connectionId)}
+	connectionHelper, err := helper.NewConnectionHelper(basicRes, nil)
+	if err != nil {
+		return "", err
+	}
+	err = connectionHelper.FirstUnresolved(connection, params)
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return "", errors.NotFound.New(fmt.Sprintf("connection %d of %s not found", connectionId, pluginName))
+		}
+		return "", err
+	}
+	fields, err := toConfigMap(connection)
+	if err != nil {
+		return "", err
+	}
+	name, _ := fields["name"].(string)
+	key := configKey(pluginName, name)
+	if id, ok := e.bundle.ConnectionIds[key]; ok {
+		if id != connectionId {
+			return "", errors.BadInput.New(fmt.Sprintf("connections %d and %d of %s are both named %s", id, connectionId, pluginName, name))
+		}
+		return name, nil
+	}
+	secrets := make([]string, 0)
+	for field, fieldType := range jsonFieldsOf(reflect.TypeOf(connection)) {
+		if _, ok := fields[field]; ok && isSecretField(fieldType) {
+			secrets = append(secrets, field)
+			delete(fields, field)
+		}
+	}
+	if len(secrets) > 0 {
+		sort.Strings(secrets)
+		e.bundle.Secrets[key] = secrets
+	}
+	e.bundle.ConnectionIds[key] = connectionId
+	e.bundle.Config.Connections = append(e.bundle.Config.Connections, &ConfigConnection{
+		Plugin: pluginName,
+		Name:   name,
+		Fields: omitFields(fields, connectionFieldsNotExported),
+	})
+	return name, nil
+}
+
+func (e *projectExporter) exportScope(pluginName string, connectionId uint64, connectionName string, scopeId string) errors.Error {
+	source, err := getPluginSource(pluginName)
+	if err != nil {
+		return err
+	}
+	if source.Scope() == nil {
+		return errors.BadInput.New(fmt.Sprintf("plugin %s has no scopes", pluginName))
+	}
+	scopeType := reflect.TypeOf(source.Scope())
+	idField, err := scopeIdField(scopeType)
+	if err != nil {
+		return err
+	}
+	id, err := scopeIdValue(jsonFieldsOf(scopeType)[idField], scopeId)
+	if err != nil {
+		return err
+	}
+	fields, err := findScope(source.Scope(), connectionId, idField, id)
+	if err != nil {
+		return err
+	}
+	if fields == nil {
+		return errors.NotFound.New(fmt.Sprintf("scope %s of connection %s of %s not found", scopeId, connectionName, pluginName))
+	}
+	ruleName := ""
+	if ruleId, ok := fields["transformationRuleId"].(float64); ok && ruleId > 0 {
+		ruleName, err = e.exportTransformationRule(source, pluginName, uint64(ruleId))
+		if err != nil {
+			return err
+		}
+	}
+	e.bundle.Config.Scopes = append(e.bundle.Config.Scopes, &ConfigScope{
+		Plugin:             pluginName,
+		Connection:         connectionName,
+		TransformationRule: ruleName,
+		Fields:             omitFields(fields, scopeFieldsNotExported),
+	})
+	return nil
+}
+
+// exportTransformationRule exports the rule once and returns its name
+func (e *projectExporter) exportTransformationRule(source plugin.PluginSource, pluginName string, ruleId uint64) (string, errors.Error) {
+	key := configKey(pluginName, fmt.Sprint(ruleId))
+	if name, ok := e.ruleNames[key]; ok {
+		return name, nil
+	}
+	if source.TransformationRule() == nil {
+		return "", errors.BadInput.New(fmt.Sprintf("plugin %s has no transformation rules", pluginName))
+	}
+	rule := reflect.New(reflect.TypeOf(source.TransformationRule()).Elem()).Interface()
+	err := db.First(rule, dal.Where("id = ?", ruleId))
+	if err != nil {
+		if db.IsErrorNotFound(err) {
+			return "", errors.NotFound.New(fmt.Sprintf("transformation rule %d of %s not found", ruleId, pluginName))
+		}
+		return "", err
+	}
+	fields, err := toConfigMap(rule)
+	if err != nil {
+		return "", err
+	}
+	name, _ := fields["name"].(string)
+	e.ruleNames[key] = name
+	e.bundle.Config.TransformationRules = append(e.bundle.Config.TransformationRules, &ConfigTransformationRule{
+		Plugin: pluginName,
+		Name:   name,
+		Fields: omitFields(fields, ruleFieldsNotExported),
+	})
+	return name, nil
+}
+
+// ImportProject creates or updates the project and the resources in the bundle the same way as ApplyConfig, with
+// connections matched by names, and replaces the domain layer data of the project with the one in the bundle after
+// remapping the connection ids. Existing resources differing from the bundle are reported as conflicts and nothing
+// is changed unless overwrite is true, neither is anything changed for invalid domain data.
+func ImportProject(input *ProjectImportInput, dryRun bool, overwrite bool, user *common.User) (*ProjectImportResult, errors.Error) {
+	bundle := input.Bundle
+	if err := validateProjectBundle(bundle); err != nil {
+		return nil, err
+	}
+	for key, secrets := range input.Secrets {
+		connection := findBundleConnection(bundle, key)
+		if connection == nil {
+			return nil, errors.BadInput.New(fmt.Sprintf("connection %s of the secrets not found in the bundle", key))
+		}
+		if connection.Fields == nil {
+			connection.Fields = make(map[string]interface{})
+		}
+		for field, value := range secrets {
+			connection.Fields[field] = value
+		}
+	}
+	// the resources are applied by the apis of the plugins and the services, which commit on their own, so the domain
+	// data are checked before anything is changed and only the insertion of them is left after the resources
+	domainData, err := prepareDomainData(bundle.DomainData)
+	if err != nil {
+		return nil, err
+	}
+	result := &ProjectImportResult{DryRun: dryRun, Conflicts: make([]*ConfigChange, 0), DomainRows: make(map[string]int)}
+	for table, rows := range domainData {
+		result.DomainRows[table] = len(rows)
+	}
+
+	plan, err := ApplyConfig(bundle.Config, true, user)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, change := range plan.Changes {
+		if change.Action == CONFIG_ACTION_UPDATE {
+			result.Conflicts = append(result.Conflicts, change)
+			name := change.Name
+			if change.Plugin != "" {
+				name = configKey(change.Plugin, name)
+			}
+			conflicts = append(conflicts, fmt.Sprintf("%s %s", change.Kind, name))
+		}
+	}
+	if dryRun {
+		result.Changes = plan.Changes
+		return result, nil
+	}
+	if len(conflicts) > 0 && !overwrite {
+		return nil, errors.HttpStatus(http.StatusConflict).New(fmt.Sprintf(
+			"existing resources differ from the bundle: %s", strings.Join(conflicts, ", ")))
+	}
+	applied, err := ApplyConfig(bundle.Config, false, user)
+	if err != nil {
+		return nil, err
+	}
+	result.Changes = applied.Changes
+	if len(domainData) == 0 {
+		return result, nil
+	}
+	mapping := make(connectionIdMapping)
+	connectionsByPlugin := make(map[string]map[string]map[string]interface{})
+	for key, oldId := range bundle.ConnectionIds {
+		pluginName, name, _ := strings.Cut(key, "/")
+		if _, ok := connectionsByPlugin[pluginName]; !ok {
+			connectionsByPlugin[pluginName], err = listConnections(pluginName)
+			if err != nil {
+				return nil, err
+			}
+		}
+		connection := connectionsByPlugin[pluginName][name]
+		if connection == nil {
+			return nil, errors.Default.New(fmt.Sprintf("connection %s not found after import", key))
+		}
+		newId, err := idOf(connection)
+		if err != nil {
+			return nil, err
+		}
+		if mapping[pluginName] == nil {
+			mapping[pluginName] = make(map[string]string)
+		}
+		mapping[pluginName][fmt.Sprint(oldId)] = fmt.Sprint(newId)
+	}
+	if err = importDomainData(bundle.Project, domainData, mapping); err != nil {
+		return nil, errors.Default.Wrap(err, "failed to import the domain data")
+	}
+	return result, nil
+}
+
+func validateProjectBundle(bundle *ProjectBundle) errors.Error {
+	if bundle == nil || bundle.Config == nil {
+		return errors.BadInput.New("bundle is required")
+	}
+	if bundle.Version != PROJECT_BUNDLE_VERSION {
+		return errors.BadInput.New(fmt.Sprintf("unsupported bundle version %s", bundle.Version))
+	}
+	for _, project := range bundle.Config.Projects {
+		if project.Name == bundle.Project {
+			return nil
+		}
+	}
+	return errors.BadInput.New(fmt.Sprintf("project %s not found in the bundle", bundle.Project))
+}
+
+func findBundleConnection(bundle *ProjectBundle, key string) *ConfigConnection {
+	for _, connection := range bundle.Config.Connections {
+		if configKey(connection.Plugin, connection.Name) == key {
+			return connection
+		}
+	}
+	return nil
+}
+
+// projectDomainTables returns the domain layer tables exported along with projects
+func projectDomainTables() []dal.Tabler {
+	tables := []dal.Tabler{&devops.CicdScope{}, &crossdomain.ProjectPrMetric{}, &crossdomain.ProjectIssueMetric{}}
+	for _, table := range domaininfo.GetDomainTablesInfo() {
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// projectDomainSelector selects the domain layer data of a project, which are the rows of its name, the scopes
+// mapped to it, and the rows converted from the same raw data params as the scopes
+type projectDomainSelector struct {
+	projectName string
+	// ids of the scopes keyed by table names
+	scopeIds  map[string][]string
+	rawParams []string
+}
+
+func (s *projectDomainSelector) where(table string, columns map[string]bool) (string, []interface{}) {
+	var conditions []string
+	var params []interface{}
+	if columns["project_name"] {
+		conditions = append(conditions, "project_name = ?")
+		params = append(params, s.projectName)
+	}
+	if ids := s.scopeIds[table]; len(ids) > 0 && columns["id"] {
+		conditions = append(conditions, "id IN ?")
+		params = append(params, ids)
+	}
+	if len(s.rawParams) > 0 && columns["_raw_data_params"] {
+		conditions = append(conditions, "_raw_data_params IN ?")
+		params = append(params, s.rawParams)
+	}
+	return strings.Join(conditions, " OR "), params
+}
+
+// exportDomainData writes the rows of the domain layer tables of the project as members of a json object keyed by
+// table names, rows are read through cursors so the data of large projects are never held in memory at once
+func exportDomainData(projectName string, w *bufio.Writer) errors.Error {
+	selector := &projectDomainSelector{projectName: projectName, scopeIds: make(map[string][]string)}
+	var mappings []*crossdomain.ProjectMapping
+	err := db.All(&mappings, dal.Where("project_name = ?", projectName))
+	if err != nil {
+		return err
+	}
+	for _, mapping := range mappings {
+		selector.scopeIds[mapping.Table] = append(selector.scopeIds[mapping.Table], mapping.RowId)
+	}
+	for table, ids := range selector.scopeIds {
+		var params []string
+		err = db.Pluck("_raw_data_params", &params, dal.From(table), dal.Where("id IN ? AND _raw_data_params != ''", ids))
+		if err != nil {
+			return err
+		}
+		selector.rawParams = append(selector.rawParams, params...)
+	}
+	tables := 0
+	for _, table := range projectDomainTables() {
+		columns, err := columnsOf(table)
+		if err != nil {
+			return err
+		}
+		where, params := selector.where(table.TableName(), columns)
+		if where == "" {
+			continue
+		}
+		// tables without rows are left out
+		prefix := fmt.Sprintf("%q:[", table.TableName())
+		if tables > 0 {
+			prefix = "," + prefix
+		}
+		rows, err := exportDomainTable(table.TableName(), where, params, prefix, w)
+		if err != nil {
+			return errors.Default.Wrap(err, fmt.Sprintf("failed to export %s", table.TableName()))
+		}
+		if rows > 0 {
+			_, _ = w.WriteString("]")
+			tables++
+		}
+	}
+	return nil
+}
+
+// exportDomainTable writes the prefix followed by the rows separated by commas, nothing is written without rows
+func exportDomainTable(tableName string, where string, params []interface{}, prefix string, w *bufio.Writer) (int, errors.Error) {
+	cursor, err := db.Cursor(dal.From(tableName), dal.Where(where, params...))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close()
+	rows := 0
+	for cursor.Next() {
+		row := make(map[string]interface{})
+		if err = db.Fetch(cursor, &row); err != nil {
+			return rows, err
+		}
+		blob, err := errors.Convert01(json.Marshal(row))
+		if err != nil {
+			return rows, err
+		}
+		if rows == 0 {
+			_, _ = w.WriteString(prefix)
+		} else {
+			_, _ = w.WriteString(",")
+		}
+		_, _ = w.Write(blob)
+		rows++
+	}
+	// rows stopping early on errors must not pass for the end of the table
+	if rowsErr, ok := cursor.(interface{ Err() error }); ok {
+		return rows, errors.Convert(rowsErr.Err())
+	}
+	return rows, nil
+}
+
+// prepareDomainData checks the tables of the bundle and converts the rows decoded from json into values of the columns
+func prepareDomainData(data map[string][]map[string]interface{}) (map[string][]map[string]interface{}, errors.Error) {
+	domainTables := make(map[string]dal.Tabler)
+	for _, table := range projectDomainTables() {
+		domainTables[table.TableName()] = table
+	}
+	prepared := make(map[string][]map[string]interface{}, len(data))
+	for tableName, rows := range data {
+		table, ok := domainTables[tableName]
+		if !ok {
+			return nil, errors.BadInput.New(fmt.Sprintf("table %s is not a domain layer table of projects", tableName))
+		}
+		columns, err := columnsOf(table)
+		if err != nil {
+			return nil, err
+		}
+		timeColumns, err := timeColumnsOf(table)
+		if err != nil {
+			return nil, err
+		}
+		prepared[tableName] = make([]map[string]interface{}, len(rows))
+		for i, row := range rows {
+			if prepared[tableName][i], err = domainRowOf(row, columns, timeColumns); err != nil {
+				return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid row of %s", tableName))
+			}
+		}
+	}
+	return prepared, nil
+}
+
+// importDomainData replaces the domain data of the project with the prepared rows in a single transaction after
+// remapping the connection ids, rows colliding with the ones outside the project are overwritten
+func importDomainData(projectName string, data map[string][]map[string]interface{}, mapping connectionIdMapping) (err errors.Error) {
+	for _, rows := range data {
+		for _, row := range rows {
+			mapping.remapRow(row)
+		}
+	}
+	// the selector is built from the bundle so the data of the project in this instance is replaced
+	selector := &projectDomainSelector{projectName: projectName, scopeIds: make(map[string][]string)}
+	for _, row := range data[crossdomain.ProjectMapping{}.TableName()] {
+		table, _ := row["table"].(string)
+		rowId, _ := row["row_id"].(string)
+		selector.scopeIds[table] = append(selector.scopeIds[table], rowId)
+	}
+	for table, ids := range selector.scopeIds {
+		for _, row := range data[table] {
+			params, _ := row["_raw_data_params"].(string)
+			if params != "" && containsString(ids, fmt.Sprint(row["id"])) {
+				selector.rawParams = append(selector.rawParams, params)
+			}
+		}
+	}
+
+	tx := db.Begin()
+	defer func() {
+		if r := recover(); r != nil || err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				logger.Error(rollbackErr, "importDomainData: failed to rollback")
+			}
+		}
+	}()
+	for _, table := range projectDomainTables() {
+		tableName := table.TableName()
+		columns, err := columnsOf(table)
+		if err != nil {
+			return err
+		}
+		where, params := selector.where(tableName, columns)
+		if where != "" {
+			err = tx.Exec("DELETE FROM ? WHERE "+where, append([]interface{}{dal.ClauseTable{Name: tableName}}, params...)...)
+			if err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("failed to delete from %s", tableName))
+			}
+		}
+		rows := data[tableName]
+		if len(rows) == 0 {
+			continue
+		}
+		// keep the number of parameters of a statement under limits of databases
+		for start := 0; start < len(rows); start += 100 {
+			end := start + 100
+			if end > len(rows) {
+				end = len(rows)
+			}
+			if err = tx.CreateOrUpdate(rows[start:end], dal.From(table)); err != nil {
+				return errors.Default.Wrap(err, fmt.Sprintf("failed to insert into %s", tableName))
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+func columnsOf(table dal.Tabler) (map[string]bool, errors.Error) {
+	columnMetas, err := db.GetColumns(table, nil)
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to get columns of %s", table.TableName()))
+	}
+	columns := make(map[string]bool, len(columnMetas))
+	for _, columnMeta := range columnMetas {
+		columns[columnMeta.Name()] = true
+	}
+	return columns, nil
+}
+
+func timeColumnsOf(table dal.Tabler) (map[string]bool, errors.Error) {
+	columnMetas, err := db.GetColumns(table, func(columnMeta dal.ColumnMeta) bool {
+		typeName := strings.ToLower(columnMeta.DatabaseTypeName())
+		return strings.Contains(typeName, "date") || strings.Contains(typeName, "time")
+	})
+	if err != nil {
+		return nil, errors.Default.Wrap(err, fmt.Sprintf("failed to get columns of %s", table.TableName()))
+	}
+	columns := make(map[string]bool, len(columnMetas))
+	for _, columnMeta := range columnMetas {
+		columns[columnMeta.Name()] = true
+	}
+	return columns, nil
+}
+
+// domainRowOf turns the row decoded from json into values of the columns, columns missing from the table are
+// dropped so bundles of other versions could be imported
+func domainRowOf(row map[string]interface{}, columns map[string]bool, timeColumns map[string]bool) (map[string]interface{}, errors.Error) {
+	values := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if !columns[column] {
+			continue
+		}
+		switch v := value.(type) {
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				value = i
+			} else if f, err := v.Float64(); err == nil {
+				value = f
+			} else {
+				return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid number of %s", column))
+			}
+		case string:
+			if timeColumns[column] {
+				t, err := time.Parse(time.RFC3339Nano, v)
+				if err != nil {
+					return nil, errors.BadInput.Wrap(err, fmt.Sprintf("invalid time of %s", column))
+				}
+				value = t
+			}
+		}
+		values[column] = value
+	}
+	return values, nil
+}
+
+// scopeIdValue converts the scope id in blueprint settings into the type of the id field of the scope
+func scopeIdValue(field reflect.StructField, id string) (interface{}, errors.Error) {
+	fieldType := field.Type
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(id, 10, 64)
+		return v, errors.BadInput.Wrap(err, fmt.Sprintf("invalid scope id %s", id))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(id, 10, 64)
+		return v, errors.BadInput.Wrap(err, fmt.Sprintf("invalid scope id %s", id))
+	default:
+		return id, nil
+	}
+}
+
+func omitFields(fields map[string]interface{}, omitted []string) map[string]interface{} {
+	for _, name := range omitted {
+		delete(fields, name)
+	}
+	return fields
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// domain ids are generated by didgen as `plugin:Entity:connectionId:...`
+	domainIdPattern = regexp.MustCompile(`^([^:]+):([^:]+):(\d+)(:|$)`)
+	// raw data params of plugins carry the connection id as `"ConnectionId":1`
+	rawParamsConnectionPattern = regexp.MustCompile(`("ConnectionId":\s*)(\d+)`)
+)
+
+// connectionIdMapping maps ids of connections in the exporting instance to those in this one, keyed by plugin names
+type connectionIdMapping map[string]map[string]string
+
+func (m connectionIdMapping) remapRow(row map[string]interface{}) {
+	rawTable, _ := row["_raw_data_table"].(string)
+	for column, value := range row {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if column == "_raw_data_params" {
+			row[column] = m.remapRawParams(rawTable, s)
+		} else {
+			row[column] = m.remapDomainId(s)
+		}
+	}
+}
+
+func (m connectionIdMapping) remapDomainId(id string) string {
+	match := domainIdPattern.FindStringSubmatchIndex(id)
+	if match == nil {
+		return id
+	}
+	newId, ok := m[id[match[2]:match[3]]][id[match[6]:match[7]]]
+	if !ok {
+		return id
+	}
+	return id[:match[6]] + newId + id[match[7]:]
+}
+
+// remapRawParams remaps the connection id in the params, raw tables are named as `_raw_plugin_...`
+func (m connectionIdMapping) remapRawParams(rawTable string, params string) string {
+	for pluginName, ids := range m {
+		if !strings.HasPrefix(rawTable, "_raw_"+pluginName+"_") {
+			continue
+		}
+		return rawParamsConnectionPattern.ReplaceAllStringFunc(params, func(s string) string {
+			match := rawParamsConnectionPattern.FindStringSubmatch(s)
+			if newId, ok := ids[match[2]]; ok {
+				return match[1] + newId
+			}
+			return s
+		})
+	}
+	return params
+}
@@ -0,0 +1,306 @@
+/*
+Licensed to the Apache Software Foundation (ASF) under one or more
+contributor license agreements.  See the NOTICE file distributed with
+this work for additional information regarding copyright ownership.
+The ASF licenses this file to You under the Apache License, Version 2.0
+(the "License"); you may not use this file except in compliance with
+the License.  You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/apache/incubator-devlake/core/dal"
+	"github.com/apache/incubator-devlake/core/errors"
+	"github.com/apache/incubator-devlake/core/models/common"
+	"github.com/apache/incubator-devlake/core/models/domainlayer"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/code"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/crossdomain"
+	"github.com/apache/incubator-devlake/core/models/domainlayer/ticket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDocumentJson(t *testing.T) {
+	doc := &ConfigDocument{
+		Connections: []*ConfigConnection{{Plugin: "github", Name: "github", Fields: map[string]interface{}{"endpoint": "e"}}},
+		Scopes:      []*ConfigScope{{Plugin: "github", Connection: "github", Fields: map[string]interface{}{"githubId": float64(1)}}},
+	}
+	blob, err := json.Marshal(doc)
+	assert.Nil(t, err)
+	assert.Contains(t, string(blob), `{"endpoint":"e","name":"github","plugin":"github"}`)
+	assert.Contains(t, string(blob), `{"connection":"github","githubId":1,"plugin":"github"}`)
+
+	decoded := &ConfigDocument{}
+	assert.Nil(t, json.Unmarshal(blob, decoded))
+	assert.Equal(t, doc.Connections, decoded.Connections)
+	assert.Equal(t, doc.Scopes, decoded.Scopes)
+}
+
+func TestConnectionIdMapping(t *testing.T) {
+	mapping := connectionIdMapping{"github": {"1": "3", "3": "5"}}
+	row := map[string]interface{}{
+		"id":               "github:GithubRepo:1:100",
+		"creator_id":       "github:GithubAccount:3:200",
+		"project_key":      "gitlab:GitlabProject:1:100",
+		"url":              "https://github.com/apache/incubator-devlake",
+		"_raw_data_table":  "_raw_github_api_repositories",
+		"_raw_data_params": `{"ConnectionId":1,"Name":"apache/incubator-devlake"}`,
+		"additions":        json.Number("10"),
+	}
+	mapping.remapRow(row)
+	assert.Equal(t, "github:GithubRepo:3:100", row["id"])
+	assert.Equal(t, "github:GithubAccount:5:200", row["creator_id"])
+	assert.Equal(t, "gitlab:GitlabProject:1:100", row["project_key"])
+	assert.Equal(t, "https://github.com/apache/incubator-devlake", row["url"])
+	assert.Equal(t, `{"ConnectionId":3,"Name":"apache/incubator-devlake"}`, row["_raw_data_params"])
+	assert.Equal(t, `{"ConnectionId":1}`, mapping.remapRawParams("_raw_gitlab_api_projects", `{"ConnectionId":1}`))
+}
+
+func TestDomainRowOf(t *testing.T) {
+	values, err := domainRowOf(
+		map[string]interface{}{"id": "1", "additions": json.Number("10"), "rate": json.Number("0.5"), "created_date": "2023-01-02T03:04:05Z", "removed": true},
+		map[string]bool{"id": true, "additions": true, "rate": true, "created_date": true},
+		map[string]bool{"created_date": true},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":           "1",
+		"additions":    int64(10),
+		"rate":         0.5,
+		"created_date": time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, values)
+
+	_, err = domainRowOf(map[string]interface{}{"created_date": "yesterday"}, map[string]bool{"created_date": true}, map[string]bool{"created_date": true})
+	assert.NotNil(t, err)
+}
+
+func TestProjectDomainSelector(t *testing.T) {
+	selector := &projectDomainSelector{
+		projectName: "p",
+		scopeIds:    map[string][]string{"repos": {"github:GithubRepo:1:100"}},
+		rawParams:   []string{`{"ConnectionId":1,"Name":"a/b"}`},
+	}
+	where, params := selector.where("repos", map[string]bool{"id": true, "_raw_data_params": true})
+	assert.Equal(t, "id IN ? OR _raw_data_params IN ?", where)
+	assert.Len(t, params, 2)
+	where, params = selector.where("project_mapping", map[string]bool{"project_name": true, "row_id": true})
+	assert.Equal(t, "project_name = ?", where)
+	assert.Equal(t, []interface{}{"p"}, params)
+	where, _ = selector.where("teams", map[string]bool{"id": true})
+	assert.Equal(t, "", where)
+}
+
+// setupProjectBundleTest starts an empty instance with the fake plugin `configtest` and the domain layer tables
+func setupProjectBundleTest(t *testing.T) {
+	setupConfigApplyTest(t)
+	for _, table := range projectDomainTables() {
+		require.Nil(t, db.AutoMigrate(table))
+	}
+}
+
+func testRepo(id string, connectionId int) *code.Repo {
+	createdDate := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &code.Repo{
+		DomainEntity: domainlayer.DomainEntity{
+			Id: id,
+			NoPKModel: common.NoPKModel{RawDataOrigin: common.RawDataOrigin{
+				RawDataTable:  "_raw_configtest_repos",
+				RawDataParams: fmt.Sprintf(`{"ConnectionId":%d,"Name":"repo"}`, connectionId),
+			}},
+		},
+		Name:        "repo",
+		CreatedDate: &createdDate,
+	}
+}
+
+// exportTestBundle exports the project `p` and decodes the bundle the same way as the import api does
+func exportTestBundle(t *testing.T) *ProjectBundle {
+	blob := &bytes.Buffer{}
+	require.Nil(t, ExportProject("p", true, blob))
+	bundle := &ProjectBundle{}
+	decoder := json.NewDecoder(blob)
+	decoder.UseNumber()
+	require.Nil(t, decoder.Decode(bundle))
+	return bundle
+}
+
+func TestExportImportProject(t *testing.T) {
+	// the exporting instance, where the connection of the project is the second one
+	setupProjectBundleTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	require.Nil(t, err)
+	doc.Connections = append([]*ConfigConnection{{
+		Plugin: "configtest",
+		Name:   "other",
+		Fields: map[string]interface{}{"endpoint": "https://other.example.com/", "token": "other"},
+	}}, doc.Connections...)
+	_, err = ApplyConfig(doc, false, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Create(testRepo("configtest:TestRepo:2:1", 2)))
+	require.Nil(t, db.Create(&crossdomain.ProjectMapping{ProjectName: "p", Table: "repos", RowId: "configtest:TestRepo:2:1"}))
+	// data of other connections are left out
+	require.Nil(t, db.Create(testRepo("configtest:TestRepo:1:9", 1)))
+
+	bundle := exportTestBundle(t)
+	assert.Equal(t, map[string]uint64{"configtest/c": 2}, bundle.ConnectionIds)
+	assert.Equal(t, map[string][]string{"configtest/c": {"token"}}, bundle.Secrets)
+	require.Len(t, bundle.Config.Connections, 1)
+	assert.NotContains(t, bundle.Config.Connections[0].Fields, "token")
+	assert.Len(t, bundle.Config.Scopes, 1)
+	assert.Len(t, bundle.DomainData["repos"], 1)
+	assert.Len(t, bundle.DomainData["project_mapping"], 1)
+
+	// the importing instance, where the connection would be the first one
+	setupProjectBundleTest(t)
+	secrets := map[string]map[string]interface{}{"configtest/c": {"token": "imported"}}
+	_, err = ImportProject(&ProjectImportInput{Bundle: bundle, Secrets: map[string]map[string]interface{}{"configtest/x": {}}}, false, false, nil)
+	assert.NotNil(t, err)
+
+	result, err := ImportProject(&ProjectImportInput{Bundle: bundle, Secrets: secrets}, true, false, nil)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"connection:c": CONFIG_ACTION_CREATE,
+		"scope:repo":   CONFIG_ACTION_CREATE,
+		"project:p":    CONFIG_ACTION_CREATE,
+		"blueprint:bp": CONFIG_ACTION_CREATE,
+	}, configActions(&ConfigApplyResult{Changes: result.Changes}))
+	assert.Equal(t, 1, result.DomainRows["repos"])
+	_, err = GetProject("p")
+	assert.NotNil(t, err)
+
+	result, err = ImportProject(&ProjectImportInput{Bundle: bundle, Secrets: secrets}, false, false, nil)
+	require.Nil(t, err)
+	assert.Empty(t, result.Conflicts)
+	connection, err := findConnection("configtest", "c")
+	require.Nil(t, err)
+	assert.Equal(t, float64(1), connection["id"])
+	assert.Equal(t, "imported", connection["token"])
+	scope, err := findScope(&testConfigPluginScope{}, 1, "id", "1")
+	require.Nil(t, err)
+	assert.Equal(t, "repo", scope["name"])
+	// ids in the domain data are remapped to the new connection
+	var repos []*code.Repo
+	require.Nil(t, db.All(&repos))
+	require.Len(t, repos, 1)
+	assert.Equal(t, "configtest:TestRepo:1:1", repos[0].Id)
+	assert.Equal(t, `{"ConnectionId":1,"Name":"repo"}`, repos[0].RawDataParams)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), repos[0].CreatedDate.UTC())
+	var mappings []*crossdomain.ProjectMapping
+	require.Nil(t, db.All(&mappings, dal.Where("project_name = ?", "p")))
+	require.Len(t, mappings, 1)
+	assert.Equal(t, "configtest:TestRepo:1:1", mappings[0].RowId)
+
+	// importing again replaces the domain data instead of adding them
+	_, err = ImportProject(&ProjectImportInput{Bundle: bundle}, false, false, nil)
+	require.Nil(t, err)
+	count, err := db.Count(dal.From(&code.Repo{}))
+	require.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// the round-tripped bundle refers to the connection of this instance
+	roundTripped := exportTestBundle(t)
+	assert.Equal(t, map[string]uint64{"configtest/c": 1}, roundTripped.ConnectionIds)
+	assert.Equal(t, "configtest:TestRepo:1:1", roundTripped.DomainData["repos"][0]["id"])
+}
+
+func TestImportProjectConflicts(t *testing.T) {
+	setupProjectBundleTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	require.Nil(t, err)
+	_, err = ApplyConfig(doc, false, nil)
+	require.Nil(t, err)
+	bundle := exportTestBundle(t)
+	bundle.Config.Projects[0].Description = "changed"
+
+	result, err := ImportProject(&ProjectImportInput{Bundle: bundle}, true, false, nil)
+	require.Nil(t, err)
+	require.Len(t, result.Conflicts, 1)
+	assert.Equal(t, CONFIG_KIND_PROJECT, result.Conflicts[0].Kind)
+	assert.Equal(t, "p", result.Conflicts[0].Name)
+
+	_, err = ImportProject(&ProjectImportInput{Bundle: bundle}, false, false, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, http.StatusConflict, err.GetType().GetHttpCode())
+	project, err := GetProject("p")
+	require.Nil(t, err)
+	assert.Equal(t, "project", project.Description)
+
+	result, err = ImportProject(&ProjectImportInput{Bundle: bundle}, false, true, nil)
+	require.Nil(t, err)
+	assert.Len(t, result.Conflicts, 1)
+	project, err = GetProject("p")
+	require.Nil(t, err)
+	assert.Equal(t, "changed", project.Description)
+}
+
+func TestImportProjectOverlappingRows(t *testing.T) {
+	setupProjectBundleTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	require.Nil(t, err)
+	_, err = ApplyConfig(doc, false, nil)
+	require.Nil(t, err)
+	repo := testRepo("configtest:TestRepo:1:1", 1)
+	require.Nil(t, db.Create(repo))
+	require.Nil(t, db.Create(&crossdomain.ProjectMapping{ProjectName: "p", Table: "repos", RowId: repo.Id}))
+	require.Nil(t, db.Create(&ticket.Issue{
+		DomainEntity: domainlayer.DomainEntity{Id: "configtest:TestIssue:1:7", NoPKModel: repo.NoPKModel},
+		Title:        "exported",
+	}))
+	bundle := exportTestBundle(t)
+
+	// the importing instance holds the same issue converted from other raw data, which is not part of the project
+	setupProjectBundleTest(t)
+	require.Nil(t, db.Create(&ticket.Issue{
+		DomainEntity: domainlayer.DomainEntity{Id: "configtest:TestIssue:1:7", NoPKModel: common.NoPKModel{
+			RawDataOrigin: common.RawDataOrigin{RawDataTable: "_raw_configtest_issues", RawDataParams: `{"ConnectionId":1,"Name":"other"}`},
+		}},
+		Title: "stale",
+	}))
+	secrets := map[string]map[string]interface{}{"configtest/c": {"token": "imported"}}
+	_, err = ImportProject(&ProjectImportInput{Bundle: bundle, Secrets: secrets}, false, false, nil)
+	require.Nil(t, err)
+	var issues []*ticket.Issue
+	require.Nil(t, db.All(&issues))
+	require.Len(t, issues, 1)
+	assert.Equal(t, "exported", issues[0].Title)
+	assert.Equal(t, repo.RawDataParams, issues[0].RawDataParams)
+}
+
+func TestImportProjectInvalidDomainData(t *testing.T) {
+	setupProjectBundleTest(t)
+	doc, err := ParseConfigDocument([]byte(testConfigDocument))
+	require.Nil(t, err)
+	_, err = ApplyConfig(doc, false, nil)
+	require.Nil(t, err)
+	require.Nil(t, db.Create(testRepo("configtest:TestRepo:1:1", 1)))
+	require.Nil(t, db.Create(&crossdomain.ProjectMapping{ProjectName: "p", Table: "repos", RowId: "configtest:TestRepo:1:1"}))
+	bundle := exportTestBundle(t)
+	bundle.DomainData["repos"][0]["created_date"] = "yesterday"
+
+	// nothing is imported for invalid domain data
+	setupProjectBundleTest(t)
+	_, err = ImportProject(&ProjectImportInput{Bundle: bundle, Secrets: map[string]map[string]interface{}{"configtest/c": {"token": "t"}}}, false, false, nil)
+	require.NotNil(t, err)
+	assert.Equal(t, errors.BadInput, err.GetType())
+	connection, err := findConnection("configtest", "c")
+	assert.Nil(t, err)
+	assert.Nil(t, connection)
+	_, err = GetProject("p")
+	assert.NotNil(t, err)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/apache/incubator-devlake/core/models"
 	"github.com/apache/incubator-devlake/impls/logruslog"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -54,14 +55,15 @@ func CreateTask(newTask *models.NewTask) (*models.Task, errors.Error) {
 	}
 
 	task := &models.Task{
-		Plugin:      newTask.Plugin,
-		Subtasks:    s,
-		Options:     string(b),
-		Status:      models.TASK_CREATED,
-		Message:     "",
-		PipelineId:  newTask.PipelineId,
-		PipelineRow: newTask.PipelineRow,
-		PipelineCol: newTask.PipelineCol,
+		Plugin:       newTask.Plugin,
+		ConnectionId: getConnectionId(newTask.Options),
+		Subtasks:     s,
+		Options:      string(b),
+		Status:       models.TASK_CREATED,
+		Message:      "",
+		PipelineId:   newTask.PipelineId,
+		PipelineRow:  newTask.PipelineRow,
+		PipelineCol:  newTask.PipelineCol,
 	}
 	if newTask.IsRerun {
 		task.Status = models.TASK_RERUN
@@ -230,3 +232,22 @@ func RerunTask(taskId uint64) (*models.Task, errors.Error) {
 	}
 	return rerunTasks[0], nil
 }
+
+// getConnectionId extracts the connectionId from task options, returns 0 if there is none
+func getConnectionId(options map[string]interface{}) uint64 {
+	switch v := options["connectionId"].(type) {
+	case float64:
+		return uint64(v)
+	case int:
+		return uint64(v)
+	case uint64:
+		return v
+	case json.Number:
+		id, _ := strconv.ParseUint(v.String(), 10, 64)
+		return id
+	case string:
+		id, _ := strconv.ParseUint(v, 10, 64)
+		return id
+	}
+	return 0
+}